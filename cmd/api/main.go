@@ -9,11 +9,19 @@ import (
 	"vybes/internal/config"
 	"vybes/internal/domain"
 	httphandler "vybes/internal/handler/http"
+	"vybes/internal/ranking"
 	"vybes/internal/repository"
 	"vybes/internal/service"
 	"vybes/pkg/cache"
+	"vybes/pkg/envelope"
+	"vybes/pkg/jwtkeys"
+	"vybes/pkg/keyvault"
+	"vybes/pkg/oidc"
+	"vybes/pkg/pagination"
+	"vybes/pkg/ratelimit"
 	"vybes/pkg/storage"
 
+	"github.com/ethereum/go-ethereum/ethclient"
 	"github.com/nats-io/nats.go"
 	"github.com/rs/zerolog"
 	"github.com/rs/zerolog/log"
@@ -59,6 +67,12 @@ func main() {
 		log.Fatal().Err(err).Msg("Failed to initialize cache client")
 	}
 
+	// Connect to the Ethereum node used by the custodial wallets
+	ethClient, err := ethclient.Dial(cfg.EthRPCURL)
+	if err != nil {
+		log.Fatal().Err(err).Msg("Failed to connect to the Ethereum client")
+	}
+
 	// Initialize NATS message broker for real-time notifications
 	notificationPublisher, err := service.NewNATSNotificationPublisher(cfg)
 	if err != nil {
@@ -75,35 +89,75 @@ func main() {
 	bookmarkRepository := repository.NewMongoBookmarkRepository(db)
 	notificationRepository := repository.NewMongoNotificationRepository(db)
 	sessionRepository := repository.NewSessionRepository(db)
+	jobRepository := repository.NewMongoJobRepository(db)
+	blockRepository := repository.NewMongoBlockRepository(db)
+	muteRepository := repository.NewMongoMuteRepository(db)
+	walletLinkRepository := repository.NewMongoWalletLinkRepository(db)
+	externalIdentityRepository := repository.NewMongoExternalIdentityRepository(db)
+	walletTransactionRepository := repository.NewMongoWalletTransactionRepository(db)
+
+	// Sessions from before refresh tokens were hashed still hold them in plaintext
+	if migrated, err := sessionRepository.HashLegacyRefreshTokens(context.Background()); err != nil {
+		log.Fatal().Err(err).Msg("Failed to hash legacy refresh tokens")
+	} else if migrated > 0 {
+		log.Info().Int("sessions", migrated).Msg("Hashed legacy refresh tokens")
+	}
+	// Password reset OTPs from before they were hashed are stored in plaintext
+	if cleared, err := userRepository.ClearLegacyOTPs(context.Background()); err != nil {
+		log.Fatal().Err(err).Msg("Failed to clear legacy password reset OTPs")
+	} else if cleared > 0 {
+		log.Info().Int64("users", cleared).Msg("Cleared legacy password reset OTPs")
+	}
+
+	// Keys for signing and verifying access tokens
+	jwtKeys := loadJWTKeys(cfg)
+
+	// Cursor codec shared by every paginated list endpoint
+	cursorCodec := pagination.NewCodec(cfg.CursorSecret)
+
+	// Request counters for the rate-limited route groups
+	rateLimiter := ratelimit.NewLimiter(cacheClient)
 
 	// Initialize all business logic services with their dependencies
 	emailService := service.NewResendEmailService(cfg)
-	walletService := service.NewWalletService(cfg)
-	notificationService := service.NewNotificationService(notificationRepository)
-	sessionService := service.NewSessionService(sessionRepository)
+	walletKeyService := service.NewWalletKeyService(userRepository, loadWalletKeys(cfg), cfg.WalletEncryptionKey)
+	walletService := service.NewWalletService(ethClient, walletKeyService)
+	walletUnlockService := service.NewWalletUnlockService(userRepository, walletKeyService, cacheClient, keyvault.New())
+	timelineService := service.NewTimelineService(cacheClient, followRepository, contentRepository, cfg)
+	blockService := service.NewBlockService(blockRepository, muteRepository, userRepository, timelineService, cursorCodec)
+	notificationService := service.NewNotificationService(notificationRepository, blockService, cursorCodec)
+	walletTransactionService := service.NewWalletTransactionService(walletTransactionRepository, notificationService, ethClient, cursorCodec, cfg)
+	walletBalanceService := service.NewWalletBalanceService(userRepository, ethClient, cacheClient, cfg)
+	sessionService := service.NewSessionService(sessionRepository, cacheClient)
 	// Pass pointers to the session repository and service
 // Cast the pointers to interfaces to satisfy the function signature
 // Cast the pointers to interfaces to satisfy the function signature
-	userService := service.NewUserService(userRepository, followRepository, counterRepository, sessionRepository, walletService, emailService, sessionService, cacheClient, cfg.JWTSecret, cfg.WalletEncryptionKey)
-	followService := service.NewFollowService(followRepository, userRepository, notificationPublisher)
-	suggestionService := service.NewSuggestionService(userRepository, followRepository)
-	storyService := service.NewStoryService(storyRepository, followRepository, storageClient, cfg)
-	contentService := service.NewContentService(contentRepository, userRepository, followRepository, storageClient, notificationPublisher, cfg)
-	reactionService := service.NewReactionService(reactionRepository, contentRepository, userRepository, notificationPublisher)
-	feedService := service.NewFeedService(contentRepository, followRepository)
-	bookmarkService := service.NewBookmarkService(bookmarkRepository, contentRepository)
-	searchService := service.NewSearchService(userRepository)
-	cronService := service.NewCronService(cfg, storyRepository, storageClient)
+	twoFactorService := service.NewTwoFactorService(userRepository, walletKeyService)
+	emailVerificationService := service.NewEmailVerificationService(userRepository, emailService, cacheClient)
+	siweService := service.NewSIWEService(userRepository, walletLinkRepository, cacheClient, cfg.SIWEDomain, cfg.SIWEChainID)
+	oidcService := service.NewOIDCService(loadOIDCProviders(cfg), userRepository, externalIdentityRepository, counterRepository, walletService, cacheClient)
+	userService := service.NewUserService(userRepository, followRepository, counterRepository, sessionRepository, walletService, emailService, sessionService, timelineService, twoFactorService, emailVerificationService, siweService, oidcService, cacheClient, jwtKeys, walletKeyService, walletUnlockService, walletTransactionService, blockService)
+	followService := service.NewFollowService(followRepository, userRepository, notificationPublisher, timelineService, blockService, cursorCodec)
+	suggestionService := service.NewSuggestionService(userRepository, followRepository, blockService)
+	storyService := service.NewStoryService(storyRepository, followRepository, blockService, storageClient, cfg)
+	contentService := service.NewContentService(contentRepository, userRepository, followRepository, storageClient, cacheClient, notificationPublisher, timelineService, blockService, cursorCodec, cfg)
+	reactionService := service.NewReactionService(reactionRepository, contentRepository, userRepository, followRepository, blockService, notificationPublisher)
+	feedService := service.NewFeedService(contentRepository, followRepository, reactionRepository, userRepository, timelineService, blockService, cacheClient, ranking.NewWeightedScorer(ranking.DefaultWeights), cursorCodec)
+	bookmarkService := service.NewBookmarkService(bookmarkRepository, contentRepository, cursorCodec)
+	searchService := service.NewSearchService(userRepository, blockService, cursorCodec)
+	jobService := service.NewJobService(jobRepository, contentRepository, reactionRepository, bookmarkRepository, notificationRepository, timelineService, storageClient, cfg)
+	cronService := service.NewCronService(cfg, storyRepository, contentRepository, storageClient, cacheClient, jobService, walletTransactionService)
 
 	// Start background NATS worker for processing notification events
 	go startNATSWorker(cfg, notificationService)
 
-	// Start background cron jobs for scheduled tasks (e.g., story cleanup)
+	// Start background cron jobs for scheduled tasks (e.g., story cleanup, view count flushes, post cleanup jobs, wallet transaction tracking)
 	go cronService.Start()
 
 	// Initialize all HTTP handlers with their corresponding services
 	userHandler := httphandler.NewUserHandler(userService)
 	followHandler := httphandler.NewFollowHandler(followService)
+	blockHandler := httphandler.NewBlockHandler(blockService)
 	suggestionHandler := httphandler.NewSuggestionHandler(suggestionService)
 	storyHandler := httphandler.NewStoryHandler(storyService)
 	contentHandler := httphandler.NewContentHandler(contentService)
@@ -113,9 +167,14 @@ func main() {
 	searchHandler := httphandler.NewSearchHandler(searchService)
 	notificationHandler := httphandler.NewNotificationHandler(notificationService)
 	sessionHandler := httphandler.NewSessionHandler(sessionService)
+	twoFactorHandler := httphandler.NewTwoFactorHandler(twoFactorService)
+	emailVerificationHandler := httphandler.NewEmailVerificationHandler(emailVerificationService)
+	walletLinkHandler := httphandler.NewWalletLinkHandler(siweService)
+	oidcHandler := httphandler.NewOIDCHandler(oidcService, userService)
+	walletHandler := httphandler.NewWalletHandler(walletTransactionService, walletBalanceService)
 
 	// Configure HTTP router with all endpoints and middleware
-	router := httphandler.SetupRouter(userHandler, followHandler, suggestionHandler, storyHandler, contentHandler, reactionHandler, feedHandler, bookmarkHandler, searchHandler, notificationHandler, sessionHandler, sessionService, cfg)
+	router := httphandler.SetupRouter(userHandler, followHandler, blockHandler, suggestionHandler, storyHandler, contentHandler, reactionHandler, feedHandler, bookmarkHandler, searchHandler, notificationHandler, sessionHandler, twoFactorHandler, emailVerificationHandler, walletLinkHandler, oidcHandler, walletHandler, sessionService, emailVerificationService, rateLimiter, jwtKeys, cfg)
 
 	// Configure HTTP server with appropriate timeouts and settings
 	server := &http.Server{
@@ -164,8 +223,8 @@ func startNATSWorker(cfg *config.Config, notificationService service.Notificatio
 
 		// Create notification in database using the event data
 		// The original CreateNotification service method expected metadata as the last argument.
-		// Now we pass the specific PostID from the event for better context.
-		if err := notificationService.CreateNotification(context.Background(), event.UserID, event.ActorID, event.Type, event.PostID); err != nil {
+		// Now we pass the specific PostID and CommentID from the event for better context.
+		if err := notificationService.CreateNotification(context.Background(), event.UserID, event.ActorID, event.Type, event.PostID, event.CommentID); err != nil {
 			log.Error().Err(err).Msg("Failed to create notification from NATS event")
 		}
 	})
@@ -178,3 +237,74 @@ func startNATSWorker(cfg *config.Config, notificationService service.Notificatio
 	// Keep the worker running indefinitely to process events
 	select {}
 }
+
+// loadJWTKeys builds the access token key set from configuration. A signing key
+// is required unless JWT_EPHEMERAL_KEY is set, in which case an ephemeral one is
+// generated. That is only suitable for local development: tokens stop working
+// on restart and aren't shared between instances.
+//
+// Parameters:
+//   - cfg: Application configuration containing the PEM-encoded keys
+//
+// Returns:
+//   - *jwtkeys.KeySet: The key set used to sign and verify access tokens
+func loadJWTKeys(cfg *config.Config) *jwtkeys.KeySet {
+	if cfg.JWTSigningKey == "" {
+		if !cfg.JWTEphemeralKey {
+			log.Fatal().Msg("JWT_SIGNING_KEY is not set; set JWT_EPHEMERAL_KEY=true to generate a throwaway key for local development")
+		}
+		log.Warn().Msg("JWT_SIGNING_KEY is not set, generating an ephemeral signing key")
+		keys, err := jwtkeys.GenerateKeySet()
+		if err != nil {
+			log.Fatal().Err(err).Msg("Failed to generate JWT signing key")
+		}
+		return keys
+	}
+
+	keys, err := jwtkeys.NewKeySet(cfg.JWTSigningKey, cfg.JWTVerificationKeys)
+	if err != nil {
+		log.Fatal().Err(err).Msg("Failed to load JWT keys")
+	}
+	log.Info().Str("kid", keys.SigningKeyID()).Msg("Loaded JWT signing key")
+	return keys
+}
+
+// loadWalletKeys loads the master keys that wrap the wallet data keys.
+//
+// Parameters:
+//   - cfg: Application configuration containing the master key set
+//
+// Returns:
+//   - envelope.KeyProvider: The provider of the master keys
+func loadWalletKeys(cfg *config.Config) envelope.KeyProvider {
+	keys, err := service.LoadWalletKeyProvider(cfg)
+	if err != nil {
+		log.Fatal().Err(err).Msg("Failed to load wallet master keys")
+	}
+	log.Info().Int("version", keys.CurrentVersion()).Msg("Loaded wallet master keys")
+	return keys
+}
+
+// loadOIDCProviders creates clients for the configured OpenID Connect providers.
+// Their discovery documents are fetched on first use.
+//
+// Parameters:
+//   - cfg: Application configuration containing the provider registrations
+//
+// Returns:
+//   - []*oidc.Provider: One client per configured provider
+func loadOIDCProviders(cfg *config.Config) []*oidc.Provider {
+	providers := make([]*oidc.Provider, 0, len(cfg.OIDCProviders))
+	for _, p := range cfg.OIDCProviders {
+		providers = append(providers, oidc.NewProvider(oidc.Config{
+			Name:         p.Name,
+			IssuerURL:    p.IssuerURL,
+			ClientID:     p.ClientID,
+			ClientSecret: p.ClientSecret,
+			RedirectURL:  p.RedirectURL,
+			Scopes:       p.Scopes,
+		}, nil))
+		log.Info().Str("provider", p.Name).Str("issuer", p.IssuerURL).Msg("Configured OpenID Connect provider")
+	}
+	return providers
+}
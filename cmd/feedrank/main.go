@@ -0,0 +1,67 @@
+// Command feedrank ranks a fixture of feed candidates offline, so scoring
+// changes can be compared without a database:
+//
+//	go run ./cmd/feedrank -fixture test/fixtures/feed_candidates.json -scorer weighted
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+	"text/tabwriter"
+	"time"
+	"vybes/internal/ranking"
+)
+
+// fixture is the on-disk format: the evaluation time and the candidates to rank.
+type fixture struct {
+	Now        time.Time           `json:"now"`
+	Candidates []ranking.Candidate `json:"candidates"`
+}
+
+func main() {
+	fixturePath := flag.String("fixture", "test/fixtures/feed_candidates.json", "path to a JSON candidate fixture")
+	scorerName := flag.String("scorer", "weighted", "scorer to use: weighted or recency")
+	decay := flag.Float64("diversity", ranking.DefaultDiversityDecay, "score multiplier per earlier post by the same author (1 disables)")
+	flag.Parse()
+
+	data, err := os.ReadFile(*fixturePath)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "failed to read fixture: %v\n", err)
+		os.Exit(1)
+	}
+	var fx fixture
+	if err := json.Unmarshal(data, &fx); err != nil {
+		fmt.Fprintf(os.Stderr, "failed to parse fixture: %v\n", err)
+		os.Exit(1)
+	}
+	if fx.Now.IsZero() {
+		fx.Now = time.Now()
+	}
+
+	var scorer ranking.Scorer
+	switch *scorerName {
+	case "weighted":
+		scorer = ranking.NewWeightedScorer(ranking.DefaultWeights)
+	case "recency":
+		scorer = ranking.RecencyScorer{HalfLife: ranking.DefaultWeights.RecencyHalfLife}
+	default:
+		fmt.Fprintf(os.Stderr, "unknown scorer %q\n", *scorerName)
+		os.Exit(2)
+	}
+
+	ranker := ranking.NewRanker(scorer)
+	ranker.DiversityDecay = *decay
+	ranked := ranker.Rank(fx.Candidates, fx.Now)
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "RANK\tSCORE\tSOURCE\tAUTHOR\tPOST\tAGE\tLIKES\tCOMMENTS\tREPOSTS\tVIEWS\tAFFINITY")
+	for i, c := range ranked {
+		p := c.Post
+		fmt.Fprintf(w, "%d\t%.3f\t%s\t%s\t%s\t%s\t%d\t%d\t%d\t%d\t%.2f\n",
+			i+1, c.Score, c.Source, p.UserID.Hex(), p.ID.Hex(), fx.Now.Sub(p.CreatedAt).Round(time.Minute),
+			p.LikeCount, p.CommentCount, p.RepostCount, p.ViewCount, c.Affinity)
+	}
+	w.Flush()
+}
@@ -0,0 +1,61 @@
+// Command rewrapkeys wraps every stored wallet and two-factor data key with the
+// current master key, and moves secrets stored before envelope encryption onto
+// it:
+//
+//	go run ./cmd/rewrapkeys
+//
+// It can run while the API serves traffic. To rotate the master key:
+//
+//  1. Add the new key to WALLET_MASTER_KEYS and make it the currentVersion,
+//     keeping the old versions listed.
+//  2. Deploy the API with the new key set, so every instance can read both.
+//  3. Run this command with the same configuration until it exits successfully,
+//     which it only does once no stored secret uses an old version.
+//  4. Remove the old versions from the key set.
+package main
+
+import (
+	"context"
+	"os"
+	"vybes/internal/config"
+	"vybes/internal/repository"
+	"vybes/internal/service"
+
+	"github.com/rs/zerolog"
+	"github.com/rs/zerolog/log"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+func main() {
+	log.Logger = log.Output(zerolog.ConsoleWriter{Out: os.Stderr})
+
+	cfg, err := config.LoadConfig()
+	if err != nil {
+		log.Fatal().Err(err).Msg("Failed to load configuration")
+	}
+
+	client, err := mongo.Connect(context.Background(), options.Client().ApplyURI(cfg.MongoURI))
+	if err != nil {
+		log.Fatal().Err(err).Msg("Failed to establish MongoDB connection")
+	}
+	defer client.Disconnect(context.Background())
+	db := client.Database(cfg.DBName)
+
+	keys, err := service.LoadWalletKeyProvider(cfg)
+	if err != nil {
+		log.Fatal().Err(err).Msg("Failed to load wallet master keys")
+	}
+	walletKeyService := service.NewWalletKeyService(repository.NewMongoUserRepository(db), keys, cfg.WalletEncryptionKey)
+
+	log.Info().Int("version", keys.CurrentVersion()).Msg("Re-wrapping wallet keys and two-factor secrets")
+	result, err := walletKeyService.RewrapAll(context.Background())
+	if err != nil {
+		log.Fatal().Err(err).Int("rewrapped", result.Rewrapped).Msg("Re-wrapping stopped")
+	}
+	log.Info().Int("rewrapped", result.Rewrapped).Int("failed", result.Failed).Int("remaining", result.Remaining).Msg("Re-wrapping finished")
+	if result.Failed > 0 || result.Remaining > 0 {
+		log.Error().Int("remaining", result.Remaining).Msg("Secrets still use an old master key version, keep the old versions and run again")
+		os.Exit(1)
+	}
+}
@@ -1,24 +1,77 @@
 package config
 
 import (
+	"encoding/hex"
+	"fmt"
 	"log"
+	"net"
 	"os"
 	"strconv"
+	"strings"
+	"time"
 
 	"github.com/joho/godotenv"
 )
 
+// RateLimit allows Limit requests per Window
+type RateLimit struct {
+	Limit  int
+	Window time.Duration
+}
+
+// OIDCProvider is an OpenID Connect provider users can sign in with
+type OIDCProvider struct {
+	Name         string // Used in routes; lower case
+	IssuerURL    string
+	ClientID     string
+	ClientSecret string
+	RedirectURL  string
+	Scopes       []string
+}
+
+// WalletToken is an ERC-20 token whose balance the wallet balance endpoint shows
+type WalletToken struct {
+	Symbol  string
+	Address string // Hex contract address
+}
+
 // Config holds the application configuration
 type Config struct {
 	Port                string
 	MongoURI            string
 	DBName              string
-	JWTSecret           string
+	CursorSecret        string // Signs pagination cursors
+	JWTSigningKey       string // PEM private key (Ed25519 or P-256) that signs access tokens
+	JWTVerificationKeys string // PEM keys of retired signing keys whose tokens are still accepted
+	JWTEphemeralKey     bool   // Generate a throwaway signing key when JWTSigningKey is unset; local development only
 	ResendAPIKey        string
 	SenderEmail         string
-	WalletEncryptionKey string
+	WalletEncryptionKey string // Decrypts wallet keys and two-factor secrets stored before envelope encryption
+	WalletMasterKeys    string // JSON master key set that wraps wallet data keys; derived from WalletEncryptionKey when empty
 	EthRPCURL           string
 
+	// Sign-In with Ethereum Configuration
+	SIWEDomain  string // Domain that signed messages must name; sign-in is disabled when empty
+	SIWEChainID int64  // Chain ID that signed messages must name
+
+	// OpenID Connect Configuration
+	OIDCProviders []OIDCProvider
+
+	// Email Verification Configuration
+	RequireVerifiedEmail bool // Unverified accounts can't post or send wallet transactions
+
+	// Rate Limiting Configuration
+	RateLimitEnabled bool
+	RateLimitDefault RateLimit // Every API request, per user or client IP
+	RateLimitAuth    RateLimit // Register, login, token refresh and email verification per client IP; 2FA, wallet unlock and export per user
+	RateLimitOTP     RateLimit // Password reset OTP requests and attempts, per client IP
+	RateLimitWrite   RateLimit // Posting, commenting, liking and following, per user
+
+	// Client IP Configuration. Rate limits and view counts key on the client IP,
+	// so forwarding headers are only believed from trusted proxies.
+	TrustedProxies  []string // IPs or CIDRs of reverse proxies whose X-Forwarded-For is trusted; none by default
+	TrustedPlatform string   // Header set by the hosting platform holding the client IP, e.g. CF-Connecting-IP
+
 	// R2 Configuration
 	R2AccountID       string
 	R2Endpoint        string
@@ -35,6 +88,24 @@ type Config struct {
 
 	// NATS Configuration
 	NatsURL string
+
+	// View Counting Configuration
+	ViewDedupWindow   time.Duration // How long a repeat view from the same viewer is ignored
+	ViewFlushInterval time.Duration // How often buffered view counts are written to MongoDB
+
+	// Background Job Configuration
+	JobPollInterval time.Duration // How often the jobs collection is polled for runnable jobs
+
+	// Wallet Transaction Tracking Configuration
+	WalletTxPollInterval time.Duration // How often pending wallet transactions are checked for receipts
+	WalletTxDropTimeout  time.Duration // How long a transaction the node doesn't know about stays pending before it counts as dropped
+
+	// Wallet Balance Configuration
+	WalletTokens          []WalletToken // ERC-20 tokens reported next to the native balance
+	WalletBalanceCacheTTL time.Duration // How long a wallet's balances are cached
+
+	// Home Timeline Configuration
+	TimelineCelebrityThreshold int // Authors with more followers than this are merged into timelines at read time instead of fanned out
 }
 
 // LoadConfig loads configuration from environment variables or a .env file
@@ -54,14 +125,55 @@ func LoadConfig() (*Config, error) {
 		redisDB = 0 // Default DB
 	}
 
+	// Anyone holding the secret can forge pagination cursors, so there's no default
+	cursorSecret, err := getSecretEnv("CURSOR_SECRET")
+	if err != nil {
+		return nil, err
+	}
+	if cursorSecret == "" {
+		return nil, fmt.Errorf("CURSOR_SECRET must be set")
+	}
+
+	jwtSigningKey, err := getSecretEnv("JWT_SIGNING_KEY")
+	if err != nil {
+		return nil, err
+	}
+	jwtVerificationKeys, err := getSecretEnv("JWT_VERIFICATION_KEYS")
+	if err != nil {
+		return nil, err
+	}
+	walletMasterKeys, err := getSecretEnv("WALLET_MASTER_KEYS")
+	if err != nil {
+		return nil, err
+	}
+
+	oidcProviders, err := getOIDCProvidersEnv()
+	if err != nil {
+		return nil, err
+	}
+
+	walletTokens, err := getWalletTokensEnv()
+	if err != nil {
+		return nil, err
+	}
+
+	trustedProxies, err := getTrustedProxiesEnv()
+	if err != nil {
+		return nil, err
+	}
+
 	return &Config{
 		Port:                port,
 		MongoURI:            os.Getenv("MONGO_URI"),
 		DBName:              os.Getenv("DB_NAME"),
-		JWTSecret:           os.Getenv("JWT_SECRET"),
+		CursorSecret:        cursorSecret,
+		JWTSigningKey:       jwtSigningKey,
+		JWTVerificationKeys: jwtVerificationKeys,
+		JWTEphemeralKey:     getBoolEnv("JWT_EPHEMERAL_KEY", false),
 		ResendAPIKey:        os.Getenv("RESEND_API_KEY"),
 		SenderEmail:         os.Getenv("SENDER_EMAIL"),
 		WalletEncryptionKey: os.Getenv("WALLET_ENCRYPTION_KEY"),
+		WalletMasterKeys:    walletMasterKeys,
 		EthRPCURL:           os.Getenv("ETH_RPC_URL"),
 		R2AccountID:         os.Getenv("R2_ACCOUNT_ID"),
 		R2Endpoint:          os.Getenv("R2_ENDPOINT"),
@@ -74,5 +186,167 @@ func LoadConfig() (*Config, error) {
 		RedisPassword:       os.Getenv("REDIS_PASSWORD"),
 		RedisDB:             redisDB,
 		NatsURL:             os.Getenv("NATS_URL"),
+		ViewDedupWindow:     getDurationEnv("VIEW_DEDUP_WINDOW", 30*time.Minute),
+		ViewFlushInterval:   getDurationEnv("VIEW_FLUSH_INTERVAL", time.Minute),
+		JobPollInterval:     getDurationEnv("JOB_POLL_INTERVAL", 30*time.Second),
+
+		WalletTxPollInterval:       getDurationEnv("WALLET_TX_POLL_INTERVAL", 15*time.Second),
+		WalletTxDropTimeout:        getDurationEnv("WALLET_TX_DROP_TIMEOUT", 30*time.Minute),
+		WalletTokens:               walletTokens,
+		WalletBalanceCacheTTL:      getDurationEnv("WALLET_BALANCE_CACHE_TTL", 30*time.Second),
+		TimelineCelebrityThreshold: getIntEnv("TIMELINE_CELEBRITY_THRESHOLD", 10000),
+		RequireVerifiedEmail:       getBoolEnv("REQUIRE_VERIFIED_EMAIL", false),
+		SIWEDomain:                 os.Getenv("SIWE_DOMAIN"),
+		SIWEChainID:                int64(getIntEnv("SIWE_CHAIN_ID", 1)),
+		OIDCProviders:              oidcProviders,
+		RateLimitEnabled:           getBoolEnv("RATE_LIMIT_ENABLED", true),
+		RateLimitDefault:           getRateLimitEnv("RATE_LIMIT_DEFAULT", RateLimit{Limit: 300, Window: time.Minute}),
+		RateLimitAuth:              getRateLimitEnv("RATE_LIMIT_AUTH", RateLimit{Limit: 10, Window: time.Minute}),
+		RateLimitOTP:               getRateLimitEnv("RATE_LIMIT_OTP", RateLimit{Limit: 5, Window: 15 * time.Minute}),
+		RateLimitWrite:             getRateLimitEnv("RATE_LIMIT_WRITE", RateLimit{Limit: 60, Window: time.Minute}),
+		TrustedProxies:             trustedProxies,
+		TrustedPlatform:            os.Getenv("TRUSTED_PLATFORM"),
 	}, nil
 }
+
+// getDurationEnv reads a duration such as "30s" or "15m" from the environment,
+// falling back to the default when it is unset or malformed.
+func getDurationEnv(key string, fallback time.Duration) time.Duration {
+	d, err := time.ParseDuration(os.Getenv(key))
+	if err != nil || d <= 0 {
+		return fallback
+	}
+	return d
+}
+
+// getIntEnv reads a positive integer from the environment,
+// falling back to the default when it is unset or malformed.
+func getIntEnv(key string, fallback int) int {
+	n, err := strconv.Atoi(os.Getenv(key))
+	if err != nil || n <= 0 {
+		return fallback
+	}
+	return n
+}
+
+// getBoolEnv reads a boolean such as "true" or "0" from the environment,
+// falling back to the default when it is unset or malformed.
+func getBoolEnv(key string, fallback bool) bool {
+	b, err := strconv.ParseBool(os.Getenv(key))
+	if err != nil {
+		return fallback
+	}
+	return b
+}
+
+// getRateLimitEnv reads a limit written as "<requests>/<window>", such as
+// "10/1m", from the environment, falling back to the default when it is unset
+// or malformed.
+func getRateLimitEnv(key string, fallback RateLimit) RateLimit {
+	limit, window, ok := strings.Cut(os.Getenv(key), "/")
+	if !ok {
+		return fallback
+	}
+	n, err := strconv.Atoi(limit)
+	if err != nil || n <= 0 {
+		return fallback
+	}
+	d, err := time.ParseDuration(window)
+	if err != nil || d <= 0 {
+		return fallback
+	}
+	return RateLimit{Limit: n, Window: d}
+}
+
+// getSecretEnv reads a secret such as PEM data from the environment variable
+// key, or from the file named by key+"_FILE" when the variable itself is unset.
+// Escaped newlines are expanded so a key can be pasted into a single-line variable.
+func getSecretEnv(key string) (string, error) {
+	if value := os.Getenv(key); value != "" {
+		return strings.ReplaceAll(value, `\n`, "\n"), nil
+	}
+	path := os.Getenv(key + "_FILE")
+	if path == "" {
+		return "", nil
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return "", fmt.Errorf("failed to read %s_FILE: %w", key, err)
+	}
+	return string(data), nil
+}
+
+// getOIDCProvidersEnv reads the providers named in OIDC_PROVIDERS, such as
+// "google,mock". Each provider is configured with OIDC_<NAME>_ISSUER,
+// OIDC_<NAME>_CLIENT_ID, OIDC_<NAME>_CLIENT_SECRET, OIDC_<NAME>_REDIRECT_URL and
+// optionally OIDC_<NAME>_SCOPES, a space separated list.
+func getOIDCProvidersEnv() ([]OIDCProvider, error) {
+	var providers []OIDCProvider
+	for _, name := range strings.Split(os.Getenv("OIDC_PROVIDERS"), ",") {
+		name = strings.ToLower(strings.TrimSpace(name))
+		if name == "" {
+			continue
+		}
+		prefix := "OIDC_" + strings.ToUpper(strings.ReplaceAll(name, "-", "_")) + "_"
+		provider := OIDCProvider{
+			Name:         name,
+			IssuerURL:    os.Getenv(prefix + "ISSUER"),
+			ClientID:     os.Getenv(prefix + "CLIENT_ID"),
+			ClientSecret: os.Getenv(prefix + "CLIENT_SECRET"),
+			RedirectURL:  os.Getenv(prefix + "REDIRECT_URL"),
+			Scopes:       strings.Fields(os.Getenv(prefix + "SCOPES")),
+		}
+		if provider.IssuerURL == "" || provider.ClientID == "" || provider.RedirectURL == "" {
+			return nil, fmt.Errorf("OIDC provider %q needs %sISSUER, %sCLIENT_ID and %sREDIRECT_URL", name, prefix, prefix, prefix)
+		}
+		providers = append(providers, provider)
+	}
+	return providers, nil
+}
+
+// getWalletTokensEnv reads the ERC-20 allowlist in WALLET_TOKENS, a comma
+// separated list of SYMBOL:ADDRESS pairs such as
+// "USDC:0xA0b86991c6218b36c1d19D4a2e9Eb0cE3606eB48".
+func getWalletTokensEnv() ([]WalletToken, error) {
+	var tokens []WalletToken
+	for _, entry := range strings.Split(os.Getenv("WALLET_TOKENS"), ",") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+		symbol, address, ok := strings.Cut(entry, ":")
+		symbol, address = strings.TrimSpace(symbol), strings.TrimSpace(address)
+		if !ok || symbol == "" || !isHexAddress(address) {
+			return nil, fmt.Errorf("invalid WALLET_TOKENS entry %q, want SYMBOL:0x<40 hex digits>", entry)
+		}
+		tokens = append(tokens, WalletToken{Symbol: symbol, Address: address})
+	}
+	return tokens, nil
+}
+
+// getTrustedProxiesEnv reads TRUSTED_PROXIES, a comma separated list of IP
+// addresses and CIDR ranges such as "10.0.0.0/8,192.168.1.10".
+func getTrustedProxiesEnv() ([]string, error) {
+	var proxies []string
+	for _, entry := range strings.Split(os.Getenv("TRUSTED_PROXIES"), ",") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+		if net.ParseIP(entry) == nil {
+			if _, _, err := net.ParseCIDR(entry); err != nil {
+				return nil, fmt.Errorf("invalid TRUSTED_PROXIES entry %q, want an IP address or CIDR range", entry)
+			}
+		}
+		proxies = append(proxies, entry)
+	}
+	return proxies, nil
+}
+
+func isHexAddress(s string) bool {
+	if len(s) != 42 || !strings.HasPrefix(s, "0x") {
+		return false
+	}
+	_, err := hex.DecodeString(s[2:])
+	return err == nil
+}
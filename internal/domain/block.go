@@ -0,0 +1,25 @@
+package domain
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// Block represents one user blocking another. Blocks work in both directions:
+// neither user can follow the other or see the other's content.
+type Block struct {
+	ID        primitive.ObjectID `bson:"_id,omitempty" json:"id,omitempty"`
+	BlockerID primitive.ObjectID `bson:"blockerId" json:"blockerId"` // The user who blocked
+	BlockedID primitive.ObjectID `bson:"blockedId" json:"blockedId"` // The user who was blocked
+	CreatedAt time.Time          `bson:"createdAt" json:"createdAt"`
+}
+
+// Mute represents one user muting another. Muting only hides the muted user's
+// content from the muter; the muted user is not told and can still follow them.
+type Mute struct {
+	ID        primitive.ObjectID `bson:"_id,omitempty" json:"id,omitempty"`
+	MuterID   primitive.ObjectID `bson:"muterId" json:"muterId"` // The user who muted
+	MutedID   primitive.ObjectID `bson:"mutedId" json:"mutedId"` // The user who was muted
+	CreatedAt time.Time          `bson:"createdAt" json:"createdAt"`
+}
@@ -6,11 +6,27 @@ import (
 	"go.mongodb.org/mongo-driver/bson/primitive"
 )
 
-// Comment represents a comment on a post.
+// MaxCommentDepth is the deepest level a reply can be nested at. Top-level comments are depth 0.
+const MaxCommentDepth = 3
+
+// Comment represents a comment on a post, or a reply to another comment.
 type Comment struct {
-	ID        primitive.ObjectID `bson:"_id,omitempty" json:"id,omitempty"`
-	UserID    primitive.ObjectID `bson:"userId" json:"userId"`
-	PostID    primitive.ObjectID `bson:"postId" json:"postId"`
-	Text      string             `bson:"text" json:"text"`
-	CreatedAt time.Time          `bson:"createdAt" json:"createdAt"`
+	ID          primitive.ObjectID  `bson:"_id,omitempty" json:"id,omitempty"`
+	UserID      primitive.ObjectID  `bson:"userId" json:"userId"`
+	PostID      primitive.ObjectID  `bson:"postId" json:"postId"`
+	ParentID    *primitive.ObjectID `bson:"parentId,omitempty" json:"parentId,omitempty"` // Nil for top-level comments
+	Depth       int                 `bson:"depth" json:"depth"`
+	Text        string              `bson:"text" json:"text"`
+	ReplyCount  int64               `bson:"replyCount" json:"replyCount"`
+	LikeCount   int64               `bson:"likeCount" json:"likeCount"`
+	EditHistory []CommentEdit       `bson:"editHistory,omitempty" json:"editHistory,omitempty"`
+	Deleted     bool                `bson:"deleted,omitempty" json:"deleted,omitempty"` // Tombstone kept so its replies stay threaded
+	EditedAt    *time.Time          `bson:"editedAt,omitempty" json:"editedAt,omitempty"`
+	CreatedAt   time.Time           `bson:"createdAt" json:"createdAt"`
+}
+
+// CommentEdit is a previous version of a comment's text.
+type CommentEdit struct {
+	Text       string    `bson:"text" json:"text"`
+	ReplacedAt time.Time `bson:"replacedAt" json:"replacedAt"` // When this version was replaced by an edit
 }
@@ -0,0 +1,18 @@
+package domain
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// ExternalIdentity connects an OpenID Connect account to a user, so the user can
+// sign in with that provider. Each provider account can belong to one user only.
+type ExternalIdentity struct {
+	ID        primitive.ObjectID `bson:"_id,omitempty" json:"id,omitempty"`
+	UserID    primitive.ObjectID `bson:"userId" json:"-"`
+	Provider  string             `bson:"provider" json:"provider"` // Configured provider name, e.g. "google"
+	Subject   string             `bson:"subject" json:"-"`         // The provider's stable user ID ("sub" claim)
+	Email     string             `bson:"email,omitempty" json:"email,omitempty"`
+	CreatedAt time.Time          `bson:"createdAt" json:"createdAt"`
+}
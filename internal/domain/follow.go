@@ -1,6 +1,8 @@
 package domain
 
 import (
+	"time"
+
 	"go.mongodb.org/mongo-driver/bson/primitive"
 )
 
@@ -10,3 +12,21 @@ type Follow struct {
 	FollowerID  primitive.ObjectID `bson:"followerId" json:"followerId"`   // The user who is doing the following
 	FollowingID primitive.ObjectID `bson:"followingId" json:"followingId"` // The user who is being followed
 }
+
+// FollowRequest is a pending request to follow a private account. It turns
+// into a Follow when the account owner approves it.
+type FollowRequest struct {
+	ID          primitive.ObjectID `bson:"_id,omitempty" json:"id,omitempty"`
+	RequesterID primitive.ObjectID `bson:"requesterId" json:"requesterId"` // The user who wants to follow
+	TargetID    primitive.ObjectID `bson:"targetId" json:"targetId"`       // The private account being requested
+	CreatedAt   time.Time          `bson:"createdAt" json:"createdAt"`
+	Requester   *User              `bson:"-" json:"requester,omitempty"` // Populated when listing requests
+}
+
+// FollowStatus describes the outcome of asking to follow a user.
+type FollowStatus string
+
+const (
+	FollowStatusFollowing FollowStatus = "following" // The follow took effect immediately
+	FollowStatusRequested FollowStatus = "requested" // The account is private and a request awaits approval
+)
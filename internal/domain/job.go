@@ -0,0 +1,45 @@
+package domain
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// JobType defines the kind of background job.
+type JobType string
+
+const (
+	JobTypePostCleanup JobType = "post_cleanup" // Removes records that depend on a deleted post
+)
+
+// JobStatus defines the lifecycle state of a background job.
+type JobStatus string
+
+const (
+	JobStatusPending JobStatus = "pending"
+	JobStatusRunning JobStatus = "running"
+	JobStatusDone    JobStatus = "done"
+	JobStatusFailed  JobStatus = "failed" // Gave up after exhausting all attempts
+)
+
+// Job represents a durable background job stored in MongoDB.
+// Jobs are claimed with a lease so a crashed worker's job is picked up again.
+type Job struct {
+	ID          primitive.ObjectID `bson:"_id,omitempty" json:"id,omitempty"`
+	Type        JobType            `bson:"type" json:"type"`
+	Status      JobStatus          `bson:"status" json:"status"`
+	Attempts    int                `bson:"attempts" json:"attempts"`
+	MaxAttempts int                `bson:"maxAttempts" json:"maxAttempts"`
+	LastError   string             `bson:"lastError,omitempty" json:"lastError,omitempty"`
+	RunAt       time.Time          `bson:"runAt" json:"runAt"`             // Earliest time the job may run
+	LockedUntil time.Time          `bson:"lockedUntil" json:"lockedUntil"` // Lease expiry while running
+
+	// Post cleanup payload: a snapshot of the deleted post
+	PostID     primitive.ObjectID `bson:"postId,omitempty" json:"postId,omitempty"`
+	AuthorID   primitive.ObjectID `bson:"authorId,omitempty" json:"authorId,omitempty"`
+	ContentURL string             `bson:"contentUrl,omitempty" json:"contentUrl,omitempty"`
+
+	CreatedAt time.Time `bson:"createdAt" json:"createdAt"`
+	UpdatedAt time.Time `bson:"updatedAt" json:"updatedAt"`
+}
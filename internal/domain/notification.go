@@ -10,18 +10,29 @@ import (
 type NotificationType string
 
 const (
-	NotificationTypeLike    NotificationType = "like"
-	NotificationTypeComment NotificationType = "comment"
-	NotificationTypeFollow  NotificationType = "follow"
+	NotificationTypeLike        NotificationType = "like"
+	NotificationTypeComment     NotificationType = "comment"
+	NotificationTypeFollow      NotificationType = "follow"
+	NotificationTypeRepost      NotificationType = "repost"
+	NotificationTypeReply       NotificationType = "reply"
+	NotificationTypeCommentLike NotificationType = "comment_like"
+	// NotificationTypeFollowRequest tells a private account that someone asked to follow it
+	NotificationTypeFollowRequest NotificationType = "follow_request"
+	// NotificationTypeFollowAccepted tells a requester that their follow request was approved
+	NotificationTypeFollowAccepted NotificationType = "follow_accepted"
+	// NotificationTypeTransactionConfirmed tells a user that a transaction sent from their wallet was mined
+	NotificationTypeTransactionConfirmed NotificationType = "transaction_confirmed"
 )
 
 // Notification represents a user notification.
 type Notification struct {
-	ID        primitive.ObjectID  `bson:"_id,omitempty" json:"id,omitempty"`
-	UserID    primitive.ObjectID  `bson:"userId" json:"userId"`   // The user who receives the notification
-	ActorID   primitive.ObjectID  `bson:"actorId" json:"actorId"` // The user who triggered the notification
-	Type      NotificationType    `bson:"type" json:"type"`
-	PostID    *primitive.ObjectID `bson:"postId,omitempty" json:"postId,omitempty"` // Optional, for like/comment
-	Read      bool                `bson:"read" json:"read"`
-	CreatedAt time.Time           `bson:"createdAt" json:"createdAt"`
+	ID            primitive.ObjectID  `bson:"_id,omitempty" json:"id,omitempty"`
+	UserID        primitive.ObjectID  `bson:"userId" json:"userId"`   // The user who receives the notification
+	ActorID       primitive.ObjectID  `bson:"actorId" json:"actorId"` // The user who triggered the notification
+	Type          NotificationType    `bson:"type" json:"type"`
+	PostID        *primitive.ObjectID `bson:"postId,omitempty" json:"postId,omitempty"`               // Optional, for like/comment/repost
+	CommentID     *primitive.ObjectID `bson:"commentId,omitempty" json:"commentId,omitempty"`         // Optional, for reply/comment_like
+	TransactionID *primitive.ObjectID `bson:"transactionId,omitempty" json:"transactionId,omitempty"` // Optional, for transaction_confirmed
+	Read          bool                `bson:"read" json:"read"`
+	CreatedAt     time.Time           `bson:"createdAt" json:"createdAt"`
 }
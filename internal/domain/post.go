@@ -31,4 +31,12 @@ type Post struct {
 	Visibility     PostVisibility      `bson:"visibility" json:"visibility"`
 	CreatedAt      time.Time           `bson:"createdAt" json:"createdAt"`
 	UpdatedAt      time.Time           `bson:"updatedAt" json:"updatedAt"`
+
+	// OriginalPost is the embedded original of a repost. It is hydrated at read time and never persisted.
+	OriginalPost *Post `bson:"-" json:"originalPost,omitempty"`
+}
+
+// IsRepost reports whether the post is a repost (plain or quoted) of another post.
+func (p *Post) IsRepost() bool {
+	return p.OriginalPostID != nil
 }
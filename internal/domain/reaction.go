@@ -13,11 +13,20 @@ const (
 	ReactionTypeLike ReactionType = "like"
 )
 
-// Reaction represents a reaction to a post.
+// ReactionTarget defines what kind of item a reaction is attached to.
+type ReactionTarget string
+
+const (
+	ReactionTargetPost    ReactionTarget = "post"
+	ReactionTargetComment ReactionTarget = "comment"
+)
+
+// Reaction represents a reaction to a post or a comment.
 type Reaction struct {
 	ID           primitive.ObjectID `bson:"_id,omitempty" json:"id,omitempty"`
 	UserID       primitive.ObjectID `bson:"userId" json:"userId"`
 	ContentID    primitive.ObjectID `bson:"contentId" json:"contentId"`
+	TargetType   ReactionTarget     `bson:"targetType,omitempty" json:"targetType,omitempty"` // Empty for reactions created before comment likes, which are all posts
 	ReactionType ReactionType       `bson:"reactionType" json:"reactionType"`
 	CreatedAt    time.Time          `bson:"createdAt" json:"createdAt"`
 }
@@ -6,13 +6,25 @@ import (
 	"go.mongodb.org/mongo-driver/bson/primitive"
 )
 
+// Session is a login on one device. It holds one refresh token family: each
+// refresh replaces the current token, and the replaced tokens are remembered so
+// that presenting one again can be recognized as reuse.
 type Session struct {
-	ID           primitive.ObjectID `bson:"_id,omitempty" json:"id"`
-	UserID       primitive.ObjectID `bson:"user_id" json:"user_id"`
-	RefreshToken string             `bson:"refresh_token" json:"refresh_token"`
-	UserAgent    string             `bson:"user_agent" json:"user_agent"`
-	ClientIP     string             `bson:"client_ip" json:"client_ip"`
-	IsBlocked    bool               `bson:"is_blocked" json:"is_blocked"`
-	ExpiresAt    time.Time          `bson:"expires_at" json:"expires_at"`
-	CreatedAt    time.Time          `bson:"created_at" json:"created_at"`
+	ID               primitive.ObjectID `bson:"_id,omitempty" json:"id"`
+	UserID           primitive.ObjectID `bson:"user_id" json:"user_id"`
+	RefreshTokenHash string             `bson:"refresh_token_hash" json:"-"` // SHA-256 of the current refresh token
+	RotatedTokens    []RotatedToken     `bson:"rotated_tokens" json:"-"`     // Refresh tokens already exchanged, most recent last
+	UserAgent        string             `bson:"user_agent" json:"user_agent"`
+	ClientIP         string             `bson:"client_ip" json:"client_ip"`
+	IsBlocked        bool               `bson:"is_blocked" json:"is_blocked"`
+	ExpiresAt        time.Time          `bson:"expires_at" json:"expires_at"`
+	CreatedAt        time.Time          `bson:"created_at" json:"created_at"`
+	LastUsedAt       time.Time          `bson:"last_used_at" json:"last_used_at"` // Login or most recent refresh
+	IsCurrent        bool               `bson:"-" json:"is_current"`              // Set when listing, for the caller's own session
+}
+
+// RotatedToken is a refresh token that was exchanged for a new one.
+type RotatedToken struct {
+	Hash      string    `bson:"hash"` // SHA-256 of the token
+	RotatedAt time.Time `bson:"rotated_at"`
 }
@@ -6,7 +6,10 @@ import (
 	"go.mongodb.org/mongo-driver/bson/primitive"
 )
 
-// User represents a user in the database.
+// User represents a user in the database. The document holds the password,
+// reset OTP, wallet key and two-factor state, so it is only ever changed with
+// field-scoped updates: writing back a whole User loaded earlier could undo a
+// password reset or key rotation that happened in between.
 type User struct {
 	ID                  primitive.ObjectID `bson:"_id,omitempty" json:"id,omitempty"`
 	VID                 int64              `bson:"vid" json:"vid"`
@@ -19,8 +22,37 @@ type User struct {
 	Bio                 string             `bson:"bio,omitempty" json:"bio,omitempty"`
 	WalletAddress       string             `bson:"walletAddress" json:"walletAddress"`
 	EncryptedPrivateKey string             `bson:"encryptedPrivateKey" json:"-"`
+	WalletDataKey       string             `bson:"walletDataKey,omitempty" json:"-"`    // Data key that encrypts the private key, wrapped by a master key; empty for keys stored before envelope encryption
+	WalletKeyVersion    int                `bson:"walletKeyVersion,omitempty" json:"-"` // Version of the master key that wrapped WalletDataKey
 	TotalLikeCount      int64              `bson:"totalLikeCount" json:"totalLikeCount"`
 	PostCount           int64              `bson:"postCount" json:"postCount"`
-	OTP                 string             `bson:"otp,omitempty" json:"-"`
+	IsPrivate           bool               `bson:"isPrivate" json:"isPrivate"` // Follows need approval and content is limited to followers
+	OTPHash             string             `bson:"otpHash,omitempty" json:"-"` // SHA-256 of the password reset OTP
 	OTPExpires          time.Time          `bson:"otpExpires,omitempty" json:"-"`
+
+	// Email verification. Only the SHA-256 of the emailed code is stored.
+	EmailVerified             bool      `bson:"emailVerified" json:"emailVerified"`
+	EmailVerificationCodeHash string    `bson:"emailVerificationCodeHash,omitempty" json:"-"`
+	EmailVerificationExpires  time.Time `bson:"emailVerificationExpires,omitempty" json:"-"`
+
+	// Two-factor authentication. Secrets are envelope-encrypted like the wallet key.
+	TOTPEnabled           bool     `bson:"totpEnabled" json:"-"`
+	TOTPSecret            string   `bson:"totpSecret,omitempty" json:"-"`
+	TOTPDataKey           string   `bson:"totpDataKey,omitempty" json:"-"`        // Data key that encrypts TOTPSecret, wrapped by a master key; empty for secrets stored before envelope encryption
+	TOTPKeyVersion        int      `bson:"totpKeyVersion,omitempty" json:"-"`     // Version of the master key that wrapped TOTPDataKey
+	TOTPPendingSecret     string   `bson:"totpPendingSecret,omitempty" json:"-"`  // Enrolled but not yet confirmed with a code
+	TOTPPendingDataKey    string   `bson:"totpPendingDataKey,omitempty" json:"-"` // Data key that encrypts TOTPPendingSecret
+	TOTPPendingKeyVersion int      `bson:"totpPendingKeyVersion,omitempty" json:"-"`
+	TOTPLastStep          int64    `bson:"totpLastStep,omitempty" json:"-"`       // Time step of the last accepted code, so codes can't be replayed
+	RecoveryCodeHashes    []string `bson:"recoveryCodeHashes,omitempty" json:"-"` // SHA-256 of the unused recovery codes
+}
+
+// ProfileUpdate holds the profile fields a user can edit. Nil fields are left
+// unchanged.
+type ProfileUpdate struct {
+	Username  *string
+	PFPURL    *string
+	BannerURL *string
+	Bio       *string
+	IsPrivate *bool
 }
@@ -0,0 +1,16 @@
+package domain
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// WalletLink connects an external Ethereum wallet to an account, so the user
+// can sign in with it. Each wallet can belong to one account only.
+type WalletLink struct {
+	ID        primitive.ObjectID `bson:"_id,omitempty" json:"id,omitempty"`
+	UserID    primitive.ObjectID `bson:"userId" json:"-"`
+	Address   string             `bson:"address" json:"address"` // EIP-55 checksummed
+	CreatedAt time.Time          `bson:"createdAt" json:"createdAt"`
+}
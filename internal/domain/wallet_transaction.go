@@ -0,0 +1,37 @@
+package domain
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// WalletTransactionStatus defines the lifecycle state of a sent wallet transaction.
+type WalletTransactionStatus string
+
+const (
+	WalletTransactionPending   WalletTransactionStatus = "pending"   // Sent, no receipt yet
+	WalletTransactionConfirmed WalletTransactionStatus = "confirmed" // Mined and succeeded
+	WalletTransactionFailed    WalletTransactionStatus = "failed"    // Mined but reverted
+	WalletTransactionDropped   WalletTransactionStatus = "dropped"   // Left the mempool without being mined
+	WalletTransactionReplaced  WalletTransactionStatus = "replaced"  // Another transaction was mined with its nonce
+)
+
+// WalletTransaction is a transaction sent from a user's custodial wallet.
+// Value is in wei as a decimal string, since it can exceed 64 bits.
+type WalletTransaction struct {
+	ID              primitive.ObjectID      `bson:"_id,omitempty" json:"id"`
+	UserID          primitive.ObjectID      `bson:"userId" json:"-"`
+	From            string                  `bson:"from" json:"from"`
+	To              string                  `bson:"to,omitempty" json:"to,omitempty"` // Empty for contract creation
+	Value           string                  `bson:"value" json:"value"`
+	Nonce           uint64                  `bson:"nonce" json:"nonce"`
+	Hash            string                  `bson:"hash" json:"hash"`
+	Status          WalletTransactionStatus `bson:"status" json:"status"`
+	BlockNumber     uint64                  `bson:"blockNumber,omitempty" json:"blockNumber,omitempty"`
+	GasUsed         uint64                  `bson:"gasUsed,omitempty" json:"gasUsed,omitempty"`
+	ContractAddress string                  `bson:"contractAddress,omitempty" json:"contractAddress,omitempty"` // Set when a contract creation is mined
+	CheckedAt       time.Time               `bson:"checkedAt" json:"-"`                                         // Last time the watcher polled a pending transaction
+	CreatedAt       time.Time               `bson:"createdAt" json:"createdAt"`
+	UpdatedAt       time.Time               `bson:"updatedAt" json:"updatedAt"`
+}
@@ -0,0 +1,95 @@
+package http
+
+import (
+	"net/http"
+	"vybes/internal/service"
+
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// BlockHandler handles HTTP requests for blocking and muting users.
+type BlockHandler struct {
+	blockService service.BlockService
+}
+
+// NewBlockHandler creates a new BlockHandler.
+func NewBlockHandler(blockService service.BlockService) *BlockHandler {
+	return &BlockHandler{
+		blockService: blockService,
+	}
+}
+
+// BlockUser is the handler for blocking a user.
+func (h *BlockHandler) BlockUser(c *gin.Context) {
+	userID, _ := c.Get("user_id")
+
+	if err := h.blockService.BlockUser(c.Request.Context(), userID.(primitive.ObjectID).Hex(), c.Param("username")); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Successfully blocked user"})
+}
+
+// UnblockUser is the handler for unblocking a user.
+func (h *BlockHandler) UnblockUser(c *gin.Context) {
+	userID, _ := c.Get("user_id")
+
+	if err := h.blockService.UnblockUser(c.Request.Context(), userID.(primitive.ObjectID).Hex(), c.Param("username")); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Successfully unblocked user"})
+}
+
+// MuteUser is the handler for muting a user.
+func (h *BlockHandler) MuteUser(c *gin.Context) {
+	userID, _ := c.Get("user_id")
+
+	if err := h.blockService.MuteUser(c.Request.Context(), userID.(primitive.ObjectID).Hex(), c.Param("username")); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Successfully muted user"})
+}
+
+// UnmuteUser is the handler for unmuting a user.
+func (h *BlockHandler) UnmuteUser(c *gin.Context) {
+	userID, _ := c.Get("user_id")
+
+	if err := h.blockService.UnmuteUser(c.Request.Context(), userID.(primitive.ObjectID).Hex(), c.Param("username")); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Successfully unmuted user"})
+}
+
+// GetBlockedUsers is the handler for listing the users the authenticated user has blocked.
+func (h *BlockHandler) GetBlockedUsers(c *gin.Context) {
+	userID, _ := c.Get("user_id")
+	cursor, limit := pageParams(c)
+
+	users, err := h.blockService.GetBlockedUsers(c.Request.Context(), userID.(primitive.ObjectID).Hex(), cursor, limit)
+	if err != nil {
+		respondListError(c, err, http.StatusInternalServerError, "Failed to get blocked users")
+		return
+	}
+	c.JSON(http.StatusOK, users)
+}
+
+// GetMutedUsers is the handler for listing the users the authenticated user has muted.
+func (h *BlockHandler) GetMutedUsers(c *gin.Context) {
+	userID, _ := c.Get("user_id")
+	cursor, limit := pageParams(c)
+
+	users, err := h.blockService.GetMutedUsers(c.Request.Context(), userID.(primitive.ObjectID).Hex(), cursor, limit)
+	if err != nil {
+		respondListError(c, err, http.StatusInternalServerError, "Failed to get muted users")
+		return
+	}
+	c.JSON(http.StatusOK, users)
+}
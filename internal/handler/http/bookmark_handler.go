@@ -2,7 +2,6 @@ package http
 
 import (
 	"net/http"
-	"strconv"
 	"vybes/internal/service"
 
 	"github.com/gin-gonic/gin"
@@ -50,12 +49,11 @@ func (h *BookmarkHandler) RemoveBookmark(c *gin.Context) {
 // GetBookmarks is the handler for getting the user's bookmarked posts.
 func (h *BookmarkHandler) GetBookmarks(c *gin.Context) {
 	userID, _ := c.Get("user_id")
-	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
-	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "20"))
+	cursor, limit := pageParams(c)
 
-	posts, err := h.bookmarkService.GetBookmarks(c.Request.Context(), userID.(primitive.ObjectID).Hex(), page, limit)
+	posts, err := h.bookmarkService.GetBookmarks(c.Request.Context(), userID.(primitive.ObjectID).Hex(), cursor, limit)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get bookmarks"})
+		respondListError(c, err, http.StatusInternalServerError, "Failed to get bookmarks")
 		return
 	}
 	c.JSON(http.StatusOK, posts)
@@ -1,8 +1,8 @@
 package http
 
 import (
+	"errors"
 	"net/http"
-	"strconv"
 	"vybes/internal/domain"
 	"vybes/internal/service"
 
@@ -70,6 +70,7 @@ func (h *ContentHandler) DeletePost(c *gin.Context) {
 }
 
 // Repost is the handler for reposting another post.
+// An optional JSON body with a caption turns the repost into a quote-repost.
 func (h *ContentHandler) Repost(c *gin.Context) {
 	userID, _ := c.Get("user_id")
 	originalPostID, err := primitive.ObjectIDFromHex(c.Param("postID"))
@@ -77,8 +78,17 @@ func (h *ContentHandler) Repost(c *gin.Context) {
 		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid post ID"})
 		return
 	}
+	var request struct {
+		Caption string `json:"caption"`
+	}
+	if c.Request.ContentLength != 0 {
+		if err := c.ShouldBindJSON(&request); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+	}
 
-	repost, err := h.contentService.Repost(c.Request.Context(), userID.(primitive.ObjectID), originalPostID)
+	repost, err := h.contentService.Repost(c.Request.Context(), userID.(primitive.ObjectID), originalPostID, request.Caption)
 	if err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
@@ -93,17 +103,23 @@ func (h *ContentHandler) GetRepostsByUser(c *gin.Context) {
 		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid user ID"})
 		return
 	}
-	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "20"))
+	cursor, limit := pageParams(c)
+	viewerID, _ := c.Get("user_id")
 
-	posts, err := h.contentService.GetRepostsByUser(c.Request.Context(), userID, limit)
+	posts, err := h.contentService.GetRepostsByUser(c.Request.Context(), viewerID.(primitive.ObjectID), userID, cursor, limit)
+	if errors.Is(err, service.ErrPrivateAccount) {
+		c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+		return
+	}
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get reposts"})
+		respondListError(c, err, http.StatusInternalServerError, "Failed to get reposts")
 		return
 	}
 	c.JSON(http.StatusOK, posts)
 }
 
 // CreateComment is the handler for adding a comment to a post.
+// Setting parentId in the body posts a reply to that comment instead.
 func (h *ContentHandler) CreateComment(c *gin.Context) {
 	userID, _ := c.Get("user_id")
 	postID, err := primitive.ObjectIDFromHex(c.Param("postID"))
@@ -112,47 +128,122 @@ func (h *ContentHandler) CreateComment(c *gin.Context) {
 		return
 	}
 	var request struct {
-		Text string `json:"text" binding:"required"`
+		Text     string `json:"text" binding:"required"`
+		ParentID string `json:"parentId"`
 	}
 	if err := c.ShouldBindJSON(&request); err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
+	var parentID *primitive.ObjectID
+	if request.ParentID != "" {
+		id, err := primitive.ObjectIDFromHex(request.ParentID)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid parent comment ID"})
+			return
+		}
+		parentID = &id
+	}
 
-	comment, err := h.contentService.CreateComment(c.Request.Context(), userID.(primitive.ObjectID), postID, request.Text)
+	comment, err := h.contentService.CreateComment(c.Request.Context(), userID.(primitive.ObjectID), postID, request.Text, parentID)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create comment"})
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
 	c.JSON(http.StatusCreated, comment)
 }
 
-// GetComments is the handler for getting comments for a post.
+// GetComments is the handler for getting the top-level comments for a post.
 func (h *ContentHandler) GetComments(c *gin.Context) {
+	userID, _ := c.Get("user_id")
 	postID, err := primitive.ObjectIDFromHex(c.Param("postID"))
 	if err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid post ID"})
 		return
 	}
-	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
-	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "20"))
+	cursor, limit := pageParams(c)
 
-	comments, err := h.contentService.GetComments(c.Request.Context(), postID, page, limit)
+	comments, err := h.contentService.GetComments(c.Request.Context(), userID.(primitive.ObjectID), postID, cursor, limit)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get comments"})
+		respondListError(c, err, http.StatusNotFound, err.Error())
 		return
 	}
 	c.JSON(http.StatusOK, comments)
 }
 
+// GetReplies is the handler for getting replies to a comment.
+func (h *ContentHandler) GetReplies(c *gin.Context) {
+	userID, _ := c.Get("user_id")
+	commentID, err := primitive.ObjectIDFromHex(c.Param("commentID"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid comment ID"})
+		return
+	}
+	cursor, limit := pageParams(c)
+
+	replies, err := h.contentService.GetReplies(c.Request.Context(), userID.(primitive.ObjectID), commentID, cursor, limit)
+	if err != nil {
+		respondListError(c, err, http.StatusNotFound, err.Error())
+		return
+	}
+	c.JSON(http.StatusOK, replies)
+}
+
+// EditComment is the handler for editing the text of a comment.
+func (h *ContentHandler) EditComment(c *gin.Context) {
+	userID, _ := c.Get("user_id")
+	commentID, err := primitive.ObjectIDFromHex(c.Param("commentID"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid comment ID"})
+		return
+	}
+	var request struct {
+		Text string `json:"text" binding:"required"`
+	}
+	if err := c.ShouldBindJSON(&request); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	comment, err := h.contentService.EditComment(c.Request.Context(), userID.(primitive.ObjectID), commentID, request.Text)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, comment)
+}
+
+// DeleteComment is the handler for deleting a comment.
+func (h *ContentHandler) DeleteComment(c *gin.Context) {
+	userID, _ := c.Get("user_id")
+	commentID, err := primitive.ObjectIDFromHex(c.Param("commentID"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid comment ID"})
+		return
+	}
+
+	if err := h.contentService.DeleteComment(c.Request.Context(), userID.(primitive.ObjectID), commentID); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	c.Status(http.StatusNoContent)
+}
+
 // RecordView is the handler for recording a view on a post.
+// Authenticated viewers are deduplicated by user ID; anonymous viewers by
+// client IP.
 func (h *ContentHandler) RecordView(c *gin.Context) {
 	postID, err := primitive.ObjectIDFromHex(c.Param("postID"))
 	if err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid post ID"})
 		return
 	}
-	if err := h.contentService.RecordView(c.Request.Context(), postID); err != nil {
+	var viewerID *primitive.ObjectID
+	if userID, exists := c.Get("user_id"); exists {
+		id := userID.(primitive.ObjectID)
+		viewerID = &id
+	}
+	if err := h.contentService.RecordView(c.Request.Context(), postID, viewerID, c.ClientIP()); err != nil {
 		// We can choose to ignore errors here or log them, but we won't send an error response
 		// to the client to avoid impacting user experience for a non-critical operation.
 	}
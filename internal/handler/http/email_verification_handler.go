@@ -0,0 +1,61 @@
+package http
+
+import (
+	"errors"
+	"net/http"
+
+	"vybes/internal/service"
+
+	"github.com/gin-gonic/gin"
+)
+
+// EmailVerificationHandler handles HTTP requests for verifying email addresses.
+type EmailVerificationHandler struct {
+	emailVerification service.EmailVerificationService
+}
+
+// NewEmailVerificationHandler creates a new EmailVerificationHandler.
+func NewEmailVerificationHandler(emailVerification service.EmailVerificationService) *EmailVerificationHandler {
+	return &EmailVerificationHandler{emailVerification: emailVerification}
+}
+
+// VerifyEmail is the handler for confirming an email address with the emailed code.
+func (h *EmailVerificationHandler) VerifyEmail(c *gin.Context) {
+	var request struct {
+		Email string `json:"email" binding:"required,email"`
+		Code  string `json:"code" binding:"required"`
+	}
+	if err := c.ShouldBindJSON(&request); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if err := h.emailVerification.Verify(c.Request.Context(), request.Email, request.Code); err != nil {
+		if errors.Is(err, service.ErrInvalidVerificationCode) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to verify email"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "Email verified successfully"})
+}
+
+// ResendVerification is the handler for requesting a new verification code.
+func (h *EmailVerificationHandler) ResendVerification(c *gin.Context) {
+	var request struct {
+		Email string `json:"email" binding:"required,email"`
+	}
+	if err := c.ShouldBindJSON(&request); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if err := h.emailVerification.Resend(c.Request.Context(), request.Email); err != nil {
+		if errors.Is(err, service.ErrVerificationThrottled) {
+			c.JSON(http.StatusTooManyRequests, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to send verification email"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "If the account exists and is unverified, a new code has been sent"})
+}
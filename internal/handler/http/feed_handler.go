@@ -2,10 +2,10 @@ package http
 
 import (
 	"net/http"
-	"strconv"
 	"vybes/internal/service"
 
 	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/bson/primitive"
 )
 
 // FeedHandler handles HTTP requests for feeds.
@@ -23,11 +23,11 @@ func NewFeedHandler(feedService service.FeedService) *FeedHandler {
 // GetForYouFeed is the handler for getting the user's "For You" feed.
 func (h *FeedHandler) GetForYouFeed(c *gin.Context) {
 	userID, _ := c.Get("user_id")
-	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "20"))
+	cursor, limit := pageParams(c)
 
-	feed, err := h.feedService.GetForYouFeed(c.Request.Context(), userID.(string), limit)
+	feed, err := h.feedService.GetForYouFeed(c.Request.Context(), userID.(primitive.ObjectID).Hex(), cursor, limit)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get feed"})
+		respondListError(c, err, http.StatusInternalServerError, "Failed to get feed")
 		return
 	}
 	c.JSON(http.StatusOK, feed)
@@ -36,11 +36,24 @@ func (h *FeedHandler) GetForYouFeed(c *gin.Context) {
 // GetFriendFeed is the handler for getting the user's "Friend" feed.
 func (h *FeedHandler) GetFriendFeed(c *gin.Context) {
 	userID, _ := c.Get("user_id")
-	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "20"))
+	cursor, limit := pageParams(c)
 
-	feed, err := h.feedService.GetFriendFeed(c.Request.Context(), userID.(string), limit)
+	feed, err := h.feedService.GetFriendFeed(c.Request.Context(), userID.(primitive.ObjectID).Hex(), cursor, limit)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get friend feed"})
+		respondListError(c, err, http.StatusInternalServerError, "Failed to get friend feed")
+		return
+	}
+	c.JSON(http.StatusOK, feed)
+}
+
+// GetFollowingFeed is the handler for getting the user's chronological "Following" feed.
+func (h *FeedHandler) GetFollowingFeed(c *gin.Context) {
+	userID, _ := c.Get("user_id")
+	cursor, limit := pageParams(c)
+
+	feed, err := h.feedService.GetFollowingFeed(c.Request.Context(), userID.(primitive.ObjectID).Hex(), cursor, limit)
+	if err != nil {
+		respondListError(c, err, http.StatusInternalServerError, "Failed to get following feed")
 		return
 	}
 	c.JSON(http.StatusOK, feed)
@@ -1,10 +1,15 @@
 package http
 
 import (
+	"context"
+	"errors"
 	"net/http"
+	"vybes/internal/domain"
 	"vybes/internal/service"
+	"vybes/pkg/pagination"
 
 	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/bson/primitive"
 )
 
 // FollowHandler handles HTTP requests for follow relationships.
@@ -29,13 +34,17 @@ func (h *FollowHandler) FollowUser(c *gin.Context) {
 
 	usernameToFollow := c.Param("username")
 
-	err := h.followService.FollowUser(c.Request.Context(), followerID.(string), usernameToFollow)
+	status, err := h.followService.FollowUser(c.Request.Context(), followerID.(primitive.ObjectID).Hex(), usernameToFollow)
 	if err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
 
-	c.JSON(http.StatusOK, gin.H{"message": "Successfully followed user"})
+	if status == domain.FollowStatusRequested {
+		c.JSON(http.StatusAccepted, gin.H{"message": "Follow request sent", "status": status})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "Successfully followed user", "status": status})
 }
 
 // UnfollowUser is the handler for unfollowing a user.
@@ -48,7 +57,7 @@ func (h *FollowHandler) UnfollowUser(c *gin.Context) {
 
 	usernameToUnfollow := c.Param("username")
 
-	err := h.followService.UnfollowUser(c.Request.Context(), followerID.(string), usernameToUnfollow)
+	err := h.followService.UnfollowUser(c.Request.Context(), followerID.(primitive.ObjectID).Hex(), usernameToUnfollow)
 	if err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
@@ -56,3 +65,71 @@ func (h *FollowHandler) UnfollowUser(c *gin.Context) {
 
 	c.JSON(http.StatusOK, gin.H{"message": "Successfully unfollowed user"})
 }
+
+// GetFollowRequests is the handler for listing pending requests to follow the authenticated user.
+func (h *FollowHandler) GetFollowRequests(c *gin.Context) {
+	userID, _ := c.Get("user_id")
+	cursor, limit := pageParams(c)
+
+	requests, err := h.followService.GetFollowRequests(c.Request.Context(), userID.(primitive.ObjectID).Hex(), cursor, limit)
+	if err != nil {
+		respondListError(c, err, http.StatusInternalServerError, "Failed to get follow requests")
+		return
+	}
+	c.JSON(http.StatusOK, requests)
+}
+
+// ApproveFollowRequest is the handler for accepting a pending follow request.
+func (h *FollowHandler) ApproveFollowRequest(c *gin.Context) {
+	userID, _ := c.Get("user_id")
+	requestID := c.Param("requestID")
+
+	if err := h.followService.ApproveFollowRequest(c.Request.Context(), userID.(primitive.ObjectID).Hex(), requestID); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "Follow request approved"})
+}
+
+// DenyFollowRequest is the handler for rejecting a pending follow request.
+func (h *FollowHandler) DenyFollowRequest(c *gin.Context) {
+	userID, _ := c.Get("user_id")
+	requestID := c.Param("requestID")
+
+	if err := h.followService.DenyFollowRequest(c.Request.Context(), userID.(primitive.ObjectID).Hex(), requestID); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "Follow request denied"})
+}
+
+// GetFollowers is the handler for listing the users who follow a user.
+func (h *FollowHandler) GetFollowers(c *gin.Context) {
+	h.listFollows(c, h.followService.GetFollowers, "Failed to get followers")
+}
+
+// GetFollowing is the handler for listing the users a user follows.
+func (h *FollowHandler) GetFollowing(c *gin.Context) {
+	h.listFollows(c, h.followService.GetFollowing, "Failed to get following")
+}
+
+func (h *FollowHandler) listFollows(
+	c *gin.Context,
+	list func(context.Context, string, string, string, int) (*pagination.Page[service.FollowListUser], error),
+	failure string,
+) {
+	viewerID, _ := c.Get("user_id")
+	cursor, limit := pageParams(c)
+
+	users, err := list(c.Request.Context(), viewerID.(primitive.ObjectID).Hex(), c.Param("username"), cursor, limit)
+	switch {
+	case errors.Is(err, service.ErrUserNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+	case errors.Is(err, service.ErrPrivateAccount):
+		c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+	case err != nil:
+		respondListError(c, err, http.StatusInternalServerError, failure)
+	default:
+		c.JSON(http.StatusOK, users)
+	}
+}
@@ -2,7 +2,6 @@ package http
 
 import (
 	"net/http"
-	"strconv"
 	"vybes/internal/service"
 
 	"github.com/gin-gonic/gin"
@@ -24,12 +23,11 @@ func NewNotificationHandler(notificationService service.NotificationService) *No
 // GetNotifications is the handler for fetching a user's notifications.
 func (h *NotificationHandler) GetNotifications(c *gin.Context) {
 	userID, _ := c.Get("user_id")
-	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
-	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "30"))
+	cursor, limit := pageParams(c)
 
-	notifications, err := h.notificationService.GetNotifications(c.Request.Context(), userID.(primitive.ObjectID).Hex(), page, limit)
+	notifications, err := h.notificationService.GetNotifications(c.Request.Context(), userID.(primitive.ObjectID).Hex(), cursor, limit)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get notifications"})
+		respondListError(c, err, http.StatusInternalServerError, "Failed to get notifications")
 		return
 	}
 	c.JSON(http.StatusOK, notifications)
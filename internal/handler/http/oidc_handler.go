@@ -0,0 +1,85 @@
+package http
+
+import (
+	"errors"
+	"net/http"
+
+	"vybes/internal/service"
+
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// OIDCHandler handles HTTP requests for signing in with OpenID Connect providers.
+type OIDCHandler struct {
+	oidcService service.OIDCService
+	userService service.UserService
+}
+
+// NewOIDCHandler creates a new OIDCHandler.
+func NewOIDCHandler(oidcService service.OIDCService, userService service.UserService) *OIDCHandler {
+	return &OIDCHandler{oidcService: oidcService, userService: userService}
+}
+
+// ListProviders is the handler for listing the providers users can sign in with.
+func (h *OIDCHandler) ListProviders(c *gin.Context) {
+	c.JSON(http.StatusOK, gin.H{"providers": h.oidcService.Providers()})
+}
+
+// Authorize is the handler for starting a sign-in. The client sends the user to
+// the returned URL and keeps the binding; the provider redirects back to the
+// configured redirect URL with the code and state for Callback.
+func (h *OIDCHandler) Authorize(c *gin.Context) {
+	authorization, err := h.oidcService.AuthorizationURL(c.Request.Context(), c.Param("provider"))
+	if err != nil {
+		if errors.Is(err, service.ErrOIDCProviderNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusBadGateway, gin.H{"error": "Failed to start sign-in with the provider"})
+		return
+	}
+	c.JSON(http.StatusOK, authorization)
+}
+
+// Callback is the handler for finishing a sign-in with the code and state the
+// provider redirected back with.
+func (h *OIDCHandler) Callback(c *gin.Context) {
+	var request struct {
+		Code    string `json:"code" binding:"required"`
+		State   string `json:"state" binding:"required"`
+		Binding string `json:"binding" binding:"required"`
+	}
+	if err := c.ShouldBindJSON(&request); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	response, err := h.userService.LoginWithOIDC(c.Request.Context(), c.Param("provider"), request.State, request.Binding, request.Code, c.Request.UserAgent(), c.ClientIP())
+	if err != nil {
+		switch {
+		case errors.Is(err, service.ErrOIDCProviderNotFound):
+			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		case errors.Is(err, service.ErrInvalidOIDCState):
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		case errors.Is(err, service.ErrOIDCLoginFailed), errors.Is(err, service.ErrOIDCEmailNotVerified):
+			c.JSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
+		case errors.Is(err, service.ErrOIDCAccountConflict):
+			c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+		default:
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to log in"})
+		}
+		return
+	}
+	c.JSON(http.StatusOK, response)
+}
+
+// ListIdentities is the handler for listing the provider accounts linked to the user.
+func (h *OIDCHandler) ListIdentities(c *gin.Context) {
+	userID, _ := c.Get("user_id")
+	identities, err := h.oidcService.ListIdentities(c.Request.Context(), userID.(primitive.ObjectID))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get linked accounts"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"identities": identities})
+}
@@ -0,0 +1,26 @@
+package http
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"vybes/pkg/pagination"
+
+	"github.com/gin-gonic/gin"
+)
+
+// pageParams reads the cursor and limit query parameters shared by every list endpoint.
+func pageParams(c *gin.Context) (string, int) {
+	limit, _ := strconv.Atoi(c.Query("limit"))
+	return c.Query("cursor"), pagination.ClampLimit(limit)
+}
+
+// respondListError reports a failed list request. A cursor that doesn't verify
+// is the client's fault; anything else is reported with the given status and message.
+func respondListError(c *gin.Context, err error, status int, message string) {
+	if errors.Is(err, pagination.ErrInvalidCursor) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid cursor"})
+		return
+	}
+	c.JSON(status, gin.H{"error": message})
+}
@@ -6,6 +6,7 @@ import (
 	"vybes/internal/service"
 
 	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/bson/primitive"
 )
 
 // ReactionHandler handles HTTP requests for reactions.
@@ -25,7 +26,7 @@ func (h *ReactionHandler) AddLike(c *gin.Context) {
 	userID, _ := c.Get("user_id")
 	postID := c.Param("postID")
 
-	err := h.reactionService.AddReaction(c.Request.Context(), userID.(string), postID, string(domain.ReactionTypeLike))
+	err := h.reactionService.AddReaction(c.Request.Context(), userID.(primitive.ObjectID).Hex(), postID, string(domain.ReactionTypeLike))
 	if err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
@@ -38,10 +39,36 @@ func (h *ReactionHandler) RemoveLike(c *gin.Context) {
 	userID, _ := c.Get("user_id")
 	postID := c.Param("postID")
 
-	err := h.reactionService.RemoveReaction(c.Request.Context(), userID.(string), postID, string(domain.ReactionTypeLike))
+	err := h.reactionService.RemoveReaction(c.Request.Context(), userID.(primitive.ObjectID).Hex(), postID, string(domain.ReactionTypeLike))
 	if err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
 	c.JSON(http.StatusOK, gin.H{"message": "Post unliked successfully"})
 }
+
+// AddCommentLike is the handler for liking a comment.
+func (h *ReactionHandler) AddCommentLike(c *gin.Context) {
+	userID, _ := c.Get("user_id")
+	commentID := c.Param("commentID")
+
+	err := h.reactionService.AddCommentReaction(c.Request.Context(), userID.(primitive.ObjectID).Hex(), commentID, string(domain.ReactionTypeLike))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "Comment liked successfully"})
+}
+
+// RemoveCommentLike is the handler for unliking a comment.
+func (h *ReactionHandler) RemoveCommentLike(c *gin.Context) {
+	userID, _ := c.Get("user_id")
+	commentID := c.Param("commentID")
+
+	err := h.reactionService.RemoveCommentReaction(c.Request.Context(), userID.(primitive.ObjectID).Hex(), commentID, string(domain.ReactionTypeLike))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "Comment unliked successfully"})
+}
@@ -4,6 +4,8 @@ import (
 	"vybes/internal/config"
 	"vybes/internal/middleware"
 	"vybes/internal/service"
+	"vybes/pkg/jwtkeys"
+	"vybes/pkg/ratelimit"
 
 	"github.com/gin-gonic/gin"
 )
@@ -12,6 +14,7 @@ import (
 func SetupRouter(
 	userHandler *UserHandler,
 	followHandler *FollowHandler,
+	blockHandler *BlockHandler,
 	suggestionHandler *SuggestionHandler,
 	storyHandler *StoryHandler,
 	contentHandler *ContentHandler,
@@ -21,11 +24,47 @@ func SetupRouter(
 	searchHandler *SearchHandler,
 	notificationHandler *NotificationHandler,
 	sessionHandler *SessionHandler,
+	twoFactorHandler *TwoFactorHandler,
+	emailVerificationHandler *EmailVerificationHandler,
+	walletLinkHandler *WalletLinkHandler,
+	oidcHandler *OIDCHandler,
+	walletHandler *WalletHandler,
 	sessionService *service.SessionService,
+	emailVerification middleware.EmailVerificationChecker,
+	limiter *ratelimit.Limiter,
+	jwtKeys *jwtkeys.KeySet,
 	cfg *config.Config,
 ) *gin.Engine {
 	router := gin.Default()
 
+	// Only believe X-Forwarded-For from our own proxies: clients could otherwise
+	// pick the IP that rate limits, password reset lockouts and view counts key on
+	if err := router.SetTrustedProxies(cfg.TrustedProxies); err != nil {
+		panic(err) // LoadConfig already rejected malformed entries
+	}
+	router.TrustedPlatform = cfg.TrustedPlatform
+
+	// Posting and wallet sends can be limited to users who verified their email
+	requireVerified := func(c *gin.Context) { c.Next() }
+	if cfg.RequireVerifiedEmail {
+		requireVerified = middleware.RequireVerifiedEmail(emailVerification)
+	}
+
+	// Rate limits per route group; each group is counted separately
+	rateLimit := func(name string, limit config.RateLimit, key middleware.RateLimitKeyFunc) gin.HandlerFunc {
+		if !cfg.RateLimitEnabled {
+			return func(c *gin.Context) { c.Next() }
+		}
+		return middleware.RateLimit(limiter, ratelimit.Rule{Name: name, Limit: limit.Limit, Window: limit.Window}, key)
+	}
+	defaultLimit := rateLimit("default", cfg.RateLimitDefault, middleware.ByUserOrClientIP)
+	authLimit := rateLimit("auth", cfg.RateLimitAuth, middleware.ByClientIP)
+	otpLimit := rateLimit("otp", cfg.RateLimitOTP, middleware.ByClientIP)
+	writeLimit := rateLimit("write", cfg.RateLimitWrite, middleware.ByUserOrClientIP)
+	// Routes that check a password or two-factor code, counted per user so
+	// guessing from many IPs with a stolen access token doesn't help
+	credentialLimit := rateLimit("credentials", cfg.RateLimitAuth, middleware.ByUserOrClientIP)
+
 	// Health check endpoint for Railway
 	router.GET("/health", func(c *gin.Context) {
 		c.JSON(200, gin.H{
@@ -45,6 +84,12 @@ func SetupRouter(
 		})
 	})
 
+	// Public keys for verifying access tokens; retired keys stay listed until their tokens expire
+	router.GET("/.well-known/jwks.json", func(c *gin.Context) {
+		c.Header("Cache-Control", "public, max-age=300")
+		c.JSON(200, jwtKeys.JWKS())
+	})
+
 	// API v1 routes
 	apiV1 := router.Group("/api/v1")
 	{
@@ -55,67 +100,114 @@ func SetupRouter(
 		// Public routes
 		publicUserRoutes := apiV1.Group("/users")
 		{
-			publicUserRoutes.POST("/register", userHandler.Register)
-			publicUserRoutes.POST("/login", userHandler.Login)
-			publicUserRoutes.POST("/refresh", userHandler.RefreshToken)
-			publicUserRoutes.POST("/request-otp", userHandler.RequestOTP)
-			publicUserRoutes.POST("/reset-password", userHandler.ResetPassword)
+			publicUserRoutes.POST("/register", authLimit, userHandler.Register)
+			publicUserRoutes.POST("/login", authLimit, userHandler.Login)
+			publicUserRoutes.POST("/login/2fa", authLimit, userHandler.CompleteTwoFactorLogin)
+			publicUserRoutes.GET("/siwe/nonce", authLimit, walletLinkHandler.GetNonce)
+			publicUserRoutes.POST("/siwe/login", authLimit, userHandler.LoginWithEthereum)
+			publicUserRoutes.GET("/oidc/providers", oidcHandler.ListProviders)
+			publicUserRoutes.GET("/oidc/:provider/authorize", authLimit, oidcHandler.Authorize)
+			publicUserRoutes.POST("/oidc/:provider/callback", authLimit, oidcHandler.Callback)
+			publicUserRoutes.POST("/refresh", authLimit, userHandler.RefreshToken)
+			publicUserRoutes.POST("/verify-email", authLimit, emailVerificationHandler.VerifyEmail)
+			publicUserRoutes.POST("/resend-verification", authLimit, emailVerificationHandler.ResendVerification)
+			publicUserRoutes.POST("/request-otp", otpLimit, userHandler.RequestOTP)
+			publicUserRoutes.POST("/reset-password", otpLimit, userHandler.ResetPassword)
 		}
 
 		publicPostRoutes := apiV1.Group("/posts")
+		publicPostRoutes.Use(middleware.OptionalAuthMiddleware(jwtKeys, sessionService), defaultLimit)
 		{
 			publicPostRoutes.POST("/:postID/view", contentHandler.RecordView)
 		}
 
 		// Authenticated routes
 		authRoutes := apiV1.Group("/")
-		authRoutes.Use(middleware.AuthMiddleware(cfg.JWTSecret))
+		authRoutes.Use(middleware.AuthMiddleware(jwtKeys, sessionService), defaultLimit)
 		{
 			// Search routes
 			authRoutes.GET("/search/users", searchHandler.SearchUsers)
 
 			// User profile and wallet routes
 			authRoutes.GET("/users/profile", userHandler.GetUserProfile)
+			authRoutes.POST("/users/logout", sessionHandler.Logout)
+			authRoutes.POST("/users/logout-all", sessionHandler.LogoutAll)
 			authRoutes.PATCH("/users/me", userHandler.UpdateProfile)
-			authRoutes.POST("/wallet/unlock", userHandler.UnlockWallet)
-			authRoutes.POST("/wallet/export", userHandler.ExportPrivateKey)
+			authRoutes.GET("/users/2fa", twoFactorHandler.GetStatus)
+			authRoutes.POST("/users/2fa/enroll", credentialLimit, twoFactorHandler.Enroll)
+			authRoutes.POST("/users/2fa/activate", credentialLimit, twoFactorHandler.Activate)
+			authRoutes.POST("/users/2fa/disable", credentialLimit, twoFactorHandler.Disable)
+			authRoutes.POST("/users/2fa/recovery-codes", credentialLimit, twoFactorHandler.RegenerateRecoveryCodes)
+			authRoutes.GET("/users/me/wallets", walletLinkHandler.ListWallets)
+			authRoutes.POST("/users/me/wallets", walletLinkHandler.LinkWallet)
+			authRoutes.DELETE("/users/me/wallets/:address", walletLinkHandler.UnlinkWallet)
+			authRoutes.GET("/users/me/identities", oidcHandler.ListIdentities)
+			authRoutes.POST("/wallet/unlock", credentialLimit, userHandler.UnlockWallet)
+			authRoutes.POST("/wallet/lock", userHandler.LockWallet)
+			authRoutes.POST("/wallet/export", credentialLimit, userHandler.ExportPrivateKey)
 			authRoutes.POST("/wallet/personal-sign", userHandler.PersonalSign)
 			authRoutes.POST("/wallet/sign-transaction", userHandler.SignTransaction)
-			authRoutes.POST("/wallet/send-transaction", userHandler.SendTransaction)
+			authRoutes.POST("/wallet/send-transaction", requireVerified, userHandler.SendTransaction)
 			authRoutes.POST("/wallet/sign-typed-data", userHandler.SignTypedDataV4)
 			authRoutes.POST("/wallet/secp256k1-sign", userHandler.Secp256k1Sign)
+			authRoutes.GET("/wallet/balance", walletHandler.GetBalance)
+			authRoutes.GET("/wallet/transactions", walletHandler.GetTransactions)
 
 			// Follow routes
-			authRoutes.POST("/users/:username/follow", followHandler.FollowUser)
-			authRoutes.DELETE("/users/:username/follow", followHandler.UnfollowUser)
+			authRoutes.POST("/users/:username/follow", writeLimit, followHandler.FollowUser)
+			authRoutes.DELETE("/users/:username/follow", writeLimit, followHandler.UnfollowUser)
+			authRoutes.GET("/users/:username/followers", followHandler.GetFollowers)
+			authRoutes.GET("/users/:username/following", followHandler.GetFollowing)
+			authRoutes.GET("/follow-requests", followHandler.GetFollowRequests)
+			authRoutes.POST("/follow-requests/:requestID/approve", followHandler.ApproveFollowRequest)
+			authRoutes.POST("/follow-requests/:requestID/deny", followHandler.DenyFollowRequest)
+
+			// Block and mute routes
+			authRoutes.POST("/users/:username/block", blockHandler.BlockUser)
+			authRoutes.DELETE("/users/:username/block", blockHandler.UnblockUser)
+			authRoutes.POST("/users/:username/mute", blockHandler.MuteUser)
+			authRoutes.DELETE("/users/:username/mute", blockHandler.UnmuteUser)
+			authRoutes.GET("/blocks", blockHandler.GetBlockedUsers)
+			authRoutes.GET("/mutes", blockHandler.GetMutedUsers)
 
 			// Suggestion routes
 			authRoutes.GET("/suggestions/users", suggestionHandler.GetSuggestions)
 
 			// Story routes
-			authRoutes.POST("/stories", storyHandler.CreateStory)
+			authRoutes.POST("/stories", requireVerified, writeLimit, storyHandler.CreateStory)
 			authRoutes.GET("/stories/feed", storyHandler.GetStoryFeed)
 
 			// Post and Content routes
 			posts := authRoutes.Group("/posts")
 			{
-				posts.POST("/", contentHandler.CreatePost)
+				posts.POST("/", requireVerified, writeLimit, contentHandler.CreatePost)
 				posts.DELETE("/:postID", contentHandler.DeletePost)
-				posts.POST("/:postID/repost", contentHandler.Repost)
+				posts.POST("/:postID/repost", requireVerified, writeLimit, contentHandler.Repost)
 				posts.GET("/:postID/comments", contentHandler.GetComments)
-				posts.POST("/:postID/comments", contentHandler.CreateComment)
-				posts.POST("/:postID/like", reactionHandler.AddLike)
-				posts.DELETE("/:postID/like", reactionHandler.RemoveLike)
+				posts.POST("/:postID/comments", requireVerified, writeLimit, contentHandler.CreateComment)
+				posts.POST("/:postID/like", writeLimit, reactionHandler.AddLike)
+				posts.DELETE("/:postID/like", writeLimit, reactionHandler.RemoveLike)
 				posts.POST("/:postID/bookmark", bookmarkHandler.AddBookmark)
 				posts.DELETE("/:postID/bookmark", bookmarkHandler.RemoveBookmark)
 			}
 
+			// Comment routes
+			comments := authRoutes.Group("/comments")
+			{
+				comments.GET("/:commentID/replies", contentHandler.GetReplies)
+				comments.PATCH("/:commentID", contentHandler.EditComment)
+				comments.DELETE("/:commentID", contentHandler.DeleteComment)
+				comments.POST("/:commentID/like", writeLimit, reactionHandler.AddCommentLike)
+				comments.DELETE("/:commentID/like", writeLimit, reactionHandler.RemoveCommentLike)
+			}
+
 			// Repost-specific routes
 			authRoutes.GET("/reposts/by-user/:userID", contentHandler.GetRepostsByUser)
 
 			// Feed and Bookmark routes
 			authRoutes.GET("/feeds/for-you", feedHandler.GetForYouFeed)
 			authRoutes.GET("/feeds/friends", feedHandler.GetFriendFeed)
+			authRoutes.GET("/feeds/following", feedHandler.GetFollowingFeed)
 			authRoutes.GET("/bookmarks", bookmarkHandler.GetBookmarks)
 
 			// Notification routes
@@ -128,8 +220,11 @@ func SetupRouter(
 			// Session routes
 			sessions := authRoutes.Group("/sessions")
 			{
+				sessions.GET("/", sessionHandler.ListSessions)
+				sessions.POST("/revoke-others", sessionHandler.RevokeOtherSessions)
 				sessions.GET("/:id", sessionHandler.GetSession)
-				sessions.POST("/:id/block", sessionHandler.BlockSession)
+				sessions.DELETE("/:id", sessionHandler.RevokeSession)
+				sessions.POST("/:id/block", sessionHandler.RevokeSession)
 			}
 		}
 	}
@@ -2,10 +2,10 @@ package http
 
 import (
 	"net/http"
-	"strconv"
 	"vybes/internal/service"
 
 	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/bson/primitive"
 )
 
 // SearchHandler handles HTTP requests for searching.
@@ -27,12 +27,12 @@ func (h *SearchHandler) SearchUsers(c *gin.Context) {
 		c.JSON(http.StatusBadRequest, gin.H{"error": "Query parameter 'q' is required"})
 		return
 	}
-	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "20"))
+	cursor, limit := pageParams(c)
+	viewerID, _ := c.Get("user_id")
 
-	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
-	users, err := h.searchService.SearchUsers(c.Request.Context(), query, page, limit)
+	users, err := h.searchService.SearchUsers(c.Request.Context(), viewerID.(primitive.ObjectID), query, cursor, limit)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to search users"})
+		respondListError(c, err, http.StatusInternalServerError, "Failed to search users")
 		return
 	}
 
@@ -1,6 +1,7 @@
 package http
 
 import (
+	"errors"
 	"net/http"
 
 	"vybes/internal/service"
@@ -17,14 +18,34 @@ func NewSessionHandler(sessionService *service.SessionService) *SessionHandler {
 	return &SessionHandler{sessionService: sessionService}
 }
 
+// callerSession returns the authenticated user and the session the request was made with.
+func callerSession(c *gin.Context) (primitive.ObjectID, primitive.ObjectID) {
+	userID, _ := c.Get("user_id")
+	sessionID, _ := c.Get("session_id")
+	return userID.(primitive.ObjectID), sessionID.(primitive.ObjectID)
+}
+
+// ListSessions is the handler for listing the devices the user is logged in on.
+func (h *SessionHandler) ListSessions(c *gin.Context) {
+	userID, sessionID := callerSession(c)
+
+	sessions, err := h.sessionService.ListActive(c.Request.Context(), userID, sessionID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get sessions"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"sessions": sessions})
+}
+
 func (h *SessionHandler) GetSession(c *gin.Context) {
 	sessionID, err := primitive.ObjectIDFromHex(c.Param("id"))
 	if err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid session ID"})
 		return
 	}
+	userID, _ := callerSession(c)
 
-	session, err := h.sessionService.GetByID(c.Request.Context(), sessionID)
+	session, err := h.sessionService.GetForUser(c.Request.Context(), userID, sessionID)
 	if err != nil {
 		c.JSON(http.StatusNotFound, gin.H{"error": "Session not found"})
 		return
@@ -33,17 +54,58 @@ func (h *SessionHandler) GetSession(c *gin.Context) {
 	c.JSON(http.StatusOK, session)
 }
 
-func (h *SessionHandler) BlockSession(c *gin.Context) {
+// RevokeSession is the handler for logging out one of the user's sessions.
+func (h *SessionHandler) RevokeSession(c *gin.Context) {
 	sessionID, err := primitive.ObjectIDFromHex(c.Param("id"))
 	if err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid session ID"})
 		return
 	}
+	userID, _ := callerSession(c)
 
-	if err := h.sessionService.Block(c.Request.Context(), sessionID); err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to block session"})
+	if err := h.sessionService.Revoke(c.Request.Context(), userID, sessionID); err != nil {
+		if errors.Is(err, service.ErrSessionNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Session not found"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to revoke session"})
 		return
 	}
 
-	c.JSON(http.StatusOK, gin.H{"message": "Session blocked successfully"})
+	c.JSON(http.StatusOK, gin.H{"message": "Session revoked successfully"})
+}
+
+// RevokeOtherSessions is the handler for logging out everywhere except the current device.
+func (h *SessionHandler) RevokeOtherSessions(c *gin.Context) {
+	userID, sessionID := callerSession(c)
+
+	revoked, err := h.sessionService.RevokeAll(c.Request.Context(), userID, sessionID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to revoke sessions"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "Other sessions revoked successfully", "revoked": revoked})
+}
+
+// Logout is the handler for ending the session the request was made with.
+func (h *SessionHandler) Logout(c *gin.Context) {
+	userID, sessionID := callerSession(c)
+
+	if err := h.sessionService.Revoke(c.Request.Context(), userID, sessionID); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to log out"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "Logged out successfully"})
+}
+
+// LogoutAll is the handler for ending every session of the user, the current one included.
+func (h *SessionHandler) LogoutAll(c *gin.Context) {
+	userID, _ := callerSession(c)
+
+	revoked, err := h.sessionService.RevokeAll(c.Request.Context(), userID, primitive.NilObjectID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to log out"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "Logged out of all sessions", "revoked": revoked})
 }
@@ -5,6 +5,7 @@ import (
 	"vybes/internal/service"
 
 	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/bson/primitive"
 )
 
 // StoryHandler handles HTTP requests for stories.
@@ -33,7 +34,7 @@ func (h *StoryHandler) CreateStory(c *gin.Context) {
 		return
 	}
 
-	story, err := h.storyService.CreateStory(c.Request.Context(), userID.(string), file)
+	story, err := h.storyService.CreateStory(c.Request.Context(), userID.(primitive.ObjectID).Hex(), file)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create story"})
 		return
@@ -50,7 +51,7 @@ func (h *StoryHandler) GetStoryFeed(c *gin.Context) {
 		return
 	}
 
-	feed, err := h.storyService.GetStoryFeed(c.Request.Context(), userID.(string))
+	feed, err := h.storyService.GetStoryFeed(c.Request.Context(), userID.(primitive.ObjectID).Hex())
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get story feed"})
 		return
@@ -5,6 +5,7 @@ import (
 	"vybes/internal/service"
 
 	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/bson/primitive"
 )
 
 // SuggestionHandler handles HTTP requests for user suggestions.
@@ -27,7 +28,7 @@ func (h *SuggestionHandler) GetSuggestions(c *gin.Context) {
 		return
 	}
 
-	suggestions, err := h.suggestionService.GetSuggestions(c.Request.Context(), userID.(string))
+	suggestions, err := h.suggestionService.GetSuggestions(c.Request.Context(), userID.(primitive.ObjectID).Hex())
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get suggestions"})
 		return
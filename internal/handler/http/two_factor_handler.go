@@ -0,0 +1,116 @@
+package http
+
+import (
+	"errors"
+	"net/http"
+
+	"vybes/internal/service"
+
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// TwoFactorHandler handles HTTP requests for managing two-factor authentication.
+type TwoFactorHandler struct {
+	twoFactorService service.TwoFactorService
+}
+
+// NewTwoFactorHandler creates a new TwoFactorHandler.
+func NewTwoFactorHandler(twoFactorService service.TwoFactorService) *TwoFactorHandler {
+	return &TwoFactorHandler{twoFactorService: twoFactorService}
+}
+
+// GetStatus is the handler for checking whether two-factor authentication is on.
+func (h *TwoFactorHandler) GetStatus(c *gin.Context) {
+	userID, _ := c.Get("user_id")
+	status, err := h.twoFactorService.GetStatus(c.Request.Context(), userID.(primitive.ObjectID).Hex())
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get two-factor status"})
+		return
+	}
+	c.JSON(http.StatusOK, status)
+}
+
+// Enroll is the handler for starting two-factor setup. The returned secret
+// only takes effect after Activate.
+func (h *TwoFactorHandler) Enroll(c *gin.Context) {
+	userID, _ := c.Get("user_id")
+	var request struct {
+		Password string `json:"password" binding:"required"`
+	}
+	if err := c.ShouldBindJSON(&request); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	enrollment, err := h.twoFactorService.Enroll(c.Request.Context(), userID.(primitive.ObjectID).Hex(), request.Password)
+	if err != nil {
+		respondTwoFactorError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, enrollment)
+}
+
+// Activate is the handler for confirming enrollment with a first code.
+func (h *TwoFactorHandler) Activate(c *gin.Context) {
+	userID, _ := c.Get("user_id")
+	var request struct {
+		Code string `json:"code" binding:"required"`
+	}
+	if err := c.ShouldBindJSON(&request); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	codes, err := h.twoFactorService.Activate(c.Request.Context(), userID.(primitive.ObjectID).Hex(), request.Code)
+	if err != nil {
+		respondTwoFactorError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"recovery_codes": codes})
+}
+
+// Disable is the handler for turning two-factor authentication off.
+func (h *TwoFactorHandler) Disable(c *gin.Context) {
+	userID, _ := c.Get("user_id")
+	var request struct {
+		Password string `json:"password" binding:"required"`
+		Code     string `json:"code" binding:"required"`
+	}
+	if err := c.ShouldBindJSON(&request); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if err := h.twoFactorService.Disable(c.Request.Context(), userID.(primitive.ObjectID).Hex(), request.Password, request.Code); err != nil {
+		respondTwoFactorError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "Two-factor authentication disabled"})
+}
+
+// RegenerateRecoveryCodes is the handler for replacing all recovery codes.
+func (h *TwoFactorHandler) RegenerateRecoveryCodes(c *gin.Context) {
+	userID, _ := c.Get("user_id")
+	var request struct {
+		Code string `json:"code" binding:"required"`
+	}
+	if err := c.ShouldBindJSON(&request); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	codes, err := h.twoFactorService.RegenerateRecoveryCodes(c.Request.Context(), userID.(primitive.ObjectID).Hex(), request.Code)
+	if err != nil {
+		respondTwoFactorError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"recovery_codes": codes})
+}
+
+func respondTwoFactorError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, service.ErrTwoFactorEnabled), errors.Is(err, service.ErrTwoFactorNotEnabled):
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+	case errors.Is(err, service.ErrInvalidTwoFactorCode), errors.Is(err, service.ErrTwoFactorRequired):
+		c.JSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
+	default:
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	}
+}
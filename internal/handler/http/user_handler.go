@@ -1,15 +1,16 @@
 package http
 
 import (
-	"encoding/json"
+	"errors"
 	"fmt"
 	"net/http"
 
 	"vybes/internal/service"
+	"vybes/pkg/evm"
 
-	"github.com/ethereum/go-ethereum/core/types"
 	"github.com/ethereum/go-ethereum/signer/core/apitypes"
 	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/bson/primitive"
 )
 
 // UserHandler handles HTTP requests for users.
@@ -59,6 +60,49 @@ func (h *UserHandler) Login(c *gin.Context) {
 	c.JSON(http.StatusOK, response)
 }
 
+// LoginWithEthereum logs in with a signed Sign-In with Ethereum (EIP-4361) message.
+func (h *UserHandler) LoginWithEthereum(c *gin.Context) {
+	var request struct {
+		Message   string `json:"message" binding:"required"`
+		Signature string `json:"signature" binding:"required"`
+	}
+	if err := c.ShouldBindJSON(&request); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	response, err := h.userService.LoginWithEthereum(c.Request.Context(), request.Message, request.Signature, c.Request.UserAgent(), c.ClientIP())
+	if err != nil {
+		switch {
+		case errors.Is(err, service.ErrSIWENotConfigured):
+			c.JSON(http.StatusServiceUnavailable, gin.H{"error": err.Error()})
+		case errors.Is(err, service.ErrInvalidSIWEMessage), errors.Is(err, service.ErrWalletNotLinked):
+			c.JSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
+		default:
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to log in"})
+		}
+		return
+	}
+	c.JSON(http.StatusOK, response)
+}
+
+// CompleteTwoFactorLogin exchanges a login challenge and a two-factor code for a session.
+func (h *UserHandler) CompleteTwoFactorLogin(c *gin.Context) {
+	var request struct {
+		ChallengeToken string `json:"challenge_token" binding:"required"`
+		Code           string `json:"code" binding:"required"`
+	}
+	if err := c.ShouldBindJSON(&request); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	response, err := h.userService.CompleteTwoFactorLogin(c.Request.Context(), request.ChallengeToken, request.Code)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, response)
+}
+
 func (h *UserHandler) RefreshToken(c *gin.Context) {
 	var request struct {
 		RefreshToken string `json:"refresh_token" binding:"required"`
@@ -81,14 +125,16 @@ func (h *UserHandler) UnlockWallet(c *gin.Context) {
 		c.JSON(http.StatusUnauthorized, gin.H{"error": "User ID not found in token"})
 		return
 	}
+	sessionID, _ := c.Get("session_id")
 	var request struct {
 		Password string `json:"password" binding:"required"`
+		TOTPCode string `json:"totpCode"`
 	}
 	if err := c.ShouldBindJSON(&request); err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
-	err := h.userService.UnlockWallet(c.Request.Context(), userID.(string), request.Password)
+	err := h.userService.UnlockWallet(c.Request.Context(), userID.(primitive.ObjectID).Hex(), sessionID.(primitive.ObjectID).Hex(), request.Password, request.TOTPCode)
 	if err != nil {
 		c.JSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
 		return
@@ -96,6 +142,17 @@ func (h *UserHandler) UnlockWallet(c *gin.Context) {
 	c.JSON(http.StatusOK, gin.H{"message": "Wallet unlocked successfully"})
 }
 
+// LockWallet is the handler for ending the session's wallet unlock before it expires.
+func (h *UserHandler) LockWallet(c *gin.Context) {
+	userID, _ := c.Get("user_id")
+	sessionID, _ := c.Get("session_id")
+	if err := h.userService.LockWallet(c.Request.Context(), userID.(primitive.ObjectID).Hex(), sessionID.(primitive.ObjectID).Hex()); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to lock wallet"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "Wallet locked successfully"})
+}
+
 func (h *UserHandler) GetUserProfile(c *gin.Context) {
 	viewerID, exists := c.Get("user_id")
 	if !exists {
@@ -122,7 +179,7 @@ func (h *UserHandler) GetUserProfile(c *gin.Context) {
 		return
 	}
 
-	profile, err := h.userService.GetUserProfile(c.Request.Context(), viewerID.(string), vidPtr, usernamePtr)
+	profile, err := h.userService.GetUserProfile(c.Request.Context(), viewerID.(primitive.ObjectID).Hex(), vidPtr, usernamePtr)
 	if err != nil {
 		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
 		return
@@ -140,7 +197,7 @@ func (h *UserHandler) UpdateProfile(c *gin.Context) {
 		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
-	updatedUser, err := h.userService.UpdateProfile(c.Request.Context(), userID.(string), payload)
+	updatedUser, err := h.userService.UpdateProfile(c.Request.Context(), userID.(primitive.ObjectID).Hex(), payload)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
@@ -155,12 +212,13 @@ func (h *UserHandler) ExportPrivateKey(c *gin.Context) {
 	}
 	var request struct {
 		Password string `json:"password" binding:"required"`
+		TOTPCode string `json:"totpCode"`
 	}
 	if err := c.ShouldBindJSON(&request); err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
-	privateKey, err := h.userService.ExportPrivateKey(c.Request.Context(), userID.(string), request.Password)
+	privateKey, err := h.userService.ExportPrivateKey(c.Request.Context(), userID.(primitive.ObjectID).Hex(), request.Password, request.TOTPCode)
 	if err != nil {
 		c.JSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
 		return
@@ -173,6 +231,7 @@ func (h *UserHandler) PersonalSign(c *gin.Context) {
 		c.JSON(http.StatusUnauthorized, gin.H{"error": "User ID not found in token"})
 		return
 	}
+	sessionID, _ := c.Get("session_id")
 	var request struct {
 		Message string `json:"message" binding:"required"`
 	}
@@ -180,7 +239,7 @@ func (h *UserHandler) PersonalSign(c *gin.Context) {
 		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
-	signature, err := h.userService.PersonalSign(c.Request.Context(), userID.(string), request.Message)
+	signature, err := h.userService.PersonalSign(c.Request.Context(), userID.(primitive.ObjectID).Hex(), sessionID.(primitive.ObjectID).Hex(), request.Message)
 	if err != nil {
 		c.JSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
 		return
@@ -193,24 +252,20 @@ func (h *UserHandler) SignTransaction(c *gin.Context) {
 		c.JSON(http.StatusUnauthorized, gin.H{"error": "User ID not found in token"})
 		return
 	}
+	sessionID, _ := c.Get("session_id")
 	var request struct {
-		Transaction json.RawMessage `json:"transaction" binding:"required"`
+		Transaction *evm.TxRequest `json:"transaction" binding:"required"`
 	}
 	if err := c.ShouldBindJSON(&request); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
-		return
-	}
-	var tx types.Transaction
-	if err := json.Unmarshal(request.Transaction, &tx); err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid transaction format"})
 		return
 	}
-	signedTx, err := h.userService.SignTransaction(c.Request.Context(), userID.(string), &tx)
+	signedTx, err := h.userService.SignTransaction(c.Request.Context(), userID.(primitive.ObjectID).Hex(), sessionID.(primitive.ObjectID).Hex(), *request.Transaction)
 	if err != nil {
-		c.JSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
+		writeWalletTransactionError(c, err)
 		return
 	}
-	c.JSON(http.StatusOK, signedTx)
+	c.JSON(http.StatusOK, gin.H{"signedTx": signedTx})
 }
 func (h *UserHandler) SignTypedDataV4(c *gin.Context) {
 	userID, exists := c.Get("user_id")
@@ -218,6 +273,7 @@ func (h *UserHandler) SignTypedDataV4(c *gin.Context) {
 		c.JSON(http.StatusUnauthorized, gin.H{"error": "User ID not found in token"})
 		return
 	}
+	sessionID, _ := c.Get("session_id")
 	var request struct {
 		TypedData apitypes.TypedData `json:"typedData" binding:"required"`
 	}
@@ -225,7 +281,7 @@ func (h *UserHandler) SignTypedDataV4(c *gin.Context) {
 		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
-	signature, err := h.userService.SignTypedDataV4(c.Request.Context(), userID.(string), request.TypedData)
+	signature, err := h.userService.SignTypedDataV4(c.Request.Context(), userID.(primitive.ObjectID).Hex(), sessionID.(primitive.ObjectID).Hex(), request.TypedData)
 	if err != nil {
 		c.JSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
 		return
@@ -238,31 +294,41 @@ func (h *UserHandler) SendTransaction(c *gin.Context) {
 		c.JSON(http.StatusUnauthorized, gin.H{"error": "User ID not found in token"})
 		return
 	}
+	sessionID, _ := c.Get("session_id")
 	var request struct {
-		Transaction json.RawMessage `json:"transaction" binding:"required"`
+		Transaction *evm.TxRequest `json:"transaction" binding:"required"`
 	}
 	if err := c.ShouldBindJSON(&request); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
-		return
-	}
-	var tx types.Transaction
-	if err := json.Unmarshal(request.Transaction, &tx); err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid transaction format"})
 		return
 	}
-	txHash, err := h.userService.SendTransaction(c.Request.Context(), userID.(string), &tx)
+	txHash, err := h.userService.SendTransaction(c.Request.Context(), userID.(primitive.ObjectID).Hex(), sessionID.(primitive.ObjectID).Hex(), *request.Transaction)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		writeWalletTransactionError(c, err)
 		return
 	}
 	c.JSON(http.StatusOK, gin.H{"transactionHash": txHash.Hex()})
 }
+
+// writeWalletTransactionError responds to a failed transaction signing or send.
+// Node errors, such as insufficient funds or a reverting call, are passed on.
+func writeWalletTransactionError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, evm.ErrInvalidTransaction):
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	case errors.Is(err, service.ErrWalletLocked):
+		c.JSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	}
+}
 func (h *UserHandler) Secp256k1Sign(c *gin.Context) {
 	userID, exists := c.Get("user_id")
 	if !exists {
 		c.JSON(http.StatusUnauthorized, gin.H{"error": "User ID not found in token"})
 		return
 	}
+	sessionID, _ := c.Get("session_id")
 	var request struct {
 		Hash string `json:"hash" binding:"required"`
 	}
@@ -270,7 +336,7 @@ func (h *UserHandler) Secp256k1Sign(c *gin.Context) {
 		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
-	signature, err := h.userService.Secp256k1Sign(c.Request.Context(), userID.(string), request.Hash)
+	signature, err := h.userService.Secp256k1Sign(c.Request.Context(), userID.(primitive.ObjectID).Hex(), sessionID.(primitive.ObjectID).Hex(), request.Hash)
 	if err != nil {
 		c.JSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
 		return
@@ -302,9 +368,16 @@ func (h *UserHandler) ResetPassword(c *gin.Context) {
 		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
-	err := h.userService.VerifyOTPAndResetPassword(c.Request.Context(), request.Email, request.OTP, request.NewPassword)
+	err := h.userService.VerifyOTPAndResetPassword(c.Request.Context(), request.Email, request.OTP, request.NewPassword, c.ClientIP())
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		switch {
+		case errors.Is(err, service.ErrTooManyOTPAttempts):
+			c.JSON(http.StatusTooManyRequests, gin.H{"error": err.Error()})
+		case errors.Is(err, service.ErrInvalidOTP):
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		default:
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to reset password"})
+		}
 		return
 	}
 	c.JSON(http.StatusOK, gin.H{"message": "Password has been reset successfully."})
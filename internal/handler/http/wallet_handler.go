@@ -0,0 +1,50 @@
+package http
+
+import (
+	"net/http"
+
+	"vybes/internal/service"
+
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// WalletHandler handles HTTP requests for the state of the user's custodial
+// wallet. Unlocking and signing are handled by UserHandler.
+type WalletHandler struct {
+	walletTxService      service.WalletTransactionService
+	walletBalanceService service.WalletBalanceService
+}
+
+// NewWalletHandler creates a new WalletHandler.
+func NewWalletHandler(walletTxService service.WalletTransactionService, walletBalanceService service.WalletBalanceService) *WalletHandler {
+	return &WalletHandler{
+		walletTxService:      walletTxService,
+		walletBalanceService: walletBalanceService,
+	}
+}
+
+// GetBalance is the handler for reading what the user's wallet holds.
+func (h *WalletHandler) GetBalance(c *gin.Context) {
+	userID, _ := c.Get("user_id")
+
+	balance, err := h.walletBalanceService.GetBalance(c.Request.Context(), userID.(primitive.ObjectID).Hex())
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get wallet balance"})
+		return
+	}
+	c.JSON(http.StatusOK, balance)
+}
+
+// GetTransactions is the handler for listing the transactions sent from the user's wallet.
+func (h *WalletHandler) GetTransactions(c *gin.Context) {
+	userID, _ := c.Get("user_id")
+	cursor, limit := pageParams(c)
+
+	transactions, err := h.walletTxService.GetTransactions(c.Request.Context(), userID.(primitive.ObjectID).Hex(), cursor, limit)
+	if err != nil {
+		respondListError(c, err, http.StatusInternalServerError, "Failed to get wallet transactions")
+		return
+	}
+	c.JSON(http.StatusOK, transactions)
+}
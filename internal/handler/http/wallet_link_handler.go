@@ -0,0 +1,90 @@
+package http
+
+import (
+	"errors"
+	"net/http"
+
+	"vybes/internal/service"
+
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// WalletLinkHandler handles HTTP requests for Sign-In with Ethereum nonces and
+// for linking external wallets to accounts.
+type WalletLinkHandler struct {
+	siweService service.SIWEService
+}
+
+// NewWalletLinkHandler creates a new WalletLinkHandler.
+func NewWalletLinkHandler(siweService service.SIWEService) *WalletLinkHandler {
+	return &WalletLinkHandler{siweService: siweService}
+}
+
+// GetNonce is the handler for issuing a nonce to sign in a Sign-In with Ethereum message.
+func (h *WalletLinkHandler) GetNonce(c *gin.Context) {
+	nonce, err := h.siweService.NewNonce(c.Request.Context())
+	if err != nil {
+		if errors.Is(err, service.ErrSIWENotConfigured) {
+			c.JSON(http.StatusServiceUnavailable, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create nonce"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"nonce": nonce})
+}
+
+// ListWallets is the handler for listing the external wallets linked to the user.
+func (h *WalletLinkHandler) ListWallets(c *gin.Context) {
+	userID, _ := c.Get("user_id")
+	links, err := h.siweService.ListWallets(c.Request.Context(), userID.(primitive.ObjectID))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get linked wallets"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"wallets": links})
+}
+
+// LinkWallet is the handler for linking an external wallet, proven with a signed
+// Sign-In with Ethereum message.
+func (h *WalletLinkHandler) LinkWallet(c *gin.Context) {
+	userID, _ := c.Get("user_id")
+	var request struct {
+		Message   string `json:"message" binding:"required"`
+		Signature string `json:"signature" binding:"required"`
+	}
+	if err := c.ShouldBindJSON(&request); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	link, err := h.siweService.LinkWallet(c.Request.Context(), userID.(primitive.ObjectID), request.Message, request.Signature)
+	if err != nil {
+		switch {
+		case errors.Is(err, service.ErrSIWENotConfigured):
+			c.JSON(http.StatusServiceUnavailable, gin.H{"error": err.Error()})
+		case errors.Is(err, service.ErrInvalidSIWEMessage):
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		case errors.Is(err, service.ErrWalletAlreadyLinked):
+			c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+		default:
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to link wallet"})
+		}
+		return
+	}
+	c.JSON(http.StatusCreated, link)
+}
+
+// UnlinkWallet is the handler for removing a linked external wallet.
+func (h *WalletLinkHandler) UnlinkWallet(c *gin.Context) {
+	userID, _ := c.Get("user_id")
+	if err := h.siweService.UnlinkWallet(c.Request.Context(), userID.(primitive.ObjectID), c.Param("address")); err != nil {
+		if errors.Is(err, service.ErrWalletNotLinked) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Wallet not linked"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to unlink wallet"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "Wallet unlinked successfully"})
+}
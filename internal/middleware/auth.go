@@ -1,8 +1,12 @@
 package middleware
 
 import (
+	"context"
+	"errors"
 	"net/http"
 	"strings"
+	"vybes/internal/service"
+	"vybes/pkg/jwtkeys"
 
 	"github.com/gin-gonic/gin"
 	"github.com/golang-jwt/jwt/v5"
@@ -10,17 +14,28 @@ import (
 	"go.mongodb.org/mongo-driver/bson/primitive"
 )
 
+// SessionValidator checks the session an access token was issued for.
+type SessionValidator interface {
+	// ValidateSession returns service.ErrSessionRevoked when the session is
+	// blocked, expired or doesn't belong to the user. Any other error means the
+	// session couldn't be checked.
+	ValidateSession(ctx context.Context, userID, sessionID primitive.ObjectID) error
+}
+
 // AuthMiddleware creates a Gin middleware for JWT authentication.
 // This middleware validates JWT tokens from the Authorization header
 // and extracts user information for use in subsequent handlers.
 // The middleware supports both "Bearer" and "Token" authorization schemes.
+// The token's "sid" claim must name a live session of the token's user, so
+// blocking a session cuts off its access tokens straight away.
 //
 // Parameters:
-//   - jwtSecret: The secret key used to sign and verify JWT tokens
+//   - jwtKeys: The key set whose keys may have signed the token
+//   - sessions: Validator used to check the session named by the "sid" claim
 //
 // Returns:
 //   - gin.HandlerFunc: A Gin middleware function that validates JWT tokens
-func AuthMiddleware(jwtSecret string) gin.HandlerFunc {
+func AuthMiddleware(jwtKeys *jwtkeys.KeySet, sessions SessionValidator) gin.HandlerFunc {
 	return func(c *gin.Context) {
 		// Extract authorization header
 		authHeader := c.GetHeader("Authorization")
@@ -42,13 +57,7 @@ func AuthMiddleware(jwtSecret string) gin.HandlerFunc {
 		tokenString := parts[1]
 
 		// Parse and validate JWT token
-		token, err := jwt.Parse(tokenString, func(token *jwt.Token) (interface{}, error) {
-			// Validate signing method
-			if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
-				return nil, jwt.ErrSignatureInvalid
-			}
-			return []byte(jwtSecret), nil
-		})
+		token, err := jwtKeys.Parse(tokenString)
 
 		if err != nil {
 			log.Warn().Err(err).Msg("JWT token validation failed")
@@ -75,8 +84,27 @@ func AuthMiddleware(jwtSecret string) gin.HandlerFunc {
 				return
 			}
 
-			// Set user ID in context for the handler to use
+			// Extract and check the session the token was issued for
+			sessionID, err := sessionIDFromClaims(claims)
+			if err != nil {
+				c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid session in token"})
+				c.Abort()
+				return
+			}
+			if err := sessions.ValidateSession(c.Request.Context(), userID, sessionID); err != nil {
+				if errors.Is(err, service.ErrSessionRevoked) {
+					c.JSON(http.StatusUnauthorized, gin.H{"error": "Session has been revoked or has expired"})
+				} else {
+					log.Error().Err(err).Msg("Failed to validate session")
+					c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to validate session"})
+				}
+				c.Abort()
+				return
+			}
+
+			// Set user and session IDs in context for the handler to use
 			c.Set("user_id", userID)
+			c.Set("session_id", sessionID)
 			c.Next()
 		} else {
 			c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid token"})
@@ -85,3 +113,52 @@ func AuthMiddleware(jwtSecret string) gin.HandlerFunc {
 		}
 	}
 }
+
+// OptionalAuthMiddleware creates a Gin middleware for routes that serve both
+// anonymous and authenticated callers. When a valid token for a live session is
+// present the user and session IDs are placed in the context exactly like
+// AuthMiddleware does; otherwise the request continues anonymously instead of
+// being rejected.
+//
+// Parameters:
+//   - jwtKeys: The key set whose keys may have signed the token
+//   - sessions: Validator used to check the session named by the "sid" claim
+//
+// Returns:
+//   - gin.HandlerFunc: A Gin middleware function that never aborts the request
+func OptionalAuthMiddleware(jwtKeys *jwtkeys.KeySet, sessions SessionValidator) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		parts := strings.Split(c.GetHeader("Authorization"), " ")
+		if len(parts) != 2 || (parts[0] != "Bearer" && parts[0] != "Token") {
+			c.Next()
+			return
+		}
+
+		token, err := jwtKeys.Parse(parts[1])
+		if err != nil || !token.Valid {
+			c.Next()
+			return
+		}
+
+		if claims, ok := token.Claims.(jwt.MapClaims); ok {
+			if userIDStr, ok := claims["sub"].(string); ok {
+				userID, err := primitive.ObjectIDFromHex(userIDStr)
+				sessionID, sidErr := sessionIDFromClaims(claims)
+				if err == nil && sidErr == nil && sessions.ValidateSession(c.Request.Context(), userID, sessionID) == nil {
+					c.Set("user_id", userID)
+					c.Set("session_id", sessionID)
+				}
+			}
+		}
+		c.Next()
+	}
+}
+
+// sessionIDFromClaims reads the session ID from the "sid" claim.
+func sessionIDFromClaims(claims jwt.MapClaims) (primitive.ObjectID, error) {
+	sid, ok := claims["sid"].(string)
+	if !ok {
+		return primitive.NilObjectID, errors.New("missing sid claim")
+	}
+	return primitive.ObjectIDFromHex(sid)
+}
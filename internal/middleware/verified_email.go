@@ -0,0 +1,48 @@
+package middleware
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/rs/zerolog/log"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// EmailVerificationChecker reports whether a user has verified their email address.
+type EmailVerificationChecker interface {
+	IsEmailVerified(ctx context.Context, userID primitive.ObjectID) (bool, error)
+}
+
+// RequireVerifiedEmail creates a Gin middleware that rejects users who haven't
+// verified their email address yet. It must run after AuthMiddleware.
+//
+// Parameters:
+//   - checker: Used to look up the user's verification status
+//
+// Returns:
+//   - gin.HandlerFunc: A Gin middleware function that aborts with 403 for unverified users
+func RequireVerifiedEmail(checker EmailVerificationChecker) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		userID, ok := c.Get("user_id")
+		if !ok {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "User ID not found in token"})
+			c.Abort()
+			return
+		}
+
+		verified, err := checker.IsEmailVerified(c.Request.Context(), userID.(primitive.ObjectID))
+		if err != nil {
+			log.Error().Err(err).Msg("Failed to check email verification")
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to check email verification"})
+			c.Abort()
+			return
+		}
+		if !verified {
+			c.JSON(http.StatusForbidden, gin.H{"error": "Verify your email address to do this"})
+			c.Abort()
+			return
+		}
+		c.Next()
+	}
+}
@@ -0,0 +1,91 @@
+package ranking
+
+import (
+	"bytes"
+	"math"
+	"sort"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// DefaultDiversityDecay is the score multiplier applied to each further post by
+// an author already placed in the feed.
+const DefaultDiversityDecay = 0.7
+
+// Ranker orders candidates with a Scorer, then re-ranks them so no single
+// author dominates the top of the feed.
+type Ranker struct {
+	Scorer         Scorer
+	DiversityDecay float64 // Multiplier per earlier post by the same author; 1 disables diversity
+}
+
+// NewRanker creates a ranker using scorer and the default diversity decay.
+func NewRanker(scorer Scorer) *Ranker {
+	return &Ranker{Scorer: scorer, DiversityDecay: DefaultDiversityDecay}
+}
+
+// Rank scores every candidate and returns them best first. Duplicate posts are
+// collapsed, keeping the copy with the highest score. Ties are broken by post
+// ID so the order is deterministic.
+func (r *Ranker) Rank(candidates []Candidate, now time.Time) []Candidate {
+	byPost := make(map[primitive.ObjectID]int, len(candidates))
+	scored := make([]Candidate, 0, len(candidates))
+	for _, c := range candidates {
+		c.Score = r.Scorer.Score(&c, now)
+		if i, seen := byPost[c.Post.ID]; seen {
+			if c.Score > scored[i].Score {
+				scored[i] = c
+			}
+			continue
+		}
+		byPost[c.Post.ID] = len(scored)
+		scored = append(scored, c)
+	}
+
+	sort.SliceStable(scored, func(i, j int) bool {
+		return better(scored[i], scored[j])
+	})
+	return r.diversify(scored)
+}
+
+// diversify greedily picks the best remaining candidate after discounting each
+// author's score by DiversityDecay for every post of theirs already picked.
+// Scores only ever go down, so a candidate whose raw score can't beat the
+// current best's discounted score can stop the scan early.
+func (r *Ranker) diversify(sorted []Candidate) []Candidate {
+	decay := r.DiversityDecay
+	if decay <= 0 || decay >= 1 || len(sorted) < 2 {
+		return sorted
+	}
+
+	picked := make(map[primitive.ObjectID]int)
+	remaining := sorted
+	out := make([]Candidate, 0, len(sorted))
+	for len(remaining) > 0 {
+		best := -1
+		var bestScore float64
+		for i, c := range remaining {
+			if best >= 0 && c.Score <= bestScore {
+				break
+			}
+			adjusted := c.Score * math.Pow(decay, float64(picked[c.Post.UserID]))
+			if best < 0 || adjusted > bestScore {
+				best, bestScore = i, adjusted
+			}
+		}
+		c := remaining[best]
+		c.Score = bestScore
+		out = append(out, c)
+		picked[c.Post.UserID]++
+		remaining = append(remaining[:best:best], remaining[best+1:]...)
+	}
+	return out
+}
+
+func better(a, b Candidate) bool {
+	if a.Score != b.Score {
+		return a.Score > b.Score
+	}
+	return bytes.Compare(a.Post.ID[:], b.Post.ID[:]) > 0
+}
@@ -0,0 +1,221 @@
+package ranking
+
+import (
+	"encoding/json"
+	"math"
+	"os"
+	"testing"
+	"time"
+)
+
+const fixturePath = "../../test/fixtures/feed_candidates.json"
+
+type fixture struct {
+	Now        time.Time   `json:"now"`
+	Candidates []Candidate `json:"candidates"`
+}
+
+func loadFixture(t *testing.T) fixture {
+	t.Helper()
+	data, err := os.ReadFile(fixturePath)
+	if err != nil {
+		t.Fatalf("failed to read fixture: %v", err)
+	}
+	var fx fixture
+	if err := json.Unmarshal(data, &fx); err != nil {
+		t.Fatalf("failed to parse fixture: %v", err)
+	}
+	return fx
+}
+
+func candidateByID(t *testing.T, fx fixture, id string) Candidate {
+	t.Helper()
+	for _, c := range fx.Candidates {
+		if c.Post.ID.Hex() == id {
+			return c
+		}
+	}
+	t.Fatalf("fixture has no post %s", id)
+	return Candidate{}
+}
+
+func assertOrder(t *testing.T, ranked []Candidate, want []string) {
+	t.Helper()
+	if len(ranked) != len(want) {
+		t.Fatalf("got %d candidates, want %d", len(ranked), len(want))
+	}
+	for i, c := range ranked {
+		if got := c.Post.ID.Hex(); got != want[i] {
+			t.Errorf("rank %d: got post %s (score %.3f), want %s", i+1, got, c.Score, want[i])
+		}
+	}
+}
+
+func assertScore(t *testing.T, name string, got, want float64) {
+	t.Helper()
+	if math.Abs(got-want) > 1e-9 {
+		t.Errorf("%s: got %.6f, want %.6f", name, got, want)
+	}
+}
+
+func TestRankFixture(t *testing.T) {
+	fx := loadFixture(t)
+
+	tests := []struct {
+		name   string
+		scorer Scorer
+		decay  float64
+		want   []string
+	}{
+		{
+			name:   "weighted with diversity",
+			scorer: NewWeightedScorer(DefaultWeights),
+			decay:  DefaultDiversityDecay,
+			want: []string{
+				"65a500000000000000000006",
+				"65a500000000000000000002",
+				"65a500000000000000000004",
+				"65a500000000000000000007",
+				"65a500000000000000000001",
+				"65a500000000000000000005",
+				"65a500000000000000000003",
+				"65a500000000000000000008",
+			},
+		},
+		{
+			name:   "weighted without diversity",
+			scorer: NewWeightedScorer(DefaultWeights),
+			decay:  1,
+			want: []string{
+				"65a500000000000000000006",
+				"65a500000000000000000002",
+				"65a500000000000000000007",
+				"65a500000000000000000001",
+				"65a500000000000000000004",
+				"65a500000000000000000003",
+				"65a500000000000000000005",
+				"65a500000000000000000008",
+			},
+		},
+		{
+			name:   "recency is chronological",
+			scorer: RecencyScorer{HalfLife: DefaultWeights.RecencyHalfLife},
+			decay:  1,
+			want: []string{
+				"65a500000000000000000001",
+				"65a500000000000000000002",
+				"65a500000000000000000003",
+				"65a500000000000000000007",
+				"65a500000000000000000004",
+				"65a500000000000000000005",
+				"65a500000000000000000006",
+				"65a500000000000000000008",
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ranker := NewRanker(tt.scorer)
+			ranker.DiversityDecay = tt.decay
+			ranked := ranker.Rank(fx.Candidates, fx.Now)
+			assertOrder(t, ranked, tt.want)
+			for i := 1; i < len(ranked); i++ {
+				if ranked[i].Score > ranked[i-1].Score {
+					t.Errorf("rank %d scores %.3f, above rank %d's %.3f", i+1, ranked[i].Score, i, ranked[i-1].Score)
+				}
+			}
+		})
+	}
+}
+
+func TestRankDiversityDiscountsRepeatAuthors(t *testing.T) {
+	fx := loadFixture(t)
+	scorer := NewWeightedScorer(DefaultWeights)
+	ranked := NewRanker(scorer).Rank(fx.Candidates, fx.Now)
+
+	// Author a01's posts are picked in score order, the n-th one discounted by decay^n
+	var seen int
+	for _, c := range ranked {
+		if c.Post.UserID.Hex() != "65a000000000000000000a01" {
+			continue
+		}
+		original := candidateByID(t, fx, c.Post.ID.Hex())
+		raw := scorer.Score(&original, fx.Now)
+		assertScore(t, c.Post.ID.Hex(), c.Score, raw*math.Pow(DefaultDiversityDecay, float64(seen)))
+		seen++
+	}
+	if seen != 3 {
+		t.Fatalf("got %d posts by author a01, want 3", seen)
+	}
+}
+
+func TestRankCollapsesDuplicates(t *testing.T) {
+	fx := loadFixture(t)
+	dup := candidateByID(t, fx, "65a500000000000000000008")
+	dup.Source = SourceFriendOfFriend
+	dup.Affinity = 1
+	candidates := append(fx.Candidates, dup)
+
+	ranker := NewRanker(NewWeightedScorer(DefaultWeights))
+	ranker.DiversityDecay = 1
+	ranked := ranker.Rank(candidates, fx.Now)
+	if len(ranked) != len(fx.Candidates) {
+		t.Fatalf("got %d candidates, want %d", len(ranked), len(fx.Candidates))
+	}
+	for _, c := range ranked {
+		if c.Post.ID == dup.Post.ID && c.Source != SourceFriendOfFriend {
+			t.Errorf("kept the %s copy, want the higher scoring %s copy", c.Source, SourceFriendOfFriend)
+		}
+	}
+}
+
+func TestWeightedScorerContributions(t *testing.T) {
+	fx := loadFixture(t)
+	// 1h15m old; 5 likes, 1 comment, 0 reposts, 80 views; affinity 0.7; following
+	c := candidateByID(t, fx, "65a500000000000000000002")
+	w := DefaultWeights
+
+	contributions := []struct {
+		name    string
+		weights Weights
+		want    float64
+	}{
+		{"recency", Weights{Recency: w.Recency, RecencyHalfLife: w.RecencyHalfLife}, w.Recency * math.Exp2(-1.25/12)},
+		{"likes", Weights{Likes: w.Likes}, w.Likes * math.Log10(6) / 2},
+		{"comments", Weights{Comments: w.Comments}, w.Comments * math.Log10(2) / 2},
+		{"reposts", Weights{Reposts: w.Reposts}, 0},
+		{"views", Weights{Views: w.Views}, w.Views * math.Log10(81) / 2},
+		{"affinity", Weights{Affinity: w.Affinity}, w.Affinity * 0.7},
+		{"source", Weights{SourceBoost: w.SourceBoost}, w.SourceBoost[SourceFollowing]},
+	}
+
+	var total float64
+	for _, tt := range contributions {
+		got := NewWeightedScorer(tt.weights).Score(&c, fx.Now)
+		assertScore(t, tt.name, got, tt.want)
+		total += tt.want
+	}
+	assertScore(t, "total", NewWeightedScorer(w).Score(&c, fx.Now), total)
+}
+
+func TestWeightedScorerSignals(t *testing.T) {
+	tests := []struct {
+		name string
+		got  float64
+		want float64
+	}{
+		{"brand-new post is fully fresh", recency(0, time.Hour), 1},
+		{"post from the future is fully fresh", recency(-time.Minute, time.Hour), 1},
+		{"one half-life halves freshness", recency(12*time.Hour, 12*time.Hour), 0.5},
+		{"no half-life has no freshness", recency(time.Minute, 0), 0},
+		{"no engagement", engagement(0), 0},
+		{"ten interactions", engagement(9), 0.5},
+		{"a thousand interactions", engagement(999), 1.5},
+		{"affinity above one is clamped", clamp01(1.8), 1},
+		{"negative affinity is clamped", clamp01(-0.3), 0},
+	}
+	for _, tt := range tests {
+		assertScore(t, tt.name, tt.got, tt.want)
+	}
+}
@@ -0,0 +1,150 @@
+package repository
+
+import (
+	"context"
+	"vybes/internal/domain"
+	"vybes/pkg/pagination"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// BlockRepository defines the interface for block relationship data operations.
+// A block cuts all ties between two users, so creating one also removes the
+// follow relationships between them.
+type BlockRepository interface {
+	// CreateBlock records a block and removes follows and follow requests in both directions atomically
+	CreateBlock(ctx context.Context, block *domain.Block) error
+	// DeleteBlock lifts a block
+	DeleteBlock(ctx context.Context, blockerID, blockedID primitive.ObjectID) error
+	// IsBlocked checks whether either user has blocked the other
+	IsBlocked(ctx context.Context, userID, otherID primitive.ObjectID) (bool, error)
+	// GetBlockedIDs returns the users a user has blocked
+	GetBlockedIDs(ctx context.Context, userID primitive.ObjectID) ([]primitive.ObjectID, error)
+	// GetBlockerIDs returns the users who have blocked a user
+	GetBlockerIDs(ctx context.Context, userID primitive.ObjectID) ([]primitive.ObjectID, error)
+	// GetBlocks retrieves a page of the blocks a user has made, newest first
+	GetBlocks(ctx context.Context, userID primitive.ObjectID, cursor *pagination.Cursor, limit int) ([]domain.Block, error)
+}
+
+// mongoBlockRepository implements BlockRepository using MongoDB as the backend
+type mongoBlockRepository struct {
+	collection *mongo.Collection
+}
+
+// NewMongoBlockRepository creates a new block repository instance with MongoDB backend.
+//
+// Parameters:
+//   - db: MongoDB database instance
+//
+// Returns:
+//   - BlockRepository: A configured block repository ready for use
+func NewMongoBlockRepository(db *mongo.Database) BlockRepository {
+	return &mongoBlockRepository{
+		collection: db.Collection("blocks"),
+	}
+}
+
+// CreateBlock stores a block and deletes any follow or pending follow request
+// between the two users in a single transaction, so a block never coexists with a follow. Blocking a user
+// twice keeps the original block.
+//
+// Parameters:
+//   - ctx: Context for the operation
+//   - block: The block to create
+//
+// Returns:
+//   - error: Any error that occurred during the operation
+func (r *mongoBlockRepository) CreateBlock(ctx context.Context, block *domain.Block) error {
+	session, err := r.collection.Database().Client().StartSession()
+	if err != nil {
+		return err
+	}
+	defer session.EndSession(ctx)
+
+	follows := r.collection.Database().Collection("follows")
+	followRequests := r.collection.Database().Collection("follow_requests")
+	_, err = session.WithTransaction(ctx, func(sessCtx mongo.SessionContext) (interface{}, error) {
+		filter := bson.M{"blockerId": block.BlockerID, "blockedId": block.BlockedID}
+		opts := options.Update().SetUpsert(true)
+		if _, err := r.collection.UpdateOne(sessCtx, filter, bson.M{"$setOnInsert": block}, opts); err != nil {
+			return nil, err
+		}
+		if _, err := follows.DeleteMany(sessCtx, bson.M{"$or": []bson.M{
+			{"followerId": block.BlockerID, "followingId": block.BlockedID},
+			{"followerId": block.BlockedID, "followingId": block.BlockerID},
+		}}); err != nil {
+			return nil, err
+		}
+		_, err := followRequests.DeleteMany(sessCtx, bson.M{"$or": []bson.M{
+			{"requesterId": block.BlockerID, "targetId": block.BlockedID},
+			{"requesterId": block.BlockedID, "targetId": block.BlockerID},
+		}})
+		return nil, err
+	})
+	return err
+}
+
+func (r *mongoBlockRepository) DeleteBlock(ctx context.Context, blockerID, blockedID primitive.ObjectID) error {
+	_, err := r.collection.DeleteOne(ctx, bson.M{"blockerId": blockerID, "blockedId": blockedID})
+	return err
+}
+
+func (r *mongoBlockRepository) IsBlocked(ctx context.Context, userID, otherID primitive.ObjectID) (bool, error) {
+	count, err := r.collection.CountDocuments(ctx, bson.M{"$or": []bson.M{
+		{"blockerId": userID, "blockedId": otherID},
+		{"blockerId": otherID, "blockedId": userID},
+	}})
+	if err != nil {
+		return false, err
+	}
+	return count > 0, nil
+}
+
+func (r *mongoBlockRepository) GetBlockedIDs(ctx context.Context, userID primitive.ObjectID) ([]primitive.ObjectID, error) {
+	blocks, err := r.find(ctx, bson.M{"blockerId": userID})
+	if err != nil {
+		return nil, err
+	}
+	ids := make([]primitive.ObjectID, len(blocks))
+	for i, b := range blocks {
+		ids[i] = b.BlockedID
+	}
+	return ids, nil
+}
+
+func (r *mongoBlockRepository) GetBlockerIDs(ctx context.Context, userID primitive.ObjectID) ([]primitive.ObjectID, error) {
+	blocks, err := r.find(ctx, bson.M{"blockedId": userID})
+	if err != nil {
+		return nil, err
+	}
+	ids := make([]primitive.ObjectID, len(blocks))
+	for i, b := range blocks {
+		ids[i] = b.BlockerID
+	}
+	return ids, nil
+}
+
+func (r *mongoBlockRepository) GetBlocks(ctx context.Context, userID primitive.ObjectID, after *pagination.Cursor, limit int) ([]domain.Block, error) {
+	var blocks []domain.Block
+	cursor, err := r.collection.Find(ctx, afterCursor(bson.M{"blockerId": userID}, after, false), newestFirst(limit))
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+	err = cursor.All(ctx, &blocks)
+	return blocks, err
+}
+
+func (r *mongoBlockRepository) find(ctx context.Context, filter bson.M) ([]domain.Block, error) {
+	var blocks []domain.Block
+	cursor, err := r.collection.Find(ctx, filter)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+	err = cursor.All(ctx, &blocks)
+	return blocks, err
+}
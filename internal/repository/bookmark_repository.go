@@ -3,6 +3,7 @@ package repository
 import (
 	"context"
 	"vybes/internal/domain"
+	"vybes/pkg/pagination"
 
 	"go.mongodb.org/mongo-driver/bson"
 	"go.mongodb.org/mongo-driver/bson/primitive"
@@ -19,11 +20,13 @@ type BookmarkRepository interface {
 	// DeleteBookmark removes a content item from a user's bookmarks
 	DeleteBookmark(ctx context.Context, userID, postID primitive.ObjectID) error
 	// GetUserBookmarks retrieves all bookmarked content for a specific user
-	GetUserBookmarks(ctx context.Context, userID primitive.ObjectID, page, limit int) ([]domain.Bookmark, error)
+	GetUserBookmarks(ctx context.Context, userID primitive.ObjectID, cursor *pagination.Cursor, limit int) ([]domain.Bookmark, error)
 	// IsBookmarked checks if a user has bookmarked a specific content item
 	IsBookmarked(ctx context.Context, userID, postID primitive.ObjectID) (bool, error)
 	// GetBookmarkCount returns the number of bookmarks for a content item
 	GetBookmarkCount(ctx context.Context, postID primitive.ObjectID) (int64, error)
+	// DeleteBookmarksByPostID removes every bookmark of a post
+	DeleteBookmarksByPostID(ctx context.Context, postID primitive.ObjectID) error
 }
 
 // mongoBookmarkRepository implements BookmarkRepository using MongoDB as the backend
@@ -58,8 +61,8 @@ func NewMongoBookmarkRepository(db *mongo.Database) BookmarkRepository {
 func (r *mongoBookmarkRepository) CreateBookmark(ctx context.Context, bookmark *domain.Bookmark) error {
 	// Use upsert to avoid duplicate bookmarks
 	filter := bson.M{
-		"userid":    bookmark.UserID,
-		"postid": bookmark.PostID,
+		"userId":    bookmark.UserID,
+		"postId": bookmark.PostID,
 	}
 	update := bson.M{"$setOnInsert": bookmark}
 	opts := options.Update().SetUpsert(true)
@@ -71,18 +74,17 @@ func (r *mongoBookmarkRepository) CreateBookmark(ctx context.Context, bookmark *
 // DeleteBookmark removes a content item from a user's bookmarks.
 func (r *mongoBookmarkRepository) DeleteBookmark(ctx context.Context, userID, postID primitive.ObjectID) error {
 	filter := bson.M{
-		"userid":    userID,
-		"postid": postID,
+		"userId":    userID,
+		"postId": postID,
 	}
 	_, err := r.collection.DeleteOne(ctx, filter)
 	return err
 }
 
-// GetUserBookmarks retrieves all bookmarked content for a specific user.
-func (r *mongoBookmarkRepository) GetUserBookmarks(ctx context.Context, userID primitive.ObjectID, page, limit int) ([]domain.Bookmark, error) {
+// GetUserBookmarks retrieves a page of a user's bookmarks, most recently bookmarked first.
+func (r *mongoBookmarkRepository) GetUserBookmarks(ctx context.Context, userID primitive.ObjectID, after *pagination.Cursor, limit int) ([]domain.Bookmark, error) {
 	var bookmarks []domain.Bookmark
-	opts := options.Find().SetSkip(int64((page - 1) * limit)).SetLimit(int64(limit))
-	cursor, err := r.collection.Find(ctx, bson.M{"userid": userID}, opts)
+	cursor, err := r.collection.Find(ctx, afterCursor(bson.M{"userId": userID}, after, false), newestFirst(limit))
 	if err != nil {
 		return nil, err
 	}
@@ -94,8 +96,8 @@ func (r *mongoBookmarkRepository) GetUserBookmarks(ctx context.Context, userID p
 // IsBookmarked checks if a user has bookmarked a specific content item.
 func (r *mongoBookmarkRepository) IsBookmarked(ctx context.Context, userID, postID primitive.ObjectID) (bool, error) {
 	filter := bson.M{
-		"userid":    userID,
-		"postid": postID,
+		"userId":    userID,
+		"postId": postID,
 	}
 	count, err := r.collection.CountDocuments(ctx, filter)
 	if err != nil {
@@ -106,5 +108,11 @@ func (r *mongoBookmarkRepository) IsBookmarked(ctx context.Context, userID, post
 
 // GetBookmarkCount returns the number of bookmarks for a content item.
 func (r *mongoBookmarkRepository) GetBookmarkCount(ctx context.Context, postID primitive.ObjectID) (int64, error) {
-	return r.collection.CountDocuments(ctx, bson.M{"postid": postID})
+	return r.collection.CountDocuments(ctx, bson.M{"postId": postID})
+}
+
+// DeleteBookmarksByPostID removes every user's bookmark of a post.
+func (r *mongoBookmarkRepository) DeleteBookmarksByPostID(ctx context.Context, postID primitive.ObjectID) error {
+	_, err := r.collection.DeleteMany(ctx, bson.M{"postId": postID})
+	return err
 }
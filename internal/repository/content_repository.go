@@ -2,7 +2,10 @@ package repository
 
 import (
 	"context"
+	"errors"
+	"time"
 	"vybes/internal/domain"
+	"vybes/pkg/pagination"
 
 	"go.mongodb.org/mongo-driver/bson"
 	"go.mongodb.org/mongo-driver/bson/primitive"
@@ -17,17 +20,45 @@ type ContentRepository interface {
 	// Content and Post methods
 	CreatePost(ctx context.Context, post *domain.Post) error
 	GetPostByID(ctx context.Context, postID primitive.ObjectID) (*domain.Post, error)
-	GetPostsByUserID(ctx context.Context, userID primitive.ObjectID, page, limit int) ([]domain.Post, error)
+	GetPostsByUserID(ctx context.Context, userID primitive.ObjectID, cursor *pagination.Cursor, limit int) ([]domain.Post, error)
 	GetPostsByIDs(ctx context.Context, postIDs []primitive.ObjectID) ([]domain.Post, error)
-	GetPostsByUsersWithVisibility(ctx context.Context, userIDs []primitive.ObjectID, visibilities []domain.PostVisibility, limit int) ([]domain.Post, error)
+	GetPostsByUsersWithVisibility(ctx context.Context, userIDs []primitive.ObjectID, visibilities []domain.PostVisibility, cursor *pagination.Cursor, limit int) ([]domain.Post, error)
 	UpdatePost(ctx context.Context, post *domain.Post) error
 	DeletePost(ctx context.Context, postID, userID primitive.ObjectID) error
-	GetFeedPosts(ctx context.Context, userIDs []primitive.ObjectID, page, limit int) ([]domain.Post, error)
-	
+	// DeletePostAndEnqueueCleanup deletes a post, adjusts its counters and enqueues its cleanup job atomically
+	DeletePostAndEnqueueCleanup(ctx context.Context, post *domain.Post, job *domain.Job) error
+
+	// Repost methods
+	// HasReposted checks if a user has already reposted a specific post
+	HasReposted(ctx context.Context, userID, originalPostID primitive.ObjectID) (bool, error)
+	// GetRepostsByUserID retrieves the most recent reposts made by a user
+	GetRepostsByUserID(ctx context.Context, userID primitive.ObjectID, cursor *pagination.Cursor, limit int) ([]domain.Post, error)
+	// GetTrendingPosts retrieves the most engaged-with public posts created in [since, until]
+	GetTrendingPosts(ctx context.Context, since, until time.Time, limit int) ([]domain.Post, error)
+	// IncrementRepostCount atomically adjusts the repost counter of a post
+	IncrementRepostCount(ctx context.Context, postID primitive.ObjectID, delta int) error
+	// GetPlainRepostsOf retrieves reposts of a post that carry no quote caption
+	GetPlainRepostsOf(ctx context.Context, postID primitive.ObjectID) ([]domain.Post, error)
+	// IncrementViewCounts applies a batch of buffered view counts in a single bulk write
+	// and returns the posts whose counts weren't applied
+	IncrementViewCounts(ctx context.Context, counts map[primitive.ObjectID]int64) ([]primitive.ObjectID, error)
+
 	// Comment methods
+	// CreateComment stores a comment or reply and bumps the post's comment count and the parent's reply count
 	CreateComment(ctx context.Context, comment *domain.Comment) error
-	GetCommentsByPostID(ctx context.Context, postID primitive.ObjectID, page, limit int) ([]domain.Comment, error)
-	DeleteComment(ctx context.Context, commentID, userID primitive.ObjectID) error
+	GetCommentByID(ctx context.Context, commentID primitive.ObjectID) (*domain.Comment, error)
+	// GetCommentsByPostID retrieves the top-level comments of a post
+	GetCommentsByPostID(ctx context.Context, postID primitive.ObjectID, cursor *pagination.Cursor, limit int) ([]domain.Comment, error)
+	// GetReplies retrieves replies to a comment in chronological order
+	GetReplies(ctx context.Context, parentID primitive.ObjectID, cursor *pagination.Cursor, limit int) ([]domain.Comment, error)
+	// UpdateCommentText replaces a comment's text and records the previous version in its edit history
+	UpdateCommentText(ctx context.Context, commentID primitive.ObjectID, text string, previous domain.CommentEdit) error
+	// DeleteComment removes a comment, or tombstones it if it has replies, and reports whether it was hard-deleted
+	DeleteComment(ctx context.Context, comment *domain.Comment) (bool, error)
+	// GetCommentAuthorsByPostID retrieves the author of every comment and reply on a post, keyed by comment ID
+	GetCommentAuthorsByPostID(ctx context.Context, postID primitive.ObjectID) (map[primitive.ObjectID]primitive.ObjectID, error)
+	// DeleteCommentsByPostID removes every comment on a post
+	DeleteCommentsByPostID(ctx context.Context, postID primitive.ObjectID) error
 	GetCommentCount(ctx context.Context, postID primitive.ObjectID) (int64, error)
 }
 
@@ -73,10 +104,9 @@ func (r *mongoContentRepository) GetPostByID(ctx context.Context, postID primiti
 	return &post, err
 }
 
-func (r *mongoContentRepository) GetPostsByUserID(ctx context.Context, userID primitive.ObjectID, page, limit int) ([]domain.Post, error) {
+func (r *mongoContentRepository) GetPostsByUserID(ctx context.Context, userID primitive.ObjectID, after *pagination.Cursor, limit int) ([]domain.Post, error) {
 	var posts []domain.Post
-	opts := options.Find().SetSkip(int64((page - 1) * limit)).SetLimit(int64(limit))
-	cursor, err := r.posts().Find(ctx, bson.M{"userid": userID}, opts)
+	cursor, err := r.posts().Find(ctx, afterCursor(bson.M{"userId": userID}, after, false), newestFirst(limit))
 	if err != nil {
 		return nil, err
 	}
@@ -96,10 +126,10 @@ func (r *mongoContentRepository) GetPostsByIDs(ctx context.Context, postIDs []pr
 	return posts, err
 }
 
-func (r *mongoContentRepository) GetPostsByUsersWithVisibility(ctx context.Context, userIDs []primitive.ObjectID, visibilities []domain.PostVisibility, limit int) ([]domain.Post, error) {
+func (r *mongoContentRepository) GetPostsByUsersWithVisibility(ctx context.Context, userIDs []primitive.ObjectID, visibilities []domain.PostVisibility, after *pagination.Cursor, limit int) ([]domain.Post, error) {
 	var posts []domain.Post
-	opts := options.Find().SetLimit(int64(limit)).SetSort(bson.D{{Key: "createdat", Value: -1}})
-	cursor, err := r.posts().Find(ctx, bson.M{"userid": bson.M{"$in": userIDs}, "visibility": bson.M{"$in": visibilities}}, opts)
+	filter := bson.M{"userId": bson.M{"$in": userIDs}, "visibility": bson.M{"$in": visibilities}}
+	cursor, err := r.posts().Find(ctx, afterCursor(filter, after, false), newestFirst(limit))
 	if err != nil {
 		return nil, err
 	}
@@ -114,14 +144,124 @@ func (r *mongoContentRepository) UpdatePost(ctx context.Context, post *domain.Po
 }
 
 func (r *mongoContentRepository) DeletePost(ctx context.Context, postID, userID primitive.ObjectID) error {
-	_, err := r.posts().DeleteOne(ctx, bson.M{"_id": postID, "userid": userID})
+	_, err := r.posts().DeleteOne(ctx, bson.M{"_id": postID, "userId": userID})
+	return err
+}
+
+// DeletePostAndEnqueueCleanup removes a post and enqueues the job that cleans up
+// its dependent records. The deletion, the counter adjustments and the job insert
+// happen in one transaction, so counters are adjusted exactly once and a deleted
+// post always has a cleanup job.
+//
+// Parameters:
+//   - ctx: Context for the operation
+//   - post: The post being deleted
+//   - job: The cleanup job to enqueue
+//
+// Returns:
+//   - error: mongo.ErrNoDocuments if the post was already deleted, or any other error
+func (r *mongoContentRepository) DeletePostAndEnqueueCleanup(ctx context.Context, post *domain.Post, job *domain.Job) error {
+	session, err := r.posts().Database().Client().StartSession()
+	if err != nil {
+		return err
+	}
+	defer session.EndSession(ctx)
+
+	db := r.posts().Database()
+	_, err = session.WithTransaction(ctx, func(sessCtx mongo.SessionContext) (interface{}, error) {
+		result, err := r.posts().DeleteOne(sessCtx, bson.M{"_id": post.ID, "userId": post.UserID})
+		if err != nil {
+			return nil, err
+		}
+		if result.DeletedCount == 0 {
+			return nil, mongo.ErrNoDocuments
+		}
+
+		if _, err := db.Collection("jobs").InsertOne(sessCtx, job); err != nil {
+			return nil, err
+		}
+
+		// Reposts count towards the original's repost counter, not the author's post count
+		if post.IsRepost() {
+			_, err = r.posts().UpdateOne(sessCtx, bson.M{"_id": *post.OriginalPostID}, bson.M{"$inc": bson.M{"repostCount": -1}})
+		} else {
+			_, err = db.Collection("users").UpdateOne(sessCtx, bson.M{"_id": post.UserID}, bson.M{"$inc": bson.M{"postCount": -1}})
+		}
+		return nil, err
+	})
+
+	return err
+}
+
+func (r *mongoContentRepository) HasReposted(ctx context.Context, userID, originalPostID primitive.ObjectID) (bool, error) {
+	count, err := r.posts().CountDocuments(ctx, bson.M{"userId": userID, "originalPostId": originalPostID})
+	if err != nil {
+		return false, err
+	}
+	return count > 0, nil
+}
+
+func (r *mongoContentRepository) GetRepostsByUserID(ctx context.Context, userID primitive.ObjectID, after *pagination.Cursor, limit int) ([]domain.Post, error) {
+	var posts []domain.Post
+	filter := bson.M{"userId": userID, "originalPostId": bson.M{"$exists": true}}
+	cursor, err := r.posts().Find(ctx, afterCursor(filter, after, false), newestFirst(limit))
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+	err = cursor.All(ctx, &posts)
+	return posts, err
+}
+
+// GetTrendingPosts ranks recent public posts by a weighted engagement total.
+// Reposts are left out so a popular post isn't listed once per repost.
+//
+// Parameters:
+//   - ctx: Context for the operation
+//   - since: Oldest creation time to consider
+//   - until: Newest creation time to consider
+//   - limit: Maximum number of posts to return
+//
+// Returns:
+//   - []domain.Post: The trending posts, most engaged-with first
+//   - error: Any error that occurred during the operation
+func (r *mongoContentRepository) GetTrendingPosts(ctx context.Context, since, until time.Time, limit int) ([]domain.Post, error) {
+	pipeline := mongo.Pipeline{
+		{{Key: "$match", Value: bson.M{
+			"visibility":     domain.VisibilityPublic,
+			"originalPostId": bson.M{"$exists": false},
+			"createdAt":      bson.M{"$gte": since, "$lte": until},
+		}}},
+		{{Key: "$addFields", Value: bson.M{"engagement": bson.M{"$add": bson.A{
+			"$likeCount",
+			bson.M{"$multiply": bson.A{"$commentCount", 2}},
+			bson.M{"$multiply": bson.A{"$repostCount", 3}},
+		}}}}},
+		{{Key: "$sort", Value: bson.D{{Key: "engagement", Value: -1}, {Key: "_id", Value: -1}}}},
+		{{Key: "$limit", Value: limit}},
+	}
+	cursor, err := r.posts().Aggregate(ctx, pipeline)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	var posts []domain.Post
+	err = cursor.All(ctx, &posts)
+	return posts, err
+}
+
+// IncrementRepostCount uses $inc so concurrent reposts never lose an update.
+func (r *mongoContentRepository) IncrementRepostCount(ctx context.Context, postID primitive.ObjectID, delta int) error {
+	_, err := r.posts().UpdateOne(ctx, bson.M{"_id": postID}, bson.M{"$inc": bson.M{"repostCount": delta}})
 	return err
 }
 
-func (r *mongoContentRepository) GetFeedPosts(ctx context.Context, userIDs []primitive.ObjectID, page, limit int) ([]domain.Post, error) {
+func (r *mongoContentRepository) GetPlainRepostsOf(ctx context.Context, postID primitive.ObjectID) ([]domain.Post, error) {
 	var posts []domain.Post
-	opts := options.Find().SetSort(bson.D{{Key: "createdAt", Value: -1}}).SetSkip(int64((page - 1) * limit)).SetLimit(int64(limit))
-	cursor, err := r.posts().Find(ctx, bson.M{"userid": bson.M{"$in": userIDs}}, opts)
+	// An empty caption is omitted from the document, so match both missing and empty
+	filter := bson.M{"originalPostId": postID, "caption": bson.M{"$in": []interface{}{nil, ""}}}
+	cursor, err := r.posts().Find(ctx, filter)
 	if err != nil {
 		return nil, err
 	}
@@ -130,15 +270,93 @@ func (r *mongoContentRepository) GetFeedPosts(ctx context.Context, userIDs []pri
 	return posts, err
 }
 
+// IncrementViewCounts applies the counts with an unordered bulk write. When
+// some updates fail, the others are still applied, so only the failed posts
+// are returned for a retry.
+//
+// Parameters:
+//   - ctx: Context for the operation
+//   - counts: Views to add per post
+//
+// Returns:
+//   - []primitive.ObjectID: Posts whose counts weren't applied; every post when the write failed as a whole
+//   - error: Any error that occurred during the operation
+func (r *mongoContentRepository) IncrementViewCounts(ctx context.Context, counts map[primitive.ObjectID]int64) ([]primitive.ObjectID, error) {
+	if len(counts) == 0 {
+		return nil, nil
+	}
+	postIDs := make([]primitive.ObjectID, 0, len(counts))
+	models := make([]mongo.WriteModel, 0, len(counts))
+	for postID, count := range counts {
+		postIDs = append(postIDs, postID)
+		models = append(models, mongo.NewUpdateOneModel().
+			SetFilter(bson.M{"_id": postID}).
+			SetUpdate(bson.M{"$inc": bson.M{"viewCount": count}}))
+	}
+	_, err := r.posts().BulkWrite(ctx, models, options.BulkWrite().SetOrdered(false))
+	if err == nil {
+		return nil, nil
+	}
+
+	var bulkErr mongo.BulkWriteException
+	if !errors.As(err, &bulkErr) {
+		// Nothing tells which updates were applied
+		return postIDs, err
+	}
+	// Updates without a write error were applied, even with a write concern error
+	failed := make([]primitive.ObjectID, 0, len(bulkErr.WriteErrors))
+	for _, writeErr := range bulkErr.WriteErrors {
+		failed = append(failed, postIDs[writeErr.Index])
+	}
+	return failed, err
+}
+
+// CreateComment inserts a comment and keeps the denormalized counters in step:
+// the post's comment count and, for replies, the parent's reply count.
+// This operation is performed within a transaction to ensure data consistency.
+//
+// Parameters:
+//   - ctx: Context for the operation
+//   - comment: The comment or reply to create
+//
+// Returns:
+//   - error: Any error that occurred during the operation
 func (r *mongoContentRepository) CreateComment(ctx context.Context, comment *domain.Comment) error {
-	_, err := r.comments().InsertOne(ctx, comment)
+	session, err := r.comments().Database().Client().StartSession()
+	if err != nil {
+		return err
+	}
+	defer session.EndSession(ctx)
+
+	_, err = session.WithTransaction(ctx, func(sessCtx mongo.SessionContext) (interface{}, error) {
+		if _, err := r.comments().InsertOne(sessCtx, comment); err != nil {
+			return nil, err
+		}
+		if _, err := r.posts().UpdateOne(sessCtx, bson.M{"_id": comment.PostID}, bson.M{"$inc": bson.M{"commentCount": 1}}); err != nil {
+			return nil, err
+		}
+		if comment.ParentID != nil {
+			if _, err := r.comments().UpdateOne(sessCtx, bson.M{"_id": *comment.ParentID}, bson.M{"$inc": bson.M{"replyCount": 1}}); err != nil {
+				return nil, err
+			}
+		}
+		return nil, nil
+	})
+
 	return err
 }
 
-func (r *mongoContentRepository) GetCommentsByPostID(ctx context.Context, postID primitive.ObjectID, page, limit int) ([]domain.Comment, error) {
+func (r *mongoContentRepository) GetCommentByID(ctx context.Context, commentID primitive.ObjectID) (*domain.Comment, error) {
+	var comment domain.Comment
+	err := r.comments().FindOne(ctx, bson.M{"_id": commentID}).Decode(&comment)
+	return &comment, err
+}
+
+func (r *mongoContentRepository) GetCommentsByPostID(ctx context.Context, postID primitive.ObjectID, after *pagination.Cursor, limit int) ([]domain.Comment, error) {
 	var comments []domain.Comment
-	opts := options.Find().SetSort(bson.D{{Key: "createdAt", Value: -1}}).SetSkip(int64((page - 1) * limit)).SetLimit(int64(limit))
-	cursor, err := r.comments().Find(ctx, bson.M{"postid": postID}, opts)
+	// A null match also covers documents without the field, i.e. top-level comments
+	filter := bson.M{"postId": postID, "parentId": nil}
+	cursor, err := r.comments().Find(ctx, afterCursor(filter, after, false), newestFirst(limit))
 	if err != nil {
 		return nil, err
 	}
@@ -147,11 +365,145 @@ func (r *mongoContentRepository) GetCommentsByPostID(ctx context.Context, postID
 	return comments, err
 }
 
-func (r *mongoContentRepository) DeleteComment(ctx context.Context, commentID, userID primitive.ObjectID) error {
-	_, err := r.comments().DeleteOne(ctx, bson.M{"_id": commentID, "userid": userID})
+func (r *mongoContentRepository) GetReplies(ctx context.Context, parentID primitive.ObjectID, after *pagination.Cursor, limit int) ([]domain.Comment, error) {
+	var replies []domain.Comment
+	// Replies read top to bottom, so they are listed oldest first
+	opts := options.Find().SetSort(bson.D{{Key: "createdAt", Value: 1}, {Key: "_id", Value: 1}}).SetLimit(int64(limit))
+	cursor, err := r.comments().Find(ctx, afterCursor(bson.M{"parentId": parentID}, after, true), opts)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+	err = cursor.All(ctx, &replies)
+	return replies, err
+}
+
+func (r *mongoContentRepository) UpdateCommentText(ctx context.Context, commentID primitive.ObjectID, text string, previous domain.CommentEdit) error {
+	update := bson.M{
+		"$set":  bson.M{"text": text, "editedAt": previous.ReplacedAt},
+		"$push": bson.M{"editHistory": previous},
+	}
+	result, err := r.comments().UpdateOne(ctx, bson.M{"_id": commentID, "deleted": bson.M{"$ne": true}}, update)
+	if err != nil {
+		return err
+	}
+	if result.MatchedCount == 0 {
+		return mongo.ErrNoDocuments
+	}
+	return nil
+}
+
+// DeleteComment removes a comment and keeps the denormalized counters in step.
+// A comment without replies is removed together with its likes. One that still
+// has replies is tombstoned instead, so the thread below it stays intact.
+// This operation is performed within a transaction to ensure data consistency.
+//
+// Parameters:
+//   - ctx: Context for the operation
+//   - comment: The comment to delete
+//
+// Returns:
+//   - bool: true if the document was removed, false if it was tombstoned
+//   - error: mongo.ErrNoDocuments if the comment was already deleted, or any other error
+func (r *mongoContentRepository) DeleteComment(ctx context.Context, comment *domain.Comment) (bool, error) {
+	session, err := r.comments().Database().Client().StartSession()
+	if err != nil {
+		return false, err
+	}
+	defer session.EndSession(ctx)
+
+	hardDeleted := false
+	_, err = session.WithTransaction(ctx, func(sessCtx mongo.SessionContext) (interface{}, error) {
+		hardDeleted = false
+		// Re-read inside the transaction so a reply added meanwhile is accounted for
+		var current domain.Comment
+		if err := r.comments().FindOne(sessCtx, bson.M{"_id": comment.ID, "deleted": bson.M{"$ne": true}}).Decode(&current); err != nil {
+			return nil, err
+		}
+
+		if current.ReplyCount > 0 {
+			update := bson.M{
+				"$set":   bson.M{"deleted": true, "text": ""},
+				"$unset": bson.M{"editHistory": ""},
+			}
+			if _, err := r.comments().UpdateOne(sessCtx, bson.M{"_id": current.ID}, update); err != nil {
+				return nil, err
+			}
+		} else {
+			if _, err := r.comments().DeleteOne(sessCtx, bson.M{"_id": current.ID}); err != nil {
+				return nil, err
+			}
+			if err := r.deleteCommentReactions(sessCtx, &current); err != nil {
+				return nil, err
+			}
+			hardDeleted = true
+			if current.ParentID != nil {
+				if _, err := r.comments().UpdateOne(sessCtx, bson.M{"_id": *current.ParentID}, bson.M{"$inc": bson.M{"replyCount": -1}}); err != nil {
+					return nil, err
+				}
+				// A tombstone whose last reply just went away has nothing left to hold together
+				var parent domain.Comment
+				tombstone := bson.M{"_id": *current.ParentID, "deleted": true, "replyCount": bson.M{"$lte": 0}}
+				err := r.comments().FindOneAndDelete(sessCtx, tombstone).Decode(&parent)
+				if err == nil {
+					err = r.deleteCommentReactions(sessCtx, &parent)
+				}
+				if err != nil && !errors.Is(err, mongo.ErrNoDocuments) {
+					return nil, err
+				}
+			}
+		}
+
+		_, err := r.posts().UpdateOne(sessCtx, bson.M{"_id": current.PostID}, bson.M{"$inc": bson.M{"commentCount": -1}})
+		return nil, err
+	})
+
+	return hardDeleted, err
+}
+
+// deleteCommentReactions removes the reactions on a comment that is being
+// deleted and takes its likes off the author's total. It must run inside the
+// transaction that deletes the comment.
+func (r *mongoContentRepository) deleteCommentReactions(sessCtx mongo.SessionContext, comment *domain.Comment) error {
+	db := r.comments().Database()
+	likes, err := db.Collection("reactions").CountDocuments(sessCtx, bson.M{"contentId": comment.ID, "reactionType": domain.ReactionTypeLike})
+	if err != nil {
+		return err
+	}
+	if _, err := db.Collection("reactions").DeleteMany(sessCtx, bson.M{"contentId": comment.ID}); err != nil {
+		return err
+	}
+	if likes == 0 {
+		return nil
+	}
+	_, err = db.Collection("users").UpdateOne(sessCtx, bson.M{"_id": comment.UserID}, bson.M{"$inc": bson.M{"totalLikeCount": -likes}})
+	return err
+}
+
+func (r *mongoContentRepository) GetCommentAuthorsByPostID(ctx context.Context, postID primitive.ObjectID) (map[primitive.ObjectID]primitive.ObjectID, error) {
+	opts := options.Find().SetProjection(bson.M{"_id": 1, "userId": 1})
+	cursor, err := r.comments().Find(ctx, bson.M{"postId": postID}, opts)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	var comments []domain.Comment
+	if err := cursor.All(ctx, &comments); err != nil {
+		return nil, err
+	}
+	authors := make(map[primitive.ObjectID]primitive.ObjectID, len(comments))
+	for _, c := range comments {
+		authors[c.ID] = c.UserID
+	}
+	return authors, nil
+}
+
+func (r *mongoContentRepository) DeleteCommentsByPostID(ctx context.Context, postID primitive.ObjectID) error {
+	_, err := r.comments().DeleteMany(ctx, bson.M{"postId": postID})
 	return err
 }
 
 func (r *mongoContentRepository) GetCommentCount(ctx context.Context, postID primitive.ObjectID) (int64, error) {
-	return r.comments().CountDocuments(ctx, bson.M{"postid": postID})
+	return r.comments().CountDocuments(ctx, bson.M{"postId": postID, "deleted": bson.M{"$ne": true}})
 }
@@ -0,0 +1,61 @@
+package repository
+
+import (
+	"context"
+	"vybes/internal/domain"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// ExternalIdentityRepository defines the interface for OpenID Connect identity data operations.
+type ExternalIdentityRepository interface {
+	// CreateIdentity links a provider account to a user. Linking an account that is already linked fails with a duplicate key error
+	CreateIdentity(ctx context.Context, identity *domain.ExternalIdentity) error
+	// GetIdentity finds the link for a provider's user ID
+	GetIdentity(ctx context.Context, provider, subject string) (*domain.ExternalIdentity, error)
+	// GetIdentitiesByUser lists a user's linked provider accounts, oldest first
+	GetIdentitiesByUser(ctx context.Context, userID primitive.ObjectID) ([]domain.ExternalIdentity, error)
+}
+
+// mongoExternalIdentityRepository implements ExternalIdentityRepository using MongoDB as the backend
+type mongoExternalIdentityRepository struct {
+	collection *mongo.Collection
+}
+
+// NewMongoExternalIdentityRepository creates a new external identity repository instance with MongoDB backend.
+//
+// Parameters:
+//   - db: MongoDB database instance
+//
+// Returns:
+//   - ExternalIdentityRepository: A configured external identity repository ready for use
+func NewMongoExternalIdentityRepository(db *mongo.Database) ExternalIdentityRepository {
+	return &mongoExternalIdentityRepository{
+		collection: db.Collection("external_identities"),
+	}
+}
+
+func (r *mongoExternalIdentityRepository) CreateIdentity(ctx context.Context, identity *domain.ExternalIdentity) error {
+	_, err := r.collection.InsertOne(ctx, identity)
+	return err
+}
+
+func (r *mongoExternalIdentityRepository) GetIdentity(ctx context.Context, provider, subject string) (*domain.ExternalIdentity, error) {
+	var identity domain.ExternalIdentity
+	err := r.collection.FindOne(ctx, bson.M{"provider": provider, "subject": subject}).Decode(&identity)
+	return &identity, err
+}
+
+func (r *mongoExternalIdentityRepository) GetIdentitiesByUser(ctx context.Context, userID primitive.ObjectID) ([]domain.ExternalIdentity, error) {
+	identities := []domain.ExternalIdentity{}
+	cursor, err := r.collection.Find(ctx, bson.M{"userId": userID}, options.Find().SetSort(bson.D{{Key: "_id", Value: 1}}))
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+	err = cursor.All(ctx, &identities)
+	return identities, err
+}
@@ -2,7 +2,9 @@ package repository
 
 import (
 	"context"
+	"errors"
 	"vybes/internal/domain"
+	"vybes/pkg/pagination"
 
 	"go.mongodb.org/mongo-driver/bson"
 	"go.mongodb.org/mongo-driver/bson/primitive"
@@ -18,18 +20,41 @@ type FollowRepository interface {
 	CreateFollow(ctx context.Context, follow *domain.Follow) error
 	// DeleteFollow removes a follow relationship between two users
 	DeleteFollow(ctx context.Context, followerID, followingID primitive.ObjectID) error
-	// GetFollowers retrieves all users who follow a specific user
-	GetFollowers(ctx context.Context, userID primitive.ObjectID, page, limit int) ([]domain.Follow, error)
-	// GetFollowing retrieves all users that a specific user is following
-	GetFollowing(ctx context.Context, userID primitive.ObjectID, page, limit int) ([]domain.Follow, error)
+	// GetFollowers retrieves a page of the users who follow a specific user, newest first
+	GetFollowers(ctx context.Context, userID primitive.ObjectID, cursor *pagination.Cursor, limit int) ([]domain.Follow, error)
+	// GetFollowing retrieves a page of the users a specific user is following, newest first
+	GetFollowing(ctx context.Context, userID primitive.ObjectID, cursor *pagination.Cursor, limit int) ([]domain.Follow, error)
 	GetFollowingIDs(ctx context.Context, userID primitive.ObjectID) ([]primitive.ObjectID, error)
 	GetFollowerIDs(ctx context.Context, userID primitive.ObjectID) ([]primitive.ObjectID, error)
+	// GetFriendsOfFriends returns accounts followed by the given followees but not by the user,
+	// most widely followed among them first
+	GetFriendsOfFriends(ctx context.Context, userID primitive.ObjectID, followingIDs []primitive.ObjectID, limit int) ([]primitive.ObjectID, error)
+	// GetFollowingAmong returns which of the candidate users the user follows
+	GetFollowingAmong(ctx context.Context, userID primitive.ObjectID, candidateIDs []primitive.ObjectID) ([]primitive.ObjectID, error)
+	// GetFollowersAmong returns which of the candidate users follow the user
+	GetFollowersAmong(ctx context.Context, userID primitive.ObjectID, candidateIDs []primitive.ObjectID) ([]primitive.ObjectID, error)
 	// IsFollowing checks if one user is following another
 	IsFollowing(ctx context.Context, followerID, followingID primitive.ObjectID) (bool, error)
 	// GetFollowerCount returns the number of followers for a user
 	GetFollowerCount(ctx context.Context, userID primitive.ObjectID) (int64, error)
 	// GetFollowingCount returns the number of users a user is following
 	GetFollowingCount(ctx context.Context, userID primitive.ObjectID) (int64, error)
+
+	// CreateFollowRequest records a pending request to follow a private account
+	CreateFollowRequest(ctx context.Context, request *domain.FollowRequest) error
+	// GetFollowRequestByID retrieves a pending follow request, or nil if it doesn't exist
+	GetFollowRequestByID(ctx context.Context, requestID primitive.ObjectID) (*domain.FollowRequest, error)
+	// GetFollowRequests retrieves a page of the pending requests to follow a user, newest first
+	GetFollowRequests(ctx context.Context, targetID primitive.ObjectID, cursor *pagination.Cursor, limit int) ([]domain.FollowRequest, error)
+	// HasFollowRequest checks if a user has a pending request to follow another
+	HasFollowRequest(ctx context.Context, requesterID, targetID primitive.ObjectID) (bool, error)
+	// DeleteFollowRequest removes a pending follow request without following
+	DeleteFollowRequest(ctx context.Context, requesterID, targetID primitive.ObjectID) error
+	// ApproveFollowRequest turns a pending request into a follow atomically
+	ApproveFollowRequest(ctx context.Context, request *domain.FollowRequest) error
+	// ApproveAllFollowRequests turns every pending request to follow a user into a follow
+	// and returns the IDs of the new followers
+	ApproveAllFollowRequests(ctx context.Context, targetID primitive.ObjectID) ([]primitive.ObjectID, error)
 }
 
 // mongoFollowRepository implements FollowRepository using MongoDB as the backend
@@ -58,14 +83,15 @@ func (r *mongoFollowRepository) CreateFollow(ctx context.Context, follow *domain
 }
 
 func (r *mongoFollowRepository) DeleteFollow(ctx context.Context, followerID, followingID primitive.ObjectID) error {
-	_, err := r.collection.DeleteOne(ctx, bson.M{"followerid": followerID, "followingid": followingID})
+	_, err := r.collection.DeleteOne(ctx, bson.M{"followerId": followerID, "followingId": followingID})
 	return err
 }
 
-func (r *mongoFollowRepository) GetFollowers(ctx context.Context, userID primitive.ObjectID, page, limit int) ([]domain.Follow, error) {
+func (r *mongoFollowRepository) GetFollowers(ctx context.Context, userID primitive.ObjectID, after *pagination.Cursor, limit int) ([]domain.Follow, error) {
 	var follows []domain.Follow
-	opts := options.Find().SetSkip(int64((page - 1) * limit)).SetLimit(int64(limit))
-	cursor, err := r.collection.Find(ctx, bson.M{"followingid": userID}, opts)
+	// Follow documents carry no createdAt, so order by _id, which embeds the creation time
+	opts := options.Find().SetSort(bson.D{{Key: "_id", Value: -1}}).SetLimit(int64(limit))
+	cursor, err := r.collection.Find(ctx, afterIDCursor(bson.M{"followingId": userID}, after), opts)
 	if err != nil {
 		return nil, err
 	}
@@ -74,10 +100,10 @@ func (r *mongoFollowRepository) GetFollowers(ctx context.Context, userID primiti
 	return follows, err
 }
 
-func (r *mongoFollowRepository) GetFollowing(ctx context.Context, userID primitive.ObjectID, page, limit int) ([]domain.Follow, error) {
+func (r *mongoFollowRepository) GetFollowing(ctx context.Context, userID primitive.ObjectID, after *pagination.Cursor, limit int) ([]domain.Follow, error) {
 	var follows []domain.Follow
-	opts := options.Find().SetSkip(int64((page - 1) * limit)).SetLimit(int64(limit))
-	cursor, err := r.collection.Find(ctx, bson.M{"followerid": userID}, opts)
+	opts := options.Find().SetSort(bson.D{{Key: "_id", Value: -1}}).SetLimit(int64(limit))
+	cursor, err := r.collection.Find(ctx, afterIDCursor(bson.M{"followerId": userID}, after), opts)
 	if err != nil {
 		return nil, err
 	}
@@ -88,7 +114,7 @@ func (r *mongoFollowRepository) GetFollowing(ctx context.Context, userID primiti
 
 func (r *mongoFollowRepository) GetFollowingIDs(ctx context.Context, userID primitive.ObjectID) ([]primitive.ObjectID, error) {
 	var follows []domain.Follow
-	cursor, err := r.collection.Find(ctx, bson.M{"followerid": userID})
+	cursor, err := r.collection.Find(ctx, bson.M{"followerId": userID})
 	if err != nil {
 		return nil, err
 	}
@@ -105,7 +131,95 @@ func (r *mongoFollowRepository) GetFollowingIDs(ctx context.Context, userID prim
 
 func (r *mongoFollowRepository) GetFollowerIDs(ctx context.Context, userID primitive.ObjectID) ([]primitive.ObjectID, error) {
 	var follows []domain.Follow
-	cursor, err := r.collection.Find(ctx, bson.M{"followingid": userID})
+	cursor, err := r.collection.Find(ctx, bson.M{"followingId": userID})
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+	if err := cursor.All(ctx, &follows); err != nil {
+		return nil, err
+	}
+	ids := make([]primitive.ObjectID, len(follows))
+	for i, f := range follows {
+		ids[i] = f.FollowerID
+	}
+	return ids, nil
+}
+
+// GetFriendsOfFriends finds second-degree connections: accounts followed by the
+// users in followingIDs that userID doesn't follow yet. Accounts followed by
+// more of the user's followees are returned first.
+//
+// Parameters:
+//   - ctx: Context for the operation
+//   - userID: ID of the user the suggestions are for
+//   - followingIDs: IDs of the accounts the user follows
+//   - limit: Maximum number of accounts to return
+//
+// Returns:
+//   - []primitive.ObjectID: IDs of the second-degree accounts
+//   - error: Any error that occurred during the operation
+func (r *mongoFollowRepository) GetFriendsOfFriends(ctx context.Context, userID primitive.ObjectID, followingIDs []primitive.ObjectID, limit int) ([]primitive.ObjectID, error) {
+	if len(followingIDs) == 0 {
+		return nil, nil
+	}
+	exclude := append([]primitive.ObjectID{userID}, followingIDs...)
+	pipeline := mongo.Pipeline{
+		{{Key: "$match", Value: bson.M{
+			"followerId":  bson.M{"$in": followingIDs},
+			"followingId": bson.M{"$nin": exclude},
+		}}},
+		{{Key: "$group", Value: bson.M{"_id": "$followingId", "mutuals": bson.M{"$sum": 1}}}},
+		{{Key: "$sort", Value: bson.D{{Key: "mutuals", Value: -1}, {Key: "_id", Value: -1}}}},
+		{{Key: "$limit", Value: limit}},
+	}
+	cursor, err := r.collection.Aggregate(ctx, pipeline)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	var results []struct {
+		ID primitive.ObjectID `bson:"_id"`
+	}
+	if err := cursor.All(ctx, &results); err != nil {
+		return nil, err
+	}
+	ids := make([]primitive.ObjectID, len(results))
+	for i, res := range results {
+		ids[i] = res.ID
+	}
+	return ids, nil
+}
+
+func (r *mongoFollowRepository) GetFollowingAmong(ctx context.Context, userID primitive.ObjectID, candidateIDs []primitive.ObjectID) ([]primitive.ObjectID, error) {
+	if len(candidateIDs) == 0 {
+		return nil, nil
+	}
+	var follows []domain.Follow
+	filter := bson.M{"followerId": userID, "followingId": bson.M{"$in": candidateIDs}}
+	cursor, err := r.collection.Find(ctx, filter)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+	if err := cursor.All(ctx, &follows); err != nil {
+		return nil, err
+	}
+	ids := make([]primitive.ObjectID, len(follows))
+	for i, f := range follows {
+		ids[i] = f.FollowingID
+	}
+	return ids, nil
+}
+
+func (r *mongoFollowRepository) GetFollowersAmong(ctx context.Context, userID primitive.ObjectID, candidateIDs []primitive.ObjectID) ([]primitive.ObjectID, error) {
+	if len(candidateIDs) == 0 {
+		return nil, nil
+	}
+	var follows []domain.Follow
+	filter := bson.M{"followerId": bson.M{"$in": candidateIDs}, "followingId": userID}
+	cursor, err := r.collection.Find(ctx, filter)
 	if err != nil {
 		return nil, err
 	}
@@ -121,7 +235,7 @@ func (r *mongoFollowRepository) GetFollowerIDs(ctx context.Context, userID primi
 }
 
 func (r *mongoFollowRepository) IsFollowing(ctx context.Context, followerID, followingID primitive.ObjectID) (bool, error) {
-	count, err := r.collection.CountDocuments(ctx, bson.M{"followerid": followerID, "followingid": followingID})
+	count, err := r.collection.CountDocuments(ctx, bson.M{"followerId": followerID, "followingId": followingID})
 	if err != nil {
 		return false, err
 	}
@@ -129,9 +243,143 @@ func (r *mongoFollowRepository) IsFollowing(ctx context.Context, followerID, fol
 }
 
 func (r *mongoFollowRepository) GetFollowerCount(ctx context.Context, userID primitive.ObjectID) (int64, error) {
-	return r.collection.CountDocuments(ctx, bson.M{"followingid": userID})
+	return r.collection.CountDocuments(ctx, bson.M{"followingId": userID})
 }
 
 func (r *mongoFollowRepository) GetFollowingCount(ctx context.Context, userID primitive.ObjectID) (int64, error) {
-	return r.collection.CountDocuments(ctx, bson.M{"followerid": userID})
+	return r.collection.CountDocuments(ctx, bson.M{"followerId": userID})
+}
+
+func (r *mongoFollowRepository) followRequests() *mongo.Collection {
+	return r.collection.Database().Collection("follow_requests")
+}
+
+// CreateFollowRequest stores a pending follow request. Asking again while a
+// request is pending keeps the original request and its place in the queue.
+//
+// Parameters:
+//   - ctx: Context for the operation
+//   - request: The follow request to create
+//
+// Returns:
+//   - error: Any error that occurred during the operation
+func (r *mongoFollowRepository) CreateFollowRequest(ctx context.Context, request *domain.FollowRequest) error {
+	filter := bson.M{"requesterId": request.RequesterID, "targetId": request.TargetID}
+	opts := options.Update().SetUpsert(true)
+	_, err := r.followRequests().UpdateOne(ctx, filter, bson.M{"$setOnInsert": request}, opts)
+	return err
+}
+
+func (r *mongoFollowRepository) GetFollowRequestByID(ctx context.Context, requestID primitive.ObjectID) (*domain.FollowRequest, error) {
+	var request domain.FollowRequest
+	err := r.followRequests().FindOne(ctx, bson.M{"_id": requestID}).Decode(&request)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &request, nil
+}
+
+func (r *mongoFollowRepository) GetFollowRequests(ctx context.Context, targetID primitive.ObjectID, after *pagination.Cursor, limit int) ([]domain.FollowRequest, error) {
+	var requests []domain.FollowRequest
+	cursor, err := r.followRequests().Find(ctx, afterCursor(bson.M{"targetId": targetID}, after, false), newestFirst(limit))
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+	err = cursor.All(ctx, &requests)
+	return requests, err
+}
+
+func (r *mongoFollowRepository) HasFollowRequest(ctx context.Context, requesterID, targetID primitive.ObjectID) (bool, error) {
+	count, err := r.followRequests().CountDocuments(ctx, bson.M{"requesterId": requesterID, "targetId": targetID})
+	if err != nil {
+		return false, err
+	}
+	return count > 0, nil
+}
+
+func (r *mongoFollowRepository) DeleteFollowRequest(ctx context.Context, requesterID, targetID primitive.ObjectID) error {
+	_, err := r.followRequests().DeleteOne(ctx, bson.M{"requesterId": requesterID, "targetId": targetID})
+	return err
+}
+
+// ApproveFollowRequest deletes a pending request and creates the follow it asked
+// for in one transaction. It returns mongo.ErrNoDocuments if the request was
+// already handled, so a double approval can't create two follows.
+//
+// Parameters:
+//   - ctx: Context for the operation
+//   - request: The pending request to approve
+//
+// Returns:
+//   - error: Any error that occurred during the operation
+func (r *mongoFollowRepository) ApproveFollowRequest(ctx context.Context, request *domain.FollowRequest) error {
+	session, err := r.collection.Database().Client().StartSession()
+	if err != nil {
+		return err
+	}
+	defer session.EndSession(ctx)
+
+	_, err = session.WithTransaction(ctx, func(sessCtx mongo.SessionContext) (interface{}, error) {
+		result, err := r.followRequests().DeleteOne(sessCtx, bson.M{"_id": request.ID})
+		if err != nil {
+			return nil, err
+		}
+		if result.DeletedCount == 0 {
+			return nil, mongo.ErrNoDocuments
+		}
+		return nil, r.upsertFollow(sessCtx, request.RequesterID, request.TargetID)
+	})
+	return err
+}
+
+// ApproveAllFollowRequests approves every pending request for an account that
+// is going public, in one transaction.
+//
+// Parameters:
+//   - ctx: Context for the operation
+//   - targetID: ID of the account whose requests are approved
+//
+// Returns:
+//   - []primitive.ObjectID: IDs of the users who now follow the account
+//   - error: Any error that occurred during the operation
+func (r *mongoFollowRepository) ApproveAllFollowRequests(ctx context.Context, targetID primitive.ObjectID) ([]primitive.ObjectID, error) {
+	session, err := r.collection.Database().Client().StartSession()
+	if err != nil {
+		return nil, err
+	}
+	defer session.EndSession(ctx)
+
+	var requesterIDs []primitive.ObjectID
+	_, err = session.WithTransaction(ctx, func(sessCtx mongo.SessionContext) (interface{}, error) {
+		requesterIDs = nil
+		var requests []domain.FollowRequest
+		cursor, err := r.followRequests().Find(sessCtx, bson.M{"targetId": targetID})
+		if err != nil {
+			return nil, err
+		}
+		if err := cursor.All(sessCtx, &requests); err != nil {
+			return nil, err
+		}
+		for _, request := range requests {
+			if err := r.upsertFollow(sessCtx, request.RequesterID, targetID); err != nil {
+				return nil, err
+			}
+			requesterIDs = append(requesterIDs, request.RequesterID)
+		}
+		_, err = r.followRequests().DeleteMany(sessCtx, bson.M{"targetId": targetID})
+		return nil, err
+	})
+	return requesterIDs, err
+}
+
+// upsertFollow creates a follow unless it already exists.
+func (r *mongoFollowRepository) upsertFollow(ctx context.Context, followerID, followingID primitive.ObjectID) error {
+	filter := bson.M{"followerId": followerID, "followingId": followingID}
+	opts := options.Update().SetUpsert(true)
+	_, err := r.collection.UpdateOne(ctx, filter, bson.M{"$setOnInsert": bson.M{"_id": primitive.NewObjectID()}}, opts)
+	return err
 }
@@ -19,7 +19,7 @@ func SetupIndexes(ctx context.Context, db *mongo.Database) {
 	// Create indexes for 'users' collection
 	createUserIndexes(ctx, db)
 	
-	// Create indexes for 'follows' collection
+	// Create indexes for 'follows' and 'follow_requests' collections
 	createFollowIndexes(ctx, db)
 	
 	// Create TTL index for 'stories' collection (auto-delete expired stories)
@@ -42,6 +42,25 @@ func SetupIndexes(ctx context.Context, db *mongo.Database) {
 	
 	// Create indexes for 'notifications' collection
 	createNotificationIndexes(ctx, db)
+
+	// Create indexes for 'jobs' collection
+	createJobIndexes(ctx, db)
+
+	// Create indexes for 'blocks' and 'mutes' collections
+	createBlockIndexes(ctx, db)
+	createMuteIndexes(ctx, db)
+
+	// Create indexes for 'sessions' collection
+	createSessionIndexes(ctx, db)
+
+	// Create indexes for 'wallet_links' collection
+	createWalletLinkIndexes(ctx, db)
+
+	// Create indexes for 'external_identities' collection
+	createExternalIdentityIndexes(ctx, db)
+
+	// Create indexes for 'wallet_transactions' collection
+	createWalletTransactionIndexes(ctx, db)
 }
 
 // createUserIndexes sets up indexes for the users collection
@@ -69,14 +88,14 @@ func createUserIndexes(ctx context.Context, db *mongo.Database) {
 	
 	// Index on wallet address for blockchain integration
 	_, err = collection.Indexes().CreateOne(ctx, mongo.IndexModel{
-		Keys: bson.D{{Key: "walletaddress", Value: 1}},
+		Keys: bson.D{{Key: "walletAddress", Value: 1}},
 	})
 	if err != nil {
 		// Log error but don't fail - index might already exist
 	}
 }
 
-// createFollowIndexes sets up indexes for the follows collection
+// createFollowIndexes sets up indexes for the follows and follow_requests collections
 // Includes compound indexes for efficient follow relationship queries
 func createFollowIndexes(ctx context.Context, db *mongo.Database) {
 	collection := db.Collection("follows")
@@ -84,8 +103,8 @@ func createFollowIndexes(ctx context.Context, db *mongo.Database) {
 	// Compound index for checking if user A follows user B
 	_, err := collection.Indexes().CreateOne(ctx, mongo.IndexModel{
 		Keys: bson.D{
-			{Key: "followerid", Value: 1},
-			{Key: "followingid", Value: 1},
+			{Key: "followerId", Value: 1},
+			{Key: "followingId", Value: 1},
 		},
 		Options: options.Index().SetUnique(true),
 	})
@@ -93,9 +112,49 @@ func createFollowIndexes(ctx context.Context, db *mongo.Database) {
 		// Log error but don't fail - index might already exist
 	}
 	
-	// Index for finding all followers of a user
+	// Index for paging through the followers of a user
+	_, err = collection.Indexes().CreateOne(ctx, mongo.IndexModel{
+		Keys: bson.D{
+			{Key: "followingId", Value: 1},
+			{Key: "_id", Value: -1},
+		},
+	})
+	if err != nil {
+		// Log error but don't fail - index might already exist
+	}
+
+	// Index for paging through the users a user follows
 	_, err = collection.Indexes().CreateOne(ctx, mongo.IndexModel{
-		Keys: bson.D{{Key: "followingid", Value: 1}},
+		Keys: bson.D{
+			{Key: "followerId", Value: 1},
+			{Key: "_id", Value: -1},
+		},
+	})
+	if err != nil {
+		// Log error but don't fail - index might already exist
+	}
+
+	requests := db.Collection("follow_requests")
+
+	// Unique index allowing one pending request per requester and account
+	_, err = requests.Indexes().CreateOne(ctx, mongo.IndexModel{
+		Keys: bson.D{
+			{Key: "requesterId", Value: 1},
+			{Key: "targetId", Value: 1},
+		},
+		Options: options.Index().SetUnique(true),
+	})
+	if err != nil {
+		// Log error but don't fail - index might already exist
+	}
+
+	// Index for paging through the pending requests of an account
+	_, err = requests.Indexes().CreateOne(ctx, mongo.IndexModel{
+		Keys: bson.D{
+			{Key: "targetId", Value: 1},
+			{Key: "createdAt", Value: -1},
+			{Key: "_id", Value: -1},
+		},
 	})
 	if err != nil {
 		// Log error but don't fail - index might already exist
@@ -109,7 +168,7 @@ func createStoryIndexes(ctx context.Context, db *mongo.Database) {
 	
 	// TTL index to automatically delete stories after 24 hours
 	_, err := collection.Indexes().CreateOne(ctx, mongo.IndexModel{
-		Keys: bson.D{{Key: "expiresat", Value: 1}},
+		Keys: bson.D{{Key: "expiresAt", Value: 1}},
 		Options: options.Index().SetExpireAfterSeconds(0), // Delete immediately when expired
 	})
 	if err != nil {
@@ -118,7 +177,7 @@ func createStoryIndexes(ctx context.Context, db *mongo.Database) {
 	
 	// Index for finding stories by user
 	_, err = collection.Indexes().CreateOne(ctx, mongo.IndexModel{
-		Keys: bson.D{{Key: "userid", Value: 1}},
+		Keys: bson.D{{Key: "userId", Value: 1}},
 	})
 	if err != nil {
 		// Log error but don't fail - index might already exist
@@ -130,20 +189,38 @@ func createStoryIndexes(ctx context.Context, db *mongo.Database) {
 func createPostIndexes(ctx context.Context, db *mongo.Database) {
 	collection := db.Collection("posts")
 	
-	// Compound index for feed queries (user + creation date)
+	// Compound index for feed queries (user + creation date, _id breaking ties for cursors)
 	_, err := collection.Indexes().CreateOne(ctx, mongo.IndexModel{
 		Keys: bson.D{
-			{Key: "userid", Value: 1},
-			{Key: "createdat", Value: -1},
+			{Key: "userId", Value: 1},
+			{Key: "createdAt", Value: -1},
+			{Key: "_id", Value: -1},
 		},
 	})
 	if err != nil {
 		// Log error but don't fail - index might already exist
 	}
 	
-	// Index for visibility-based queries
+	// Index for visibility-based queries, including recent trending posts
 	_, err = collection.Indexes().CreateOne(ctx, mongo.IndexModel{
-		Keys: bson.D{{Key: "visibility", Value: 1}},
+		Keys: bson.D{
+			{Key: "visibility", Value: 1},
+			{Key: "createdAt", Value: -1},
+		},
+	})
+	if err != nil {
+		// Log error but don't fail - index might already exist
+	}
+
+	// Unique index preventing a user from reposting the same post twice
+	_, err = collection.Indexes().CreateOne(ctx, mongo.IndexModel{
+		Keys: bson.D{
+			{Key: "userId", Value: 1},
+			{Key: "originalPostId", Value: 1},
+		},
+		Options: options.Index().
+			SetUnique(true).
+			SetPartialFilterExpression(bson.M{"originalPostId": bson.M{"$exists": true}}),
 	})
 	if err != nil {
 		// Log error but don't fail - index might already exist
@@ -180,9 +257,9 @@ func createReactionIndexes(ctx context.Context, db *mongo.Database) {
 	// Compound index for checking if user reacted to content
 	_, err := collection.Indexes().CreateOne(ctx, mongo.IndexModel{
 		Keys: bson.D{
-			{Key: "userid", Value: 1},
-			{Key: "contentid", Value: 1},
-			{Key: "reactiontype", Value: 1},
+			{Key: "userId", Value: 1},
+			{Key: "contentId", Value: 1},
+			{Key: "reactionType", Value: 1},
 		},
 		Options: options.Index().SetUnique(true),
 	})
@@ -192,7 +269,18 @@ func createReactionIndexes(ctx context.Context, db *mongo.Database) {
 	
 	// Index for finding all reactions to content
 	_, err = collection.Indexes().CreateOne(ctx, mongo.IndexModel{
-		Keys: bson.D{{Key: "contentid", Value: 1}},
+		Keys: bson.D{{Key: "contentId", Value: 1}},
+	})
+	if err != nil {
+		// Log error but don't fail - index might already exist
+	}
+
+	// Index for a user's recent likes, used for feed affinity
+	_, err = collection.Indexes().CreateOne(ctx, mongo.IndexModel{
+		Keys: bson.D{
+			{Key: "userId", Value: 1},
+			{Key: "createdAt", Value: -1},
+		},
 	})
 	if err != nil {
 		// Log error but don't fail - index might already exist
@@ -204,11 +292,25 @@ func createReactionIndexes(ctx context.Context, db *mongo.Database) {
 func createCommentIndexes(ctx context.Context, db *mongo.Database) {
 	collection := db.Collection("comments")
 	
-	// Index for finding comments by post
+	// Index for finding top-level comments by post
 	_, err := collection.Indexes().CreateOne(ctx, mongo.IndexModel{
 		Keys: bson.D{
-			{Key: "postid", Value: 1},
-			{Key: "createdat", Value: -1},
+			{Key: "postId", Value: 1},
+			{Key: "parentId", Value: 1},
+			{Key: "createdAt", Value: -1},
+			{Key: "_id", Value: -1},
+		},
+	})
+	if err != nil {
+		// Log error but don't fail - index might already exist
+	}
+
+	// Index for paginating replies to a comment
+	_, err = collection.Indexes().CreateOne(ctx, mongo.IndexModel{
+		Keys: bson.D{
+			{Key: "parentId", Value: 1},
+			{Key: "createdAt", Value: 1},
+			{Key: "_id", Value: 1},
 		},
 	})
 	if err != nil {
@@ -217,7 +319,7 @@ func createCommentIndexes(ctx context.Context, db *mongo.Database) {
 	
 	// Index for finding comments by user
 	_, err = collection.Indexes().CreateOne(ctx, mongo.IndexModel{
-		Keys: bson.D{{Key: "userid", Value: 1}},
+		Keys: bson.D{{Key: "userId", Value: 1}},
 	})
 	if err != nil {
 		// Log error but don't fail - index might already exist
@@ -232,8 +334,8 @@ func createBookmarkIndexes(ctx context.Context, db *mongo.Database) {
 	// Compound index for checking if user bookmarked content
 	_, err := collection.Indexes().CreateOne(ctx, mongo.IndexModel{
 		Keys: bson.D{
-			{Key: "userid", Value: 1},
-			{Key: "contentid", Value: 1},
+			{Key: "userId", Value: 1},
+			{Key: "postId", Value: 1},
 		},
 		Options: options.Index().SetUnique(true),
 	})
@@ -244,8 +346,9 @@ func createBookmarkIndexes(ctx context.Context, db *mongo.Database) {
 	// Index for finding user bookmarks
 	_, err = collection.Indexes().CreateOne(ctx, mongo.IndexModel{
 		Keys: bson.D{
-			{Key: "userid", Value: 1},
-			{Key: "createdat", Value: -1},
+			{Key: "userId", Value: 1},
+			{Key: "createdAt", Value: -1},
+			{Key: "_id", Value: -1},
 		},
 	})
 	if err != nil {
@@ -261,8 +364,9 @@ func createNotificationIndexes(ctx context.Context, db *mongo.Database) {
 	// Index for finding notifications by user
 	_, err := collection.Indexes().CreateOne(ctx, mongo.IndexModel{
 		Keys: bson.D{
-			{Key: "userid", Value: 1},
-			{Key: "createdat", Value: -1},
+			{Key: "userId", Value: 1},
+			{Key: "createdAt", Value: -1},
+			{Key: "_id", Value: -1},
 		},
 	})
 	if err != nil {
@@ -272,7 +376,7 @@ func createNotificationIndexes(ctx context.Context, db *mongo.Database) {
 	// Index for unread notification queries
 	_, err = collection.Indexes().CreateOne(ctx, mongo.IndexModel{
 		Keys: bson.D{
-			{Key: "userid", Value: 1},
+			{Key: "userId", Value: 1},
 			{Key: "read", Value: 1},
 		},
 	})
@@ -280,3 +384,214 @@ func createNotificationIndexes(ctx context.Context, db *mongo.Database) {
 		// Log error but don't fail - index might already exist
 	}
 }
+
+// createJobIndexes sets up indexes for the jobs collection
+// Includes indexes for claiming runnable jobs and recovering expired leases
+func createJobIndexes(ctx context.Context, db *mongo.Database) {
+	collection := db.Collection("jobs")
+
+	// Index for claiming due pending jobs in order
+	_, err := collection.Indexes().CreateOne(ctx, mongo.IndexModel{
+		Keys: bson.D{
+			{Key: "status", Value: 1},
+			{Key: "runAt", Value: 1},
+		},
+	})
+	if err != nil {
+		// Log error but don't fail - index might already exist
+	}
+
+	// Index for finding running jobs whose lease has expired
+	_, err = collection.Indexes().CreateOne(ctx, mongo.IndexModel{
+		Keys: bson.D{
+			{Key: "status", Value: 1},
+			{Key: "lockedUntil", Value: 1},
+		},
+	})
+	if err != nil {
+		// Log error but don't fail - index might already exist
+	}
+}
+
+// createBlockIndexes sets up indexes for the blocks collection
+// Includes indexes for checking blocks in either direction and listing a user's blocks
+func createBlockIndexes(ctx context.Context, db *mongo.Database) {
+	collection := db.Collection("blocks")
+
+	// Unique index preventing duplicate blocks, also used to check a block from one side
+	_, err := collection.Indexes().CreateOne(ctx, mongo.IndexModel{
+		Keys: bson.D{
+			{Key: "blockerId", Value: 1},
+			{Key: "blockedId", Value: 1},
+		},
+		Options: options.Index().SetUnique(true),
+	})
+	if err != nil {
+		// Log error but don't fail - index might already exist
+	}
+
+	// Index for finding the users who blocked a user
+	_, err = collection.Indexes().CreateOne(ctx, mongo.IndexModel{
+		Keys: bson.D{{Key: "blockedId", Value: 1}},
+	})
+	if err != nil {
+		// Log error but don't fail - index might already exist
+	}
+
+	// Index for paging through a user's blocks
+	_, err = collection.Indexes().CreateOne(ctx, mongo.IndexModel{
+		Keys: bson.D{
+			{Key: "blockerId", Value: 1},
+			{Key: "createdAt", Value: -1},
+			{Key: "_id", Value: -1},
+		},
+	})
+	if err != nil {
+		// Log error but don't fail - index might already exist
+	}
+}
+
+// createMuteIndexes sets up indexes for the mutes collection
+// Includes indexes for checking and listing a user's mutes
+func createMuteIndexes(ctx context.Context, db *mongo.Database) {
+	collection := db.Collection("mutes")
+
+	// Unique index preventing duplicate mutes
+	_, err := collection.Indexes().CreateOne(ctx, mongo.IndexModel{
+		Keys: bson.D{
+			{Key: "muterId", Value: 1},
+			{Key: "mutedId", Value: 1},
+		},
+		Options: options.Index().SetUnique(true),
+	})
+	if err != nil {
+		// Log error but don't fail - index might already exist
+	}
+
+	// Index for paging through a user's mutes
+	_, err = collection.Indexes().CreateOne(ctx, mongo.IndexModel{
+		Keys: bson.D{
+			{Key: "muterId", Value: 1},
+			{Key: "createdAt", Value: -1},
+			{Key: "_id", Value: -1},
+		},
+	})
+	if err != nil {
+		// Log error but don't fail - index might already exist
+	}
+}
+
+// createSessionIndexes sets up indexes for the sessions collection
+// Includes indexes for finding a session by its refresh tokens and listing a user's sessions
+func createSessionIndexes(ctx context.Context, db *mongo.Database) {
+	collection := db.Collection("sessions")
+
+	// Index for looking up a session by its current refresh token
+	_, err := collection.Indexes().CreateOne(ctx, mongo.IndexModel{
+		Keys: bson.D{{Key: "refresh_token_hash", Value: 1}},
+	})
+	if err != nil {
+		// Log error but don't fail - index might already exist
+	}
+
+	// Multikey index for recognizing refresh tokens that were already rotated out
+	_, err = collection.Indexes().CreateOne(ctx, mongo.IndexModel{
+		Keys: bson.D{{Key: "rotated_tokens.hash", Value: 1}},
+	})
+	if err != nil {
+		// Log error but don't fail - index might already exist
+	}
+
+	// Same for sessions rotated before rotation times were kept
+	_, err = collection.Indexes().CreateOne(ctx, mongo.IndexModel{
+		Keys: bson.D{{Key: "rotated_token_hashes", Value: 1}},
+	})
+	if err != nil {
+		// Log error but don't fail - index might already exist
+	}
+
+	// Index for listing a user's devices, most recently used first
+	_, err = collection.Indexes().CreateOne(ctx, mongo.IndexModel{
+		Keys: bson.D{
+			{Key: "user_id", Value: 1},
+			{Key: "last_used_at", Value: -1},
+		},
+	})
+	if err != nil {
+		// Log error but don't fail - index might already exist
+	}
+}
+
+// createWalletLinkIndexes sets up indexes for the wallet_links collection
+func createWalletLinkIndexes(ctx context.Context, db *mongo.Database) {
+	collection := db.Collection("wallet_links")
+
+	// Unique index so a wallet can only be linked to one account
+	_, err := collection.Indexes().CreateOne(ctx, mongo.IndexModel{
+		Keys:    bson.D{{Key: "address", Value: 1}},
+		Options: options.Index().SetUnique(true),
+	})
+	if err != nil {
+		// Log error but don't fail - index might already exist
+	}
+
+	// Index for listing a user's linked wallets
+	_, err = collection.Indexes().CreateOne(ctx, mongo.IndexModel{
+		Keys: bson.D{{Key: "userId", Value: 1}},
+	})
+	if err != nil {
+		// Log error but don't fail - index might already exist
+	}
+}
+
+// createExternalIdentityIndexes sets up indexes for the external_identities collection
+func createExternalIdentityIndexes(ctx context.Context, db *mongo.Database) {
+	collection := db.Collection("external_identities")
+
+	// Unique index so a provider account can only be linked to one user
+	_, err := collection.Indexes().CreateOne(ctx, mongo.IndexModel{
+		Keys:    bson.D{{Key: "provider", Value: 1}, {Key: "subject", Value: 1}},
+		Options: options.Index().SetUnique(true),
+	})
+	if err != nil {
+		// Log error but don't fail - index might already exist
+	}
+
+	// Index for listing a user's linked provider accounts
+	_, err = collection.Indexes().CreateOne(ctx, mongo.IndexModel{
+		Keys: bson.D{{Key: "userId", Value: 1}},
+	})
+	if err != nil {
+		// Log error but don't fail - index might already exist
+	}
+}
+
+// createWalletTransactionIndexes sets up indexes for the wallet_transactions collection
+func createWalletTransactionIndexes(ctx context.Context, db *mongo.Database) {
+	collection := db.Collection("wallet_transactions")
+
+	// Unique index so a transaction is only recorded once
+	_, err := collection.Indexes().CreateOne(ctx, mongo.IndexModel{
+		Keys:    bson.D{{Key: "hash", Value: 1}},
+		Options: options.Index().SetUnique(true),
+	})
+	if err != nil {
+		// Log error but don't fail - index might already exist
+	}
+
+	// Compound index for listing a user's transactions, newest first
+	_, err = collection.Indexes().CreateOne(ctx, mongo.IndexModel{
+		Keys: bson.D{{Key: "userId", Value: 1}, {Key: "createdAt", Value: -1}, {Key: "_id", Value: -1}},
+	})
+	if err != nil {
+		// Log error but don't fail - index might already exist
+	}
+
+	// Compound index for the watcher's pending transaction batches
+	_, err = collection.Indexes().CreateOne(ctx, mongo.IndexModel{
+		Keys: bson.D{{Key: "status", Value: 1}, {Key: "checkedAt", Value: 1}},
+	})
+	if err != nil {
+		// Log error but don't fail - index might already exist
+	}
+}
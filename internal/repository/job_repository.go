@@ -0,0 +1,115 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"time"
+	"vybes/internal/domain"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// JobRepository defines the interface for durable background job operations.
+// Jobs are stored in MongoDB so they survive restarts, and are claimed with a
+// time-limited lease so that several API instances can share the work.
+type JobRepository interface {
+	// CreateJob enqueues a new job
+	CreateJob(ctx context.Context, job *domain.Job) error
+	// ClaimNextJob atomically leases the next runnable job, or returns nil if there is none
+	ClaimNextJob(ctx context.Context, lease time.Duration) (*domain.Job, error)
+	// CompleteJob marks a job as successfully finished
+	CompleteJob(ctx context.Context, jobID primitive.ObjectID) error
+	// RetryJob records a failure and schedules the job to run again at runAt
+	RetryJob(ctx context.Context, jobID primitive.ObjectID, lastError string, runAt time.Time) error
+	// FailJob records a failure and stops retrying the job
+	FailJob(ctx context.Context, jobID primitive.ObjectID, lastError string) error
+}
+
+// mongoJobRepository implements JobRepository using MongoDB as the backend
+type mongoJobRepository struct {
+	collection *mongo.Collection
+}
+
+// NewMongoJobRepository creates a new job repository instance with MongoDB backend.
+//
+// Parameters:
+//   - db: MongoDB database instance
+//
+// Returns:
+//   - JobRepository: A configured job repository ready for use
+func NewMongoJobRepository(db *mongo.Database) JobRepository {
+	return &mongoJobRepository{
+		collection: db.Collection("jobs"),
+	}
+}
+
+func (r *mongoJobRepository) CreateJob(ctx context.Context, job *domain.Job) error {
+	_, err := r.collection.InsertOne(ctx, job)
+	return err
+}
+
+// ClaimNextJob leases the oldest runnable job. A job is runnable when it is
+// pending and due, or when it is running but its lease has expired because
+// the worker that claimed it died.
+//
+// Parameters:
+//   - ctx: Context for the operation
+//   - lease: How long the claiming worker owns the job
+//
+// Returns:
+//   - *domain.Job: The claimed job, or nil if no job is runnable
+//   - error: Any error that occurred during the operation
+func (r *mongoJobRepository) ClaimNextJob(ctx context.Context, lease time.Duration) (*domain.Job, error) {
+	now := time.Now()
+	filter := bson.M{"$or": []bson.M{
+		{"status": domain.JobStatusPending, "runAt": bson.M{"$lte": now}},
+		{"status": domain.JobStatusRunning, "lockedUntil": bson.M{"$lte": now}},
+	}}
+	update := bson.M{
+		"$set": bson.M{"status": domain.JobStatusRunning, "lockedUntil": now.Add(lease), "updatedAt": now},
+		"$inc": bson.M{"attempts": 1},
+	}
+	opts := options.FindOneAndUpdate().
+		SetSort(bson.D{{Key: "runAt", Value: 1}}).
+		SetReturnDocument(options.After)
+
+	var job domain.Job
+	err := r.collection.FindOneAndUpdate(ctx, filter, update, opts).Decode(&job)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &job, nil
+}
+
+func (r *mongoJobRepository) CompleteJob(ctx context.Context, jobID primitive.ObjectID) error {
+	update := bson.M{"$set": bson.M{"status": domain.JobStatusDone, "updatedAt": time.Now()}}
+	_, err := r.collection.UpdateOne(ctx, bson.M{"_id": jobID}, update)
+	return err
+}
+
+func (r *mongoJobRepository) RetryJob(ctx context.Context, jobID primitive.ObjectID, lastError string, runAt time.Time) error {
+	update := bson.M{"$set": bson.M{
+		"status":    domain.JobStatusPending,
+		"lastError": lastError,
+		"runAt":     runAt,
+		"updatedAt": time.Now(),
+	}}
+	_, err := r.collection.UpdateOne(ctx, bson.M{"_id": jobID}, update)
+	return err
+}
+
+func (r *mongoJobRepository) FailJob(ctx context.Context, jobID primitive.ObjectID, lastError string) error {
+	update := bson.M{"$set": bson.M{
+		"status":    domain.JobStatusFailed,
+		"lastError": lastError,
+		"updatedAt": time.Now(),
+	}}
+	_, err := r.collection.UpdateOne(ctx, bson.M{"_id": jobID}, update)
+	return err
+}
@@ -0,0 +1,94 @@
+package repository
+
+import (
+	"context"
+	"vybes/internal/domain"
+	"vybes/pkg/pagination"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// MuteRepository defines the interface for mute relationship data operations.
+// Mutes are one-sided and only affect what the muting user sees.
+type MuteRepository interface {
+	// CreateMute records that a user muted another
+	CreateMute(ctx context.Context, mute *domain.Mute) error
+	// DeleteMute unmutes a user
+	DeleteMute(ctx context.Context, muterID, mutedID primitive.ObjectID) error
+	// IsMuted checks whether a user has muted another
+	IsMuted(ctx context.Context, muterID, mutedID primitive.ObjectID) (bool, error)
+	// GetMutedIDs returns the users a user has muted
+	GetMutedIDs(ctx context.Context, userID primitive.ObjectID) ([]primitive.ObjectID, error)
+	// GetMutes retrieves a page of the mutes a user has made, newest first
+	GetMutes(ctx context.Context, userID primitive.ObjectID, cursor *pagination.Cursor, limit int) ([]domain.Mute, error)
+}
+
+// mongoMuteRepository implements MuteRepository using MongoDB as the backend
+type mongoMuteRepository struct {
+	collection *mongo.Collection
+}
+
+// NewMongoMuteRepository creates a new mute repository instance with MongoDB backend.
+//
+// Parameters:
+//   - db: MongoDB database instance
+//
+// Returns:
+//   - MuteRepository: A configured mute repository ready for use
+func NewMongoMuteRepository(db *mongo.Database) MuteRepository {
+	return &mongoMuteRepository{
+		collection: db.Collection("mutes"),
+	}
+}
+
+// CreateMute stores a mute. Muting a user twice keeps the original mute.
+func (r *mongoMuteRepository) CreateMute(ctx context.Context, mute *domain.Mute) error {
+	filter := bson.M{"muterId": mute.MuterID, "mutedId": mute.MutedID}
+	opts := options.Update().SetUpsert(true)
+	_, err := r.collection.UpdateOne(ctx, filter, bson.M{"$setOnInsert": mute}, opts)
+	return err
+}
+
+func (r *mongoMuteRepository) DeleteMute(ctx context.Context, muterID, mutedID primitive.ObjectID) error {
+	_, err := r.collection.DeleteOne(ctx, bson.M{"muterId": muterID, "mutedId": mutedID})
+	return err
+}
+
+func (r *mongoMuteRepository) IsMuted(ctx context.Context, muterID, mutedID primitive.ObjectID) (bool, error) {
+	count, err := r.collection.CountDocuments(ctx, bson.M{"muterId": muterID, "mutedId": mutedID})
+	if err != nil {
+		return false, err
+	}
+	return count > 0, nil
+}
+
+func (r *mongoMuteRepository) GetMutedIDs(ctx context.Context, userID primitive.ObjectID) ([]primitive.ObjectID, error) {
+	var mutes []domain.Mute
+	cursor, err := r.collection.Find(ctx, bson.M{"muterId": userID})
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+	if err := cursor.All(ctx, &mutes); err != nil {
+		return nil, err
+	}
+	ids := make([]primitive.ObjectID, len(mutes))
+	for i, m := range mutes {
+		ids[i] = m.MutedID
+	}
+	return ids, nil
+}
+
+func (r *mongoMuteRepository) GetMutes(ctx context.Context, userID primitive.ObjectID, after *pagination.Cursor, limit int) ([]domain.Mute, error) {
+	var mutes []domain.Mute
+	cursor, err := r.collection.Find(ctx, afterCursor(bson.M{"muterId": userID}, after, false), newestFirst(limit))
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+	err = cursor.All(ctx, &mutes)
+	return mutes, err
+}
@@ -3,11 +3,11 @@ package repository
 import (
 	"context"
 	"vybes/internal/domain"
+	"vybes/pkg/pagination"
 
 	"go.mongodb.org/mongo-driver/bson"
 	"go.mongodb.org/mongo-driver/bson/primitive"
 	"go.mongodb.org/mongo-driver/mongo"
-	"go.mongodb.org/mongo-driver/mongo/options"
 )
 
 // NotificationRepository defines the interface for notification data operations.
@@ -17,7 +17,7 @@ type NotificationRepository interface {
 	// CreateNotification creates a new notification for a user
 	CreateNotification(ctx context.Context, notification *domain.Notification) error
 	// GetUserNotifications retrieves notifications for a specific user
-	GetUserNotifications(ctx context.Context, userID primitive.ObjectID, page, limit int) ([]domain.Notification, error)
+	GetUserNotifications(ctx context.Context, userID primitive.ObjectID, cursor *pagination.Cursor, limit int) ([]domain.Notification, error)
 	// MarkAsRead marks a notification as read
 	MarkAsRead(ctx context.Context, notificationID, userID primitive.ObjectID) error
 	// MarkAllAsRead marks all notifications for a user as read
@@ -26,6 +26,8 @@ type NotificationRepository interface {
 	GetUnreadCount(ctx context.Context, userID primitive.ObjectID) (int64, error)
 	// DeleteNotification removes a notification from the database
 	DeleteNotification(ctx context.Context, notificationID, userID primitive.ObjectID) error
+	// DeleteNotificationsByPostID removes every notification that points at a post
+	DeleteNotificationsByPostID(ctx context.Context, postID primitive.ObjectID) error
 }
 
 // mongoNotificationRepository implements NotificationRepository using MongoDB as the backend
@@ -68,21 +70,15 @@ func (r *mongoNotificationRepository) CreateNotification(ctx context.Context, no
 // Parameters:
 //   - ctx: Context for the operation
 //   - userID: ID of the user whose notifications to retrieve
-//   - page: Page number for pagination (0-based)
+//   - after: Position of the last notification already returned, or nil for the first page
 //   - limit: Maximum number of notifications to return
 //
 // Returns:
 //   - []domain.Notification: List of notifications for the user
 //   - error: Any error that occurred during the operation
-func (r *mongoNotificationRepository) GetUserNotifications(ctx context.Context, userID primitive.ObjectID, page, limit int) ([]domain.Notification, error) {
-	skip := int64(page * limit)
-	opts := options.Find().
-		SetSort(bson.D{{Key: "createdat", Value: -1}}).
-		SetSkip(skip).
-		SetLimit(int64(limit))
-	
-	filter := bson.M{"userid": userID}
-	cursor, err := r.collection.Find(ctx, filter, opts)
+func (r *mongoNotificationRepository) GetUserNotifications(ctx context.Context, userID primitive.ObjectID, after *pagination.Cursor, limit int) ([]domain.Notification, error) {
+	filter := afterCursor(bson.M{"userId": userID}, after, false)
+	cursor, err := r.collection.Find(ctx, filter, newestFirst(limit))
 	if err != nil {
 		return nil, err
 	}
@@ -108,7 +104,7 @@ func (r *mongoNotificationRepository) GetUserNotifications(ctx context.Context,
 func (r *mongoNotificationRepository) MarkAsRead(ctx context.Context, notificationID, userID primitive.ObjectID) error {
 	filter := bson.M{
 		"_id":    notificationID,
-		"userid": userID, // Ensure users can only mark their own notifications as read
+		"userId": userID, // Ensure users can only mark their own notifications as read
 	}
 	update := bson.M{"$set": bson.M{"read": true}}
 	
@@ -117,17 +113,22 @@ func (r *mongoNotificationRepository) MarkAsRead(ctx context.Context, notificati
 }
 
 func (r *mongoNotificationRepository) MarkAllAsRead(ctx context.Context, userID primitive.ObjectID) error {
-	filter := bson.M{"userid": userID}
+	filter := bson.M{"userId": userID}
 	update := bson.M{"$set": bson.M{"read": true}}
 	_, err := r.collection.UpdateMany(ctx, filter, update)
 	return err
 }
 
 func (r *mongoNotificationRepository) GetUnreadCount(ctx context.Context, userID primitive.ObjectID) (int64, error) {
-	return r.collection.CountDocuments(ctx, bson.M{"userid": userID, "read": false})
+	return r.collection.CountDocuments(ctx, bson.M{"userId": userID, "read": false})
 }
 
 func (r *mongoNotificationRepository) DeleteNotification(ctx context.Context, notificationID, userID primitive.ObjectID) error {
-	_, err := r.collection.DeleteOne(ctx, bson.M{"_id": notificationID, "userid": userID})
+	_, err := r.collection.DeleteOne(ctx, bson.M{"_id": notificationID, "userId": userID})
+	return err
+}
+
+func (r *mongoNotificationRepository) DeleteNotificationsByPostID(ctx context.Context, postID primitive.ObjectID) error {
+	_, err := r.collection.DeleteMany(ctx, bson.M{"postId": postID})
 	return err
 }
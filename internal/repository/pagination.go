@@ -0,0 +1,42 @@
+package repository
+
+import (
+	"vybes/pkg/pagination"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// afterCursor narrows filter to the documents that come after cursor in a
+// (createdAt, _id) ordering. Descending lists continue with older documents,
+// ascending lists with newer ones. A nil cursor leaves the filter unchanged.
+func afterCursor(filter bson.M, cursor *pagination.Cursor, ascending bool) bson.M {
+	if cursor == nil {
+		return filter
+	}
+	op := "$lt"
+	if ascending {
+		op = "$gt"
+	}
+	return bson.M{"$and": []bson.M{filter, {"$or": []bson.M{
+		{"createdAt": bson.M{op: cursor.CreatedAt}},
+		{"createdAt": cursor.CreatedAt, "_id": bson.M{op: cursor.ID}},
+	}}}}
+}
+
+// afterIDCursor narrows filter to the documents after cursor in a newest-first
+// _id ordering. It suits collections without a createdAt field, where the
+// ObjectID's embedded timestamp already gives creation order.
+func afterIDCursor(filter bson.M, cursor *pagination.Cursor) bson.M {
+	if cursor == nil {
+		return filter
+	}
+	return bson.M{"$and": []bson.M{filter, {"_id": bson.M{"$lt": cursor.ID}}}}
+}
+
+// newestFirst returns find options for a page ordered by (createdAt, _id) descending.
+func newestFirst(limit int) *options.FindOptions {
+	return options.Find().
+		SetSort(bson.D{{Key: "createdAt", Value: -1}, {Key: "_id", Value: -1}}).
+		SetLimit(int64(limit))
+}
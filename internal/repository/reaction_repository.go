@@ -3,11 +3,13 @@ package repository
 import (
 	"context"
 	"fmt"
+	"time"
 	"vybes/internal/domain"
 
 	"go.mongodb.org/mongo-driver/bson"
 	"go.mongodb.org/mongo-driver/bson/primitive"
 	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
 )
 
 // ReactionRepository defines the interface for reaction data operations.
@@ -24,6 +26,13 @@ type ReactionRepository interface {
 	GetReactionCounts(ctx context.Context, contentID primitive.ObjectID) (map[domain.ReactionType]int64, error)
 	// HasUserReacted checks if a user has reacted to a specific content item
 	HasUserReacted(ctx context.Context, userID, contentID primitive.ObjectID, reactionType domain.ReactionType) (bool, error)
+	// DeleteReactionsByContentID removes every reaction on a content item and takes its likes off the owner's total
+	DeleteReactionsByContentID(ctx context.Context, contentID, ownerID primitive.ObjectID) error
+	// DeleteCommentReactions removes every reaction on the given comments, keyed by comment ID with their
+	// authors as values, and takes each comment's likes off its author's total
+	DeleteCommentReactions(ctx context.Context, authors map[primitive.ObjectID]primitive.ObjectID) error
+	// GetLikedPostIDs returns the posts a user liked since a point in time, most recent first
+	GetLikedPostIDs(ctx context.Context, userID primitive.ObjectID, since time.Time, limit int) ([]primitive.ObjectID, error)
 }
 
 // mongoReactionRepository implements ReactionRepository using MongoDB as the backend
@@ -46,7 +55,7 @@ func NewMongoReactionRepository(db *mongo.Database) ReactionRepository {
 	}
 }
 
-// AddReaction creates a new reaction in the database and updates the content's reaction counters.
+// AddReaction creates a new reaction in the database and updates the reacted-to item's like counter.
 // This operation is performed within a transaction to ensure data consistency.
 //
 // Parameters:
@@ -67,11 +76,11 @@ func (r *mongoReactionRepository) AddReaction(ctx context.Context, reaction *dom
 	_, err = session.WithTransaction(ctx, func(sessCtx mongo.SessionContext) (interface{}, error) {
 		// Check if reaction already exists
 		filter := bson.M{
-			"userid":      reaction.UserID,
-			"contentid":   reaction.ContentID,
-			"reactiontype": reaction.ReactionType,
+			"userId":       reaction.UserID,
+			"contentId":    reaction.ContentID,
+			"reactionType": reaction.ReactionType,
 		}
-		
+
 		var existingReaction domain.Reaction
 		err := r.collection.FindOne(sessCtx, filter).Decode(&existingReaction)
 		if err == nil {
@@ -84,24 +93,27 @@ func (r *mongoReactionRepository) AddReaction(ctx context.Context, reaction *dom
 			return nil, err
 		}
 
-		// Increment the correct counter on the post
+		// Increment the like counter on the post or comment
+		if reaction.ReactionType != domain.ReactionTypeLike {
+			return nil, nil
+		}
 		updateFilter := bson.M{"_id": reaction.ContentID}
-		update := bson.M{"$inc": bson.M{fmt.Sprintf("reactioncounts.%s", reaction.ReactionType): 1}}
-		_, err = r.collection.Database().Collection("posts").UpdateOne(sessCtx, updateFilter, update)
-		
+		update := bson.M{"$inc": bson.M{"likeCount": 1}}
+		_, err = r.targetCollection(reaction.TargetType).UpdateOne(sessCtx, updateFilter, update)
+
 		return nil, err
 	})
 
 	return err
 }
 
-// RemoveReaction deletes an existing reaction from the database and updates the content's reaction counters.
+// RemoveReaction deletes an existing reaction from the database and updates the reacted-to item's like counter.
 // This operation is performed within a transaction to ensure data consistency.
 //
 // Parameters:
 //   - ctx: Context for the operation
 //   - userID: ID of the user who created the reaction
-//   - contentID: ID of the content item
+//   - contentID: ID of the post or comment
 //   - reactionType: Type of reaction to remove
 //
 // Returns:
@@ -118,30 +130,41 @@ func (r *mongoReactionRepository) RemoveReaction(ctx context.Context, userID, co
 	_, err = session.WithTransaction(ctx, func(sessCtx mongo.SessionContext) (interface{}, error) {
 		// Delete the reaction
 		filter := bson.M{
-			"userid":      userID,
-			"contentid":   contentID,
-			"reactiontype": reactionType,
+			"userId":       userID,
+			"contentId":    contentID,
+			"reactionType": reactionType,
 		}
-		
-		result, err := r.collection.DeleteOne(sessCtx, filter)
-		if err != nil || result.DeletedCount == 0 {
+
+		var removed domain.Reaction
+		if err := r.collection.FindOneAndDelete(sessCtx, filter).Decode(&removed); err != nil {
 			return nil, fmt.Errorf("reaction not found")
 		}
 
-		// Decrement the correct counter on the post
+		// Decrement the like counter on the post or comment
+		if reactionType != domain.ReactionTypeLike {
+			return nil, nil
+		}
 		updateFilter := bson.M{"_id": contentID}
-		update := bson.M{"$inc": bson.M{fmt.Sprintf("reactioncounts.%s", reactionType): -1}}
-		_, err = r.collection.Database().Collection("posts").UpdateOne(sessCtx, updateFilter, update)
-		
+		update := bson.M{"$inc": bson.M{"likeCount": -1}}
+		_, err := r.targetCollection(removed.TargetType).UpdateOne(sessCtx, updateFilter, update)
+
 		return nil, err
 	})
 
 	return err
 }
 
+// targetCollection returns the collection that holds the item a reaction is attached to.
+func (r *mongoReactionRepository) targetCollection(targetType domain.ReactionTarget) *mongo.Collection {
+	if targetType == domain.ReactionTargetComment {
+		return r.collection.Database().Collection("comments")
+	}
+	return r.collection.Database().Collection("posts")
+}
+
 func (r *mongoReactionRepository) GetReactionsByContentID(ctx context.Context, contentID primitive.ObjectID) ([]domain.Reaction, error) {
 	var reactions []domain.Reaction
-	cursor, err := r.collection.Find(ctx, bson.M{"contentid": contentID})
+	cursor, err := r.collection.Find(ctx, bson.M{"contentId": contentID})
 	if err != nil {
 		return nil, err
 	}
@@ -152,8 +175,8 @@ func (r *mongoReactionRepository) GetReactionsByContentID(ctx context.Context, c
 
 func (r *mongoReactionRepository) GetReactionCounts(ctx context.Context, contentID primitive.ObjectID) (map[domain.ReactionType]int64, error) {
 	pipeline := mongo.Pipeline{
-		{{Key: "$match", Value: bson.M{"contentid": contentID}}},
-		{{Key: "$group", Value: bson.M{"_id": "$reactiontype", "count": bson.M{"$sum": 1}}}},
+		{{Key: "$match", Value: bson.M{"contentId": contentID}}},
+		{{Key: "$group", Value: bson.M{"_id": "$reactionType", "count": bson.M{"$sum": 1}}}},
 	}
 	cursor, err := r.collection.Aggregate(ctx, pipeline)
 	if err != nil {
@@ -177,9 +200,9 @@ func (r *mongoReactionRepository) GetReactionCounts(ctx context.Context, content
 
 func (r *mongoReactionRepository) HasUserReacted(ctx context.Context, userID, contentID primitive.ObjectID, reactionType domain.ReactionType) (bool, error) {
 	filter := bson.M{
-		"userid":      userID,
-		"contentid":   contentID,
-		"reactiontype": reactionType,
+		"userId":       userID,
+		"contentId":    contentID,
+		"reactionType": reactionType,
 	}
 	count, err := r.collection.CountDocuments(ctx, filter)
 	if err != nil {
@@ -187,3 +210,137 @@ func (r *mongoReactionRepository) HasUserReacted(ctx context.Context, userID, co
 	}
 	return count > 0, nil
 }
+
+// DeleteReactionsByContentID removes every reaction on a content item and
+// decrements the owner's total like count by the number of likes removed.
+// Both happen in one transaction, so running it again after a partial failure
+// never subtracts the same likes twice.
+//
+// Parameters:
+//   - ctx: Context for the operation
+//   - contentID: ID of the content item whose reactions are removed
+//   - ownerID: ID of the user who owns the content item
+//
+// Returns:
+//   - error: Any error that occurred during the operation
+func (r *mongoReactionRepository) DeleteReactionsByContentID(ctx context.Context, contentID, ownerID primitive.ObjectID) error {
+	session, err := r.collection.Database().Client().StartSession()
+	if err != nil {
+		return err
+	}
+	defer session.EndSession(ctx)
+
+	_, err = session.WithTransaction(ctx, func(sessCtx mongo.SessionContext) (interface{}, error) {
+		likes, err := r.collection.CountDocuments(sessCtx, bson.M{"contentId": contentID, "reactionType": domain.ReactionTypeLike})
+		if err != nil {
+			return nil, err
+		}
+
+		if _, err := r.collection.DeleteMany(sessCtx, bson.M{"contentId": contentID}); err != nil {
+			return nil, err
+		}
+
+		if likes == 0 {
+			return nil, nil
+		}
+		_, err = r.collection.Database().Collection("users").UpdateOne(
+			sessCtx,
+			bson.M{"_id": ownerID},
+			bson.M{"$inc": bson.M{"totalLikeCount": -likes}},
+		)
+		return nil, err
+	})
+
+	return err
+}
+
+// DeleteCommentReactions removes every reaction on a set of comments and
+// decrements each comment author's total like count by the likes removed from
+// their comments. Like DeleteReactionsByContentID, it runs in one transaction,
+// so running it again after a partial failure never subtracts likes twice.
+//
+// Parameters:
+//   - ctx: Context for the operation
+//   - authors: Author of each comment, keyed by comment ID
+//
+// Returns:
+//   - error: Any error that occurred during the operation
+func (r *mongoReactionRepository) DeleteCommentReactions(ctx context.Context, authors map[primitive.ObjectID]primitive.ObjectID) error {
+	if len(authors) == 0 {
+		return nil
+	}
+	commentIDs := make([]primitive.ObjectID, 0, len(authors))
+	for id := range authors {
+		commentIDs = append(commentIDs, id)
+	}
+
+	session, err := r.collection.Database().Client().StartSession()
+	if err != nil {
+		return err
+	}
+	defer session.EndSession(ctx)
+
+	_, err = session.WithTransaction(ctx, func(sessCtx mongo.SessionContext) (interface{}, error) {
+		pipeline := mongo.Pipeline{
+			{{Key: "$match", Value: bson.M{"contentId": bson.M{"$in": commentIDs}, "reactionType": domain.ReactionTypeLike}}},
+			{{Key: "$group", Value: bson.M{"_id": "$contentId", "likes": bson.M{"$sum": 1}}}},
+		}
+		cursor, err := r.collection.Aggregate(sessCtx, pipeline)
+		if err != nil {
+			return nil, err
+		}
+		var counts []struct {
+			CommentID primitive.ObjectID `bson:"_id"`
+			Likes     int64              `bson:"likes"`
+		}
+		if err := cursor.All(sessCtx, &counts); err != nil {
+			return nil, err
+		}
+
+		if _, err := r.collection.DeleteMany(sessCtx, bson.M{"contentId": bson.M{"$in": commentIDs}}); err != nil {
+			return nil, err
+		}
+
+		likesByAuthor := make(map[primitive.ObjectID]int64)
+		for _, c := range counts {
+			likesByAuthor[authors[c.CommentID]] += c.Likes
+		}
+		users := r.collection.Database().Collection("users")
+		for authorID, likes := range likesByAuthor {
+			if _, err := users.UpdateOne(sessCtx, bson.M{"_id": authorID}, bson.M{"$inc": bson.M{"totalLikeCount": -likes}}); err != nil {
+				return nil, err
+			}
+		}
+		return nil, nil
+	})
+
+	return err
+}
+
+func (r *mongoReactionRepository) GetLikedPostIDs(ctx context.Context, userID primitive.ObjectID, since time.Time, limit int) ([]primitive.ObjectID, error) {
+	filter := bson.M{
+		"userId":       userID,
+		"reactionType": domain.ReactionTypeLike,
+		"targetType":   bson.M{"$ne": domain.ReactionTargetComment}, // Older post reactions have no targetType
+		"createdAt":    bson.M{"$gte": since},
+	}
+	opts := options.Find().
+		SetSort(bson.D{{Key: "createdAt", Value: -1}}).
+		SetLimit(int64(limit)).
+		SetProjection(bson.M{"contentId": 1})
+	cursor, err := r.collection.Find(ctx, filter, opts)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	var reactions []domain.Reaction
+	if err := cursor.All(ctx, &reactions); err != nil {
+		return nil, err
+	}
+	ids := make([]primitive.ObjectID, len(reactions))
+	for i, reaction := range reactions {
+		ids[i] = reaction.ContentID
+	}
+	return ids, nil
+}
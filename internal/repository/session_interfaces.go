@@ -2,6 +2,7 @@ package repository
 
 import (
 	"context"
+	"time"
 	"vybes/internal/domain"
 
 	"go.mongodb.org/mongo-driver/bson/primitive"
@@ -11,8 +12,11 @@ import (
 type ISessionRepository interface {
 	Create(ctx context.Context, session *domain.Session) error
 	GetByID(ctx context.Context, id primitive.ObjectID) (*domain.Session, error)
-	FindByRefreshToken(ctx context.Context, refreshToken string) (*domain.Session, error)
+	FindByRefreshTokenHash(ctx context.Context, tokenHash string) (*domain.Session, error)
+	RotateRefreshToken(ctx context.Context, id primitive.ObjectID, oldHash, newHash string, retention time.Duration) (bool, error)
 	Block(ctx context.Context, id primitive.ObjectID) error
+	ListActiveByUser(ctx context.Context, userID primitive.ObjectID) ([]domain.Session, error)
+	BlockAllByUser(ctx context.Context, userID, exceptID primitive.ObjectID) ([]primitive.ObjectID, error)
 }
 
 // Ensure *SessionRepository implements ISessionRepository
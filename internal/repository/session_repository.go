@@ -2,22 +2,33 @@ package repository
 
 import (
 	"context"
+	"time"
 
 	"go.mongodb.org/mongo-driver/bson"
 	"go.mongodb.org/mongo-driver/bson/primitive"
 	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
 	"vybes/internal/domain"
+	"vybes/pkg/utils"
 )
 
 // SessionRepositoryInterface defines the interface for session data operations.
 type SessionRepositoryInterface interface {
 	Create(ctx context.Context, session *domain.Session) error
 	GetByID(ctx context.Context, id primitive.ObjectID) (*domain.Session, error)
-	FindByRefreshToken(ctx context.Context, refreshToken string) (*domain.Session, error)
+	FindByRefreshTokenHash(ctx context.Context, tokenHash string) (*domain.Session, error)
+	RotateRefreshToken(ctx context.Context, id primitive.ObjectID, oldHash, newHash string, retention time.Duration) (bool, error)
 	Block(ctx context.Context, id primitive.ObjectID) error
+	ListActiveByUser(ctx context.Context, userID primitive.ObjectID) ([]domain.Session, error)
+	BlockAllByUser(ctx context.Context, userID, exceptID primitive.ObjectID) ([]primitive.ObjectID, error)
+	HashLegacyRefreshTokens(ctx context.Context) (int, error)
 }
 const sessionCollection = "sessions"
 
+// maxRotatedTokens caps how many exchanged refresh tokens a session remembers
+// for reuse detection, so long-lived sessions don't grow without limit
+const maxRotatedTokens = 50
+
 // Ensure *SessionRepository implements SessionRepositoryInterface
 var _ SessionRepositoryInterface = (*SessionRepository)(nil)
 
@@ -43,15 +54,58 @@ func (r *SessionRepository) GetByID(ctx context.Context, id primitive.ObjectID)
 	return &session, nil
 }
 
-func (r *SessionRepository) FindByRefreshToken(ctx context.Context, refreshToken string) (*domain.Session, error) {
+// FindByRefreshTokenHash finds the session a refresh token belongs to, whether
+// it is the session's current token or one that has already been rotated out.
+func (r *SessionRepository) FindByRefreshTokenHash(ctx context.Context, tokenHash string) (*domain.Session, error) {
 	var session domain.Session
-	err := r.db.Collection(sessionCollection).FindOne(ctx, bson.M{"refresh_token": refreshToken}).Decode(&session)
+	filter := bson.M{"$or": []bson.M{
+		{"refresh_token_hash": tokenHash},
+		{"rotated_tokens.hash": tokenHash},
+		{"rotated_token_hashes": tokenHash}, // Sessions rotated before rotation times were kept
+	}}
+	err := r.db.Collection(sessionCollection).FindOne(ctx, filter).Decode(&session)
 	if err != nil {
 		return nil, err
 	}
 	return &session, nil
 }
 
+// RotateRefreshToken replaces the session's current refresh token, provided it is
+// still oldHash and the session hasn't been blocked, and marks the session as used.
+// It reports whether the token was replaced, so two refreshes racing with the
+// same token can't both win. The replaced token is remembered for reuse
+// detection; the session keeps the most recent maxRotatedTokens of them, and
+// forgets ones rotated out longer than retention ago.
+func (r *SessionRepository) RotateRefreshToken(ctx context.Context, id primitive.ObjectID, oldHash, newHash string, retention time.Duration) (bool, error) {
+	collection := r.db.Collection(sessionCollection)
+	now := time.Now()
+
+	// A field can't be pulled from and pushed to in one update, so old tokens are
+	// forgotten first; the rotation itself is still a single conditional update
+	_, err := collection.UpdateOne(ctx, bson.M{"_id": id}, bson.M{
+		"$pull": bson.M{"rotated_tokens": bson.M{"rotated_at": bson.M{"$lt": now.Add(-retention)}}},
+	})
+	if err != nil {
+		return false, err
+	}
+
+	result, err := collection.UpdateOne(
+		ctx,
+		bson.M{"_id": id, "refresh_token_hash": oldHash, "is_blocked": false},
+		bson.M{
+			"$set": bson.M{"refresh_token_hash": newHash, "last_used_at": now},
+			"$push": bson.M{"rotated_tokens": bson.M{
+				"$each":  []domain.RotatedToken{{Hash: oldHash, RotatedAt: now}},
+				"$slice": -maxRotatedTokens,
+			}},
+		},
+	)
+	if err != nil {
+		return false, err
+	}
+	return result.ModifiedCount == 1, nil
+}
+
 func (r *SessionRepository) Block(ctx context.Context, id primitive.ObjectID) error {
 	_, err := r.db.Collection(sessionCollection).UpdateOne(
 		ctx,
@@ -60,3 +114,102 @@ func (r *SessionRepository) Block(ctx context.Context, id primitive.ObjectID) er
 	)
 	return err
 }
+
+// activeSessionsFilter matches the user's sessions that are neither blocked nor expired.
+func activeSessionsFilter(userID primitive.ObjectID) bson.M {
+	return bson.M{
+		"user_id":    userID,
+		"is_blocked": false,
+		"expires_at": bson.M{"$gt": time.Now()},
+	}
+}
+
+// ListActiveByUser returns the user's active sessions, most recently used first.
+func (r *SessionRepository) ListActiveByUser(ctx context.Context, userID primitive.ObjectID) ([]domain.Session, error) {
+	var sessions []domain.Session
+	opts := options.Find().SetSort(bson.D{{Key: "last_used_at", Value: -1}, {Key: "_id", Value: -1}})
+	cursor, err := r.db.Collection(sessionCollection).Find(ctx, activeSessionsFilter(userID), opts)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+	if err := cursor.All(ctx, &sessions); err != nil {
+		return nil, err
+	}
+	return sessions, nil
+}
+
+// BlockAllByUser blocks every active session of the user except exceptID, which
+// may be primitive.NilObjectID to block them all. It returns the IDs of the
+// sessions it blocked.
+func (r *SessionRepository) BlockAllByUser(ctx context.Context, userID, exceptID primitive.ObjectID) ([]primitive.ObjectID, error) {
+	filter := activeSessionsFilter(userID)
+	if !exceptID.IsZero() {
+		filter["_id"] = bson.M{"$ne": exceptID}
+	}
+	opts := options.Find().SetProjection(bson.M{"_id": 1})
+	cursor, err := r.db.Collection(sessionCollection).Find(ctx, filter, opts)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+	var sessions []domain.Session
+	if err := cursor.All(ctx, &sessions); err != nil {
+		return nil, err
+	}
+	if len(sessions) == 0 {
+		return nil, nil
+	}
+
+	ids := make([]primitive.ObjectID, len(sessions))
+	for i, s := range sessions {
+		ids[i] = s.ID
+	}
+	_, err = r.db.Collection(sessionCollection).UpdateMany(
+		ctx,
+		bson.M{"_id": bson.M{"$in": ids}},
+		bson.M{"$set": bson.M{"is_blocked": true}},
+	)
+	if err != nil {
+		return nil, err
+	}
+	return ids, nil
+}
+
+// HashLegacyRefreshTokens migrates sessions created before refresh tokens were
+// hashed: the plaintext refresh_token is replaced by its digest, so the session
+// keeps working without the token staying readable in the database. Sessions
+// without a usable token are blocked instead. It returns how many sessions it
+// migrated and is safe to run on every startup.
+func (r *SessionRepository) HashLegacyRefreshTokens(ctx context.Context) (int, error) {
+	collection := r.db.Collection(sessionCollection)
+	opts := options.Find().SetProjection(bson.M{"_id": 1, "refresh_token": 1})
+	cursor, err := collection.Find(ctx, bson.M{"refresh_token": bson.M{"$exists": true}}, opts)
+	if err != nil {
+		return 0, err
+	}
+	defer cursor.Close(ctx)
+
+	migrated := 0
+	for cursor.Next(ctx) {
+		var legacy struct {
+			ID           primitive.ObjectID `bson:"_id"`
+			RefreshToken interface{}        `bson:"refresh_token"`
+		}
+		if err := cursor.Decode(&legacy); err != nil {
+			return migrated, err
+		}
+
+		update := bson.M{"$unset": bson.M{"refresh_token": ""}}
+		if token, ok := legacy.RefreshToken.(string); ok && token != "" {
+			update["$set"] = bson.M{"refresh_token_hash": utils.HashToken(token)}
+		} else {
+			update["$set"] = bson.M{"is_blocked": true}
+		}
+		if _, err := collection.UpdateOne(ctx, bson.M{"_id": legacy.ID}, update); err != nil {
+			return migrated, err
+		}
+		migrated++
+	}
+	return migrated, cursor.Err()
+}
@@ -55,7 +55,7 @@ func (r *mongoStoryRepository) CreateStory(ctx context.Context, story *domain.St
 
 func (r *mongoStoryRepository) GetStoriesByUserID(ctx context.Context, userID primitive.ObjectID) ([]domain.Story, error) {
 	var stories []domain.Story
-	cursor, err := r.collection.Find(ctx, bson.M{"userid": userID, "expiresat": bson.M{"$gt": time.Now()}})
+	cursor, err := r.collection.Find(ctx, bson.M{"userId": userID, "expiresAt": bson.M{"$gt": time.Now()}})
 	if err != nil {
 		return nil, err
 	}
@@ -66,7 +66,7 @@ func (r *mongoStoryRepository) GetStoriesByUserID(ctx context.Context, userID pr
 
 func (r *mongoStoryRepository) GetStoriesForFeed(ctx context.Context, userIDs []primitive.ObjectID) ([]domain.Story, error) {
 	var stories []domain.Story
-	cursor, err := r.collection.Find(ctx, bson.M{"userid": bson.M{"$in": userIDs}, "expiresat": bson.M{"$gt": time.Now()}})
+	cursor, err := r.collection.Find(ctx, bson.M{"userId": bson.M{"$in": userIDs}, "expiresAt": bson.M{"$gt": time.Now()}})
 	if err != nil {
 		return nil, err
 	}
@@ -76,18 +76,18 @@ func (r *mongoStoryRepository) GetStoriesForFeed(ctx context.Context, userIDs []
 }
 
 func (r *mongoStoryRepository) DeleteStory(ctx context.Context, storyID, userID primitive.ObjectID) error {
-	_, err := r.collection.DeleteOne(ctx, bson.M{"_id": storyID, "userid": userID})
+	_, err := r.collection.DeleteOne(ctx, bson.M{"_id": storyID, "userId": userID})
 	return err
 }
 
 func (r *mongoStoryRepository) DeleteExpiredStories(ctx context.Context) error {
-	_, err := r.collection.DeleteMany(ctx, bson.M{"expiresat": bson.M{"$lte": time.Now()}})
+	_, err := r.collection.DeleteMany(ctx, bson.M{"expiresAt": bson.M{"$lte": time.Now()}})
 	return err
 }
 
 func (r *mongoStoryRepository) FindExpired(ctx context.Context) ([]domain.Story, error) {
 	var stories []domain.Story
-	cursor, err := r.collection.Find(ctx, bson.M{"expiresat": bson.M{"$lte": time.Now()}})
+	cursor, err := r.collection.Find(ctx, bson.M{"expiresAt": bson.M{"$lte": time.Now()}})
 	if err != nil {
 		return nil, err
 	}
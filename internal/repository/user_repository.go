@@ -2,7 +2,9 @@ package repository
 
 import (
 	"context"
+	"time"
 	"vybes/internal/domain"
+	"vybes/pkg/pagination"
 
 	"go.mongodb.org/mongo-driver/bson"
 	"go.mongodb.org/mongo-driver/bson/primitive"
@@ -26,15 +28,53 @@ type UserRepository interface {
 	GetUserByVID(ctx context.Context, vid int64) (*domain.User, error)
 	// GetUserByWalletAddress retrieves a user by their wallet address
 	GetUserByWalletAddress(ctx context.Context, walletAddress string) (*domain.User, error)
-	// UpdateUser updates an existing user's information
-	UpdateUser(ctx context.Context, user *domain.User) error
+	// UpdateProfile sets the given profile fields, leaving the rest of the user untouched
+	UpdateProfile(ctx context.Context, userID primitive.ObjectID, update domain.ProfileUpdate) error
 	// DeleteUser removes a user account from the database
 	DeleteUser(ctx context.Context, userID primitive.ObjectID) error
 	// SearchUsers finds users based on search criteria (name, username)
-	SearchUsers(ctx context.Context, query string, page, limit int) ([]domain.User, error)
+	SearchUsers(ctx context.Context, query string, cursor *pagination.Cursor, limit int) ([]domain.User, error)
 	// GetUsersByIDs retrieves multiple users by their IDs
 	GetUsersByIDs(ctx context.Context, userIDs []primitive.ObjectID) ([]domain.User, error)
 	IncrementTotalLikes(ctx context.Context, userID primitive.ObjectID, count int) error
+	// IncrementPostCount adjusts the number of posts a user has published
+	IncrementPostCount(ctx context.Context, userID primitive.ObjectID, count int) error
+
+	// SetPendingTOTPSecret stores an encrypted two-factor secret that still has to be confirmed
+	SetPendingTOTPSecret(ctx context.Context, userID primitive.ObjectID, secret, dataKey string, keyVersion int) error
+	// EnableTOTP turns two-factor authentication on with the given encrypted secret and recovery codes
+	EnableTOTP(ctx context.Context, userID primitive.ObjectID, secret, dataKey string, keyVersion int, recoveryCodeHashes []string, step int64) error
+	// DisableTOTP turns two-factor authentication off and forgets its secrets
+	DisableTOTP(ctx context.Context, userID primitive.ObjectID) error
+	// SetRecoveryCodes replaces the user's recovery codes
+	SetRecoveryCodes(ctx context.Context, userID primitive.ObjectID, recoveryCodeHashes []string) error
+	// ConsumeTOTPStep records a code's time step as used, reporting false if it or a later one already was
+	ConsumeTOTPStep(ctx context.Context, userID primitive.ObjectID, step int64) (bool, error)
+	// ConsumeRecoveryCode removes a recovery code, reporting false if the user doesn't have it
+	ConsumeRecoveryCode(ctx context.Context, userID primitive.ObjectID, codeHash string) (bool, error)
+
+	// SetEmailVerificationCode stores a new email verification code, replacing any earlier one
+	SetEmailVerificationCode(ctx context.Context, userID primitive.ObjectID, codeHash string, expires time.Time) error
+	// ClearEmailVerificationCode invalidates the pending email verification code
+	ClearEmailVerificationCode(ctx context.Context, userID primitive.ObjectID) error
+	// VerifyEmail marks the email as verified if the code matches and hasn't expired, reporting whether it did
+	VerifyEmail(ctx context.Context, userID primitive.ObjectID, codeHash string) (bool, error)
+
+	// SetPasswordResetOTP stores a new password reset OTP, replacing any earlier one
+	SetPasswordResetOTP(ctx context.Context, userID primitive.ObjectID, otpHash string, expires time.Time) error
+	// ResetPassword replaces the password if the OTP matches and hasn't expired, consuming the OTP
+	ResetPassword(ctx context.Context, userID primitive.ObjectID, otpHash, passwordHash string) (bool, error)
+	// ClearLegacyOTPs removes password reset OTPs stored in plaintext before they were hashed, returning how many it removed
+	ClearLegacyOTPs(ctx context.Context) (int64, error)
+
+	// GetUsersWithStaleWalletKey lists users whose wallet key isn't wrapped by the given master key version, in ID order after afterID
+	GetUsersWithStaleWalletKey(ctx context.Context, keyVersion int, afterID primitive.ObjectID, limit int64) ([]domain.User, error)
+	// UpdateWalletKey replaces the encrypted wallet key if it is still oldEncryptedKey, reporting whether it was
+	UpdateWalletKey(ctx context.Context, userID primitive.ObjectID, oldEncryptedKey, encryptedKey, dataKey string, keyVersion int) (bool, error)
+	// GetUsersWithStaleTOTPSecret lists users whose two-factor secret isn't wrapped by the given master key version, in ID order after afterID
+	GetUsersWithStaleTOTPSecret(ctx context.Context, keyVersion int, afterID primitive.ObjectID, limit int64) ([]domain.User, error)
+	// UpdateTOTPSecret replaces the encrypted two-factor secret if it is still oldSecret, reporting whether it was
+	UpdateTOTPSecret(ctx context.Context, userID primitive.ObjectID, oldSecret, secret, dataKey string, keyVersion int) (bool, error)
 }
 
 // mongoUserRepository implements UserRepository using MongoDB as the backend
@@ -88,12 +128,33 @@ func (r *mongoUserRepository) GetUserByVID(ctx context.Context, vid int64) (*dom
 
 func (r *mongoUserRepository) GetUserByWalletAddress(ctx context.Context, walletAddress string) (*domain.User, error) {
 	var user domain.User
-	err := r.collection.FindOne(ctx, bson.M{"walletaddress": walletAddress}).Decode(&user)
+	err := r.collection.FindOne(ctx, bson.M{"walletAddress": walletAddress}).Decode(&user)
 	return &user, err
 }
 
-func (r *mongoUserRepository) UpdateUser(ctx context.Context, user *domain.User) error {
-	_, err := r.collection.UpdateOne(ctx, bson.M{"_id": user.ID}, bson.M{"$set": user})
+func (r *mongoUserRepository) UpdateProfile(ctx context.Context, userID primitive.ObjectID, update domain.ProfileUpdate) error {
+	// Only the edited fields are written: the user document also holds credentials and
+	// two-factor state, which a whole-document write could roll back
+	set := bson.M{}
+	if update.Username != nil {
+		set["username"] = *update.Username
+	}
+	if update.PFPURL != nil {
+		set["pfpUrl"] = *update.PFPURL
+	}
+	if update.BannerURL != nil {
+		set["bannerUrl"] = *update.BannerURL
+	}
+	if update.Bio != nil {
+		set["bio"] = *update.Bio
+	}
+	if update.IsPrivate != nil {
+		set["isPrivate"] = *update.IsPrivate
+	}
+	if len(set) == 0 {
+		return nil
+	}
+	_, err := r.collection.UpdateOne(ctx, bson.M{"_id": userID}, bson.M{"$set": set})
 	return err
 }
 
@@ -102,14 +163,15 @@ func (r *mongoUserRepository) DeleteUser(ctx context.Context, userID primitive.O
 	return err
 }
 
-func (r *mongoUserRepository) SearchUsers(ctx context.Context, query string, page, limit int) ([]domain.User, error) {
+func (r *mongoUserRepository) SearchUsers(ctx context.Context, query string, after *pagination.Cursor, limit int) ([]domain.User, error) {
 	var users []domain.User
 	filter := bson.M{"$or": []bson.M{
 		{"name": bson.M{"$regex": query, "$options": "i"}},
 		{"username": bson.M{"$regex": query, "$options": "i"}},
 	}}
-	opts := options.Find().SetSkip(int64((page - 1) * limit)).SetLimit(int64(limit))
-	cursor, err := r.collection.Find(ctx, filter, opts)
+	// Users carry no createdAt, so order by _id, which embeds the creation time
+	opts := options.Find().SetSort(bson.D{{Key: "_id", Value: -1}}).SetLimit(int64(limit))
+	cursor, err := r.collection.Find(ctx, afterIDCursor(filter, after), opts)
 	if err != nil {
 		return nil, err
 	}
@@ -130,6 +192,213 @@ func (r *mongoUserRepository) GetUsersByIDs(ctx context.Context, userIDs []primi
 }
 
 func (r *mongoUserRepository) IncrementTotalLikes(ctx context.Context, userID primitive.ObjectID, count int) error {
-	_, err := r.collection.UpdateOne(ctx, bson.M{"_id": userID}, bson.M{"$inc": bson.M{"totallikecount": count}})
+	_, err := r.collection.UpdateOne(ctx, bson.M{"_id": userID}, bson.M{"$inc": bson.M{"totalLikeCount": count}})
+	return err
+}
+
+func (r *mongoUserRepository) IncrementPostCount(ctx context.Context, userID primitive.ObjectID, count int) error {
+	_, err := r.collection.UpdateOne(ctx, bson.M{"_id": userID}, bson.M{"$inc": bson.M{"postCount": count}})
+	return err
+}
+
+func (r *mongoUserRepository) SetPendingTOTPSecret(ctx context.Context, userID primitive.ObjectID, secret, dataKey string, keyVersion int) error {
+	_, err := r.collection.UpdateOne(ctx, bson.M{"_id": userID}, bson.M{"$set": bson.M{
+		"totpPendingSecret":     secret,
+		"totpPendingDataKey":    dataKey,
+		"totpPendingKeyVersion": keyVersion,
+	}})
+	return err
+}
+
+func (r *mongoUserRepository) EnableTOTP(ctx context.Context, userID primitive.ObjectID, secret, dataKey string, keyVersion int, recoveryCodeHashes []string, step int64) error {
+	_, err := r.collection.UpdateOne(ctx, bson.M{"_id": userID}, bson.M{
+		"$set": bson.M{
+			"totpEnabled":        true,
+			"totpSecret":         secret,
+			"totpDataKey":        dataKey,
+			"totpKeyVersion":     keyVersion,
+			"totpLastStep":       step,
+			"recoveryCodeHashes": recoveryCodeHashes,
+		},
+		"$unset": bson.M{"totpPendingSecret": "", "totpPendingDataKey": "", "totpPendingKeyVersion": ""},
+	})
+	return err
+}
+
+func (r *mongoUserRepository) DisableTOTP(ctx context.Context, userID primitive.ObjectID) error {
+	_, err := r.collection.UpdateOne(ctx, bson.M{"_id": userID}, bson.M{
+		"$set": bson.M{"totpEnabled": false},
+		"$unset": bson.M{
+			"totpSecret":            "",
+			"totpDataKey":           "",
+			"totpKeyVersion":        "",
+			"totpPendingSecret":     "",
+			"totpPendingDataKey":    "",
+			"totpPendingKeyVersion": "",
+			"totpLastStep":          "",
+			"recoveryCodeHashes":    "",
+		},
+	})
+	return err
+}
+
+func (r *mongoUserRepository) SetRecoveryCodes(ctx context.Context, userID primitive.ObjectID, recoveryCodeHashes []string) error {
+	_, err := r.collection.UpdateOne(ctx, bson.M{"_id": userID}, bson.M{"$set": bson.M{"recoveryCodeHashes": recoveryCodeHashes}})
+	return err
+}
+
+// ConsumeTOTPStep only advances totpLastStep, so of two requests presenting the
+// same code at most one succeeds.
+func (r *mongoUserRepository) ConsumeTOTPStep(ctx context.Context, userID primitive.ObjectID, step int64) (bool, error) {
+	filter := bson.M{"_id": userID, "totpLastStep": bson.M{"$not": bson.M{"$gte": step}}}
+	result, err := r.collection.UpdateOne(ctx, filter, bson.M{"$set": bson.M{"totpLastStep": step}})
+	if err != nil {
+		return false, err
+	}
+	return result.ModifiedCount == 1, nil
+}
+
+func (r *mongoUserRepository) ConsumeRecoveryCode(ctx context.Context, userID primitive.ObjectID, codeHash string) (bool, error) {
+	filter := bson.M{"_id": userID, "recoveryCodeHashes": codeHash}
+	result, err := r.collection.UpdateOne(ctx, filter, bson.M{"$pull": bson.M{"recoveryCodeHashes": codeHash}})
+	if err != nil {
+		return false, err
+	}
+	return result.ModifiedCount == 1, nil
+}
+
+func (r *mongoUserRepository) SetEmailVerificationCode(ctx context.Context, userID primitive.ObjectID, codeHash string, expires time.Time) error {
+	_, err := r.collection.UpdateOne(ctx, bson.M{"_id": userID}, bson.M{"$set": bson.M{
+		"emailVerificationCodeHash": codeHash,
+		"emailVerificationExpires":  expires,
+	}})
+	return err
+}
+
+func (r *mongoUserRepository) ClearEmailVerificationCode(ctx context.Context, userID primitive.ObjectID) error {
+	_, err := r.collection.UpdateOne(ctx, bson.M{"_id": userID}, bson.M{"$unset": bson.M{
+		"emailVerificationCodeHash": "",
+		"emailVerificationExpires":  "",
+	}})
+	return err
+}
+
+// VerifyEmail checks the code in the update filter, so a code can only be used once.
+func (r *mongoUserRepository) VerifyEmail(ctx context.Context, userID primitive.ObjectID, codeHash string) (bool, error) {
+	filter := bson.M{
+		"_id":                       userID,
+		"emailVerificationCodeHash": codeHash,
+		"emailVerificationExpires":  bson.M{"$gt": time.Now()},
+	}
+	result, err := r.collection.UpdateOne(ctx, filter, bson.M{
+		"$set": bson.M{"emailVerified": true},
+		"$unset": bson.M{
+			"emailVerificationCodeHash": "",
+			"emailVerificationExpires":  "",
+		},
+	})
+	if err != nil {
+		return false, err
+	}
+	return result.ModifiedCount == 1, nil
+}
+
+func (r *mongoUserRepository) SetPasswordResetOTP(ctx context.Context, userID primitive.ObjectID, otpHash string, expires time.Time) error {
+	_, err := r.collection.UpdateOne(ctx, bson.M{"_id": userID}, bson.M{
+		"$set": bson.M{
+			"otpHash":    otpHash,
+			"otpExpires": expires,
+		},
+		"$unset": bson.M{"otp": ""},
+	})
 	return err
 }
+
+// ResetPassword checks the OTP in the update filter, so an OTP can only be used once.
+func (r *mongoUserRepository) ResetPassword(ctx context.Context, userID primitive.ObjectID, otpHash, passwordHash string) (bool, error) {
+	filter := bson.M{
+		"_id":        userID,
+		"otpHash":    otpHash,
+		"otpExpires": bson.M{"$gt": time.Now()},
+	}
+	result, err := r.collection.UpdateOne(ctx, filter, bson.M{
+		"$set":   bson.M{"password": passwordHash},
+		"$unset": bson.M{"otp": "", "otpHash": "", "otpExpires": ""},
+	})
+	if err != nil {
+		return false, err
+	}
+	return result.ModifiedCount == 1, nil
+}
+
+// ClearLegacyOTPs drops the plaintext OTPs outright rather than hashing them:
+// they are short-lived, and the user can request a new one.
+func (r *mongoUserRepository) ClearLegacyOTPs(ctx context.Context) (int64, error) {
+	result, err := r.collection.UpdateMany(ctx, bson.M{"otp": bson.M{"$exists": true}}, bson.M{"$unset": bson.M{"otp": ""}})
+	if err != nil {
+		return 0, err
+	}
+	return result.ModifiedCount, nil
+}
+
+func (r *mongoUserRepository) GetUsersWithStaleWalletKey(ctx context.Context, keyVersion int, afterID primitive.ObjectID, limit int64) ([]domain.User, error) {
+	users := []domain.User{}
+	// Keys stored before envelope encryption have no version and match too
+	filter := bson.M{"_id": bson.M{"$gt": afterID}, "walletKeyVersion": bson.M{"$ne": keyVersion}}
+	opts := options.Find().SetSort(bson.D{{Key: "_id", Value: 1}}).SetLimit(limit)
+	cursor, err := r.collection.Find(ctx, filter, opts)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+	err = cursor.All(ctx, &users)
+	return users, err
+}
+
+// UpdateWalletKey only matches the ciphertext it was computed from, so a
+// concurrent change to the wallet key isn't overwritten.
+func (r *mongoUserRepository) UpdateWalletKey(ctx context.Context, userID primitive.ObjectID, oldEncryptedKey, encryptedKey, dataKey string, keyVersion int) (bool, error) {
+	filter := bson.M{"_id": userID, "encryptedPrivateKey": oldEncryptedKey}
+	result, err := r.collection.UpdateOne(ctx, filter, bson.M{"$set": bson.M{
+		"encryptedPrivateKey": encryptedKey,
+		"walletDataKey":       dataKey,
+		"walletKeyVersion":    keyVersion,
+	}})
+	if err != nil {
+		return false, err
+	}
+	return result.MatchedCount == 1, nil
+}
+
+func (r *mongoUserRepository) GetUsersWithStaleTOTPSecret(ctx context.Context, keyVersion int, afterID primitive.ObjectID, limit int64) ([]domain.User, error) {
+	users := []domain.User{}
+	// Secrets stored before envelope encryption have no version and match too
+	filter := bson.M{
+		"_id":            bson.M{"$gt": afterID},
+		"totpSecret":     bson.M{"$exists": true},
+		"totpKeyVersion": bson.M{"$ne": keyVersion},
+	}
+	opts := options.Find().SetSort(bson.D{{Key: "_id", Value: 1}}).SetLimit(limit)
+	cursor, err := r.collection.Find(ctx, filter, opts)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+	err = cursor.All(ctx, &users)
+	return users, err
+}
+
+// UpdateTOTPSecret only matches the ciphertext it was computed from, so a
+// concurrent re-enrollment isn't overwritten.
+func (r *mongoUserRepository) UpdateTOTPSecret(ctx context.Context, userID primitive.ObjectID, oldSecret, secret, dataKey string, keyVersion int) (bool, error) {
+	filter := bson.M{"_id": userID, "totpSecret": oldSecret}
+	result, err := r.collection.UpdateOne(ctx, filter, bson.M{"$set": bson.M{
+		"totpSecret":     secret,
+		"totpDataKey":    dataKey,
+		"totpKeyVersion": keyVersion,
+	}})
+	if err != nil {
+		return false, err
+	}
+	return result.MatchedCount == 1, nil
+}
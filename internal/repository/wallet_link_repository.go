@@ -0,0 +1,71 @@
+package repository
+
+import (
+	"context"
+	"vybes/internal/domain"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// WalletLinkRepository defines the interface for external wallet link data operations.
+type WalletLinkRepository interface {
+	// CreateWalletLink links a wallet to a user. Linking a wallet that is already linked fails with a duplicate key error
+	CreateWalletLink(ctx context.Context, link *domain.WalletLink) error
+	// GetWalletLinkByAddress finds the link for a wallet address
+	GetWalletLinkByAddress(ctx context.Context, address string) (*domain.WalletLink, error)
+	// GetWalletLinksByUser lists a user's linked wallets, oldest first
+	GetWalletLinksByUser(ctx context.Context, userID primitive.ObjectID) ([]domain.WalletLink, error)
+	// DeleteWalletLink unlinks a wallet from a user, reporting whether the user had it linked
+	DeleteWalletLink(ctx context.Context, userID primitive.ObjectID, address string) (bool, error)
+}
+
+// mongoWalletLinkRepository implements WalletLinkRepository using MongoDB as the backend
+type mongoWalletLinkRepository struct {
+	collection *mongo.Collection
+}
+
+// NewMongoWalletLinkRepository creates a new wallet link repository instance with MongoDB backend.
+//
+// Parameters:
+//   - db: MongoDB database instance
+//
+// Returns:
+//   - WalletLinkRepository: A configured wallet link repository ready for use
+func NewMongoWalletLinkRepository(db *mongo.Database) WalletLinkRepository {
+	return &mongoWalletLinkRepository{
+		collection: db.Collection("wallet_links"),
+	}
+}
+
+func (r *mongoWalletLinkRepository) CreateWalletLink(ctx context.Context, link *domain.WalletLink) error {
+	_, err := r.collection.InsertOne(ctx, link)
+	return err
+}
+
+func (r *mongoWalletLinkRepository) GetWalletLinkByAddress(ctx context.Context, address string) (*domain.WalletLink, error) {
+	var link domain.WalletLink
+	err := r.collection.FindOne(ctx, bson.M{"address": address}).Decode(&link)
+	return &link, err
+}
+
+func (r *mongoWalletLinkRepository) GetWalletLinksByUser(ctx context.Context, userID primitive.ObjectID) ([]domain.WalletLink, error) {
+	links := []domain.WalletLink{}
+	cursor, err := r.collection.Find(ctx, bson.M{"userId": userID}, options.Find().SetSort(bson.D{{Key: "_id", Value: 1}}))
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+	err = cursor.All(ctx, &links)
+	return links, err
+}
+
+func (r *mongoWalletLinkRepository) DeleteWalletLink(ctx context.Context, userID primitive.ObjectID, address string) (bool, error) {
+	result, err := r.collection.DeleteOne(ctx, bson.M{"userId": userID, "address": address})
+	if err != nil {
+		return false, err
+	}
+	return result.DeletedCount == 1, nil
+}
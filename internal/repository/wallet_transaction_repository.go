@@ -0,0 +1,126 @@
+package repository
+
+import (
+	"context"
+	"time"
+	"vybes/internal/domain"
+	"vybes/pkg/pagination"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// WalletTransactionRepository defines the interface for the history of
+// transactions sent from custodial wallets.
+type WalletTransactionRepository interface {
+	// CreateTransaction records a sent transaction
+	CreateTransaction(ctx context.Context, tx *domain.WalletTransaction) error
+	// GetTransactionsByUser retrieves a user's transactions, newest first
+	GetTransactionsByUser(ctx context.Context, userID primitive.ObjectID, after *pagination.Cursor, limit int) ([]domain.WalletTransaction, error)
+	// GetPendingTransactions retrieves pending transactions, least recently checked first
+	GetPendingTransactions(ctx context.Context, limit int) ([]domain.WalletTransaction, error)
+	// MarkChecked records that a pending transaction was polled without a change
+	MarkChecked(ctx context.Context, id primitive.ObjectID) error
+	// ResolveTransaction stores the final status of a pending transaction,
+	// reporting whether it was still pending
+	ResolveTransaction(ctx context.Context, tx *domain.WalletTransaction) (bool, error)
+}
+
+// mongoWalletTransactionRepository implements WalletTransactionRepository using MongoDB as the backend
+type mongoWalletTransactionRepository struct {
+	collection *mongo.Collection
+}
+
+// NewMongoWalletTransactionRepository creates a new wallet transaction repository instance with MongoDB backend.
+//
+// Parameters:
+//   - db: MongoDB database instance
+//
+// Returns:
+//   - WalletTransactionRepository: A configured wallet transaction repository ready for use
+func NewMongoWalletTransactionRepository(db *mongo.Database) WalletTransactionRepository {
+	return &mongoWalletTransactionRepository{
+		collection: db.Collection("wallet_transactions"),
+	}
+}
+
+func (r *mongoWalletTransactionRepository) CreateTransaction(ctx context.Context, tx *domain.WalletTransaction) error {
+	_, err := r.collection.InsertOne(ctx, tx)
+	return err
+}
+
+func (r *mongoWalletTransactionRepository) GetTransactionsByUser(ctx context.Context, userID primitive.ObjectID, after *pagination.Cursor, limit int) ([]domain.WalletTransaction, error) {
+	filter := afterCursor(bson.M{"userId": userID}, after, false)
+	cursor, err := r.collection.Find(ctx, filter, newestFirst(limit))
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	var transactions []domain.WalletTransaction
+	if err = cursor.All(ctx, &transactions); err != nil {
+		return nil, err
+	}
+	return transactions, nil
+}
+
+// GetPendingTransactions orders by checkedAt so that, with more pending
+// transactions than fit in one batch, every transaction still gets polled.
+//
+// Parameters:
+//   - ctx: Context for the operation
+//   - limit: Maximum number of transactions to return
+//
+// Returns:
+//   - []domain.WalletTransaction: Pending transactions
+//   - error: Any error that occurred during the operation
+func (r *mongoWalletTransactionRepository) GetPendingTransactions(ctx context.Context, limit int) ([]domain.WalletTransaction, error) {
+	opts := options.Find().
+		SetSort(bson.D{{Key: "checkedAt", Value: 1}}).
+		SetLimit(int64(limit))
+	cursor, err := r.collection.Find(ctx, bson.M{"status": domain.WalletTransactionPending}, opts)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	var transactions []domain.WalletTransaction
+	if err = cursor.All(ctx, &transactions); err != nil {
+		return nil, err
+	}
+	return transactions, nil
+}
+
+func (r *mongoWalletTransactionRepository) MarkChecked(ctx context.Context, id primitive.ObjectID) error {
+	_, err := r.collection.UpdateOne(ctx, bson.M{"_id": id}, bson.M{"$set": bson.M{"checkedAt": time.Now()}})
+	return err
+}
+
+// ResolveTransaction only updates transactions that are still pending, so when
+// several instances poll the same transaction exactly one of them resolves it.
+//
+// Parameters:
+//   - ctx: Context for the operation
+//   - tx: The transaction with its final status and receipt details
+//
+// Returns:
+//   - bool: Whether this call resolved the transaction
+//   - error: Any error that occurred during the operation
+func (r *mongoWalletTransactionRepository) ResolveTransaction(ctx context.Context, tx *domain.WalletTransaction) (bool, error) {
+	now := time.Now()
+	update := bson.M{"$set": bson.M{
+		"status":          tx.Status,
+		"blockNumber":     tx.BlockNumber,
+		"gasUsed":         tx.GasUsed,
+		"contractAddress": tx.ContractAddress,
+		"checkedAt":       now,
+		"updatedAt":       now,
+	}}
+	result, err := r.collection.UpdateOne(ctx, bson.M{"_id": tx.ID, "status": domain.WalletTransactionPending}, update)
+	if err != nil {
+		return false, err
+	}
+	return result.ModifiedCount == 1, nil
+}
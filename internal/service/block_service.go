@@ -0,0 +1,254 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"time"
+	"vybes/internal/domain"
+	"vybes/internal/repository"
+	"vybes/pkg/pagination"
+
+	"github.com/rs/zerolog/log"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// BlockService defines the interface for blocking and muting users, and for
+// the visibility checks other services run against those relationships.
+type BlockService interface {
+	BlockUser(ctx context.Context, userID, username string) error
+	UnblockUser(ctx context.Context, userID, username string) error
+	MuteUser(ctx context.Context, userID, username string) error
+	UnmuteUser(ctx context.Context, userID, username string) error
+	GetBlockedUsers(ctx context.Context, userID, cursor string, limit int) (*pagination.Page[domain.User], error)
+	GetMutedUsers(ctx context.Context, userID, cursor string, limit int) (*pagination.Page[domain.User], error)
+
+	// IsBlocked reports whether either user has blocked the other
+	IsBlocked(ctx context.Context, userID, otherID primitive.ObjectID) (bool, error)
+	// IsHidden reports whether the author's content and notifications are hidden from the viewer
+	IsHidden(ctx context.Context, viewerID, authorID primitive.ObjectID) (bool, error)
+	// GetBlockedUserIDs returns the users the user has blocked or been blocked by
+	GetBlockedUserIDs(ctx context.Context, userID primitive.ObjectID) (map[primitive.ObjectID]bool, error)
+	// GetHiddenUserIDs returns the users whose content is hidden from the viewer: blocks both ways plus mutes
+	GetHiddenUserIDs(ctx context.Context, viewerID primitive.ObjectID) (map[primitive.ObjectID]bool, error)
+}
+
+type blockService struct {
+	blockRepo       repository.BlockRepository
+	muteRepo        repository.MuteRepository
+	userRepo        repository.UserRepository
+	timelineService TimelineService
+	cursorCodec     *pagination.Codec
+}
+
+// NewBlockService creates a new block service.
+func NewBlockService(blockRepo repository.BlockRepository, muteRepo repository.MuteRepository, userRepo repository.UserRepository, timelineService TimelineService, cursorCodec *pagination.Codec) BlockService {
+	return &blockService{
+		blockRepo:       blockRepo,
+		muteRepo:        muteRepo,
+		userRepo:        userRepo,
+		timelineService: timelineService,
+		cursorCodec:     cursorCodec,
+	}
+}
+
+// resolveTarget parses the acting user's ID and looks up the target by username.
+func (s *blockService) resolveTarget(ctx context.Context, userIDStr, username string) (primitive.ObjectID, *domain.User, error) {
+	userID, err := primitive.ObjectIDFromHex(userIDStr)
+	if err != nil {
+		return userID, nil, errors.New("invalid user ID format")
+	}
+	target, err := s.userRepo.GetUserByUsername(ctx, username)
+	if err != nil {
+		return userID, nil, err
+	}
+	if target == nil {
+		return userID, nil, errors.New("user not found")
+	}
+	if target.ID == userID {
+		return userID, nil, errors.New("cannot block or mute yourself")
+	}
+	return userID, target, nil
+}
+
+// BlockUser blocks a user and removes the follows between them. Their posts are
+// also trimmed from each other's home timelines.
+func (s *blockService) BlockUser(ctx context.Context, userIDStr, username string) error {
+	userID, target, err := s.resolveTarget(ctx, userIDStr, username)
+	if err != nil {
+		return err
+	}
+
+	block := &domain.Block{
+		ID:        primitive.NewObjectID(),
+		BlockerID: userID,
+		BlockedID: target.ID,
+		CreatedAt: time.Now(),
+	}
+	if err := s.blockRepo.CreateBlock(ctx, block); err != nil {
+		return err
+	}
+
+	if err := s.timelineService.RemoveFollow(ctx, userID, target.ID); err != nil {
+		log.Error().Err(err).Msg("Failed to remove blocked user's posts from timeline")
+	}
+	if err := s.timelineService.RemoveFollow(ctx, target.ID, userID); err != nil {
+		log.Error().Err(err).Msg("Failed to remove blocking user's posts from timeline")
+	}
+	return nil
+}
+
+func (s *blockService) UnblockUser(ctx context.Context, userIDStr, username string) error {
+	userID, target, err := s.resolveTarget(ctx, userIDStr, username)
+	if err != nil {
+		return err
+	}
+	return s.blockRepo.DeleteBlock(ctx, userID, target.ID)
+}
+
+func (s *blockService) MuteUser(ctx context.Context, userIDStr, username string) error {
+	userID, target, err := s.resolveTarget(ctx, userIDStr, username)
+	if err != nil {
+		return err
+	}
+	return s.muteRepo.CreateMute(ctx, &domain.Mute{
+		ID:        primitive.NewObjectID(),
+		MuterID:   userID,
+		MutedID:   target.ID,
+		CreatedAt: time.Now(),
+	})
+}
+
+func (s *blockService) UnmuteUser(ctx context.Context, userIDStr, username string) error {
+	userID, target, err := s.resolveTarget(ctx, userIDStr, username)
+	if err != nil {
+		return err
+	}
+	return s.muteRepo.DeleteMute(ctx, userID, target.ID)
+}
+
+func (s *blockService) GetBlockedUsers(ctx context.Context, userIDStr, cursor string, limit int) (*pagination.Page[domain.User], error) {
+	userID, err := primitive.ObjectIDFromHex(userIDStr)
+	if err != nil {
+		return nil, err
+	}
+	after, err := s.cursorCodec.Decode(cursor)
+	if err != nil {
+		return nil, err
+	}
+	blocks, err := s.blockRepo.GetBlocks(ctx, userID, after, limit+1)
+	if err != nil {
+		return nil, err
+	}
+	page := pagination.NewPage(s.cursorCodec, blocks, limit, blockCursor)
+	ids := make([]primitive.ObjectID, len(page.Items))
+	for i, b := range page.Items {
+		ids[i] = b.BlockedID
+	}
+	return s.usersPage(ctx, ids, page.NextCursor)
+}
+
+func (s *blockService) GetMutedUsers(ctx context.Context, userIDStr, cursor string, limit int) (*pagination.Page[domain.User], error) {
+	userID, err := primitive.ObjectIDFromHex(userIDStr)
+	if err != nil {
+		return nil, err
+	}
+	after, err := s.cursorCodec.Decode(cursor)
+	if err != nil {
+		return nil, err
+	}
+	mutes, err := s.muteRepo.GetMutes(ctx, userID, after, limit+1)
+	if err != nil {
+		return nil, err
+	}
+	page := pagination.NewPage(s.cursorCodec, mutes, limit, muteCursor)
+	ids := make([]primitive.ObjectID, len(page.Items))
+	for i, m := range page.Items {
+		ids[i] = m.MutedID
+	}
+	return s.usersPage(ctx, ids, page.NextCursor)
+}
+
+// usersPage loads users in the given order; the cursor keeps following the
+// underlying block or mute list. Deleted accounts are skipped.
+func (s *blockService) usersPage(ctx context.Context, ids []primitive.ObjectID, nextCursor string) (*pagination.Page[domain.User], error) {
+	page := &pagination.Page[domain.User]{Items: []domain.User{}, NextCursor: nextCursor}
+	if len(ids) == 0 {
+		return page, nil
+	}
+	users, err := s.userRepo.GetUsersByIDs(ctx, ids)
+	if err != nil {
+		return nil, err
+	}
+	usersByID := make(map[primitive.ObjectID]domain.User, len(users))
+	for _, u := range users {
+		usersByID[u.ID] = u
+	}
+	for _, id := range ids {
+		if u, ok := usersByID[id]; ok {
+			page.Items = append(page.Items, u)
+		}
+	}
+	return page, nil
+}
+
+func (s *blockService) IsBlocked(ctx context.Context, userID, otherID primitive.ObjectID) (bool, error) {
+	return s.blockRepo.IsBlocked(ctx, userID, otherID)
+}
+
+func (s *blockService) IsHidden(ctx context.Context, viewerID, authorID primitive.ObjectID) (bool, error) {
+	blocked, err := s.blockRepo.IsBlocked(ctx, viewerID, authorID)
+	if err != nil || blocked {
+		return blocked, err
+	}
+	return s.muteRepo.IsMuted(ctx, viewerID, authorID)
+}
+
+func (s *blockService) GetBlockedUserIDs(ctx context.Context, userID primitive.ObjectID) (map[primitive.ObjectID]bool, error) {
+	blocked, err := s.blockRepo.GetBlockedIDs(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	blockers, err := s.blockRepo.GetBlockerIDs(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	ids := make(map[primitive.ObjectID]bool, len(blocked)+len(blockers))
+	for _, id := range blocked {
+		ids[id] = true
+	}
+	for _, id := range blockers {
+		ids[id] = true
+	}
+	return ids, nil
+}
+
+func (s *blockService) GetHiddenUserIDs(ctx context.Context, viewerID primitive.ObjectID) (map[primitive.ObjectID]bool, error) {
+	ids, err := s.GetBlockedUserIDs(ctx, viewerID)
+	if err != nil {
+		return nil, err
+	}
+	muted, err := s.muteRepo.GetMutedIDs(ctx, viewerID)
+	if err != nil {
+		return nil, err
+	}
+	for _, id := range muted {
+		ids[id] = true
+	}
+	return ids, nil
+}
+
+// withoutHiddenAuthors drops posts by hidden users, including reposts of their
+// posts. Originals must already be attached for reposts to be caught.
+func withoutHiddenAuthors(posts []domain.Post, hidden map[primitive.ObjectID]bool) []domain.Post {
+	if len(hidden) == 0 {
+		return posts
+	}
+	visible := posts[:0]
+	for _, p := range posts {
+		if hidden[p.UserID] || (p.OriginalPost != nil && hidden[p.OriginalPost.UserID]) {
+			continue
+		}
+		visible = append(visible, p)
+	}
+	return visible
+}
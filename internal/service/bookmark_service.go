@@ -5,6 +5,7 @@ import (
 	"time"
 	"vybes/internal/domain"
 	"vybes/internal/repository"
+	"vybes/pkg/pagination"
 
 	"go.mongodb.org/mongo-driver/bson/primitive"
 )
@@ -13,19 +14,21 @@ import (
 type BookmarkService interface {
 	AddBookmark(ctx context.Context, userID, postID string) error
 	RemoveBookmark(ctx context.Context, userID, postID string) error
-	GetBookmarks(ctx context.Context, userID string, page, limit int) ([]domain.Post, error)
+	GetBookmarks(ctx context.Context, userID, cursor string, limit int) (*pagination.Page[domain.Post], error)
 }
 
 type bookmarkService struct {
 	bookmarkRepo repository.BookmarkRepository
 	contentRepo  repository.ContentRepository
+	cursorCodec  *pagination.Codec
 }
 
 // NewBookmarkService creates a new bookmark service.
-func NewBookmarkService(bookmarkRepo repository.BookmarkRepository, contentRepo repository.ContentRepository) BookmarkService {
+func NewBookmarkService(bookmarkRepo repository.BookmarkRepository, contentRepo repository.ContentRepository, cursorCodec *pagination.Codec) BookmarkService {
 	return &bookmarkService{
 		bookmarkRepo: bookmarkRepo,
 		contentRepo:  contentRepo,
+		cursorCodec:  cursorCodec,
 	}
 }
 
@@ -59,27 +62,54 @@ func (s *bookmarkService) RemoveBookmark(ctx context.Context, userIDStr, postIDS
 	return s.bookmarkRepo.DeleteBookmark(ctx, userID, postID)
 }
 
-func (s *bookmarkService) GetBookmarks(ctx context.Context, userIDStr string, page, limit int) ([]domain.Post, error) {
+// GetBookmarks returns a page of the user's bookmarked posts, most recently
+// bookmarked first. The cursor follows the bookmarks rather than the posts, so
+// the order matches when each post was saved.
+func (s *bookmarkService) GetBookmarks(ctx context.Context, userIDStr, cursor string, limit int) (*pagination.Page[domain.Post], error) {
 	userID, err := primitive.ObjectIDFromHex(userIDStr)
 	if err != nil {
 		return nil, err
 	}
-
-	bookmarks, err := s.bookmarkRepo.GetUserBookmarks(ctx, userID, page, limit)
+	after, err := s.cursorCodec.Decode(cursor)
 	if err != nil {
 		return nil, err
 	}
 
-	if len(bookmarks) == 0 {
-		return []domain.Post{}, nil
+	// Fetch one extra bookmark to learn whether another page exists
+	bookmarks, err := s.bookmarkRepo.GetUserBookmarks(ctx, userID, after, limit+1)
+	if err != nil {
+		return nil, err
+	}
+	bookmarkPage := pagination.NewPage(s.cursorCodec, bookmarks, limit, bookmarkCursor)
+	page := &pagination.Page[domain.Post]{Items: []domain.Post{}, NextCursor: bookmarkPage.NextCursor}
+	if len(bookmarkPage.Items) == 0 {
+		return page, nil
 	}
 
 	// Extract post IDs from bookmarks
 	var postIDs []primitive.ObjectID
-	for _, b := range bookmarks {
+	for _, b := range bookmarkPage.Items {
 		postIDs = append(postIDs, b.PostID)
 	}
 
 	// Fetch the full post details for the bookmarked posts
-	return s.contentRepo.GetPostsByIDs(ctx, postIDs)
+	posts, err := s.contentRepo.GetPostsByIDs(ctx, postIDs)
+	if err != nil {
+		return nil, err
+	}
+	postsByID := make(map[primitive.ObjectID]domain.Post, len(posts))
+	for _, p := range posts {
+		postsByID[p.ID] = p
+	}
+
+	// Keep bookmark order; posts deleted since they were bookmarked are skipped
+	for _, id := range postIDs {
+		if p, ok := postsByID[id]; ok {
+			page.Items = append(page.Items, p)
+		}
+	}
+	if err := attachOriginalPosts(ctx, s.contentRepo, page.Items); err != nil {
+		return nil, err
+	}
+	return page, nil
 }
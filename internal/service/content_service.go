@@ -2,6 +2,9 @@ package service
 
 import (
 	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
 	"fmt"
 	"mime/multipart"
 	"path/filepath"
@@ -10,11 +13,14 @@ import (
 	"vybes/internal/config"
 	"vybes/internal/domain"
 	"vybes/internal/repository"
+	"vybes/pkg/cache"
+	"vybes/pkg/pagination"
 	"vybes/pkg/storage"
 
 	"github.com/google/uuid"
 	"github.com/rs/zerolog/log"
 	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
 )
 
 // ContentService defines the interface for content business logic operations.
@@ -26,25 +32,43 @@ type ContentService interface {
 	// GetPostByID retrieves a specific post by its ID
 	GetPostByID(ctx context.Context, postID primitive.ObjectID) (*domain.Post, error)
 	// GetPostsByUserID retrieves posts created by a specific user
-	GetPostsByUserID(ctx context.Context, userID primitive.ObjectID, page, limit int) ([]domain.Post, error)
+	GetPostsByUserID(ctx context.Context, userID primitive.ObjectID, cursor string, limit int) (*pagination.Page[domain.Post], error)
 	// DeletePost removes a post and its associated content
 	DeletePost(ctx context.Context, postID, userID primitive.ObjectID) error
-	// GetFeedPosts retrieves posts for a user's feed based on followed users
-	GetFeedPosts(ctx context.Context, userID primitive.ObjectID, page, limit int) ([]domain.Post, error)
-	Repost(ctx context.Context, userID, originalPostID primitive.ObjectID) (*domain.Post, error)
-	GetRepostsByUser(ctx context.Context, userID primitive.ObjectID, limit int) ([]domain.Post, error)
-	CreateComment(ctx context.Context, userID, postID primitive.ObjectID, text string) (*domain.Comment, error)
-	GetComments(ctx context.Context, postID primitive.ObjectID, page, limit int) ([]domain.Comment, error)
-	RecordView(ctx context.Context, postID primitive.ObjectID) error
+	// Repost shares an existing post, optionally with a quote caption
+	Repost(ctx context.Context, userID, originalPostID primitive.ObjectID, caption string) (*domain.Post, error)
+	// GetRepostsByUser retrieves a user's reposts with their originals embedded, if the viewer may see them
+	GetRepostsByUser(ctx context.Context, viewerID, userID primitive.ObjectID, cursor string, limit int) (*pagination.Page[domain.Post], error)
+	// CreateComment adds a comment to a post, or a reply when parentID is set
+	CreateComment(ctx context.Context, userID, postID primitive.ObjectID, text string, parentID *primitive.ObjectID) (*domain.Comment, error)
+	// GetComments retrieves the top-level comments of a post the viewer can see
+	GetComments(ctx context.Context, viewerID, postID primitive.ObjectID, cursor string, limit int) (*pagination.Page[domain.Comment], error)
+	// GetReplies retrieves replies to a comment, oldest first
+	GetReplies(ctx context.Context, viewerID, commentID primitive.ObjectID, cursor string, limit int) (*pagination.Page[domain.Comment], error)
+	// EditComment changes the text of the user's own comment and keeps the previous version
+	EditComment(ctx context.Context, userID, commentID primitive.ObjectID, text string) (*domain.Comment, error)
+	// DeleteComment removes a comment; allowed for its author and the post's author
+	DeleteComment(ctx context.Context, userID, commentID primitive.ObjectID) error
+	// RecordView counts a view once per viewer within the dedup window.
+	// viewerID is nil for anonymous viewers, who are identified by IP instead.
+	RecordView(ctx context.Context, postID primitive.ObjectID, viewerID *primitive.ObjectID, clientIP string) error
 }
 
+// viewBufferKey is the Redis hash that accumulates view counts per post until
+// the cron job flushes them to MongoDB.
+const viewBufferKey = "views:pending"
+
 // contentService implements ContentService with business logic for content management
 type contentService struct {
 	contentRepository     repository.ContentRepository
 	userRepository        repository.UserRepository
 	followRepository      repository.FollowRepository
 	storageClient         storage.Client
+	cache                 cache.Client
 	notificationPublisher NotificationPublisher
+	timelineService       TimelineService
+	blockService          BlockService
+	cursorCodec           *pagination.Codec
 	config                *config.Config
 }
 
@@ -55,18 +79,26 @@ type contentService struct {
 //   - contentRepository: Repository for content data operations
 //   - userRepository: Repository for user data operations
 //   - storageClient: Client for file storage operations
+//   - cache: Cache client used for view dedup and buffering
 //   - notificationPublisher: Publisher for real-time notifications
+//   - timelineService: Service that pushes new posts into followers' home timelines
+//   - blockService: Service deciding which users' content is hidden from a viewer
+//   - cursorCodec: Codec for signing and verifying pagination cursors
 //   - config: Application configuration
 //
 // Returns:
 //   - ContentService: A configured content service ready for use
-func NewContentService(contentRepository repository.ContentRepository, userRepository repository.UserRepository, followRepository repository.FollowRepository, storageClient storage.Client, notificationPublisher NotificationPublisher, config *config.Config) ContentService {
+func NewContentService(contentRepository repository.ContentRepository, userRepository repository.UserRepository, followRepository repository.FollowRepository, storageClient storage.Client, cache cache.Client, notificationPublisher NotificationPublisher, timelineService TimelineService, blockService BlockService, cursorCodec *pagination.Codec, config *config.Config) ContentService {
 	return &contentService{
 		contentRepository:     contentRepository,
 		userRepository:        userRepository,
 		followRepository:      followRepository,
 		storageClient:         storageClient,
+		cache:                 cache,
 		notificationPublisher: notificationPublisher,
+		timelineService:       timelineService,
+		blockService:          blockService,
+		cursorCodec:           cursorCodec,
 		config:                config,
 	}
 }
@@ -143,6 +175,12 @@ func (s *contentService) CreatePost(ctx context.Context, userID primitive.Object
 		return nil, fmt.Errorf("failed to create post: %w", err)
 	}
 
+	if err := s.userRepository.IncrementPostCount(ctx, userID, 1); err != nil {
+		log.Error().Err(err).Msg("Failed to increment user post count")
+	}
+
+	s.fanOutPost(post)
+
 	// Publish notification for new post (if public)
 	if visibility == domain.VisibilityPublic {
 		// TODO: Implement notification publishing for new posts
@@ -151,9 +189,9 @@ func (s *contentService) CreatePost(ctx context.Context, userID primitive.Object
 	return post, nil
 }
 
-// DeletePost removes a post and its associated content from the system.
-// This operation includes file cleanup from cloud storage and cascading
-// deletion of related data like comments and reactions.
+// DeletePost removes a post from the system. The post disappears immediately,
+// while its comments, reactions, bookmarks, notifications, plain reposts and
+// media are removed by a background cleanup job so the request is not blocked.
 //
 // Parameters:
 //   - ctx: Context for the operation
@@ -163,7 +201,7 @@ func (s *contentService) CreatePost(ctx context.Context, userID primitive.Object
 // Returns:
 //   - error: Any error that occurred during post deletion
 func (s *contentService) DeletePost(ctx context.Context, postID, userID primitive.ObjectID) error {
-	// 1. Get the post to verify ownership and get content details
+	// 1. Get the post to verify ownership and snapshot it for the cleanup job
 	post, err := s.contentRepository.GetPostByID(ctx, postID)
 	if err != nil {
 		return fmt.Errorf("failed to get post: %w", err)
@@ -177,26 +215,13 @@ func (s *contentService) DeletePost(ctx context.Context, postID, userID primitiv
 		return fmt.Errorf("unauthorized: user does not own this post")
 	}
 
-	// 2. Delete file from R2 if content exists
-	if post.ContentURL != "" {
-		// Extract object name from URL (assuming URL format: https://bucket.r2.cloudflarestorage.com/object-name)
-		objectName := strings.TrimPrefix(post.ContentURL, fmt.Sprintf("https://%s.r2.cloudflarestorage.com/", s.config.R2BucketName))
-		
-		if err := s.storageClient.DeleteFile(ctx, s.config.R2BucketName, objectName); err != nil {
-			log.Error().Err(err).Msg("Failed to delete file from storage during post deletion")
-		}
-	}
-
-	// 3. Delete the post and content documents
-	if err := s.contentRepository.DeletePost(ctx, postID, userID); err != nil {
+	// 2. Delete the post, adjust counters and enqueue the cleanup job in one transaction
+	if err := s.contentRepository.DeletePostAndEnqueueCleanup(ctx, post, newPostCleanupJob(post)); err != nil {
 		// Log this error
 		log.Error().Err(err).Msg("Failed to delete post from database")
 		return fmt.Errorf("failed to delete post: %w", err)
 	}
 
-	// TODO: Delete associated comments, likes, bookmarks, notifications in a transaction or background job.
-	// This should be implemented as a background job to avoid blocking the user request.
-
 	return nil
 }
 
@@ -204,36 +229,258 @@ func (s *contentService) GetPostByID(ctx context.Context, postID primitive.Objec
 	return s.contentRepository.GetPostByID(ctx, postID)
 }
 
-func (s *contentService) GetPostsByUserID(ctx context.Context, userID primitive.ObjectID, page, limit int) ([]domain.Post, error) {
-	return s.contentRepository.GetPostsByUserID(ctx, userID, page, limit)
+func (s *contentService) GetPostsByUserID(ctx context.Context, userID primitive.ObjectID, cursor string, limit int) (*pagination.Page[domain.Post], error) {
+	after, err := s.cursorCodec.Decode(cursor)
+	if err != nil {
+		return nil, err
+	}
+	posts, err := s.contentRepository.GetPostsByUserID(ctx, userID, after, limit+1)
+	if err != nil {
+		return nil, err
+	}
+	page := pagination.NewPage(s.cursorCodec, posts, limit, postCursor)
+	return &page, nil
 }
 
-func (s *contentService) GetFeedPosts(ctx context.Context, userID primitive.ObjectID, page, limit int) ([]domain.Post, error) {
-	following, err := s.followRepository.GetFollowing(ctx, userID, 1, 1000) //TODO: handle pagination
+// Repost creates a new post that points at an existing one. A non-empty caption
+// turns it into a quote-repost. Reposting a plain repost targets its original,
+// so chains always point at the root post.
+//
+// Parameters:
+//   - ctx: Context for the operation
+//   - userID: ID of the user reposting
+//   - originalPostID: ID of the post being reposted
+//   - caption: Optional quote caption
+//
+// Returns:
+//   - *domain.Post: The created repost with the original embedded
+//   - error: Any error that occurred during reposting
+func (s *contentService) Repost(ctx context.Context, userID, originalPostID primitive.ObjectID, caption string) (*domain.Post, error) {
+	original, err := s.contentRepository.GetPostByID(ctx, originalPostID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get post: %w", err)
+	}
+
+	// A plain repost carries nothing of its own, so repost its original instead
+	if original.IsRepost() && original.Caption == "" {
+		original, err = s.contentRepository.GetPostByID(ctx, *original.OriginalPostID)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get original post: %w", err)
+		}
+	}
+
+	canView, err := canViewPost(ctx, s.followRepository, s.userRepository, s.blockService, userID, original)
 	if err != nil {
 		return nil, err
 	}
+	if !canView {
+		return nil, fmt.Errorf("post not found")
+	}
+
+	// Reposts are shown to the reposter's whole audience, so only public posts may be reposted
+	if original.Visibility != domain.VisibilityPublic {
+		return nil, fmt.Errorf("only public posts can be reposted")
+	}
+	if original.UserID == userID {
+		return nil, fmt.Errorf("cannot repost your own post")
+	}
+	author, err := s.userRepository.GetUserByID(ctx, original.UserID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get post author: %w", err)
+	}
+	if author.IsPrivate {
+		return nil, fmt.Errorf("posts from private accounts can't be reposted")
+	}
+
+	alreadyReposted, err := s.contentRepository.HasReposted(ctx, userID, original.ID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to check existing repost: %w", err)
+	}
+	if alreadyReposted {
+		return nil, fmt.Errorf("post already reposted")
+	}
 
-	followingIDs := make([]primitive.ObjectID, len(following))
-	for i, f := range following {
-		followingIDs[i] = f.FollowingID
+	now := time.Now()
+	repost := &domain.Post{
+		ID:             primitive.NewObjectID(),
+		UserID:         userID,
+		Caption:        caption,
+		Type:           original.Type,
+		OriginalPostID: &original.ID,
+		Visibility:     domain.VisibilityPublic,
+		CreatedAt:      now,
+		UpdatedAt:      now,
 	}
-	followingIDs = append(followingIDs, userID)
 
-	return s.contentRepository.GetFeedPosts(ctx, followingIDs, page, limit)
+	// The unique (userId, originalPostId) index rejects a concurrent double repost here
+	if err := s.contentRepository.CreatePost(ctx, repost); err != nil {
+		log.Error().Err(err).Msg("Failed to save repost to database")
+		return nil, fmt.Errorf("failed to create repost: %w", err)
+	}
+
+	if err := s.contentRepository.IncrementRepostCount(ctx, original.ID, 1); err != nil {
+		log.Error().Err(err).Msg("Failed to increment repost count")
+	} else {
+		original.RepostCount++
+	}
+	repost.OriginalPost = original
+
+	s.fanOutPost(repost)
+
+	// Publish notification event
+	go s.notificationPublisher.Publish(domain.Notification{
+		UserID:  original.UserID, // The original author receives the notification
+		ActorID: userID,
+		Type:    domain.NotificationTypeRepost,
+		PostID:  &original.ID,
+	})
+
+	return repost, nil
+}
+
+// fanOutPost pushes a new post into its followers' home timelines in the
+// background. A failed fan-out only delays the post until timelines are rebuilt.
+func (s *contentService) fanOutPost(post *domain.Post) {
+	entry := domain.Post{ID: post.ID, UserID: post.UserID, Visibility: post.Visibility, CreatedAt: post.CreatedAt}
+	go func() {
+		ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+		defer cancel()
+		if err := s.timelineService.FanOutPost(ctx, &entry); err != nil {
+			log.Error().Err(err).Str("post_id", entry.ID.Hex()).Msg("Failed to fan out post to timelines")
+		}
+	}()
+}
+
+// GetRepostsByUser retrieves the most recent reposts made by a user, each with
+// its original post embedded for rendering. The reposts of a private account are
+// only listed for its followers.
+func (s *contentService) GetRepostsByUser(ctx context.Context, viewerID, userID primitive.ObjectID, cursor string, limit int) (*pagination.Page[domain.Post], error) {
+	after, err := s.cursorCodec.Decode(cursor)
+	if err != nil {
+		return nil, err
+	}
+	owner, err := s.userRepository.GetUserByID(ctx, userID)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return &pagination.Page[domain.Post]{Items: []domain.Post{}}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	canView, err := canViewAccount(ctx, s.followRepository, viewerID, owner)
+	if err != nil {
+		return nil, err
+	}
+	if !canView {
+		return nil, ErrPrivateAccount
+	}
+	reposts, err := s.contentRepository.GetRepostsByUserID(ctx, userID, after, limit+1)
+	if err != nil {
+		return nil, err
+	}
+	page := pagination.NewPage(s.cursorCodec, reposts, limit, postCursor)
+	if err := attachOriginalPosts(ctx, s.contentRepository, page.Items); err != nil {
+		return nil, err
+	}
+	return &page, nil
 }
 
-func (s *contentService) Repost(ctx context.Context, userID, originalPostID primitive.ObjectID) (*domain.Post, error) {
-	//TODO: implement
-	return nil, nil
+// canViewPost applies PostVisibility rules for a viewer: public posts are visible
+// to everyone, friends posts to the author's followers, private posts to the author only.
+// Public posts of private accounts are limited to followers as well, and posts are
+// never visible when either the viewer or the author has blocked the other.
+func canViewPost(ctx context.Context, followRepo repository.FollowRepository, userRepo repository.UserRepository, blockService BlockService, viewerID primitive.ObjectID, post *domain.Post) (bool, error) {
+	if post.UserID == viewerID {
+		return true, nil
+	}
+	blocked, err := blockService.IsBlocked(ctx, viewerID, post.UserID)
+	if err != nil || blocked {
+		return false, err
+	}
+	switch post.Visibility {
+	case domain.VisibilityPublic:
+		author, err := userRepo.GetUserByID(ctx, post.UserID)
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			return false, nil
+		}
+		if err != nil {
+			return false, err
+		}
+		return canViewAccount(ctx, followRepo, viewerID, author)
+	case domain.VisibilityFriends:
+		return followRepo.IsFollowing(ctx, viewerID, post.UserID)
+	default:
+		return false, nil
+	}
 }
 
-func (s *contentService) GetRepostsByUser(ctx context.Context, userID primitive.ObjectID, limit int) ([]domain.Post, error) {
-	//TODO: implement
-	return nil, nil
+// getViewablePost loads a post and hides it behind "post not found" when the
+// viewer is not allowed to see it.
+func getViewablePost(ctx context.Context, contentRepo repository.ContentRepository, followRepo repository.FollowRepository, userRepo repository.UserRepository, blockService BlockService, viewerID, postID primitive.ObjectID) (*domain.Post, error) {
+	post, err := contentRepo.GetPostByID(ctx, postID)
+	if err != nil {
+		return nil, fmt.Errorf("post not found")
+	}
+	canView, err := canViewPost(ctx, followRepo, userRepo, blockService, viewerID, post)
+	if err != nil {
+		return nil, err
+	}
+	if !canView {
+		return nil, fmt.Errorf("post not found")
+	}
+	return post, nil
 }
 
-func (s *contentService) CreateComment(ctx context.Context, userID, postID primitive.ObjectID, text string) (*domain.Comment, error) {
+// attachOriginalPosts embeds the original post into every repost in posts so a
+// feed can render a repost without a second round trip. Originals that have
+// since been deleted are left empty.
+func attachOriginalPosts(ctx context.Context, contentRepo repository.ContentRepository, posts []domain.Post) error {
+	var originalIDs []primitive.ObjectID
+	for _, p := range posts {
+		if p.IsRepost() {
+			originalIDs = append(originalIDs, *p.OriginalPostID)
+		}
+	}
+	if len(originalIDs) == 0 {
+		return nil
+	}
+
+	originals, err := contentRepo.GetPostsByIDs(ctx, originalIDs)
+	if err != nil {
+		return err
+	}
+	originalsByID := make(map[primitive.ObjectID]*domain.Post, len(originals))
+	for i := range originals {
+		originalsByID[originals[i].ID] = &originals[i]
+	}
+
+	for i := range posts {
+		if posts[i].IsRepost() {
+			posts[i].OriginalPost = originalsByID[*posts[i].OriginalPostID]
+		}
+	}
+	return nil
+}
+
+// CreateComment adds a comment to a post, or a reply to an existing comment when
+// parentID is set. Replies nested deeper than domain.MaxCommentDepth are attached
+// to the parent's own parent instead, so threads stay readable on small screens.
+//
+// Parameters:
+//   - ctx: Context for the operation
+//   - userID: ID of the commenting user
+//   - postID: ID of the post being commented on
+//   - text: Comment text
+//   - parentID: ID of the comment being replied to, or nil for a top-level comment
+//
+// Returns:
+//   - *domain.Comment: The created comment
+//   - error: Any error that occurred during creation
+func (s *contentService) CreateComment(ctx context.Context, userID, postID primitive.ObjectID, text string, parentID *primitive.ObjectID) (*domain.Comment, error) {
+	post, err := getViewablePost(ctx, s.contentRepository, s.followRepository, s.userRepository, s.blockService, userID, postID)
+	if err != nil {
+		return nil, err
+	}
+
 	comment := &domain.Comment{
 		ID:        primitive.NewObjectID(),
 		UserID:    userID,
@@ -241,19 +488,211 @@ func (s *contentService) CreateComment(ctx context.Context, userID, postID primi
 		Text:      text,
 		CreatedAt: time.Now(),
 	}
-	err := s.contentRepository.CreateComment(ctx, comment)
-	return comment, err
+
+	var parent *domain.Comment
+	if parentID != nil {
+		parent, err = s.contentRepository.GetCommentByID(ctx, *parentID)
+		if err != nil || parent.PostID != postID {
+			return nil, fmt.Errorf("parent comment not found")
+		}
+		// A tombstone only keeps its existing replies threaded
+		if parent.Deleted {
+			return nil, fmt.Errorf("cannot reply to a deleted comment")
+		}
+		blocked, err := s.blockService.IsBlocked(ctx, userID, parent.UserID)
+		if err != nil {
+			return nil, err
+		}
+		if blocked {
+			return nil, fmt.Errorf("parent comment not found")
+		}
+		if parent.Depth >= domain.MaxCommentDepth && parent.ParentID != nil {
+			// Flatten: reply alongside the parent rather than below it
+			comment.ParentID = parent.ParentID
+			comment.Depth = parent.Depth
+		} else {
+			comment.ParentID = &parent.ID
+			comment.Depth = parent.Depth + 1
+		}
+	}
+
+	if err := s.contentRepository.CreateComment(ctx, comment); err != nil {
+		log.Error().Err(err).Msg("Failed to save comment to database")
+		return nil, fmt.Errorf("failed to create comment: %w", err)
+	}
+
+	// Publish notification events
+	if post.UserID != userID {
+		go s.notificationPublisher.Publish(domain.Notification{
+			UserID:    post.UserID, // The post author receives the notification
+			ActorID:   userID,
+			Type:      domain.NotificationTypeComment,
+			PostID:    &post.ID,
+			CommentID: &comment.ID,
+		})
+	}
+	if parent != nil && parent.UserID != userID && parent.UserID != post.UserID {
+		go s.notificationPublisher.Publish(domain.Notification{
+			UserID:    parent.UserID, // The author of the replied-to comment receives the notification
+			ActorID:   userID,
+			Type:      domain.NotificationTypeReply,
+			PostID:    &post.ID,
+			CommentID: &comment.ID,
+		})
+	}
+
+	return comment, nil
 }
 
-func (s *contentService) GetComments(ctx context.Context, postID primitive.ObjectID, page, limit int) ([]domain.Comment, error) {
-	return s.contentRepository.GetCommentsByPostID(ctx, postID, page, limit)
+func (s *contentService) GetComments(ctx context.Context, viewerID, postID primitive.ObjectID, cursor string, limit int) (*pagination.Page[domain.Comment], error) {
+	after, err := s.cursorCodec.Decode(cursor)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := getViewablePost(ctx, s.contentRepository, s.followRepository, s.userRepository, s.blockService, viewerID, postID); err != nil {
+		return nil, err
+	}
+	comments, err := s.contentRepository.GetCommentsByPostID(ctx, postID, after, limit+1)
+	if err != nil {
+		return nil, err
+	}
+	page := pagination.NewPage(s.cursorCodec, comments, limit, commentCursor)
+	if page.Items, err = s.withoutHiddenComments(ctx, viewerID, page.Items); err != nil {
+		return nil, err
+	}
+	return &page, nil
 }
 
-func (s *contentService) RecordView(ctx context.Context, postID primitive.ObjectID) error {
-	//TODO: implement
+func (s *contentService) GetReplies(ctx context.Context, viewerID, commentID primitive.ObjectID, cursor string, limit int) (*pagination.Page[domain.Comment], error) {
+	after, err := s.cursorCodec.Decode(cursor)
+	if err != nil {
+		return nil, err
+	}
+	comment, err := s.contentRepository.GetCommentByID(ctx, commentID)
+	if err != nil {
+		return nil, fmt.Errorf("comment not found")
+	}
+	if _, err := getViewablePost(ctx, s.contentRepository, s.followRepository, s.userRepository, s.blockService, viewerID, comment.PostID); err != nil {
+		return nil, err
+	}
+	replies, err := s.contentRepository.GetReplies(ctx, commentID, after, limit+1)
+	if err != nil {
+		return nil, err
+	}
+	page := pagination.NewPage(s.cursorCodec, replies, limit, commentCursor)
+	if page.Items, err = s.withoutHiddenComments(ctx, viewerID, page.Items); err != nil {
+		return nil, err
+	}
+	return &page, nil
+}
+
+// withoutHiddenComments drops comments written by users blocked or muted by the
+// viewer. It runs after the page is cut so the cursor still follows the full thread.
+func (s *contentService) withoutHiddenComments(ctx context.Context, viewerID primitive.ObjectID, comments []domain.Comment) ([]domain.Comment, error) {
+	hidden, err := s.blockService.GetHiddenUserIDs(ctx, viewerID)
+	if err != nil || len(hidden) == 0 {
+		return comments, err
+	}
+	visible := comments[:0]
+	for _, c := range comments {
+		if !hidden[c.UserID] {
+			visible = append(visible, c)
+		}
+	}
+	return visible, nil
+}
+
+// EditComment replaces the text of a comment owned by userID. The previous text
+// is appended to the comment's edit history so edits stay visible to readers.
+func (s *contentService) EditComment(ctx context.Context, userID, commentID primitive.ObjectID, text string) (*domain.Comment, error) {
+	comment, err := s.contentRepository.GetCommentByID(ctx, commentID)
+	if err != nil || comment.Deleted {
+		return nil, fmt.Errorf("comment not found")
+	}
+	if comment.UserID != userID {
+		return nil, fmt.Errorf("unauthorized: you can only edit your own comments")
+	}
+	if comment.Text == text {
+		return comment, nil
+	}
+
+	previous := domain.CommentEdit{Text: comment.Text, ReplacedAt: time.Now()}
+	if err := s.contentRepository.UpdateCommentText(ctx, commentID, text, previous); err != nil {
+		return nil, fmt.Errorf("failed to edit comment: %w", err)
+	}
+
+	comment.Text = text
+	comment.EditedAt = &previous.ReplacedAt
+	comment.EditHistory = append(comment.EditHistory, previous)
+	return comment, nil
+}
+
+// DeleteComment deletes a comment. Besides the comment's author, the author of the
+// post may remove comments from their own post.
+func (s *contentService) DeleteComment(ctx context.Context, userID, commentID primitive.ObjectID) error {
+	comment, err := s.contentRepository.GetCommentByID(ctx, commentID)
+	if err != nil || comment.Deleted {
+		return fmt.Errorf("comment not found")
+	}
+	if comment.UserID != userID {
+		post, err := s.contentRepository.GetPostByID(ctx, comment.PostID)
+		if err != nil || post.UserID != userID {
+			return fmt.Errorf("unauthorized: you can only delete your own comments")
+		}
+	}
+
+	if _, err := s.contentRepository.DeleteComment(ctx, comment); err != nil {
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			return fmt.Errorf("comment not found")
+		}
+		return fmt.Errorf("failed to delete comment: %w", err)
+	}
 	return nil
 }
 
+// RecordView records a view on a post. The first view from a viewer inside the
+// dedup window increments a Redis hash, once the post is confirmed to exist and
+// be visible to the viewer; repeat views never reach MongoDB. CronService
+// periodically flushes that hash to the posts collection in one bulk $inc.
+//
+// Parameters:
+//   - ctx: Context for the operation
+//   - postID: ID of the viewed post
+//   - viewerID: ID of the authenticated viewer, or nil for anonymous viewers
+//   - clientIP: IP address of the viewer, used for anonymous dedup
+//
+// Returns:
+//   - error: Any error that occurred while recording the view
+func (s *contentService) RecordView(ctx context.Context, postID primitive.ObjectID, viewerID *primitive.ObjectID, clientIP string) error {
+	var viewerKey string
+	viewer := primitive.NilObjectID // Anonymous viewers only see what everyone can see
+	if viewerID != nil {
+		viewerKey = "u:" + viewerID.Hex()
+		viewer = *viewerID
+	} else {
+		// Anonymous viewers are told apart by IP alone: anything the client sends
+		// itself could be changed on every request to count another view. The IP
+		// is hashed so raw IPs are never written to Redis.
+		sum := sha256.Sum256([]byte(clientIP))
+		viewerKey = "a:" + hex.EncodeToString(sum[:16])
+	}
+
+	dedupKey := fmt.Sprintf("view:%s:%s", postID.Hex(), viewerKey)
+	firstView, err := s.cache.SetNX(ctx, dedupKey, 1, s.config.ViewDedupWindow)
+	if err != nil {
+		return fmt.Errorf("failed to check view dedup: %w", err)
+	}
+	if !firstView {
+		return nil
+	}
+
+	// Only posts the viewer can see get into the buffer, so made-up IDs can't grow it
+	if _, err := getViewablePost(ctx, s.contentRepository, s.followRepository, s.userRepository, s.blockService, viewer, postID); err != nil {
+		return err
+	}
+	return s.cache.HIncrBy(ctx, viewBufferKey, postID.Hex(), 1)
+}
+
 func (s *contentService) validateFile(file *multipart.FileHeader) error {
 	// 50MB limit
 	if file.Size > 50*1024*1024 {
@@ -2,9 +2,13 @@ package service
 
 import (
 	"context"
+	"fmt"
+	"strconv"
 	"strings"
+	"time"
 	"vybes/internal/config"
 	"vybes/internal/repository"
+	"vybes/pkg/cache"
 	"vybes/pkg/storage"
 
 	"github.com/robfig/cron/v3"
@@ -14,17 +18,25 @@ import (
 
 // CronService manages scheduled tasks.
 type CronService struct {
-	cfg       *config.Config
-	storyRepo repository.StoryRepository
-	storage   storage.Client
+	cfg         *config.Config
+	storyRepo   repository.StoryRepository
+	contentRepo repository.ContentRepository
+	storage     storage.Client
+	cache       cache.Client
+	jobService  JobService
+	walletTx    WalletTransactionService
 }
 
 // NewCronService creates a new cron service.
-func NewCronService(cfg *config.Config, storyRepo repository.StoryRepository, storage storage.Client) *CronService {
+func NewCronService(cfg *config.Config, storyRepo repository.StoryRepository, contentRepo repository.ContentRepository, storage storage.Client, cache cache.Client, jobService JobService, walletTx WalletTransactionService) *CronService {
 	return &CronService{
-		cfg:       cfg,
-		storyRepo: storyRepo,
-		storage:   storage,
+		cfg:         cfg,
+		storyRepo:   storyRepo,
+		contentRepo: contentRepo,
+		storage:     storage,
+		cache:       cache,
+		jobService:  jobService,
+		walletTx:    walletTx,
 	}
 }
 
@@ -35,6 +47,15 @@ func (s *CronService) Start() {
 	// Schedule a job to run every hour to clean up expired stories.
 	c.AddFunc("@hourly", s.cleanupExpiredStories)
 
+	// Schedule a job to flush buffered view counts to MongoDB.
+	c.AddFunc(fmt.Sprintf("@every %s", s.cfg.ViewFlushInterval), s.flushViewCounts)
+
+	// Schedule a job to process durable background jobs such as post cleanup.
+	c.AddFunc(fmt.Sprintf("@every %s", s.cfg.JobPollInterval), s.runPendingJobs)
+
+	// Schedule a job to track sent wallet transactions until they are mined or dropped.
+	c.AddFunc(fmt.Sprintf("@every %s", s.cfg.WalletTxPollInterval), s.checkWalletTransactions)
+
 	log.Info().Msg("Starting cron jobs...")
 	c.Start()
 }
@@ -86,3 +107,113 @@ func (s *CronService) cleanupExpiredStories() {
 
 	log.Info().Int("count", len(storyIDsToDelete)).Msg("Successfully cleaned up expired stories.")
 }
+
+// staleViewFlushAge is how old a claimed view buffer must be before another
+// run takes it over from a flush that failed or crashed
+const staleViewFlushAge = 10 * time.Minute
+
+// flushViewCounts moves the Redis view buffer aside and applies it to MongoDB
+// as a single bulk $inc. Renaming first means views recorded during the flush
+// land in a fresh buffer and are picked up by the next run.
+func (s *CronService) flushViewCounts() {
+	ctx := context.Background()
+
+	s.recoverViewBuffers(ctx)
+
+	exists, err := s.cache.Exists(ctx, viewBufferKey)
+	if err != nil {
+		log.Error().Err(err).Msg("Failed to check view count buffer")
+		return
+	}
+	if !exists {
+		return
+	}
+
+	flushKey := viewFlushKey(time.Now())
+	if err := s.cache.Rename(ctx, viewBufferKey, flushKey); err != nil {
+		// Another instance may have claimed the buffer first.
+		log.Warn().Err(err).Msg("Failed to claim view count buffer")
+		return
+	}
+	s.flushViewBuffer(ctx, flushKey)
+}
+
+// recoverViewBuffers flushes buffers left behind by runs that couldn't read
+// or delete them. Each is claimed again under a fresh name first, so only one
+// instance takes it over. A buffer whose run died after writing its counts
+// but before deleting it is counted twice; that window is a single round trip.
+func (s *CronService) recoverViewBuffers(ctx context.Context) {
+	prefix := viewBufferKey + ":flushing:"
+	keys, err := s.cache.Scan(ctx, prefix+"*")
+	if err != nil {
+		log.Error().Err(err).Msg("Failed to look for leftover view count buffers")
+		return
+	}
+	for _, key := range keys {
+		claimedAt, err := strconv.ParseInt(strings.TrimPrefix(key, prefix), 10, 64)
+		if err != nil || time.Since(time.Unix(0, claimedAt)) < staleViewFlushAge {
+			// Possibly still being flushed by another run
+			continue
+		}
+		flushKey := viewFlushKey(time.Now())
+		if err := s.cache.Rename(ctx, key, flushKey); err != nil {
+			continue
+		}
+		log.Warn().Str("key", key).Msg("Recovering leftover view count buffer")
+		s.flushViewBuffer(ctx, flushKey)
+	}
+}
+
+// flushViewBuffer applies a claimed view buffer to MongoDB and deletes it.
+// Counts that couldn't be applied go back to the live buffer. A buffer that
+// can't be read is left for recoverViewBuffers.
+func (s *CronService) flushViewBuffer(ctx context.Context, flushKey string) {
+	buffered, err := s.cache.HGetAll(ctx, flushKey)
+	if err != nil {
+		log.Error().Err(err).Str("key", flushKey).Msg("Failed to read view count buffer")
+		return
+	}
+
+	counts := make(map[primitive.ObjectID]int64, len(buffered))
+	for postIDHex, countStr := range buffered {
+		postID, err := primitive.ObjectIDFromHex(postIDHex)
+		if err != nil {
+			continue
+		}
+		count, err := strconv.ParseInt(countStr, 10, 64)
+		if err != nil || count <= 0 {
+			continue
+		}
+		counts[postID] = count
+	}
+
+	failed, err := s.contentRepo.IncrementViewCounts(ctx, counts)
+	if err != nil {
+		log.Error().Err(err).Int("failed", len(failed)).Msg("Failed to flush some view counts, returning them to the buffer")
+		// Put back only the counts that weren't applied so the next run retries them.
+		for _, postID := range failed {
+			if err := s.cache.HIncrBy(ctx, viewBufferKey, postID.Hex(), counts[postID]); err != nil {
+				log.Error().Err(err).Str("post_id", postID.Hex()).Msg("Failed to return view count to buffer")
+			}
+		}
+	}
+
+	if err := s.cache.Del(ctx, flushKey); err != nil {
+		log.Error().Err(err).Str("key", flushKey).Msg("Failed to delete flushed view count buffer")
+	}
+
+	log.Info().Int("posts", len(counts)-len(failed)).Msg("Flushed buffered view counts.")
+}
+
+// viewFlushKey names a claimed view buffer after the time it was claimed.
+func viewFlushKey(claimedAt time.Time) string {
+	return fmt.Sprintf("%s:flushing:%d", viewBufferKey, claimedAt.UnixNano())
+}
+
+func (s *CronService) runPendingJobs() {
+	s.jobService.RunPendingJobs(context.Background())
+}
+
+func (s *CronService) checkWalletTransactions() {
+	s.walletTx.CheckPendingTransactions(context.Background())
+}
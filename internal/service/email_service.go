@@ -10,6 +10,7 @@ import (
 // EmailService defines the interface for sending emails.
 type EmailService interface {
 	SendOTPEmail(to, otp string) error
+	SendVerificationEmail(to, code string) error
 }
 
 type resendEmailService struct {
@@ -44,3 +45,19 @@ func (s *resendEmailService) SendOTPEmail(to, otp string) error {
 	}
 	return nil
 }
+
+// SendVerificationEmail sends the code that confirms the user owns their email address.
+func (s *resendEmailService) SendVerificationEmail(to, code string) error {
+	subject := "Verify your Vybes email"
+	htmlBody := fmt.Sprintf("<h1>Welcome to Vybes</h1><p>Your email verification code is: <b>%s</b></p><p>It expires in 30 minutes.</p>", code)
+
+	params := &resend.SendEmailRequest{
+		From:    s.senderEmail,
+		To:      []string{to},
+		Subject: subject,
+		Html:    htmlBody,
+	}
+
+	_, err := s.client.Emails.Send(params)
+	return err
+}
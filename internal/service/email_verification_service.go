@@ -0,0 +1,155 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+	"vybes/internal/domain"
+	"vybes/internal/repository"
+	"vybes/pkg/cache"
+	"vybes/pkg/utils"
+
+	"github.com/rs/zerolog/log"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+const (
+	emailVerificationCodeLength = 6
+	emailVerificationCodeTTL    = 30 * time.Minute
+	// emailVerificationMaxAttempts wrong codes invalidate the pending code
+	emailVerificationMaxAttempts = 5
+
+	// A new code can be requested once per cooldown, and at most
+	// emailVerificationMaxSends times per emailVerificationSendWindow
+	emailVerificationCooldown   = time.Minute
+	emailVerificationMaxSends   = 5
+	emailVerificationSendWindow = time.Hour
+)
+
+var (
+	// ErrInvalidVerificationCode is returned for wrong, expired or exhausted verification codes.
+	ErrInvalidVerificationCode = errors.New("invalid or expired verification code")
+	// ErrVerificationThrottled is returned when verification emails are requested too often.
+	ErrVerificationThrottled = errors.New("too many verification emails requested, try again later")
+)
+
+// EmailVerificationService defines the interface for confirming that users own their email address.
+type EmailVerificationService interface {
+	// SendCode emails a new verification code to the user
+	SendCode(ctx context.Context, user *domain.User) error
+	// Resend sends a new code to an unverified account. Unknown and already
+	// verified addresses are ignored, so the endpoint doesn't reveal accounts.
+	Resend(ctx context.Context, email string) error
+	Verify(ctx context.Context, email, code string) error
+	IsEmailVerified(ctx context.Context, userID primitive.ObjectID) (bool, error)
+}
+
+type emailVerificationService struct {
+	userRepo     repository.UserRepository
+	emailService EmailService
+	cache        cache.Client
+}
+
+// NewEmailVerificationService creates a new email verification service.
+func NewEmailVerificationService(userRepo repository.UserRepository, emailService EmailService, cache cache.Client) EmailVerificationService {
+	return &emailVerificationService{
+		userRepo:     userRepo,
+		emailService: emailService,
+		cache:        cache,
+	}
+}
+
+func (s *emailVerificationService) SendCode(ctx context.Context, user *domain.User) error {
+	code, err := utils.GenerateOTP(emailVerificationCodeLength)
+	if err != nil {
+		return errors.New("could not generate verification code")
+	}
+	if err := s.userRepo.SetEmailVerificationCode(ctx, user.ID, utils.HashToken(code), time.Now().Add(emailVerificationCodeTTL)); err != nil {
+		return err
+	}
+	if err := s.cache.Del(ctx, emailVerificationAttemptsKey(user.ID)); err != nil {
+		log.Warn().Err(err).Msg("Failed to reset email verification attempts")
+	}
+	return s.emailService.SendVerificationEmail(user.Email, code)
+}
+
+func (s *emailVerificationService) Resend(ctx context.Context, email string) error {
+	user, err := s.userRepo.GetUserByEmail(ctx, email)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	if user.EmailVerified {
+		return nil
+	}
+
+	allowed, err := s.cache.SetNX(ctx, fmt.Sprintf("email:verify:cooldown:%s", user.ID.Hex()), 1, emailVerificationCooldown)
+	if err != nil {
+		return err
+	}
+	if !allowed {
+		return ErrVerificationThrottled
+	}
+	sends, err := s.cache.Incr(ctx, fmt.Sprintf("email:verify:sends:%s", user.ID.Hex()), emailVerificationSendWindow)
+	if err != nil {
+		return err
+	}
+	if sends > emailVerificationMaxSends {
+		return ErrVerificationThrottled
+	}
+	return s.SendCode(ctx, user)
+}
+
+func (s *emailVerificationService) Verify(ctx context.Context, email, code string) error {
+	user, err := s.userRepo.GetUserByEmail(ctx, email)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return ErrInvalidVerificationCode
+	}
+	if err != nil {
+		return err
+	}
+	if user.EmailVerified {
+		return nil
+	}
+
+	// Count attempts before checking, so concurrent guesses can't exceed the limit
+	attempts, err := s.cache.Incr(ctx, emailVerificationAttemptsKey(user.ID), emailVerificationCodeTTL)
+	if err != nil {
+		return err
+	}
+	if attempts > emailVerificationMaxAttempts {
+		if err := s.userRepo.ClearEmailVerificationCode(ctx, user.ID); err != nil {
+			return err
+		}
+		return ErrInvalidVerificationCode
+	}
+
+	verified, err := s.userRepo.VerifyEmail(ctx, user.ID, utils.HashToken(strings.TrimSpace(code)))
+	if err != nil {
+		return err
+	}
+	if !verified {
+		return ErrInvalidVerificationCode
+	}
+	if err := s.cache.Del(ctx, emailVerificationAttemptsKey(user.ID)); err != nil {
+		log.Warn().Err(err).Msg("Failed to reset email verification attempts")
+	}
+	return nil
+}
+
+func (s *emailVerificationService) IsEmailVerified(ctx context.Context, userID primitive.ObjectID) (bool, error) {
+	user, err := s.userRepo.GetUserByID(ctx, userID)
+	if err != nil {
+		return false, err
+	}
+	return user.EmailVerified, nil
+}
+
+func emailVerificationAttemptsKey(userID primitive.ObjectID) string {
+	return fmt.Sprintf("email:verify:attempts:%s", userID.Hex())
+}
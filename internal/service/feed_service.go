@@ -2,91 +2,341 @@ package service
 
 import (
 	"context"
-	"sort"
+	"fmt"
+	"strings"
+	"time"
 	"vybes/internal/domain"
+	"vybes/internal/ranking"
 	"vybes/internal/repository"
+	"vybes/pkg/cache"
+	"vybes/pkg/pagination"
 
+	"github.com/rs/zerolog/log"
 	"go.mongodb.org/mongo-driver/bson/primitive"
 )
 
 // FeedService defines the interface for feed generation logic.
 type FeedService interface {
-	GetForYouFeed(ctx context.Context, userID string, limit int) ([]domain.Post, error)
-	GetFriendFeed(ctx context.Context, userID string, limit int) ([]domain.Post, error)
+	GetForYouFeed(ctx context.Context, userID, cursor string, limit int) (*pagination.Page[domain.Post], error)
+	GetFriendFeed(ctx context.Context, userID, cursor string, limit int) (*pagination.Page[domain.Post], error)
+	GetFollowingFeed(ctx context.Context, userID, cursor string, limit int) (*pagination.Page[domain.Post], error)
 }
 
+// Candidate generation limits for the "For You" feed
+const (
+	forYouFollowingLimit      = 300                 // Newest posts taken from the home timeline
+	forYouFriendOfFriendUsers = 50                  // Second-degree accounts considered
+	forYouFriendOfFriendLimit = 150                 // Newest posts taken from those accounts
+	forYouTrendingLimit       = 100                 // Trending public posts considered
+	forYouTrendingWindow      = 72 * time.Hour      // How far back trending posts are looked for
+	forYouAffinityWindow      = 30 * 24 * time.Hour // How far back likes count towards author affinity
+	forYouAffinityLikes       = 500                 // Most recent likes used to estimate author affinity
+	forYouSnapshotTTL         = 30 * time.Minute    // How long a ranked feed stays pageable without re-ranking
+)
+
 type feedService struct {
-	contentRepo repository.ContentRepository
-	followRepo  repository.FollowRepository
+	contentRepo     repository.ContentRepository
+	followRepo      repository.FollowRepository
+	reactionRepo    repository.ReactionRepository
+	userRepo        repository.UserRepository
+	timelineService TimelineService
+	blockService    BlockService
+	cache           cache.Client
+	ranker          *ranking.Ranker
+	cursorCodec     *pagination.Codec
 }
 
-// NewFeedService creates a new feed service.
-func NewFeedService(contentRepo repository.ContentRepository, followRepo repository.FollowRepository) FeedService {
+// NewFeedService creates a new feed service. The scorer decides how "For You"
+// candidates are ranked; pass ranking.NewWeightedScorer(ranking.DefaultWeights)
+// for the default model.
+func NewFeedService(contentRepo repository.ContentRepository, followRepo repository.FollowRepository, reactionRepo repository.ReactionRepository, userRepo repository.UserRepository, timelineService TimelineService, blockService BlockService, cache cache.Client, scorer ranking.Scorer, cursorCodec *pagination.Codec) FeedService {
 	return &feedService{
-		contentRepo: contentRepo,
-		followRepo:  followRepo,
+		contentRepo:     contentRepo,
+		followRepo:      followRepo,
+		reactionRepo:    reactionRepo,
+		userRepo:        userRepo,
+		timelineService: timelineService,
+		blockService:    blockService,
+		cache:           cache,
+		ranker:          ranking.NewRanker(scorer),
+		cursorCodec:     cursorCodec,
 	}
 }
 
-// GetForYouFeed fetches posts for the "For You" feed.
-// This includes:
-// 1. Posts from users the current user follows (visibility: public, friends).
-// 2. The current user's own posts (visibility: public, friends, private).
-// The results are combined, sorted by creation date, and limited.
-func (s *feedService) GetForYouFeed(ctx context.Context, userIDStr string, limit int) ([]domain.Post, error) {
+// GetForYouFeed returns a page of the ranked "For You" feed.
+//
+// The first request generates candidates from followed accounts, friends of
+// friends and trending public posts, ranks them and stores the ranked order in
+// Redis as a snapshot. The cursor records the snapshot time and the last post
+// served, so later pages continue the same order even while likes and new posts
+// arrive. If the snapshot has expired it is rebuilt from posts created up to
+// the snapshot time.
+func (s *feedService) GetForYouFeed(ctx context.Context, userIDStr, cursor string, limit int) (*pagination.Page[domain.Post], error) {
 	userID, err := primitive.ObjectIDFromHex(userIDStr)
 	if err != nil {
 		return nil, err
 	}
+	after, err := s.cursorCodec.Decode(cursor)
+	if err != nil {
+		return nil, err
+	}
+
+	asOf := time.Now().UTC().Truncate(time.Millisecond)
+	if after != nil {
+		asOf = after.CreatedAt
+	}
+
+	ranked, err := s.getForYouSnapshot(ctx, userID, asOf)
+	if err != nil {
+		return nil, err
+	}
+
+	// Continue right after the last post served
+	start := 0
+	if after != nil {
+		start = len(ranked)
+		for i, id := range ranked {
+			if id == after.ID {
+				start = i + 1
+				break
+			}
+		}
+	}
+	end := min(start+limit, len(ranked))
+	pageIDs := ranked[start:end]
+
+	page := &pagination.Page[domain.Post]{Items: []domain.Post{}}
+	if end < len(ranked) && len(pageIDs) > 0 {
+		page.NextCursor = s.cursorCodec.Encode(pagination.Cursor{CreatedAt: asOf, ID: pageIDs[len(pageIDs)-1]})
+	}
+	if len(pageIDs) == 0 {
+		return page, nil
+	}
+
+	// Load fresh copies so counters are current; posts deleted since ranking are skipped
+	posts, err := s.contentRepo.GetPostsByIDs(ctx, pageIDs)
+	if err != nil {
+		return nil, err
+	}
+	postsByID := make(map[primitive.ObjectID]domain.Post, len(posts))
+	for _, p := range posts {
+		postsByID[p.ID] = p
+	}
+	for _, id := range pageIDs {
+		if p, ok := postsByID[id]; ok {
+			page.Items = append(page.Items, p)
+		}
+	}
+
+	// Embed originals so reposts render in place
+	if err := attachOriginalPosts(ctx, s.contentRepo, page.Items); err != nil {
+		return nil, err
+	}
+	// Re-check blocks and mutes, which may have changed since the snapshot was ranked
+	hidden, err := s.blockService.GetHiddenUserIDs(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	page.Items = withoutHiddenAuthors(page.Items, hidden)
+	return page, nil
+}
+
+// getForYouSnapshot returns the ranked post IDs for a viewer as of a point in
+// time, from Redis when available and otherwise by ranking from scratch.
+func (s *feedService) getForYouSnapshot(ctx context.Context, userID primitive.ObjectID, asOf time.Time) ([]primitive.ObjectID, error) {
+	key := fmt.Sprintf("feed:foryou:%s:%d", userID.Hex(), asOf.UnixMilli())
+	if cached, err := s.cache.Get(ctx, key); err == nil {
+		return decodeObjectIDs(cached), nil
+	}
+
+	candidates, err := s.generateCandidates(ctx, userID, asOf)
+	if err != nil {
+		return nil, err
+	}
+	ranked := s.ranker.Rank(candidates, asOf)
+
+	ids := make([]primitive.ObjectID, len(ranked))
+	for i, c := range ranked {
+		ids[i] = c.Post.ID
+	}
+	if err := s.cache.Set(ctx, key, encodeObjectIDs(ids), forYouSnapshotTTL); err != nil {
+		// The feed still works without a snapshot; later pages just rebuild it
+		log.Error().Err(err).Msg("Failed to store For You snapshot")
+	}
+	return ids, nil
+}
+
+// generateCandidates collects posts created up to asOf that the viewer may see:
+// posts from followed accounts, public posts from friends of friends and
+// trending public posts. Each candidate carries the viewer's affinity for its author.
+func (s *feedService) generateCandidates(ctx context.Context, userID primitive.ObjectID, asOf time.Time) ([]ranking.Candidate, error) {
+	// A cursor with a zero ID just past asOf matches everything created up to and including asOf
+	upTo := &pagination.Cursor{CreatedAt: asOf.Add(time.Millisecond)}
 
 	followingIDs, err := s.followRepo.GetFollowingIDs(ctx, userID)
 	if err != nil {
 		return nil, err
 	}
+	followingSet := make(map[primitive.ObjectID]bool, len(followingIDs))
+	for _, id := range followingIDs {
+		followingSet[id] = true
+	}
+	hidden, err := s.blockService.GetHiddenUserIDs(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	var candidates []ranking.Candidate
+	add := func(posts []domain.Post, source ranking.Source) {
+		for _, p := range posts {
+			if p.UserID == userID || hidden[p.UserID] {
+				continue
+			}
+			candidates = append(candidates, ranking.Candidate{Post: p, Source: source})
+		}
+	}
+
+	// 1. Followed accounts, read from the precomputed home timeline
+	posts, err := s.timelineService.GetTimeline(ctx, userID, upTo, forYouFollowingLimit)
+	if err != nil {
+		return nil, err
+	}
+	add(posts, ranking.SourceFollowing)
+
+	// 2. Friends of friends (public posts only)
+	fofIDs, err := s.followRepo.GetFriendsOfFriends(ctx, userID, followingIDs, forYouFriendOfFriendUsers)
+	if err != nil {
+		return nil, err
+	}
+	if len(fofIDs) > 0 {
+		posts, err := s.contentRepo.GetPostsByUsersWithVisibility(ctx, fofIDs,
+			[]domain.PostVisibility{domain.VisibilityPublic}, upTo, forYouFriendOfFriendLimit)
+		if err != nil {
+			return nil, err
+		}
+		add(posts, ranking.SourceFriendOfFriend)
+	}
+
+	// 3. Trending public posts
+	trending, err := s.contentRepo.GetTrendingPosts(ctx, asOf.Add(-forYouTrendingWindow), asOf, forYouTrendingLimit)
+	if err != nil {
+		return nil, err
+	}
+	add(trending, ranking.SourceTrending)
 
-	// 1. Fetch posts from followed users (public and friends-only)
-	followedPosts, err := s.contentRepo.GetPostsByUsersWithVisibility(
-		ctx,
-		followingIDs,
-		[]domain.PostVisibility{domain.VisibilityPublic, domain.VisibilityFriends},
-		limit, // We fetch `limit` for each part, then sort and re-limit. Not perfectly efficient but works.
-	)
+	candidates, err = s.withoutPrivateStrangers(ctx, candidates, followingSet)
 	if err != nil {
 		return nil, err
 	}
 
-	// 2. Fetch user's own posts (all visibilities)
-	myPosts, err := s.contentRepo.GetPostsByUsersWithVisibility(
-		ctx,
-		[]primitive.ObjectID{userID},
-		[]domain.PostVisibility{domain.VisibilityPublic, domain.VisibilityFriends, domain.VisibilityPrivate},
-		limit,
-	)
+	// 4. Author affinity
+	affinity, err := s.authorAffinity(ctx, userID, asOf, followingSet)
 	if err != nil {
 		return nil, err
 	}
+	for i := range candidates {
+		candidates[i].Affinity = affinity(candidates[i].Post.UserID)
+	}
+	return candidates, nil
+}
 
-	// 3. Combine and sort
-	combinedPosts := append(followedPosts, myPosts...)
-	sort.Slice(combinedPosts, func(i, j int) bool {
-		return combinedPosts[i].CreatedAt.After(combinedPosts[j].CreatedAt)
-	})
+// withoutPrivateStrangers drops candidates by private accounts the viewer
+// doesn't follow. Their public posts are still limited to approved followers.
+func (s *feedService) withoutPrivateStrangers(ctx context.Context, candidates []ranking.Candidate, followingSet map[primitive.ObjectID]bool) ([]ranking.Candidate, error) {
+	seen := make(map[primitive.ObjectID]bool)
+	var strangerIDs []primitive.ObjectID
+	for _, c := range candidates {
+		if !followingSet[c.Post.UserID] && !seen[c.Post.UserID] {
+			seen[c.Post.UserID] = true
+			strangerIDs = append(strangerIDs, c.Post.UserID)
+		}
+	}
+	if len(strangerIDs) == 0 {
+		return candidates, nil
+	}
+	strangers, err := s.userRepo.GetUsersByIDs(ctx, strangerIDs)
+	if err != nil {
+		return nil, err
+	}
+	private := make(map[primitive.ObjectID]bool)
+	for _, u := range strangers {
+		if u.IsPrivate {
+			private[u.ID] = true
+		}
+	}
+	if len(private) == 0 {
+		return candidates, nil
+	}
+	visible := candidates[:0]
+	for _, c := range candidates {
+		if !private[c.Post.UserID] {
+			visible = append(visible, c)
+		}
+	}
+	return visible, nil
+}
 
-	// 4. Apply limit
-	if len(combinedPosts) > limit {
-		return combinedPosts[:limit], nil
+// authorAffinity estimates how much the viewer cares about each author. Following
+// an author is a baseline; every recent like on the author's posts adds to it.
+func (s *feedService) authorAffinity(ctx context.Context, userID primitive.ObjectID, asOf time.Time, followingSet map[primitive.ObjectID]bool) (func(primitive.ObjectID) float64, error) {
+	likedIDs, err := s.reactionRepo.GetLikedPostIDs(ctx, userID, asOf.Add(-forYouAffinityWindow), forYouAffinityLikes)
+	if err != nil {
+		return nil, err
+	}
+	likesByAuthor := make(map[primitive.ObjectID]int)
+	if len(likedIDs) > 0 {
+		liked, err := s.contentRepo.GetPostsByIDs(ctx, likedIDs)
+		if err != nil {
+			return nil, err
+		}
+		for _, p := range liked {
+			likesByAuthor[p.UserID]++
+		}
 	}
 
-	return combinedPosts, nil
+	return func(authorID primitive.ObjectID) float64 {
+		affinity := 0.0
+		if followingSet[authorID] {
+			affinity = 0.4
+		}
+		// Ten recent likes on an author's posts saturate the signal
+		return affinity + float64(likesByAuthor[authorID])*0.06
+	}, nil
+}
+
+func encodeObjectIDs(ids []primitive.ObjectID) string {
+	hexIDs := make([]string, len(ids))
+	for i, id := range ids {
+		hexIDs[i] = id.Hex()
+	}
+	return strings.Join(hexIDs, ",")
+}
+
+func decodeObjectIDs(s string) []primitive.ObjectID {
+	if s == "" {
+		return nil
+	}
+	parts := strings.Split(s, ",")
+	ids := make([]primitive.ObjectID, 0, len(parts))
+	for _, part := range parts {
+		if id, err := primitive.ObjectIDFromHex(part); err == nil {
+			ids = append(ids, id)
+		}
+	}
+	return ids
 }
 
 // GetFriendFeed implements a feed of posts only from mutual follows (friends).
 // It should only show posts with 'public' or 'friends' visibility.
-func (s *feedService) GetFriendFeed(ctx context.Context, userIDStr string, limit int) ([]domain.Post, error) {
+func (s *feedService) GetFriendFeed(ctx context.Context, userIDStr, cursor string, limit int) (*pagination.Page[domain.Post], error) {
 	userID, err := primitive.ObjectIDFromHex(userIDStr)
 	if err != nil {
 		return nil, err
 	}
+	after, err := s.cursorCodec.Decode(cursor)
+	if err != nil {
+		return nil, err
+	}
 
 	followingIDs, err := s.followRepo.GetFollowingIDs(ctx, userID)
 	if err != nil {
@@ -102,18 +352,61 @@ func (s *feedService) GetFriendFeed(ctx context.Context, userIDStr string, limit
 		followingSet[id] = struct{}{}
 	}
 
+	hidden, err := s.blockService.GetHiddenUserIDs(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
 	var mutualIDs []primitive.ObjectID
 	for _, id := range followerIDs {
-		if _, found := followingSet[id]; found {
+		if _, found := followingSet[id]; found && !hidden[id] {
 			mutualIDs = append(mutualIDs, id)
 		}
 	}
 
 	if len(mutualIDs) == 0 {
-		return []domain.Post{}, nil
+		return &pagination.Page[domain.Post]{Items: []domain.Post{}}, nil
 	}
 
 	// Friends can see public and friends-only posts.
 	visibilities := []domain.PostVisibility{domain.VisibilityPublic, domain.VisibilityFriends}
-	return s.contentRepo.GetPostsByUsersWithVisibility(ctx, mutualIDs, visibilities, limit)
+	posts, err := s.contentRepo.GetPostsByUsersWithVisibility(ctx, mutualIDs, visibilities, after, limit+1)
+	if err != nil {
+		return nil, err
+	}
+	page := pagination.NewPage(s.cursorCodec, posts, limit, postCursor)
+	if err := attachOriginalPosts(ctx, s.contentRepo, page.Items); err != nil {
+		return nil, err
+	}
+	page.Items = withoutHiddenAuthors(page.Items, hidden)
+	return &page, nil
+}
+
+// GetFollowingFeed returns the chronological home feed: the user's own posts and
+// those of the accounts they follow, served from the precomputed Redis timeline.
+func (s *feedService) GetFollowingFeed(ctx context.Context, userIDStr, cursor string, limit int) (*pagination.Page[domain.Post], error) {
+	userID, err := primitive.ObjectIDFromHex(userIDStr)
+	if err != nil {
+		return nil, err
+	}
+	after, err := s.cursorCodec.Decode(cursor)
+	if err != nil {
+		return nil, err
+	}
+
+	posts, err := s.timelineService.GetTimeline(ctx, userID, after, limit+1)
+	if err != nil {
+		return nil, err
+	}
+	// Muted accounts stay followed, so their posts are still in the timeline
+	hidden, err := s.blockService.GetHiddenUserIDs(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	page := pagination.NewPage(s.cursorCodec, posts, limit, postCursor)
+	if err := attachOriginalPosts(ctx, s.contentRepo, page.Items); err != nil {
+		return nil, err
+	}
+	page.Items = withoutHiddenAuthors(page.Items, hidden)
+	return &page, nil
 }
@@ -3,49 +3,116 @@ package service
 import (
 	"context"
 	"errors"
+	"time"
 	"vybes/internal/domain"
 	"vybes/internal/repository"
+	"vybes/pkg/pagination"
 
+	"github.com/rs/zerolog/log"
 	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
 )
 
+var (
+	// ErrPrivateAccount is returned when a viewer asks for the content of a private
+	// account they don't follow.
+	ErrPrivateAccount = errors.New("this account is private")
+	// ErrUserNotFound is returned when a user doesn't exist or is blocked from the viewer.
+	ErrUserNotFound = errors.New("user not found")
+)
+
+// FollowListUser is a user summary in a followers or following list, annotated
+// with the viewer's relationship to that user.
+type FollowListUser struct {
+	ID            primitive.ObjectID `json:"id"`
+	VID           int64              `json:"vid"`
+	Name          string             `json:"name"`
+	Username      string             `json:"username,omitempty"`
+	PFPURL        string             `json:"pfpUrl,omitempty"`
+	IsPrivate     bool               `json:"isPrivate"`
+	ViewerFollows bool               `json:"viewerFollows"` // The viewer follows this user
+	FollowsViewer bool               `json:"followsViewer"` // This user follows the viewer
+}
+
 // FollowService defines the interface for follow business logic.
 type FollowService interface {
-	FollowUser(ctx context.Context, followerID, followingUsername string) error
+	FollowUser(ctx context.Context, followerID, followingUsername string) (domain.FollowStatus, error)
 	UnfollowUser(ctx context.Context, followerID, followingUsername string) error
+	GetFollowRequests(ctx context.Context, userID, cursor string, limit int) (*pagination.Page[domain.FollowRequest], error)
+	ApproveFollowRequest(ctx context.Context, userID, requestID string) error
+	DenyFollowRequest(ctx context.Context, userID, requestID string) error
+	GetFollowers(ctx context.Context, viewerID, username, cursor string, limit int) (*pagination.Page[FollowListUser], error)
+	GetFollowing(ctx context.Context, viewerID, username, cursor string, limit int) (*pagination.Page[FollowListUser], error)
 }
 
 type followService struct {
 	followRepo            repository.FollowRepository
 	userRepo              repository.UserRepository
 	notificationPublisher NotificationPublisher
+	timelineService       TimelineService
+	blockService          BlockService
+	cursorCodec           *pagination.Codec
 }
 
 // NewFollowService creates a new follow service.
-func NewFollowService(followRepo repository.FollowRepository, userRepo repository.UserRepository, notificationPublisher NotificationPublisher) FollowService {
+func NewFollowService(followRepo repository.FollowRepository, userRepo repository.UserRepository, notificationPublisher NotificationPublisher, timelineService TimelineService, blockService BlockService, cursorCodec *pagination.Codec) FollowService {
 	return &followService{
 		followRepo:            followRepo,
 		userRepo:              userRepo,
 		notificationPublisher: notificationPublisher,
+		timelineService:       timelineService,
+		blockService:          blockService,
+		cursorCodec:           cursorCodec,
 	}
 }
 
-func (s *followService) FollowUser(ctx context.Context, followerIDStr, followingUsername string) error {
+// canViewAccount reports whether a viewer may see the profile details and posts
+// of an account: public accounts are open to everyone, private accounts only to
+// their approved followers.
+func canViewAccount(ctx context.Context, followRepo repository.FollowRepository, viewerID primitive.ObjectID, owner *domain.User) (bool, error) {
+	if owner.ID == viewerID || !owner.IsPrivate {
+		return true, nil
+	}
+	return followRepo.IsFollowing(ctx, viewerID, owner.ID)
+}
+
+// FollowUser follows a user. Following a private account only creates a
+// request, which takes effect once the account owner approves it.
+func (s *followService) FollowUser(ctx context.Context, followerIDStr, followingUsername string) (domain.FollowStatus, error) {
 	followerID, err := primitive.ObjectIDFromHex(followerIDStr)
 	if err != nil {
-		return errors.New("invalid follower ID format")
+		return "", errors.New("invalid follower ID format")
 	}
 
 	userToFollow, err := s.userRepo.GetUserByUsername(ctx, followingUsername)
 	if err != nil {
-		return err
+		return "", err
 	}
 	if userToFollow == nil {
-		return errors.New("user to follow not found")
+		return "", errors.New("user to follow not found")
 	}
 
 	if followerID == userToFollow.ID {
-		return errors.New("cannot follow yourself")
+		return "", errors.New("cannot follow yourself")
+	}
+
+	blocked, err := s.blockService.IsBlocked(ctx, followerID, userToFollow.ID)
+	if err != nil {
+		return "", err
+	}
+	if blocked {
+		return "", errors.New("cannot follow this user")
+	}
+
+	if userToFollow.IsPrivate {
+		following, err := s.followRepo.IsFollowing(ctx, followerID, userToFollow.ID)
+		if err != nil {
+			return "", err
+		}
+		if following {
+			return domain.FollowStatusFollowing, nil
+		}
+		return domain.FollowStatusRequested, s.requestFollow(ctx, followerID, userToFollow.ID)
 	}
 
 	follow := &domain.Follow{
@@ -53,7 +120,12 @@ func (s *followService) FollowUser(ctx context.Context, followerIDStr, following
 		FollowingID: userToFollow.ID,
 	}
 	if err := s.followRepo.CreateFollow(ctx, follow); err != nil {
-		return err
+		return "", err
+	}
+
+	// The follow stands even if the timeline can't be updated; it is rebuilt when it expires
+	if err := s.timelineService.AddFollow(ctx, followerID, userToFollow.ID); err != nil {
+		log.Error().Err(err).Msg("Failed to add followed user's posts to timeline")
 	}
 
 	// Publish notification event
@@ -63,9 +135,36 @@ func (s *followService) FollowUser(ctx context.Context, followerIDStr, following
 		Type:    domain.NotificationTypeFollow,
 	})
 
+	return domain.FollowStatusFollowing, nil
+}
+
+// requestFollow records a pending follow request and notifies the account owner.
+// Repeating a pending request neither duplicates it nor notifies again.
+func (s *followService) requestFollow(ctx context.Context, requesterID, targetID primitive.ObjectID) error {
+	pending, err := s.followRepo.HasFollowRequest(ctx, requesterID, targetID)
+	if err != nil || pending {
+		return err
+	}
+
+	request := &domain.FollowRequest{
+		ID:          primitive.NewObjectID(),
+		RequesterID: requesterID,
+		TargetID:    targetID,
+		CreatedAt:   time.Now(),
+	}
+	if err := s.followRepo.CreateFollowRequest(ctx, request); err != nil {
+		return err
+	}
+
+	go s.notificationPublisher.Publish(domain.Notification{
+		UserID:  targetID, // The private account receives the request
+		ActorID: requesterID,
+		Type:    domain.NotificationTypeFollowRequest,
+	})
 	return nil
 }
 
+// UnfollowUser unfollows a user, or withdraws a pending request to follow them.
 func (s *followService) UnfollowUser(ctx context.Context, followerIDStr, followingUsername string) error {
 	followerID, err := primitive.ObjectIDFromHex(followerIDStr)
 	if err != nil {
@@ -80,5 +179,226 @@ func (s *followService) UnfollowUser(ctx context.Context, followerIDStr, followi
 		return errors.New("user to unfollow not found")
 	}
 
-	return s.followRepo.DeleteFollow(ctx, followerID, userToUnfollow.ID)
+	if err := s.followRepo.DeleteFollowRequest(ctx, followerID, userToUnfollow.ID); err != nil {
+		return err
+	}
+	if err := s.followRepo.DeleteFollow(ctx, followerID, userToUnfollow.ID); err != nil {
+		return err
+	}
+
+	if err := s.timelineService.RemoveFollow(ctx, followerID, userToUnfollow.ID); err != nil {
+		log.Error().Err(err).Msg("Failed to remove unfollowed user's posts from timeline")
+	}
+	return nil
+}
+
+// GetFollowRequests lists the pending requests to follow the user, newest first,
+// each with the requesting user embedded.
+func (s *followService) GetFollowRequests(ctx context.Context, userIDStr, cursor string, limit int) (*pagination.Page[domain.FollowRequest], error) {
+	userID, err := primitive.ObjectIDFromHex(userIDStr)
+	if err != nil {
+		return nil, err
+	}
+	after, err := s.cursorCodec.Decode(cursor)
+	if err != nil {
+		return nil, err
+	}
+	requests, err := s.followRepo.GetFollowRequests(ctx, userID, after, limit+1)
+	if err != nil {
+		return nil, err
+	}
+	page := pagination.NewPage(s.cursorCodec, requests, limit, followRequestCursor)
+	if len(page.Items) == 0 {
+		return &page, nil
+	}
+
+	requesterIDs := make([]primitive.ObjectID, len(page.Items))
+	for i, r := range page.Items {
+		requesterIDs[i] = r.RequesterID
+	}
+	users, err := s.userRepo.GetUsersByIDs(ctx, requesterIDs)
+	if err != nil {
+		return nil, err
+	}
+	usersByID := make(map[primitive.ObjectID]*domain.User, len(users))
+	for i := range users {
+		usersByID[users[i].ID] = &users[i]
+	}
+	for i := range page.Items {
+		page.Items[i].Requester = usersByID[page.Items[i].RequesterID]
+	}
+	return &page, nil
+}
+
+// ApproveFollowRequest lets the requester follow the user and tells them so.
+func (s *followService) ApproveFollowRequest(ctx context.Context, userIDStr, requestIDStr string) error {
+	request, err := s.getOwnFollowRequest(ctx, userIDStr, requestIDStr)
+	if err != nil {
+		return err
+	}
+	if err := s.followRepo.ApproveFollowRequest(ctx, request); err != nil {
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			return errors.New("follow request not found")
+		}
+		return err
+	}
+
+	if err := s.timelineService.AddFollow(ctx, request.RequesterID, request.TargetID); err != nil {
+		log.Error().Err(err).Msg("Failed to add followed user's posts to timeline")
+	}
+
+	go s.notificationPublisher.Publish(domain.Notification{
+		UserID:  request.RequesterID, // The requester learns they can now see the account
+		ActorID: request.TargetID,
+		Type:    domain.NotificationTypeFollowAccepted,
+	})
+	return nil
+}
+
+// DenyFollowRequest drops a pending request. The requester is not notified.
+func (s *followService) DenyFollowRequest(ctx context.Context, userIDStr, requestIDStr string) error {
+	request, err := s.getOwnFollowRequest(ctx, userIDStr, requestIDStr)
+	if err != nil {
+		return err
+	}
+	return s.followRepo.DeleteFollowRequest(ctx, request.RequesterID, request.TargetID)
+}
+
+// getOwnFollowRequest loads a pending request addressed to the user. Requests
+// for other accounts are reported as missing.
+func (s *followService) getOwnFollowRequest(ctx context.Context, userIDStr, requestIDStr string) (*domain.FollowRequest, error) {
+	userID, err := primitive.ObjectIDFromHex(userIDStr)
+	if err != nil {
+		return nil, errors.New("invalid user ID format")
+	}
+	requestID, err := primitive.ObjectIDFromHex(requestIDStr)
+	if err != nil {
+		return nil, errors.New("invalid request ID format")
+	}
+	request, err := s.followRepo.GetFollowRequestByID(ctx, requestID)
+	if err != nil {
+		return nil, err
+	}
+	if request == nil || request.TargetID != userID {
+		return nil, errors.New("follow request not found")
+	}
+	return request, nil
+}
+
+// GetFollowers lists the users who follow the given user, most recent first.
+// A private account's list is only shown to its approved followers.
+func (s *followService) GetFollowers(ctx context.Context, viewerIDStr, username, cursor string, limit int) (*pagination.Page[FollowListUser], error) {
+	return s.listFollows(ctx, viewerIDStr, username, cursor, limit, s.followRepo.GetFollowers,
+		func(f domain.Follow) primitive.ObjectID { return f.FollowerID })
+}
+
+// GetFollowing lists the users the given user follows, most recently followed
+// first. A private account's list is only shown to its approved followers.
+func (s *followService) GetFollowing(ctx context.Context, viewerIDStr, username, cursor string, limit int) (*pagination.Page[FollowListUser], error) {
+	return s.listFollows(ctx, viewerIDStr, username, cursor, limit, s.followRepo.GetFollowing,
+		func(f domain.Follow) primitive.ObjectID { return f.FollowingID })
+}
+
+// listFollows pages through one side of a user's follows and hydrates the users
+// on the other side. The cursor follows the follow list, so rows dropped for
+// deleted or blocked users can leave a page short.
+func (s *followService) listFollows(
+	ctx context.Context,
+	viewerIDStr, username, cursor string,
+	limit int,
+	fetch func(context.Context, primitive.ObjectID, *pagination.Cursor, int) ([]domain.Follow, error),
+	other func(domain.Follow) primitive.ObjectID,
+) (*pagination.Page[FollowListUser], error) {
+	viewerID, err := primitive.ObjectIDFromHex(viewerIDStr)
+	if err != nil {
+		return nil, errors.New("invalid viewer ID format")
+	}
+	owner, err := s.userRepo.GetUserByUsername(ctx, username)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return nil, ErrUserNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	blocked, err := s.blockService.GetBlockedUserIDs(ctx, viewerID)
+	if err != nil {
+		return nil, err
+	}
+	if blocked[owner.ID] {
+		return nil, ErrUserNotFound
+	}
+	canView, err := canViewAccount(ctx, s.followRepo, viewerID, owner)
+	if err != nil {
+		return nil, err
+	}
+	if !canView {
+		return nil, ErrPrivateAccount
+	}
+
+	after, err := s.cursorCodec.Decode(cursor)
+	if err != nil {
+		return nil, err
+	}
+	follows, err := fetch(ctx, owner.ID, after, limit+1)
+	if err != nil {
+		return nil, err
+	}
+	page := pagination.NewPage(s.cursorCodec, follows, limit, followCursor)
+	result := &pagination.Page[FollowListUser]{Items: []FollowListUser{}, NextCursor: page.NextCursor}
+
+	ids := make([]primitive.ObjectID, 0, len(page.Items))
+	for _, f := range page.Items {
+		if id := other(f); !blocked[id] {
+			ids = append(ids, id)
+		}
+	}
+	if len(ids) == 0 {
+		return result, nil
+	}
+
+	users, err := s.userRepo.GetUsersByIDs(ctx, ids)
+	if err != nil {
+		return nil, err
+	}
+	viewerFollows, err := s.followRepo.GetFollowingAmong(ctx, viewerID, ids)
+	if err != nil {
+		return nil, err
+	}
+	followsViewer, err := s.followRepo.GetFollowersAmong(ctx, viewerID, ids)
+	if err != nil {
+		return nil, err
+	}
+
+	usersByID := make(map[primitive.ObjectID]domain.User, len(users))
+	for _, u := range users {
+		usersByID[u.ID] = u
+	}
+	following := idSet(viewerFollows)
+	followers := idSet(followsViewer)
+	for _, id := range ids {
+		u, ok := usersByID[id]
+		if !ok {
+			continue
+		}
+		result.Items = append(result.Items, FollowListUser{
+			ID:            u.ID,
+			VID:           u.VID,
+			Name:          u.Name,
+			Username:      u.Username,
+			PFPURL:        u.PFPURL,
+			IsPrivate:     u.IsPrivate,
+			ViewerFollows: following[id],
+			FollowsViewer: followers[id],
+		})
+	}
+	return result, nil
+}
+
+func idSet(ids []primitive.ObjectID) map[primitive.ObjectID]bool {
+	set := make(map[primitive.ObjectID]bool, len(ids))
+	for _, id := range ids {
+		set[id] = true
+	}
+	return set
 }
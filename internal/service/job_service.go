@@ -0,0 +1,183 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+	"vybes/internal/config"
+	"vybes/internal/domain"
+	"vybes/internal/repository"
+	"vybes/pkg/storage"
+
+	"github.com/rs/zerolog/log"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+const (
+	// jobLease is how long a worker owns a claimed job before another worker may retry it
+	jobLease = 5 * time.Minute
+	// jobMaxAttempts is how many times a job runs before it is marked as failed
+	jobMaxAttempts = 8
+	// jobBatchSize caps how many jobs a single run processes
+	jobBatchSize = 100
+)
+
+// JobService defines the interface for running durable background jobs.
+type JobService interface {
+	// RunPendingJobs claims and processes runnable jobs until none are left or the batch limit is reached
+	RunPendingJobs(ctx context.Context)
+}
+
+type jobService struct {
+	jobRepo          repository.JobRepository
+	contentRepo      repository.ContentRepository
+	reactionRepo     repository.ReactionRepository
+	bookmarkRepo     repository.BookmarkRepository
+	notificationRepo repository.NotificationRepository
+	timelineService  TimelineService
+	storage          storage.Client
+	cfg              *config.Config
+}
+
+// NewJobService creates a new job service.
+func NewJobService(jobRepo repository.JobRepository, contentRepo repository.ContentRepository, reactionRepo repository.ReactionRepository, bookmarkRepo repository.BookmarkRepository, notificationRepo repository.NotificationRepository, timelineService TimelineService, storage storage.Client, cfg *config.Config) JobService {
+	return &jobService{
+		jobRepo:          jobRepo,
+		contentRepo:      contentRepo,
+		reactionRepo:     reactionRepo,
+		bookmarkRepo:     bookmarkRepo,
+		notificationRepo: notificationRepo,
+		timelineService:  timelineService,
+		storage:          storage,
+		cfg:              cfg,
+	}
+}
+
+// newPostCleanupJob builds the cleanup job for a post that is about to be deleted.
+// The job carries a snapshot of the post because the post document is gone by the time it runs.
+func newPostCleanupJob(post *domain.Post) *domain.Job {
+	now := time.Now()
+	return &domain.Job{
+		ID:          primitive.NewObjectID(),
+		Type:        domain.JobTypePostCleanup,
+		Status:      domain.JobStatusPending,
+		MaxAttempts: jobMaxAttempts,
+		RunAt:       now,
+		PostID:      post.ID,
+		AuthorID:    post.UserID,
+		ContentURL:  post.ContentURL,
+		CreatedAt:   now,
+		UpdatedAt:   now,
+	}
+}
+
+func (s *jobService) RunPendingJobs(ctx context.Context) {
+	for i := 0; i < jobBatchSize; i++ {
+		job, err := s.jobRepo.ClaimNextJob(ctx, jobLease)
+		if err != nil {
+			log.Error().Err(err).Msg("Failed to claim background job")
+			return
+		}
+		if job == nil {
+			return
+		}
+		s.runJob(ctx, job)
+	}
+}
+
+// runJob executes a claimed job and records the outcome. Failed jobs are
+// retried with exponential backoff until they run out of attempts.
+func (s *jobService) runJob(ctx context.Context, job *domain.Job) {
+	var err error
+	switch job.Type {
+	case domain.JobTypePostCleanup:
+		err = s.cleanupPost(ctx, job)
+	default:
+		err = fmt.Errorf("unknown job type: %s", job.Type)
+	}
+
+	if err == nil {
+		if err := s.jobRepo.CompleteJob(ctx, job.ID); err != nil {
+			log.Error().Err(err).Str("job_id", job.ID.Hex()).Msg("Failed to mark job as done")
+		}
+		return
+	}
+
+	logger := log.Error().Err(err).Str("job_id", job.ID.Hex()).Str("type", string(job.Type)).Int("attempt", job.Attempts)
+	if job.Attempts >= job.MaxAttempts {
+		logger.Msg("Background job failed permanently")
+		if err := s.jobRepo.FailJob(ctx, job.ID, err.Error()); err != nil {
+			log.Error().Err(err).Str("job_id", job.ID.Hex()).Msg("Failed to mark job as failed")
+		}
+		return
+	}
+
+	backoff := time.Duration(1<<job.Attempts) * 30 * time.Second
+	logger.Dur("retry_in", backoff).Msg("Background job failed, scheduling retry")
+	if err := s.jobRepo.RetryJob(ctx, job.ID, err.Error(), time.Now().Add(backoff)); err != nil {
+		log.Error().Err(err).Str("job_id", job.ID.Hex()).Msg("Failed to schedule job retry")
+	}
+}
+
+// cleanupPost removes everything that depends on a deleted post. Every step is
+// idempotent, so a retry after a partial failure simply finishes the work.
+func (s *jobService) cleanupPost(ctx context.Context, job *domain.Job) error {
+	// 1. Home timeline entries
+	if err := s.timelineService.RemovePost(ctx, job.PostID, job.AuthorID); err != nil {
+		return fmt.Errorf("failed to remove post from timelines: %w", err)
+	}
+
+	// 2. Plain reposts have nothing of their own left to show, so delete them too.
+	// Each one gets its own cleanup job. Quote-reposts are kept and render without their original.
+	reposts, err := s.contentRepo.GetPlainRepostsOf(ctx, job.PostID)
+	if err != nil {
+		return fmt.Errorf("failed to find reposts: %w", err)
+	}
+	for i := range reposts {
+		repost := &reposts[i]
+		err := s.contentRepo.DeletePostAndEnqueueCleanup(ctx, repost, newPostCleanupJob(repost))
+		if err != nil && !errors.Is(err, mongo.ErrNoDocuments) {
+			return fmt.Errorf("failed to delete repost %s: %w", repost.ID.Hex(), err)
+		}
+	}
+
+	// 3. Reactions, taking the post's likes off the author's total
+	if err := s.reactionRepo.DeleteReactionsByContentID(ctx, job.PostID, job.AuthorID); err != nil {
+		return fmt.Errorf("failed to delete reactions: %w", err)
+	}
+
+	// 4. Comments, along with the likes on them, taking those off the comment authors' totals
+	commentAuthors, err := s.contentRepo.GetCommentAuthorsByPostID(ctx, job.PostID)
+	if err != nil {
+		return fmt.Errorf("failed to find comments: %w", err)
+	}
+	if err := s.reactionRepo.DeleteCommentReactions(ctx, commentAuthors); err != nil {
+		return fmt.Errorf("failed to delete comment reactions: %w", err)
+	}
+	if err := s.contentRepo.DeleteCommentsByPostID(ctx, job.PostID); err != nil {
+		return fmt.Errorf("failed to delete comments: %w", err)
+	}
+
+	// 5. Bookmarks
+	if err := s.bookmarkRepo.DeleteBookmarksByPostID(ctx, job.PostID); err != nil {
+		return fmt.Errorf("failed to delete bookmarks: %w", err)
+	}
+
+	// 6. Notifications
+	if err := s.notificationRepo.DeleteNotificationsByPostID(ctx, job.PostID); err != nil {
+		return fmt.Errorf("failed to delete notifications: %w", err)
+	}
+
+	// 7. Media file. Upload URLs have the form https://<account>.r2.cloudflarestorage.com/<key>
+	if job.ContentURL != "" {
+		objectName := strings.TrimPrefix(job.ContentURL, fmt.Sprintf("https://%s.r2.cloudflarestorage.com/", s.cfg.R2AccountID))
+		if err := s.storage.DeleteFile(ctx, s.cfg.R2PostsBucket, objectName); err != nil {
+			return fmt.Errorf("failed to delete media: %w", err)
+		}
+	}
+
+	return nil
+}
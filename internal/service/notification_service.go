@@ -5,52 +5,108 @@ import (
 	"time"
 	"vybes/internal/domain"
 	"vybes/internal/repository"
+	"vybes/pkg/pagination"
 
 	"go.mongodb.org/mongo-driver/bson/primitive"
 )
 
 // NotificationService defines the interface for notification business logic.
 type NotificationService interface {
-	CreateNotification(ctx context.Context, userID, actorID primitive.ObjectID, notifType domain.NotificationType, postID *primitive.ObjectID) error
-	GetNotifications(ctx context.Context, userID string, page, limit int) ([]domain.Notification, error)
+	CreateNotification(ctx context.Context, userID, actorID primitive.ObjectID, notifType domain.NotificationType, postID, commentID *primitive.ObjectID) error
+	// CreateTransactionNotification tells a user that a transaction from their wallet confirmed
+	CreateTransactionNotification(ctx context.Context, userID, transactionID primitive.ObjectID) error
+	GetNotifications(ctx context.Context, userID, cursor string, limit int) (*pagination.Page[domain.Notification], error)
 	MarkNotificationsAsRead(ctx context.Context, userID string, notificationIDs []string) (int64, error)
 }
 
 type notificationService struct {
 	notificationRepo repository.NotificationRepository
+	blockService     BlockService
+	cursorCodec      *pagination.Codec
 }
 
 // NewNotificationService creates a new notification service.
-func NewNotificationService(notificationRepo repository.NotificationRepository) NotificationService {
+func NewNotificationService(notificationRepo repository.NotificationRepository, blockService BlockService, cursorCodec *pagination.Codec) NotificationService {
 	return &notificationService{
 		notificationRepo: notificationRepo,
+		blockService:     blockService,
+		cursorCodec:      cursorCodec,
 	}
 }
 
-func (s *notificationService) CreateNotification(ctx context.Context, userID, actorID primitive.ObjectID, notifType domain.NotificationType, postID *primitive.ObjectID) error {
+func (s *notificationService) CreateNotification(ctx context.Context, userID, actorID primitive.ObjectID, notifType domain.NotificationType, postID, commentID *primitive.ObjectID) error {
 	// Avoid self-notification
 	if userID == actorID {
 		return nil
 	}
 
+	// Drop notifications from users the recipient has blocked, been blocked by or muted
+	hidden, err := s.blockService.IsHidden(ctx, userID, actorID)
+	if err != nil {
+		return err
+	}
+	if hidden {
+		return nil
+	}
+
 	notification := &domain.Notification{
 		ID:        primitive.NewObjectID(),
 		UserID:    userID,
 		ActorID:   actorID,
 		Type:      notifType,
 		PostID:    postID,
+		CommentID: commentID,
 		Read:      false,
 		CreatedAt: time.Now(),
 	}
 	return s.notificationRepo.CreateNotification(ctx, notification)
 }
 
-func (s *notificationService) GetNotifications(ctx context.Context, userIDStr string, page, limit int) ([]domain.Notification, error) {
+// CreateTransactionNotification creates the notification directly: the user is
+// both its actor and recipient, which CreateNotification treats as a
+// self-notification.
+func (s *notificationService) CreateTransactionNotification(ctx context.Context, userID, transactionID primitive.ObjectID) error {
+	notification := &domain.Notification{
+		ID:            primitive.NewObjectID(),
+		UserID:        userID,
+		ActorID:       userID,
+		Type:          domain.NotificationTypeTransactionConfirmed,
+		TransactionID: &transactionID,
+		Read:          false,
+		CreatedAt:     time.Now(),
+	}
+	return s.notificationRepo.CreateNotification(ctx, notification)
+}
+
+func (s *notificationService) GetNotifications(ctx context.Context, userIDStr, cursor string, limit int) (*pagination.Page[domain.Notification], error) {
 	userID, err := primitive.ObjectIDFromHex(userIDStr)
 	if err != nil {
 		return nil, err
 	}
-	return s.notificationRepo.GetUserNotifications(ctx, userID, page, limit)
+	after, err := s.cursorCodec.Decode(cursor)
+	if err != nil {
+		return nil, err
+	}
+	// Fetch one extra notification to learn whether another page exists
+	notifications, err := s.notificationRepo.GetUserNotifications(ctx, userID, after, limit+1)
+	if err != nil {
+		return nil, err
+	}
+	page := pagination.NewPage(s.cursorCodec, notifications, limit, notificationCursor)
+
+	// Notifications created before a block or mute are hidden as well
+	hidden, err := s.blockService.GetHiddenUserIDs(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	visible := page.Items[:0]
+	for _, n := range page.Items {
+		if !hidden[n.ActorID] {
+			visible = append(visible, n)
+		}
+	}
+	page.Items = visible
+	return &page, nil
 }
 
 func (s *notificationService) MarkNotificationsAsRead(ctx context.Context, userIDStr string, notificationIDStrs []string) (int64, error) {
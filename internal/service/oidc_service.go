@@ -0,0 +1,251 @@
+package service
+
+import (
+	"context"
+	"crypto/subtle"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+	"vybes/internal/domain"
+	"vybes/internal/repository"
+	"vybes/pkg/cache"
+	"vybes/pkg/oidc"
+	"vybes/pkg/utils"
+
+	"github.com/rs/zerolog/log"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// oidcStateTTL is how long a user has to finish signing in at the provider
+const oidcStateTTL = 10 * time.Minute
+
+var (
+	// ErrOIDCProviderNotFound is returned for provider names that aren't configured.
+	ErrOIDCProviderNotFound = errors.New("unknown sign-in provider")
+	// ErrInvalidOIDCState is returned for unknown, expired or already used state values.
+	ErrInvalidOIDCState = errors.New("invalid or expired sign-in state")
+	// ErrOIDCLoginFailed is returned when the provider rejects the code or its ID token is invalid.
+	ErrOIDCLoginFailed = errors.New("sign-in with the provider failed")
+	// ErrOIDCEmailNotVerified is returned when the provider doesn't vouch for the user's email.
+	ErrOIDCEmailNotVerified = errors.New("the provider didn't share a verified email address")
+	// ErrOIDCAccountConflict is returned when an account with an unverified email
+	// already uses the provider's email, so it can't be linked safely.
+	ErrOIDCAccountConflict = errors.New("an account with this email already exists; sign in and verify your email to link it")
+)
+
+// oidcState is what a pending provider sign-in remembers until its callback.
+type oidcState struct {
+	Provider     string `json:"provider"`
+	Nonce        string `json:"nonce"`
+	CodeVerifier string `json:"codeVerifier"`
+	BindingHash  string `json:"bindingHash"` // SHA-256 of the binding handed to the client that started the sign-in
+}
+
+// OIDCAuthorization is a started provider sign-in.
+type OIDCAuthorization struct {
+	URL string `json:"authorization_url"`
+	// Binding ties the sign-in to the client that started it: the callback must
+	// present it, so a state from someone else's sign-in can't log the client
+	// in to their account. Clients keep it to themselves.
+	Binding string `json:"binding"`
+}
+
+// OIDCService defines the interface for OpenID Connect social login and the
+// provider accounts linked to users.
+type OIDCService interface {
+	// Providers lists the configured provider names
+	Providers() []string
+	// AuthorizationURL starts a sign-in and returns the provider URL to send the user to
+	AuthorizationURL(ctx context.Context, provider string) (*OIDCAuthorization, error)
+	// Authenticate consumes the state of a sign-in started with the given binding
+	// and exchanges its code for the user's validated claims
+	Authenticate(ctx context.Context, provider, state, binding, code string) (*oidc.Claims, error)
+	// FindOrCreateUser returns the user linked to the provider account, linking an
+	// account with the same verified email or creating one with a new wallet
+	FindOrCreateUser(ctx context.Context, provider string, claims *oidc.Claims) (*domain.User, error)
+	ListIdentities(ctx context.Context, userID primitive.ObjectID) ([]domain.ExternalIdentity, error)
+}
+
+type oidcService struct {
+	providers     map[string]*oidc.Provider
+	userRepo      repository.UserRepository
+	identityRepo  repository.ExternalIdentityRepository
+	counterRepo   repository.CounterRepository
+	walletService WalletService
+	cache         cache.Client
+}
+
+// NewOIDCService creates a new OpenID Connect login service for the given providers.
+func NewOIDCService(providers []*oidc.Provider, userRepo repository.UserRepository, identityRepo repository.ExternalIdentityRepository, counterRepo repository.CounterRepository, walletService WalletService, cache cache.Client) OIDCService {
+	byName := make(map[string]*oidc.Provider, len(providers))
+	for _, p := range providers {
+		byName[p.Name()] = p
+	}
+	return &oidcService{
+		providers:     byName,
+		userRepo:      userRepo,
+		identityRepo:  identityRepo,
+		counterRepo:   counterRepo,
+		walletService: walletService,
+		cache:         cache,
+	}
+}
+
+func (s *oidcService) Providers() []string {
+	names := make([]string, 0, len(s.providers))
+	for name := range s.providers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+func (s *oidcService) AuthorizationURL(ctx context.Context, provider string) (*OIDCAuthorization, error) {
+	p, ok := s.providers[provider]
+	if !ok {
+		return nil, ErrOIDCProviderNotFound
+	}
+
+	var values [4]string
+	for i := range values {
+		value, err := oidc.NewRandomValue()
+		if err != nil {
+			return nil, err
+		}
+		values[i] = value
+	}
+	state, nonce, codeVerifier, binding := values[0], values[1], values[2], values[3]
+
+	url, err := p.AuthCodeURL(ctx, state, nonce, codeVerifier)
+	if err != nil {
+		return nil, err
+	}
+	data, err := json.Marshal(oidcState{
+		Provider:     provider,
+		Nonce:        nonce,
+		CodeVerifier: codeVerifier,
+		BindingHash:  utils.HashToken(binding),
+	})
+	if err != nil {
+		return nil, err
+	}
+	if err := s.cache.Set(ctx, oidcStateKey(state), data, oidcStateTTL); err != nil {
+		return nil, err
+	}
+	return &OIDCAuthorization{URL: url, Binding: binding}, nil
+}
+
+func (s *oidcService) Authenticate(ctx context.Context, provider, state, binding, code string) (*oidc.Claims, error) {
+	p, ok := s.providers[provider]
+	if !ok {
+		return nil, ErrOIDCProviderNotFound
+	}
+
+	// Renaming fails when the key is missing, so only one request can use a state
+	key := oidcStateKey(state)
+	if err := s.cache.Rename(ctx, key, key+":used"); err != nil {
+		return nil, ErrInvalidOIDCState
+	}
+	raw, err := s.cache.Get(ctx, key+":used")
+	if delErr := s.cache.Del(ctx, key+":used"); delErr != nil {
+		log.Warn().Err(delErr).Msg("Failed to delete used OIDC state")
+	}
+	if err != nil {
+		return nil, ErrInvalidOIDCState
+	}
+	var pending oidcState
+	if err := json.Unmarshal([]byte(raw), &pending); err != nil || pending.Provider != provider {
+		return nil, ErrInvalidOIDCState
+	}
+	// A state alone could come from a sign-in the attacker started and planted on the victim
+	if subtle.ConstantTimeCompare([]byte(utils.HashToken(binding)), []byte(pending.BindingHash)) != 1 {
+		return nil, ErrInvalidOIDCState
+	}
+
+	claims, err := p.Exchange(ctx, code, pending.CodeVerifier, pending.Nonce)
+	if err != nil {
+		log.Warn().Err(err).Str("provider", provider).Msg("OIDC code exchange failed")
+		return nil, ErrOIDCLoginFailed
+	}
+	return claims, nil
+}
+
+func (s *oidcService) FindOrCreateUser(ctx context.Context, provider string, claims *oidc.Claims) (*domain.User, error) {
+	identity, err := s.identityRepo.GetIdentity(ctx, provider, claims.Subject)
+	if err == nil {
+		return s.userRepo.GetUserByID(ctx, identity.UserID)
+	}
+	if !errors.Is(err, mongo.ErrNoDocuments) {
+		return nil, err
+	}
+
+	// Without a verified email the provider account can't be matched to a user
+	if claims.Email == "" || !claims.EmailVerified {
+		return nil, ErrOIDCEmailNotVerified
+	}
+
+	user, err := s.userRepo.GetUserByEmail(ctx, claims.Email)
+	switch {
+	case err == nil:
+		// Whoever registered an unverified email may not own it, and linking
+		// would give the provider's user an account someone else can sign in to
+		if !user.EmailVerified {
+			return nil, ErrOIDCAccountConflict
+		}
+	case errors.Is(err, mongo.ErrNoDocuments):
+		user, err = s.createUser(ctx, claims)
+		if err != nil {
+			return nil, err
+		}
+	default:
+		return nil, err
+	}
+
+	identity = &domain.ExternalIdentity{
+		ID:        primitive.NewObjectID(),
+		UserID:    user.ID,
+		Provider:  provider,
+		Subject:   claims.Subject,
+		Email:     claims.Email,
+		CreatedAt: time.Now(),
+	}
+	if err := s.identityRepo.CreateIdentity(ctx, identity); err != nil {
+		// A concurrent callback for the same provider account linked it first
+		if mongo.IsDuplicateKeyError(err) {
+			return s.FindOrCreateUser(ctx, provider, claims)
+		}
+		return nil, err
+	}
+	return user, nil
+}
+
+// createUser creates an account for a new provider user. It has no password;
+// one can be set with a password reset.
+func (s *oidcService) createUser(ctx context.Context, claims *oidc.Claims) (*domain.User, error) {
+	name := claims.Name
+	if name == "" {
+		name, _, _ = strings.Cut(claims.Email, "@")
+	}
+	user, err := newAccount(ctx, s.counterRepo, s.walletService, name, claims.Email, "")
+	if err != nil {
+		return nil, err
+	}
+	user.EmailVerified = true
+	user.PFPURL = claims.Picture
+	if err := s.userRepo.CreateUser(ctx, user); err != nil {
+		return nil, err
+	}
+	return user, nil
+}
+
+func (s *oidcService) ListIdentities(ctx context.Context, userID primitive.ObjectID) ([]domain.ExternalIdentity, error) {
+	return s.identityRepo.GetIdentitiesByUser(ctx, userID)
+}
+
+func oidcStateKey(state string) string {
+	return fmt.Sprintf("oidc:state:%s", utils.HashToken(state))
+}
@@ -0,0 +1,353 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+	"vybes/internal/domain"
+	"vybes/internal/repository"
+	"vybes/pkg/cache"
+	"vybes/pkg/envelope"
+	"vybes/pkg/oidc"
+	"vybes/pkg/oidc/oidctest"
+
+	"github.com/redis/go-redis/v9"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// memoryCache implements the parts of cache.Client the OIDC service uses.
+type memoryCache struct {
+	cache.Client
+	mu     sync.Mutex
+	values map[string]string
+}
+
+func (c *memoryCache) Set(_ context.Context, key string, value interface{}, _ time.Duration) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	switch v := value.(type) {
+	case []byte:
+		c.values[key] = string(v)
+	case string:
+		c.values[key] = v
+	default:
+		return errors.New("unsupported value type")
+	}
+	return nil
+}
+
+func (c *memoryCache) Get(_ context.Context, key string) (string, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	value, ok := c.values[key]
+	if !ok {
+		return "", redis.Nil
+	}
+	return value, nil
+}
+
+func (c *memoryCache) Del(_ context.Context, keys ...string) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for _, key := range keys {
+		delete(c.values, key)
+	}
+	return nil
+}
+
+func (c *memoryCache) Rename(_ context.Context, key, newKey string) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	value, ok := c.values[key]
+	if !ok {
+		return errors.New("ERR no such key")
+	}
+	delete(c.values, key)
+	c.values[newKey] = value
+	return nil
+}
+
+// memoryUsers implements the parts of repository.UserRepository the OIDC service uses.
+type memoryUsers struct {
+	repository.UserRepository
+	users []*domain.User
+}
+
+func (r *memoryUsers) CreateUser(_ context.Context, user *domain.User) error {
+	r.users = append(r.users, user)
+	return nil
+}
+
+func (r *memoryUsers) GetUserByID(_ context.Context, id primitive.ObjectID) (*domain.User, error) {
+	for _, u := range r.users {
+		if u.ID == id {
+			return u, nil
+		}
+	}
+	return nil, mongo.ErrNoDocuments
+}
+
+func (r *memoryUsers) GetUserByEmail(_ context.Context, email string) (*domain.User, error) {
+	for _, u := range r.users {
+		if u.Email == email {
+			return u, nil
+		}
+	}
+	return nil, mongo.ErrNoDocuments
+}
+
+type memoryIdentities struct {
+	identities []*domain.ExternalIdentity
+}
+
+func (r *memoryIdentities) CreateIdentity(_ context.Context, identity *domain.ExternalIdentity) error {
+	r.identities = append(r.identities, identity)
+	return nil
+}
+
+func (r *memoryIdentities) GetIdentity(_ context.Context, provider, subject string) (*domain.ExternalIdentity, error) {
+	for _, i := range r.identities {
+		if i.Provider == provider && i.Subject == subject {
+			return i, nil
+		}
+	}
+	return nil, mongo.ErrNoDocuments
+}
+
+func (r *memoryIdentities) GetIdentitiesByUser(_ context.Context, userID primitive.ObjectID) ([]domain.ExternalIdentity, error) {
+	var identities []domain.ExternalIdentity
+	for _, i := range r.identities {
+		if i.UserID == userID {
+			identities = append(identities, *i)
+		}
+	}
+	return identities, nil
+}
+
+type memoryCounter struct {
+	repository.CounterRepository
+	next int64
+}
+
+func (r *memoryCounter) GetNextSequence(context.Context, string) (int64, error) {
+	r.next++
+	return r.next, nil
+}
+
+// stubWallets hands out a fixed wallet instead of generating keys.
+type stubWallets struct {
+	WalletService
+}
+
+func (stubWallets) CreateWallet(context.Context) (string, envelope.Sealed, error) {
+	return "0x000000000000000000000000000000000000dEaD", envelope.Sealed{Ciphertext: "sealed", WrappedKey: "wrapped", KeyVersion: 1}, nil
+}
+
+type oidcTestEnv struct {
+	service    OIDCService
+	issuer     *oidctest.Issuer
+	users      *memoryUsers
+	identities *memoryIdentities
+}
+
+func newOIDCTestEnv(t *testing.T) *oidcTestEnv {
+	t.Helper()
+	issuer := oidctest.NewIssuer(t)
+	provider := oidc.NewProvider(oidc.Config{
+		Name:         "test",
+		IssuerURL:    issuer.URL,
+		ClientID:     oidctest.ClientID,
+		ClientSecret: oidctest.ClientSecret,
+		RedirectURL:  oidctest.RedirectURL,
+	}, nil)
+	env := &oidcTestEnv{
+		issuer:     issuer,
+		users:      &memoryUsers{},
+		identities: &memoryIdentities{},
+	}
+	env.service = NewOIDCService([]*oidc.Provider{provider}, env.users, env.identities, &memoryCounter{}, stubWallets{}, &memoryCache{values: map[string]string{}})
+	return env
+}
+
+// signIn starts a sign-in and completes it at the provider, returning what the
+// client would post to the callback.
+func (env *oidcTestEnv) signIn(t *testing.T) (state, binding, code string) {
+	t.Helper()
+	authorization, err := env.service.AuthorizationURL(context.Background(), "test")
+	if err != nil {
+		t.Fatalf("AuthorizationURL: %v", err)
+	}
+	if authorization.Binding == "" {
+		t.Fatal("sign-in started without a binding")
+	}
+	code, state = env.issuer.Authorize(t, authorization.URL)
+	return state, authorization.Binding, code
+}
+
+func TestOIDCAuthenticate(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("state is single use", func(t *testing.T) {
+		env := newOIDCTestEnv(t)
+		state, binding, code := env.signIn(t)
+		claims, err := env.service.Authenticate(ctx, "test", state, binding, code)
+		if err != nil {
+			t.Fatalf("Authenticate: %v", err)
+		}
+		if claims.Subject != "provider-user-1" {
+			t.Errorf("subject = %q, want provider-user-1", claims.Subject)
+		}
+		if _, err := env.service.Authenticate(ctx, "test", state, binding, code); !errors.Is(err, ErrInvalidOIDCState) {
+			t.Fatalf("replayed state: err = %v, want ErrInvalidOIDCState", err)
+		}
+	})
+
+	t.Run("state must come with its binding", func(t *testing.T) {
+		env := newOIDCTestEnv(t)
+		state, binding, code := env.signIn(t)
+		_, otherBinding, _ := env.signIn(t)
+		if _, err := env.service.Authenticate(ctx, "test", state, otherBinding, code); !errors.Is(err, ErrInvalidOIDCState) {
+			t.Fatalf("foreign binding: err = %v, want ErrInvalidOIDCState", err)
+		}
+		if _, err := env.service.Authenticate(ctx, "test", state, "", code); !errors.Is(err, ErrInvalidOIDCState) {
+			t.Fatalf("missing binding: err = %v, want ErrInvalidOIDCState", err)
+		}
+		// The rejected attempt used up the state
+		if _, err := env.service.Authenticate(ctx, "test", state, binding, code); !errors.Is(err, ErrInvalidOIDCState) {
+			t.Fatalf("state reused after a rejected attempt: err = %v, want ErrInvalidOIDCState", err)
+		}
+	})
+
+	t.Run("unknown state", func(t *testing.T) {
+		env := newOIDCTestEnv(t)
+		_, binding, code := env.signIn(t)
+		if _, err := env.service.Authenticate(ctx, "test", "made-up", binding, code); !errors.Is(err, ErrInvalidOIDCState) {
+			t.Fatalf("err = %v, want ErrInvalidOIDCState", err)
+		}
+	})
+
+	t.Run("unknown provider", func(t *testing.T) {
+		env := newOIDCTestEnv(t)
+		if _, err := env.service.AuthorizationURL(ctx, "other"); !errors.Is(err, ErrOIDCProviderNotFound) {
+			t.Fatalf("AuthorizationURL: err = %v, want ErrOIDCProviderNotFound", err)
+		}
+		state, binding, code := env.signIn(t)
+		if _, err := env.service.Authenticate(ctx, "other", state, binding, code); !errors.Is(err, ErrOIDCProviderNotFound) {
+			t.Fatalf("Authenticate: err = %v, want ErrOIDCProviderNotFound", err)
+		}
+	})
+
+	t.Run("invalid ID token", func(t *testing.T) {
+		env := newOIDCTestEnv(t)
+		env.issuer.SetClaim("aud", "other-client")
+		state, binding, code := env.signIn(t)
+		if _, err := env.service.Authenticate(ctx, "test", state, binding, code); !errors.Is(err, ErrOIDCLoginFailed) {
+			t.Fatalf("err = %v, want ErrOIDCLoginFailed", err)
+		}
+	})
+
+	t.Run("email_verified as a string", func(t *testing.T) {
+		env := newOIDCTestEnv(t)
+		env.issuer.SetClaim("email_verified", "true")
+		state, binding, code := env.signIn(t)
+		claims, err := env.service.Authenticate(ctx, "test", state, binding, code)
+		if err != nil {
+			t.Fatalf("Authenticate: %v", err)
+		}
+		if !claims.EmailVerified {
+			t.Error("email_verified \"true\" not treated as verified")
+		}
+	})
+}
+
+func TestOIDCFindOrCreateUser(t *testing.T) {
+	ctx := context.Background()
+	claims := func(email string, verified bool) *oidc.Claims {
+		return &oidc.Claims{Subject: "provider-user-1", Email: email, EmailVerified: verified, Name: "Provider User"}
+	}
+
+	t.Run("returns the linked user", func(t *testing.T) {
+		env := newOIDCTestEnv(t)
+		linked := &domain.User{ID: primitive.NewObjectID(), Email: "old@example.com"}
+		env.users.users = append(env.users.users, linked)
+		env.identities.identities = append(env.identities.identities, &domain.ExternalIdentity{UserID: linked.ID, Provider: "test", Subject: "provider-user-1"})
+
+		// Once linked, the provider's email no longer matters
+		user, err := env.service.FindOrCreateUser(ctx, "test", claims("new@example.com", false))
+		if err != nil {
+			t.Fatalf("FindOrCreateUser: %v", err)
+		}
+		if user.ID != linked.ID {
+			t.Errorf("got user %s, want the linked user %s", user.ID.Hex(), linked.ID.Hex())
+		}
+		if len(env.identities.identities) != 1 {
+			t.Errorf("got %d identities, want 1", len(env.identities.identities))
+		}
+	})
+
+	t.Run("links the account with the same verified email", func(t *testing.T) {
+		env := newOIDCTestEnv(t)
+		existing := &domain.User{ID: primitive.NewObjectID(), Email: "user@example.com", EmailVerified: true}
+		env.users.users = append(env.users.users, existing)
+
+		user, err := env.service.FindOrCreateUser(ctx, "test", claims("user@example.com", true))
+		if err != nil {
+			t.Fatalf("FindOrCreateUser: %v", err)
+		}
+		if user.ID != existing.ID {
+			t.Errorf("got user %s, want the existing user %s", user.ID.Hex(), existing.ID.Hex())
+		}
+		if len(env.users.users) != 1 {
+			t.Errorf("got %d users, want no new account", len(env.users.users))
+		}
+		identity, err := env.identities.GetIdentity(ctx, "test", "provider-user-1")
+		if err != nil || identity.UserID != existing.ID {
+			t.Errorf("provider account not linked to the existing user: %+v, %v", identity, err)
+		}
+	})
+
+	t.Run("refuses to link an unverified email", func(t *testing.T) {
+		env := newOIDCTestEnv(t)
+		env.users.users = append(env.users.users, &domain.User{ID: primitive.NewObjectID(), Email: "user@example.com"})
+
+		if _, err := env.service.FindOrCreateUser(ctx, "test", claims("user@example.com", true)); !errors.Is(err, ErrOIDCAccountConflict) {
+			t.Fatalf("err = %v, want ErrOIDCAccountConflict", err)
+		}
+		if len(env.identities.identities) != 0 {
+			t.Errorf("got %d identities, want none", len(env.identities.identities))
+		}
+	})
+
+	t.Run("requires a verified email from the provider", func(t *testing.T) {
+		env := newOIDCTestEnv(t)
+		existing := &domain.User{ID: primitive.NewObjectID(), Email: "user@example.com", EmailVerified: true}
+		env.users.users = append(env.users.users, existing)
+
+		for _, c := range []*oidc.Claims{claims("user@example.com", false), claims("", true)} {
+			if _, err := env.service.FindOrCreateUser(ctx, "test", c); !errors.Is(err, ErrOIDCEmailNotVerified) {
+				t.Fatalf("claims %+v: err = %v, want ErrOIDCEmailNotVerified", c, err)
+			}
+		}
+		if len(env.identities.identities) != 0 {
+			t.Errorf("got %d identities, want none", len(env.identities.identities))
+		}
+	})
+
+	t.Run("creates an account for a new email", func(t *testing.T) {
+		env := newOIDCTestEnv(t)
+		user, err := env.service.FindOrCreateUser(ctx, "test", claims("new@example.com", true))
+		if err != nil {
+			t.Fatalf("FindOrCreateUser: %v", err)
+		}
+		if len(env.users.users) != 1 || !user.EmailVerified || user.Email != "new@example.com" || user.Password != "" {
+			t.Errorf("unexpected new account: %+v", user)
+		}
+		identity, err := env.identities.GetIdentity(ctx, "test", "provider-user-1")
+		if err != nil || identity.UserID != user.ID {
+			t.Errorf("provider account not linked to the new user: %+v, %v", identity, err)
+		}
+	})
+}
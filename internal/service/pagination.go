@@ -0,0 +1,51 @@
+package service
+
+import (
+	"vybes/internal/domain"
+	"vybes/pkg/pagination"
+)
+
+// Cursor keys for the lists the services paginate. Each returns the position
+// of an item in the (createdAt, _id) order its repository query sorts by.
+
+func postCursor(p domain.Post) pagination.Cursor {
+	return pagination.Cursor{CreatedAt: p.CreatedAt, ID: p.ID}
+}
+
+func commentCursor(c domain.Comment) pagination.Cursor {
+	return pagination.Cursor{CreatedAt: c.CreatedAt, ID: c.ID}
+}
+
+func bookmarkCursor(b domain.Bookmark) pagination.Cursor {
+	return pagination.Cursor{CreatedAt: b.CreatedAt, ID: b.ID}
+}
+
+func notificationCursor(n domain.Notification) pagination.Cursor {
+	return pagination.Cursor{CreatedAt: n.CreatedAt, ID: n.ID}
+}
+
+// userCursor positions users by _id, whose embedded timestamp stands in for createdAt.
+func userCursor(u domain.User) pagination.Cursor {
+	return pagination.Cursor{CreatedAt: u.ID.Timestamp(), ID: u.ID}
+}
+
+func blockCursor(b domain.Block) pagination.Cursor {
+	return pagination.Cursor{CreatedAt: b.CreatedAt, ID: b.ID}
+}
+
+func muteCursor(m domain.Mute) pagination.Cursor {
+	return pagination.Cursor{CreatedAt: m.CreatedAt, ID: m.ID}
+}
+
+func followRequestCursor(r domain.FollowRequest) pagination.Cursor {
+	return pagination.Cursor{CreatedAt: r.CreatedAt, ID: r.ID}
+}
+
+// followCursor positions follows by _id; follow documents carry no createdAt.
+func followCursor(f domain.Follow) pagination.Cursor {
+	return pagination.Cursor{CreatedAt: f.ID.Timestamp(), ID: f.ID}
+}
+
+func walletTransactionCursor(t domain.WalletTransaction) pagination.Cursor {
+	return pagination.Cursor{CreatedAt: t.CreatedAt, ID: t.ID}
+}
@@ -2,6 +2,7 @@ package service
 
 import (
 	"context"
+	"fmt"
 	"time"
 	"vybes/internal/domain"
 	"vybes/internal/repository"
@@ -13,21 +14,27 @@ import (
 type ReactionService interface {
 	AddReaction(ctx context.Context, userID, postID, reactionType string) error
 	RemoveReaction(ctx context.Context, userID, postID, reactionType string) error
+	AddCommentReaction(ctx context.Context, userID, commentID, reactionType string) error
+	RemoveCommentReaction(ctx context.Context, userID, commentID, reactionType string) error
 }
 
 type reactionService struct {
 	reactionRepo          repository.ReactionRepository
 	contentRepo           repository.ContentRepository
 	userRepo              repository.UserRepository
+	followRepo            repository.FollowRepository
+	blockService          BlockService
 	notificationPublisher NotificationPublisher
 }
 
 // NewReactionService creates a new reaction service.
-func NewReactionService(reactionRepo repository.ReactionRepository, contentRepo repository.ContentRepository, userRepo repository.UserRepository, notificationPublisher NotificationPublisher) ReactionService {
+func NewReactionService(reactionRepo repository.ReactionRepository, contentRepo repository.ContentRepository, userRepo repository.UserRepository, followRepo repository.FollowRepository, blockService BlockService, notificationPublisher NotificationPublisher) ReactionService {
 	return &reactionService{
 		reactionRepo:          reactionRepo,
 		contentRepo:           contentRepo,
 		userRepo:              userRepo,
+		followRepo:            followRepo,
+		blockService:          blockService,
 		notificationPublisher: notificationPublisher,
 	}
 }
@@ -42,8 +49,8 @@ func (s *reactionService) AddReaction(ctx context.Context, userIDStr, postIDStr,
 		return err
 	}
 
-	post, err := s.contentRepo.GetPostByID(ctx, postID)
-	if err != nil || post == nil {
+	post, err := getViewablePost(ctx, s.contentRepo, s.followRepo, s.userRepo, s.blockService, userID, postID)
+	if err != nil {
 		return err
 	}
 
@@ -51,6 +58,7 @@ func (s *reactionService) AddReaction(ctx context.Context, userIDStr, postIDStr,
 		ID:           primitive.NewObjectID(),
 		UserID:       userID,
 		ContentID:    postID,
+		TargetType:   domain.ReactionTargetPost,
 		ReactionType: domain.ReactionType(reactionTypeStr),
 		CreatedAt:    time.Now(),
 	}
@@ -99,3 +107,85 @@ func (s *reactionService) RemoveReaction(ctx context.Context, userIDStr, postIDS
 	}
 	return nil
 }
+
+// AddCommentReaction reacts to a comment or reply. Comment likes count towards
+// the comment and the commenter's total likes, the same as post likes.
+func (s *reactionService) AddCommentReaction(ctx context.Context, userIDStr, commentIDStr, reactionTypeStr string) error {
+	userID, err := primitive.ObjectIDFromHex(userIDStr)
+	if err != nil {
+		return err
+	}
+	commentID, err := primitive.ObjectIDFromHex(commentIDStr)
+	if err != nil {
+		return err
+	}
+
+	comment, err := s.contentRepo.GetCommentByID(ctx, commentID)
+	if err != nil || comment.Deleted {
+		return fmt.Errorf("comment not found")
+	}
+	if _, err := getViewablePost(ctx, s.contentRepo, s.followRepo, s.userRepo, s.blockService, userID, comment.PostID); err != nil {
+		return err
+	}
+	blocked, err := s.blockService.IsBlocked(ctx, userID, comment.UserID)
+	if err != nil {
+		return err
+	}
+	if blocked {
+		return fmt.Errorf("comment not found")
+	}
+
+	reaction := &domain.Reaction{
+		ID:           primitive.NewObjectID(),
+		UserID:       userID,
+		ContentID:    commentID,
+		TargetType:   domain.ReactionTargetComment,
+		ReactionType: domain.ReactionType(reactionTypeStr),
+		CreatedAt:    time.Now(),
+	}
+
+	if err := s.reactionRepo.AddReaction(ctx, reaction); err != nil {
+		return err
+	}
+	if reaction.ReactionType != domain.ReactionTypeLike {
+		return nil
+	}
+	if err := s.userRepo.IncrementTotalLikes(ctx, comment.UserID, 1); err != nil {
+		return err
+	}
+	if comment.UserID != userID {
+		// Publish notification event
+		go s.notificationPublisher.Publish(domain.Notification{
+			UserID:    comment.UserID, // The comment author receives the notification
+			ActorID:   userID,
+			Type:      domain.NotificationTypeCommentLike,
+			PostID:    &comment.PostID,
+			CommentID: &comment.ID,
+		})
+	}
+	return nil
+}
+
+func (s *reactionService) RemoveCommentReaction(ctx context.Context, userIDStr, commentIDStr, reactionTypeStr string) error {
+	userID, err := primitive.ObjectIDFromHex(userIDStr)
+	if err != nil {
+		return err
+	}
+	commentID, err := primitive.ObjectIDFromHex(commentIDStr)
+	if err != nil {
+		return err
+	}
+	reactionType := domain.ReactionType(reactionTypeStr)
+
+	comment, err := s.contentRepo.GetCommentByID(ctx, commentID)
+	if err != nil {
+		return fmt.Errorf("comment not found")
+	}
+	if err := s.reactionRepo.RemoveReaction(ctx, userID, commentID, reactionType); err != nil {
+		return err
+	}
+	if reactionType == domain.ReactionTypeLike {
+		return s.userRepo.IncrementTotalLikes(ctx, comment.UserID, -1)
+	}
+	return nil
+}
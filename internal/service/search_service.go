@@ -4,24 +4,55 @@ import (
 	"context"
 	"vybes/internal/domain"
 	"vybes/internal/repository"
+	"vybes/pkg/pagination"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
 )
 
 // SearchService defines the interface for search business logic.
 type SearchService interface {
-	SearchUsers(ctx context.Context, query string, page, limit int) ([]domain.User, error)
+	SearchUsers(ctx context.Context, viewerID primitive.ObjectID, query, cursor string, limit int) (*pagination.Page[domain.User], error)
 }
 
 type searchService struct {
-	userRepo repository.UserRepository
+	userRepo     repository.UserRepository
+	blockService BlockService
+	cursorCodec  *pagination.Codec
 }
 
 // NewSearchService creates a new search service.
-func NewSearchService(userRepo repository.UserRepository) SearchService {
+func NewSearchService(userRepo repository.UserRepository, blockService BlockService, cursorCodec *pagination.Codec) SearchService {
 	return &searchService{
-		userRepo: userRepo,
+		userRepo:     userRepo,
+		blockService: blockService,
+		cursorCodec:  cursorCodec,
 	}
 }
 
-func (s *searchService) SearchUsers(ctx context.Context, query string, page, limit int) ([]domain.User, error) {
-	return s.userRepo.SearchUsers(ctx, query, page, limit)
+// SearchUsers finds users by name or username, leaving out anyone the viewer
+// has blocked or been blocked by.
+func (s *searchService) SearchUsers(ctx context.Context, viewerID primitive.ObjectID, query, cursor string, limit int) (*pagination.Page[domain.User], error) {
+	after, err := s.cursorCodec.Decode(cursor)
+	if err != nil {
+		return nil, err
+	}
+	// Fetch one extra user to learn whether another page exists
+	users, err := s.userRepo.SearchUsers(ctx, query, after, limit+1)
+	if err != nil {
+		return nil, err
+	}
+	page := pagination.NewPage(s.cursorCodec, users, limit, userCursor)
+
+	blocked, err := s.blockService.GetBlockedUserIDs(ctx, viewerID)
+	if err != nil {
+		return nil, err
+	}
+	visible := page.Items[:0]
+	for _, u := range page.Items {
+		if !blocked[u.ID] {
+			visible = append(visible, u)
+		}
+	}
+	page.Items = visible
+	return &page, nil
 }
@@ -12,6 +12,12 @@ type ISessionService interface {
 	Create(ctx context.Context, userID primitive.ObjectID, refreshToken, userAgent, clientIP string) (*domain.Session, error)
 	GetByID(ctx context.Context, id primitive.ObjectID) (*domain.Session, error)
 	Block(ctx context.Context, id primitive.ObjectID) error
+	GetForUser(ctx context.Context, userID, sessionID primitive.ObjectID) (*domain.Session, error)
+	ListActive(ctx context.Context, userID, currentSessionID primitive.ObjectID) ([]domain.Session, error)
+	Revoke(ctx context.Context, userID, sessionID primitive.ObjectID) error
+	RevokeAll(ctx context.Context, userID, exceptSessionID primitive.ObjectID) (int, error)
+	RotateRefreshToken(ctx context.Context, refreshToken string) (*domain.Session, string, error)
+	ValidateSession(ctx context.Context, userID, sessionID primitive.ObjectID) error
 }
 
 // Ensure *SessionService implements ISessionService
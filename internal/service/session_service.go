@@ -2,11 +2,48 @@ package service
 
 import (
 	"context"
+	"errors"
+	"fmt"
 	"time"
 
+	"github.com/rs/zerolog/log"
 	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
 	"vybes/internal/domain"
 	"vybes/internal/repository"
+	"vybes/pkg/cache"
+	"vybes/pkg/utils"
+)
+
+const (
+	// sessionStatusTTL bounds how long a cached "active" status is trusted. Blocking
+	// a session through the service overwrites the cache immediately; the TTL only
+	// matters for changes made to the sessions collection directly.
+	sessionStatusTTL = 5 * time.Minute
+	// sessionRevokedTTL keeps the "revoked" status for as long as an access token
+	// issued for the session can still be presented.
+	sessionRevokedTTL = 24 * time.Hour
+	sessionRevoked    = "revoked"
+	// sessionLifetime is how long a login lasts. Refresh tokens stay valid until
+	// their session expires, so exchanged ones are only remembered for as long.
+	sessionLifetime = 7 * 24 * time.Hour
+
+	refreshTokenLength  = 32
+	refreshTokenCharset = "abcdefghijklmnopqrstuvwxyzABCDEFGHIJKLMNOPQRSTUVWXYZ0123456789"
+)
+
+var (
+	// ErrSessionRevoked is returned when an access token refers to a session that has
+	// been blocked, has expired or doesn't belong to the token's user.
+	ErrSessionRevoked = errors.New("session is no longer valid")
+	// ErrInvalidRefreshToken is returned for unknown refresh tokens and for tokens
+	// of blocked or expired sessions.
+	ErrInvalidRefreshToken = errors.New("invalid or expired refresh token")
+	// ErrRefreshTokenReused is returned when a refresh token that was already
+	// exchanged is presented again. The session is revoked when this happens.
+	ErrRefreshTokenReused = errors.New("refresh token has already been used")
+	// ErrSessionNotFound is returned for sessions that don't exist or belong to another user.
+	ErrSessionNotFound = errors.New("session not found")
 )
 
 // SessionServiceInterface defines the interface for session business logic.
@@ -14,28 +51,44 @@ type SessionServiceInterface interface {
 	Create(ctx context.Context, userID primitive.ObjectID, refreshToken, userAgent, clientIP string) (*domain.Session, error)
 	GetByID(ctx context.Context, id primitive.ObjectID) (*domain.Session, error)
 	Block(ctx context.Context, id primitive.ObjectID) error
+	GetForUser(ctx context.Context, userID, sessionID primitive.ObjectID) (*domain.Session, error)
+	ListActive(ctx context.Context, userID, currentSessionID primitive.ObjectID) ([]domain.Session, error)
+	Revoke(ctx context.Context, userID, sessionID primitive.ObjectID) error
+	RevokeAll(ctx context.Context, userID, exceptSessionID primitive.ObjectID) (int, error)
+	RotateRefreshToken(ctx context.Context, refreshToken string) (*domain.Session, string, error)
+	ValidateSession(ctx context.Context, userID, sessionID primitive.ObjectID) error
 }
 // Ensure *SessionService implements SessionServiceInterface
 var _ SessionServiceInterface = (*SessionService)(nil)
 
 type SessionService struct {
 	sessionRepo *repository.SessionRepository
+	cache       cache.Client
+}
+
+func NewSessionService(sessionRepo *repository.SessionRepository, cache cache.Client) *SessionService {
+	return &SessionService{sessionRepo: sessionRepo, cache: cache}
 }
 
-func NewSessionService(sessionRepo *repository.SessionRepository) *SessionService {
-	return &SessionService{sessionRepo: sessionRepo}
+// newRefreshToken generates a random refresh token. Only its hash is stored.
+func newRefreshToken() (string, error) {
+	return utils.GenerateRandomString(refreshTokenLength, refreshTokenCharset)
 }
 
+// Create starts a session for a login. The refresh token is stored hashed.
 func (s *SessionService) Create(ctx context.Context, userID primitive.ObjectID, refreshToken, userAgent, clientIP string) (*domain.Session, error) {
+	now := time.Now()
 	session := &domain.Session{
-		ID:           primitive.NewObjectID(),
-		UserID:       userID,
-		RefreshToken: refreshToken,
-		UserAgent:    userAgent,
-		ClientIP:     clientIP,
-		IsBlocked:    false,
-		ExpiresAt:    time.Now().Add(time.Hour * 24 * 7), // 7 days
-		CreatedAt:    time.Now(),
+		ID:               primitive.NewObjectID(),
+		UserID:           userID,
+		RefreshTokenHash: utils.HashToken(refreshToken),
+		RotatedTokens:    []domain.RotatedToken{},
+		UserAgent:        userAgent,
+		ClientIP:         clientIP,
+		IsBlocked:        false,
+		ExpiresAt:        now.Add(sessionLifetime),
+		CreatedAt:        now,
+		LastUsedAt:       now,
 	}
 
 	if err := s.sessionRepo.Create(ctx, session); err != nil {
@@ -49,6 +102,183 @@ func (s *SessionService) GetByID(ctx context.Context, id primitive.ObjectID) (*d
 	return s.sessionRepo.GetByID(ctx, id)
 }
 
+// Block revokes a session. Access tokens issued for it are rejected from the
+// next request on, not just once they expire.
 func (s *SessionService) Block(ctx context.Context, id primitive.ObjectID) error {
-	return s.sessionRepo.Block(ctx, id)
+	if err := s.sessionRepo.Block(ctx, id); err != nil {
+		return err
+	}
+	return s.markRevoked(ctx, id)
+}
+
+// GetForUser returns one of the user's sessions. Other users' sessions are
+// reported as missing.
+func (s *SessionService) GetForUser(ctx context.Context, userID, sessionID primitive.ObjectID) (*domain.Session, error) {
+	session, err := s.sessionRepo.GetByID(ctx, sessionID)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return nil, ErrSessionNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	if session.UserID != userID {
+		return nil, ErrSessionNotFound
+	}
+	return session, nil
+}
+
+// ListActive returns the devices the user is logged in on, most recently used
+// first. The session making the request is flagged as current.
+func (s *SessionService) ListActive(ctx context.Context, userID, currentSessionID primitive.ObjectID) ([]domain.Session, error) {
+	sessions, err := s.sessionRepo.ListActiveByUser(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	if sessions == nil {
+		sessions = []domain.Session{}
+	}
+	for i := range sessions {
+		sessions[i].IsCurrent = sessions[i].ID == currentSessionID
+	}
+	return sessions, nil
+}
+
+// Revoke logs the user out of one of their sessions.
+func (s *SessionService) Revoke(ctx context.Context, userID, sessionID primitive.ObjectID) error {
+	if _, err := s.GetForUser(ctx, userID, sessionID); err != nil {
+		return err
+	}
+	return s.Block(ctx, sessionID)
+}
+
+// RevokeAll logs the user out of all their sessions except exceptSessionID,
+// which may be primitive.NilObjectID to end every session. It returns the
+// number of sessions revoked.
+func (s *SessionService) RevokeAll(ctx context.Context, userID, exceptSessionID primitive.ObjectID) (int, error) {
+	ids, err := s.sessionRepo.BlockAllByUser(ctx, userID, exceptSessionID)
+	if err != nil {
+		return 0, err
+	}
+	for _, id := range ids {
+		if err := s.markRevoked(ctx, id); err != nil {
+			return 0, err
+		}
+	}
+	return len(ids), nil
+}
+
+// RotateRefreshToken exchanges a refresh token for a new one and returns the
+// session it belongs to. Every refresh token can be exchanged once. If a token
+// that was already exchanged turns up again, either it or its successor has
+// been stolen, so the whole session is revoked and every token and access token
+// issued for it stops working.
+func (s *SessionService) RotateRefreshToken(ctx context.Context, refreshToken string) (*domain.Session, string, error) {
+	tokenHash := utils.HashToken(refreshToken)
+	session, err := s.sessionRepo.FindByRefreshTokenHash(ctx, tokenHash)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return nil, "", ErrInvalidRefreshToken
+	}
+	if err != nil {
+		return nil, "", err
+	}
+	if session.IsBlocked || time.Now().After(session.ExpiresAt) {
+		return nil, "", ErrInvalidRefreshToken
+	}
+	if session.RefreshTokenHash != tokenHash {
+		return nil, "", s.revokeForReuse(ctx, session)
+	}
+
+	newToken, err := newRefreshToken()
+	if err != nil {
+		return nil, "", err
+	}
+	rotated, err := s.sessionRepo.RotateRefreshToken(ctx, session.ID, tokenHash, utils.HashToken(newToken), sessionLifetime)
+	if err != nil {
+		return nil, "", err
+	}
+	if !rotated {
+		// Another refresh exchanged the same token first
+		return nil, "", s.revokeForReuse(ctx, session)
+	}
+	return session, newToken, nil
+}
+
+func (s *SessionService) revokeForReuse(ctx context.Context, session *domain.Session) error {
+	log.Warn().
+		Str("session_id", session.ID.Hex()).
+		Str("user_id", session.UserID.Hex()).
+		Msg("Refresh token reuse detected, revoking session")
+	if err := s.Block(ctx, session.ID); err != nil {
+		return err
+	}
+	return ErrRefreshTokenReused
+}
+
+// ValidateSession checks that a session named by an access token is still usable
+// and belongs to the token's user. The status is cached in Redis so that most
+// requests don't reach MongoDB; if Redis is unavailable the database is used.
+func (s *SessionService) ValidateSession(ctx context.Context, userID, sessionID primitive.ObjectID) error {
+	key := sessionStatusKey(sessionID)
+	if status, err := s.cache.Get(ctx, key); err == nil {
+		if status != userID.Hex() {
+			return ErrSessionRevoked
+		}
+		return nil
+	}
+
+	session, err := s.sessionRepo.GetByID(ctx, sessionID)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		s.cacheStatus(ctx, key, sessionRevoked, sessionRevokedTTL)
+		return ErrSessionRevoked
+	}
+	if err != nil {
+		return err
+	}
+
+	remaining := time.Until(session.ExpiresAt)
+	if session.IsBlocked || remaining <= 0 {
+		s.cacheStatus(ctx, key, sessionRevoked, sessionRevokedTTL)
+		return ErrSessionRevoked
+	}
+	// The active status is stored as the owner's ID, so a token pairing someone
+	// else's session with its own subject never matches. It is only stored if no
+	// status is cached yet: the session may have been revoked since it was read,
+	// and the revoked status must win.
+	stored, err := s.cache.SetNX(ctx, key, session.UserID.Hex(), min(sessionStatusTTL, remaining))
+	if err != nil {
+		log.Warn().Err(err).Msg("Failed to cache session status")
+	} else if !stored {
+		if status, err := s.cache.Get(ctx, key); err == nil && status != userID.Hex() {
+			return ErrSessionRevoked
+		}
+	}
+	if session.UserID != userID {
+		return ErrSessionRevoked
+	}
+	return nil
+}
+
+// markRevoked caches a revoked session's status. If that fails, the cached
+// status is removed instead, so a stale active status can't outlive the revocation.
+func (s *SessionService) markRevoked(ctx context.Context, id primitive.ObjectID) error {
+	key := sessionStatusKey(id)
+	err := s.cache.Set(ctx, key, sessionRevoked, sessionRevokedTTL)
+	if err == nil {
+		return nil
+	}
+	if delErr := s.cache.Del(ctx, key); delErr != nil {
+		return fmt.Errorf("failed to cache revoked session status: %w", err)
+	}
+	log.Warn().Err(err).Str("session_id", id.Hex()).Msg("Failed to cache revoked session status, cleared it instead")
+	return nil
+}
+
+func (s *SessionService) cacheStatus(ctx context.Context, key, status string, ttl time.Duration) {
+	if err := s.cache.Set(ctx, key, status, ttl); err != nil {
+		log.Warn().Err(err).Msg("Failed to cache session status")
+	}
+}
+
+func sessionStatusKey(sessionID primitive.ObjectID) string {
+	return fmt.Sprintf("session:status:%s", sessionID.Hex())
 }
@@ -0,0 +1,192 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+	"vybes/internal/domain"
+	"vybes/internal/repository"
+	"vybes/pkg/cache"
+	"vybes/pkg/siwe"
+	"vybes/pkg/utils"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/rs/zerolog/log"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+const (
+	siweNonceTTL     = 10 * time.Minute
+	siweNonceLength  = 16
+	siweNonceCharset = "abcdefghijklmnopqrstuvwxyzABCDEFGHIJKLMNOPQRSTUVWXYZ0123456789"
+	// siweClockSkew is how far in the future a message's issue time may be
+	siweClockSkew = 5 * time.Minute
+)
+
+var (
+	// ErrSIWENotConfigured is returned when no sign-in domain is configured.
+	ErrSIWENotConfigured = errors.New("sign-in with Ethereum is not configured")
+	// ErrInvalidSIWEMessage is returned when a signed message fails any check.
+	ErrInvalidSIWEMessage = errors.New("invalid sign-in with Ethereum message")
+	// ErrWalletNotLinked is returned when no account uses the signing wallet.
+	ErrWalletNotLinked = errors.New("no account is linked to this wallet")
+	// ErrWalletAlreadyLinked is returned when linking a wallet that belongs to an account.
+	ErrWalletAlreadyLinked = errors.New("this wallet is already linked to an account")
+)
+
+// SIWEService defines the interface for Sign-In with Ethereum (EIP-4361) and
+// for linking external wallets to accounts.
+type SIWEService interface {
+	// NewNonce issues a single-use nonce to put in the message
+	NewNonce(ctx context.Context) (string, error)
+	// Verify checks a signed message and consumes its nonce, returning the wallet that signed it
+	Verify(ctx context.Context, message, signature string) (common.Address, error)
+	// FindUser returns the account whose own or linked wallet is address
+	FindUser(ctx context.Context, address common.Address) (*domain.User, error)
+	ListWallets(ctx context.Context, userID primitive.ObjectID) ([]domain.WalletLink, error)
+	// LinkWallet links the wallet that signed message to the user
+	LinkWallet(ctx context.Context, userID primitive.ObjectID, message, signature string) (*domain.WalletLink, error)
+	UnlinkWallet(ctx context.Context, userID primitive.ObjectID, address string) error
+}
+
+type siweService struct {
+	userRepo       repository.UserRepository
+	walletLinkRepo repository.WalletLinkRepository
+	cache          cache.Client
+	domain         string
+	chainID        int64
+}
+
+// NewSIWEService creates a new Sign-In with Ethereum service. Messages must be
+// issued for the given domain and chain.
+func NewSIWEService(userRepo repository.UserRepository, walletLinkRepo repository.WalletLinkRepository, cache cache.Client, domain string, chainID int64) SIWEService {
+	return &siweService{
+		userRepo:       userRepo,
+		walletLinkRepo: walletLinkRepo,
+		cache:          cache,
+		domain:         domain,
+		chainID:        chainID,
+	}
+}
+
+func (s *siweService) NewNonce(ctx context.Context) (string, error) {
+	if s.domain == "" {
+		return "", ErrSIWENotConfigured
+	}
+	nonce, err := utils.GenerateRandomString(siweNonceLength, siweNonceCharset)
+	if err != nil {
+		return "", err
+	}
+	if err := s.cache.Set(ctx, siweNonceKey(nonce), 1, siweNonceTTL); err != nil {
+		return "", err
+	}
+	return nonce, nil
+}
+
+func (s *siweService) Verify(ctx context.Context, message, signature string) (common.Address, error) {
+	if s.domain == "" {
+		return common.Address{}, ErrSIWENotConfigured
+	}
+	msg, err := siwe.Parse(message)
+	if err != nil {
+		return common.Address{}, fmt.Errorf("%w: %v", ErrInvalidSIWEMessage, err)
+	}
+
+	now := time.Now()
+	switch {
+	case msg.Domain != s.domain:
+		return common.Address{}, fmt.Errorf("%w: wrong domain", ErrInvalidSIWEMessage)
+	case msg.ChainID != s.chainID:
+		return common.Address{}, fmt.Errorf("%w: wrong chain ID", ErrInvalidSIWEMessage)
+	case msg.IssuedAt.After(now.Add(siweClockSkew)) || !msg.ValidAt(now):
+		return common.Address{}, fmt.Errorf("%w: expired or not yet valid", ErrInvalidSIWEMessage)
+	}
+
+	signer, err := siwe.RecoverAddress(message, signature)
+	if err != nil || signer != msg.Address {
+		return common.Address{}, fmt.Errorf("%w: signature doesn't match the address", ErrInvalidSIWEMessage)
+	}
+
+	// Renaming fails when the key is missing, so only one request can use a nonce
+	key := siweNonceKey(msg.Nonce)
+	if err := s.cache.Rename(ctx, key, key+":used"); err != nil {
+		return common.Address{}, fmt.Errorf("%w: unknown or already used nonce", ErrInvalidSIWEMessage)
+	}
+	if err := s.cache.Del(ctx, key+":used"); err != nil {
+		log.Warn().Err(err).Msg("Failed to delete used SIWE nonce")
+	}
+	return signer, nil
+}
+
+func (s *siweService) FindUser(ctx context.Context, address common.Address) (*domain.User, error) {
+	user, err := s.userRepo.GetUserByWalletAddress(ctx, address.Hex())
+	if err == nil {
+		return user, nil
+	}
+	if !errors.Is(err, mongo.ErrNoDocuments) {
+		return nil, err
+	}
+
+	link, err := s.walletLinkRepo.GetWalletLinkByAddress(ctx, address.Hex())
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return nil, ErrWalletNotLinked
+	}
+	if err != nil {
+		return nil, err
+	}
+	return s.userRepo.GetUserByID(ctx, link.UserID)
+}
+
+func (s *siweService) ListWallets(ctx context.Context, userID primitive.ObjectID) ([]domain.WalletLink, error) {
+	return s.walletLinkRepo.GetWalletLinksByUser(ctx, userID)
+}
+
+func (s *siweService) LinkWallet(ctx context.Context, userID primitive.ObjectID, message, signature string) (*domain.WalletLink, error) {
+	address, err := s.Verify(ctx, message, signature)
+	if err != nil {
+		return nil, err
+	}
+
+	// Accounts' own wallets sign in their owners already
+	_, err = s.userRepo.GetUserByWalletAddress(ctx, address.Hex())
+	if err == nil {
+		return nil, ErrWalletAlreadyLinked
+	}
+	if !errors.Is(err, mongo.ErrNoDocuments) {
+		return nil, err
+	}
+
+	link := &domain.WalletLink{
+		ID:        primitive.NewObjectID(),
+		UserID:    userID,
+		Address:   address.Hex(),
+		CreatedAt: time.Now(),
+	}
+	if err := s.walletLinkRepo.CreateWalletLink(ctx, link); err != nil {
+		if mongo.IsDuplicateKeyError(err) {
+			return nil, ErrWalletAlreadyLinked
+		}
+		return nil, err
+	}
+	return link, nil
+}
+
+func (s *siweService) UnlinkWallet(ctx context.Context, userID primitive.ObjectID, address string) error {
+	if !common.IsHexAddress(address) {
+		return ErrWalletNotLinked
+	}
+	deleted, err := s.walletLinkRepo.DeleteWalletLink(ctx, userID, common.HexToAddress(address).Hex())
+	if err != nil {
+		return err
+	}
+	if !deleted {
+		return ErrWalletNotLinked
+	}
+	return nil
+}
+
+func siweNonceKey(nonce string) string {
+	return fmt.Sprintf("siwe:nonce:%s", nonce)
+}
@@ -21,19 +21,21 @@ type StoryService interface {
 }
 
 type storyService struct {
-	storyRepo  repository.StoryRepository
-	followRepo repository.FollowRepository
-	storage    storage.Client
-	cfg        *config.Config
+	storyRepo    repository.StoryRepository
+	followRepo   repository.FollowRepository
+	blockService BlockService
+	storage      storage.Client
+	cfg          *config.Config
 }
 
 // NewStoryService creates a new story service.
-func NewStoryService(storyRepo repository.StoryRepository, followRepo repository.FollowRepository, storage storage.Client, cfg *config.Config) StoryService {
+func NewStoryService(storyRepo repository.StoryRepository, followRepo repository.FollowRepository, blockService BlockService, storage storage.Client, cfg *config.Config) StoryService {
 	return &storyService{
-		storyRepo:  storyRepo,
-		followRepo: followRepo,
-		storage:    storage,
-		cfg:        cfg,
+		storyRepo:    storyRepo,
+		followRepo:   followRepo,
+		blockService: blockService,
+		storage:      storage,
+		cfg:          cfg,
 	}
 }
 
@@ -88,8 +90,20 @@ func (s *storyService) GetStoryFeed(ctx context.Context, userIDStr string) ([]do
 		return nil, err
 	}
 
+	// Muted users are still followed but their stories are hidden
+	hidden, err := s.blockService.GetHiddenUserIDs(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	authorIDs := make([]primitive.ObjectID, 0, len(followingIDs)+1)
+	for _, id := range followingIDs {
+		if !hidden[id] {
+			authorIDs = append(authorIDs, id)
+		}
+	}
+
 	// Also include the user's own stories in their feed
-	followingIDs = append(followingIDs, userID)
+	authorIDs = append(authorIDs, userID)
 
-	return s.storyRepo.GetStoriesForFeed(ctx, followingIDs)
+	return s.storyRepo.GetStoriesForFeed(ctx, authorIDs)
 }
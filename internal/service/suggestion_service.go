@@ -14,15 +14,17 @@ type SuggestionService interface {
 }
 
 type suggestionService struct {
-	userRepo   repository.UserRepository
-	followRepo repository.FollowRepository
+	userRepo     repository.UserRepository
+	followRepo   repository.FollowRepository
+	blockService BlockService
 }
 
 // NewSuggestionService creates a new suggestion service.
-func NewSuggestionService(userRepo repository.UserRepository, followRepo repository.FollowRepository) SuggestionService {
+func NewSuggestionService(userRepo repository.UserRepository, followRepo repository.FollowRepository, blockService BlockService) SuggestionService {
 	return &suggestionService{
-		userRepo:   userRepo,
-		followRepo: followRepo,
+		userRepo:     userRepo,
+		followRepo:   followRepo,
+		blockService: blockService,
 	}
 }
 
@@ -39,6 +41,12 @@ func (s *suggestionService) GetSuggestions(ctx context.Context, userIDStr string
 		return nil, err
 	}
 
+	// Never suggest users on either side of a block
+	blocked, err := s.blockService.GetBlockedUserIDs(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
 	// 2. For each user they are following, get the list of users *they* are following.
 	suggestionMap := make(map[primitive.ObjectID]bool)
 	for _, friendID := range followingIDs {
@@ -55,7 +63,7 @@ func (s *suggestionService) GetSuggestions(ctx context.Context, userIDStr string
 					break
 				}
 			}
-			if fofID != userID && !isFollowing {
+			if fofID != userID && !isFollowing && !blocked[fofID] {
 				suggestionMap[fofID] = true
 			}
 		}
@@ -0,0 +1,370 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+	"time"
+	"vybes/internal/config"
+	"vybes/internal/domain"
+	"vybes/internal/repository"
+	"vybes/pkg/cache"
+	"vybes/pkg/pagination"
+
+	"github.com/rs/zerolog/log"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// Home timeline storage limits
+const (
+	timelineMaxLength      = 800                // Newest entries kept per timeline; older pages are read from MongoDB
+	timelineTTL            = 7 * 24 * time.Hour // Timelines of users who stop reading expire and are rebuilt on return
+	timelineFanOutBatch    = 1000               // Follower timelines written per Redis round trip
+	timelineBackfillLimit  = 50                 // Recent posts merged into a timeline when a new account is followed
+	timelineCelebritiesKey = "timeline:celebrities"
+)
+
+// TimelineService maintains precomputed home timelines in Redis.
+//
+// Every user's timeline is a sorted set of "<postID>:<authorID>" members scored
+// by creation time in milliseconds. New posts are pushed into the timelines of
+// the author's followers when they are created (fan-out on write). Authors with
+// more followers than the celebrity threshold are skipped on write and their
+// posts are merged in when a timeline is read (fan-out on read) instead.
+type TimelineService interface {
+	// FanOutPost pushes a newly created post into the timelines of its author and their followers
+	FanOutPost(ctx context.Context, post *domain.Post) error
+	// RemovePost takes a deleted post out of every timeline it was pushed to
+	RemovePost(ctx context.Context, postID, authorID primitive.ObjectID) error
+	// AddFollow merges the recent posts of a newly followed account into the follower's timeline
+	AddFollow(ctx context.Context, followerID, followingID primitive.ObjectID) error
+	// RemoveFollow trims an unfollowed account's posts from the follower's timeline
+	RemoveFollow(ctx context.Context, followerID, followingID primitive.ObjectID) error
+	// GetTimeline returns up to limit posts of a user's home timeline after the cursor, newest first
+	GetTimeline(ctx context.Context, userID primitive.ObjectID, after *pagination.Cursor, limit int) ([]domain.Post, error)
+}
+
+type timelineService struct {
+	cache       cache.Client
+	followRepo  repository.FollowRepository
+	contentRepo repository.ContentRepository
+	cfg         *config.Config
+}
+
+// NewTimelineService creates a new timeline service.
+func NewTimelineService(cache cache.Client, followRepo repository.FollowRepository, contentRepo repository.ContentRepository, cfg *config.Config) TimelineService {
+	return &timelineService{
+		cache:       cache,
+		followRepo:  followRepo,
+		contentRepo: contentRepo,
+		cfg:         cfg,
+	}
+}
+
+// followerVisibilities are the post visibilities followers are allowed to see
+var followerVisibilities = []domain.PostVisibility{domain.VisibilityPublic, domain.VisibilityFriends}
+
+func timelineKey(userID primitive.ObjectID) string {
+	return "timeline:" + userID.Hex()
+}
+
+// timelineReadyKey marks a timeline as complete. Timelines that only hold
+// fanned-out entries, or none at all, are rebuilt from MongoDB on first read.
+func timelineReadyKey(userID primitive.ObjectID) string {
+	return "timeline:" + userID.Hex() + ":ready"
+}
+
+func timelineMember(postID, authorID primitive.ObjectID) string {
+	return postID.Hex() + ":" + authorID.Hex()
+}
+
+func timelineEntry(post *domain.Post) cache.ZMember {
+	return cache.ZMember{Member: timelineMember(post.ID, post.UserID), Score: float64(post.CreatedAt.UnixMilli())}
+}
+
+// parseTimelineMember splits a timeline member into its post and author IDs.
+func parseTimelineMember(member string) (postID, authorID primitive.ObjectID, ok bool) {
+	postHex, authorHex, found := strings.Cut(member, ":")
+	if !found {
+		return postID, authorID, false
+	}
+	postID, err := primitive.ObjectIDFromHex(postHex)
+	if err != nil {
+		return postID, authorID, false
+	}
+	authorID, err = primitive.ObjectIDFromHex(authorHex)
+	return postID, authorID, err == nil
+}
+
+// isCelebrity reports whether an author has too many followers to fan out to,
+// keeping the shared celebrity set in step with the answer.
+func (s *timelineService) isCelebrity(ctx context.Context, authorID primitive.ObjectID) (bool, error) {
+	count, err := s.followRepo.GetFollowerCount(ctx, authorID)
+	if err != nil {
+		return false, err
+	}
+	if count > int64(s.cfg.TimelineCelebrityThreshold) {
+		return true, s.cache.SAdd(ctx, timelineCelebritiesKey, authorID.Hex())
+	}
+	return false, s.cache.SRem(ctx, timelineCelebritiesKey, authorID.Hex())
+}
+
+func (s *timelineService) FanOutPost(ctx context.Context, post *domain.Post) error {
+	entry := timelineEntry(post)
+
+	// Authors always see their own posts, whatever their visibility
+	keys := []string{timelineKey(post.UserID)}
+	if post.Visibility != domain.VisibilityPrivate {
+		celebrity, err := s.isCelebrity(ctx, post.UserID)
+		if err != nil {
+			return fmt.Errorf("failed to check follower count: %w", err)
+		}
+		if !celebrity {
+			followerIDs, err := s.followRepo.GetFollowerIDs(ctx, post.UserID)
+			if err != nil {
+				return fmt.Errorf("failed to get followers: %w", err)
+			}
+			for _, id := range followerIDs {
+				keys = append(keys, timelineKey(id))
+			}
+		}
+	}
+
+	for start := 0; start < len(keys); start += timelineFanOutBatch {
+		end := min(start+timelineFanOutBatch, len(keys))
+		if err := s.cache.ZAddCapped(ctx, keys[start:end], entry, timelineMaxLength, timelineTTL); err != nil {
+			return fmt.Errorf("failed to push post to timelines: %w", err)
+		}
+	}
+	return nil
+}
+
+func (s *timelineService) RemovePost(ctx context.Context, postID, authorID primitive.ObjectID) error {
+	member := timelineMember(postID, authorID)
+	keys := []string{timelineKey(authorID)}
+
+	// Celebrity posts were never fanned out. Any entries left from before the
+	// author crossed the threshold are skipped on read once the post is gone.
+	celebrity, err := s.isCelebrity(ctx, authorID)
+	if err != nil {
+		return fmt.Errorf("failed to check follower count: %w", err)
+	}
+	if !celebrity {
+		followerIDs, err := s.followRepo.GetFollowerIDs(ctx, authorID)
+		if err != nil {
+			return fmt.Errorf("failed to get followers: %w", err)
+		}
+		for _, id := range followerIDs {
+			keys = append(keys, timelineKey(id))
+		}
+	}
+
+	for start := 0; start < len(keys); start += timelineFanOutBatch {
+		end := min(start+timelineFanOutBatch, len(keys))
+		if err := s.cache.ZRemFromAll(ctx, keys[start:end], member); err != nil {
+			return fmt.Errorf("failed to remove post from timelines: %w", err)
+		}
+	}
+	return nil
+}
+
+func (s *timelineService) AddFollow(ctx context.Context, followerID, followingID primitive.ObjectID) error {
+	// A timeline that isn't built yet picks the new account up when it is rebuilt
+	ready, err := s.cache.Exists(ctx, timelineReadyKey(followerID))
+	if err != nil || !ready {
+		return err
+	}
+	celebrity, err := s.isCelebrity(ctx, followingID)
+	if err != nil || celebrity {
+		return err
+	}
+
+	posts, err := s.contentRepo.GetPostsByUsersWithVisibility(ctx, []primitive.ObjectID{followingID}, followerVisibilities, nil, timelineBackfillLimit)
+	if err != nil {
+		return fmt.Errorf("failed to get posts of followed user: %w", err)
+	}
+	entries := make([]cache.ZMember, len(posts))
+	for i := range posts {
+		entries[i] = timelineEntry(&posts[i])
+	}
+	return s.cache.ZAdd(ctx, timelineKey(followerID), entries...)
+}
+
+func (s *timelineService) RemoveFollow(ctx context.Context, followerID, followingID primitive.ObjectID) error {
+	key := timelineKey(followerID)
+	entries, err := s.cache.ZRevRangeByScore(ctx, key, math.Inf(1), 0)
+	if err != nil {
+		return fmt.Errorf("failed to read timeline: %w", err)
+	}
+	suffix := ":" + followingID.Hex()
+	var stale []string
+	for _, e := range entries {
+		if strings.HasSuffix(e.Member, suffix) {
+			stale = append(stale, e.Member)
+		}
+	}
+	return s.cache.ZRem(ctx, key, stale...)
+}
+
+// GetTimeline reads a page of the home timeline from Redis, rebuilding it from
+// MongoDB if it is missing. Posts of followed celebrities are merged in from
+// MongoDB, and pages older than the newest timelineMaxLength entries are served
+// from MongoDB entirely.
+func (s *timelineService) GetTimeline(ctx context.Context, userID primitive.ObjectID, after *pagination.Cursor, limit int) ([]domain.Post, error) {
+	ready, err := s.cache.Exists(ctx, timelineReadyKey(userID))
+	if err != nil {
+		return nil, err
+	}
+	if !ready {
+		if err := s.rebuild(ctx, userID); err != nil {
+			return nil, fmt.Errorf("failed to rebuild timeline: %w", err)
+		}
+	}
+
+	key := timelineKey(userID)
+	maxScore := math.Inf(1)
+	if after != nil {
+		maxScore = float64(after.CreatedAt.UnixMilli())
+	}
+	// Read a little extra to make up for entries tied with the cursor and posts deleted since fan-out
+	count := int64(limit + limit/2 + 10)
+	entries, err := s.cache.ZRevRangeByScore(ctx, key, maxScore, count)
+	if err != nil {
+		return nil, err
+	}
+
+	if int64(len(entries)) < count {
+		size, err := s.cache.ZCard(ctx, key)
+		if err != nil {
+			return nil, err
+		}
+		if size >= timelineMaxLength {
+			// The page reaches past the oldest entry Redis keeps
+			return s.loadFromMongo(ctx, userID, after, limit)
+		}
+	}
+
+	var postIDs []primitive.ObjectID
+	for _, e := range entries {
+		postID, _, ok := parseTimelineMember(e.Member)
+		if !ok {
+			continue
+		}
+		// Members with the cursor's score come back in descending ID order; skip those already served
+		if after != nil && e.Score == maxScore && postID.Hex() >= after.ID.Hex() {
+			continue
+		}
+		postIDs = append(postIDs, postID)
+	}
+
+	var posts []domain.Post
+	if len(postIDs) > 0 {
+		// Posts deleted since they were fanned out are simply missing here
+		posts, err = s.contentRepo.GetPostsByIDs(ctx, postIDs)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	celebrityPosts, err := s.getCelebrityPosts(ctx, userID, after, limit)
+	if err != nil {
+		return nil, err
+	}
+	return newestPosts(append(posts, celebrityPosts...), limit), nil
+}
+
+// getCelebrityPosts fetches the posts of followed accounts that are not fanned out.
+func (s *timelineService) getCelebrityPosts(ctx context.Context, userID primitive.ObjectID, after *pagination.Cursor, limit int) ([]domain.Post, error) {
+	members, err := s.cache.SMembers(ctx, timelineCelebritiesKey)
+	if err != nil || len(members) == 0 {
+		return nil, err
+	}
+	celebrityIDs := make([]primitive.ObjectID, 0, len(members))
+	for _, m := range members {
+		if id, err := primitive.ObjectIDFromHex(m); err == nil {
+			celebrityIDs = append(celebrityIDs, id)
+		}
+	}
+	followed, err := s.followRepo.GetFollowingAmong(ctx, userID, celebrityIDs)
+	if err != nil || len(followed) == 0 {
+		return nil, err
+	}
+	return s.contentRepo.GetPostsByUsersWithVisibility(ctx, followed, followerVisibilities, after, limit)
+}
+
+// rebuild repopulates a timeline from MongoDB. The old set is dropped first so
+// entries of accounts unfollowed while the timeline was incomplete go away;
+// posts fanned out while the rebuild runs are kept by the union in ZADD.
+func (s *timelineService) rebuild(ctx context.Context, userID primitive.ObjectID) error {
+	key := timelineKey(userID)
+	if err := s.cache.Del(ctx, key); err != nil {
+		return err
+	}
+	posts, err := s.loadFromMongo(ctx, userID, nil, timelineMaxLength)
+	if err != nil {
+		return err
+	}
+	entries := make([]cache.ZMember, len(posts))
+	for i := range posts {
+		entries[i] = timelineEntry(&posts[i])
+	}
+	if err := s.cache.ZAdd(ctx, key, entries...); err != nil {
+		return err
+	}
+	if err := s.cache.Expire(ctx, key, timelineTTL); err != nil {
+		return err
+	}
+	// Expire the marker slightly before the set so a ready timeline always exists
+	if err := s.cache.Set(ctx, timelineReadyKey(userID), "1", timelineTTL-time.Minute); err != nil {
+		return err
+	}
+	log.Debug().Str("user_id", userID.Hex()).Int("entries", len(entries)).Msg("Rebuilt home timeline")
+	return nil
+}
+
+// loadFromMongo builds a page of the home timeline straight from MongoDB: the
+// user's own posts plus the public and friends-only posts of followed accounts.
+func (s *timelineService) loadFromMongo(ctx context.Context, userID primitive.ObjectID, after *pagination.Cursor, limit int) ([]domain.Post, error) {
+	own, err := s.contentRepo.GetPostsByUserID(ctx, userID, after, limit)
+	if err != nil {
+		return nil, err
+	}
+	followingIDs, err := s.followRepo.GetFollowingIDs(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	if len(followingIDs) == 0 {
+		return own, nil
+	}
+	following, err := s.contentRepo.GetPostsByUsersWithVisibility(ctx, followingIDs, followerVisibilities, after, limit)
+	if err != nil {
+		return nil, err
+	}
+	return newestPosts(append(own, following...), limit), nil
+}
+
+// newestPosts dedupes posts and returns up to limit of them, newest first with
+// ties broken by descending ID, matching the order of post cursors.
+func newestPosts(posts []domain.Post, limit int) []domain.Post {
+	seen := make(map[primitive.ObjectID]bool, len(posts))
+	unique := make([]domain.Post, 0, len(posts))
+	for _, p := range posts {
+		if !seen[p.ID] {
+			seen[p.ID] = true
+			unique = append(unique, p)
+		}
+	}
+	sort.Slice(unique, func(i, j int) bool {
+		a, b := unique[i].CreatedAt.UnixMilli(), unique[j].CreatedAt.UnixMilli()
+		if a != b {
+			return a > b
+		}
+		return unique[i].ID.Hex() > unique[j].ID.Hex()
+	})
+	if len(unique) > limit {
+		unique = unique[:limit]
+	}
+	return unique
+}
@@ -0,0 +1,279 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"regexp"
+	"strings"
+	"time"
+	"vybes/internal/domain"
+	"vybes/internal/repository"
+	"vybes/pkg/totp"
+	"vybes/pkg/utils"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+const (
+	totpIssuer = "Vybes"
+
+	recoveryCodeCount = 10
+	// recoveryCodeCharset has 32 symbols, so random bytes map onto it without bias,
+	// and leaves out characters that are easy to misread (0/O, 1/I)
+	recoveryCodeCharset = "ABCDEFGHJKLMNPQRSTUVWXYZ23456789"
+	recoveryCodeLength  = 10
+)
+
+var (
+	// ErrTwoFactorRequired is returned when an operation needs a two-factor code and none was given.
+	ErrTwoFactorRequired = errors.New("a two-factor authentication code is required")
+	// ErrInvalidTwoFactorCode is returned for wrong, expired or already used codes.
+	ErrInvalidTwoFactorCode = errors.New("invalid two-factor authentication code")
+	// ErrTwoFactorEnabled is returned when enrolling an account that already uses two-factor authentication.
+	ErrTwoFactorEnabled = errors.New("two-factor authentication is already enabled")
+	// ErrTwoFactorNotEnabled is returned when managing two-factor authentication that isn't turned on.
+	ErrTwoFactorNotEnabled = errors.New("two-factor authentication is not enabled")
+
+	totpCodePattern = regexp.MustCompile(`^[0-9]{6}$`)
+)
+
+// TwoFactorStatus describes a user's two-factor authentication setup.
+type TwoFactorStatus struct {
+	Enabled                bool `json:"enabled"`
+	RecoveryCodesRemaining int  `json:"recovery_codes_remaining"`
+}
+
+// TwoFactorEnrollment holds what an authenticator app needs to start producing codes.
+type TwoFactorEnrollment struct {
+	Secret          string `json:"secret"`
+	ProvisioningURI string `json:"provisioning_uri"`
+}
+
+// TwoFactorService defines the interface for TOTP two-factor authentication (RFC 6238).
+type TwoFactorService interface {
+	GetStatus(ctx context.Context, userID string) (*TwoFactorStatus, error)
+	// Enroll creates a new secret. It takes effect once Activate confirms a code from it.
+	Enroll(ctx context.Context, userID, password string) (*TwoFactorEnrollment, error)
+	// Activate turns two-factor authentication on and returns the recovery codes
+	Activate(ctx context.Context, userID, code string) ([]string, error)
+	Disable(ctx context.Context, userID, password, code string) error
+	RegenerateRecoveryCodes(ctx context.Context, userID, code string) ([]string, error)
+
+	// VerifyCode accepts a current TOTP code or an unused recovery code
+	VerifyCode(ctx context.Context, user *domain.User, code string) error
+	// RequireFreshTOTP guards sensitive operations: users with two-factor
+	// authentication must present a current TOTP code that hasn't been used yet
+	RequireFreshTOTP(ctx context.Context, user *domain.User, code string) error
+}
+
+type twoFactorService struct {
+	userRepo repository.UserRepository
+	keys     WalletKeyService
+}
+
+// NewTwoFactorService creates a new two-factor service. TOTP secrets are
+// envelope-encrypted with the wallet master keys, so they are re-wrapped
+// along with the wallet keys when a master key is rotated.
+func NewTwoFactorService(userRepo repository.UserRepository, keys WalletKeyService) TwoFactorService {
+	return &twoFactorService{
+		userRepo: userRepo,
+		keys:     keys,
+	}
+}
+
+func (s *twoFactorService) getUser(ctx context.Context, userIDStr string) (*domain.User, error) {
+	userID, err := primitive.ObjectIDFromHex(userIDStr)
+	if err != nil {
+		return nil, errors.New("invalid user ID format")
+	}
+	return s.userRepo.GetUserByID(ctx, userID)
+}
+
+func (s *twoFactorService) GetStatus(ctx context.Context, userIDStr string) (*TwoFactorStatus, error) {
+	user, err := s.getUser(ctx, userIDStr)
+	if err != nil {
+		return nil, err
+	}
+	return &TwoFactorStatus{
+		Enabled:                user.TOTPEnabled,
+		RecoveryCodesRemaining: len(user.RecoveryCodeHashes),
+	}, nil
+}
+
+func (s *twoFactorService) Enroll(ctx context.Context, userIDStr, password string) (*TwoFactorEnrollment, error) {
+	user, err := s.getUser(ctx, userIDStr)
+	if err != nil {
+		return nil, err
+	}
+	if !utils.CheckPasswordHash(password, user.Password) {
+		return nil, errors.New("invalid password")
+	}
+	if user.TOTPEnabled {
+		return nil, ErrTwoFactorEnabled
+	}
+
+	secret, err := totp.GenerateSecret()
+	if err != nil {
+		return nil, err
+	}
+	sealed, err := s.keys.Seal(ctx, secret)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.userRepo.SetPendingTOTPSecret(ctx, user.ID, sealed.Ciphertext, sealed.WrappedKey, sealed.KeyVersion); err != nil {
+		return nil, err
+	}
+	return &TwoFactorEnrollment{
+		Secret:          secret,
+		ProvisioningURI: totp.ProvisioningURI(secret, totpIssuer, user.Email),
+	}, nil
+}
+
+func (s *twoFactorService) Activate(ctx context.Context, userIDStr, code string) ([]string, error) {
+	user, err := s.getUser(ctx, userIDStr)
+	if err != nil {
+		return nil, err
+	}
+	if user.TOTPEnabled {
+		return nil, ErrTwoFactorEnabled
+	}
+	if user.TOTPPendingSecret == "" {
+		return nil, errors.New("start two-factor enrollment first")
+	}
+
+	secret, err := s.keys.OpenSecret(ctx, sealedPendingTOTPSecret(user))
+	if err != nil {
+		return nil, errors.New("could not decrypt two-factor secret")
+	}
+	step, ok := totp.Validate(secret, code, time.Now())
+	if !ok {
+		return nil, ErrInvalidTwoFactorCode
+	}
+	// Sealed afresh, so a secret enrolled before a master key rotation is stored under the current key
+	sealed, err := s.keys.Seal(ctx, secret)
+	if err != nil {
+		return nil, err
+	}
+
+	codes, hashes, err := generateRecoveryCodes()
+	if err != nil {
+		return nil, err
+	}
+	if err := s.userRepo.EnableTOTP(ctx, user.ID, sealed.Ciphertext, sealed.WrappedKey, sealed.KeyVersion, hashes, step); err != nil {
+		return nil, err
+	}
+	return codes, nil
+}
+
+func (s *twoFactorService) Disable(ctx context.Context, userIDStr, password, code string) error {
+	user, err := s.getUser(ctx, userIDStr)
+	if err != nil {
+		return err
+	}
+	if !utils.CheckPasswordHash(password, user.Password) {
+		return errors.New("invalid password")
+	}
+	if !user.TOTPEnabled {
+		return ErrTwoFactorNotEnabled
+	}
+	if err := s.VerifyCode(ctx, user, code); err != nil {
+		return err
+	}
+	return s.userRepo.DisableTOTP(ctx, user.ID)
+}
+
+func (s *twoFactorService) RegenerateRecoveryCodes(ctx context.Context, userIDStr, code string) ([]string, error) {
+	user, err := s.getUser(ctx, userIDStr)
+	if err != nil {
+		return nil, err
+	}
+	if !user.TOTPEnabled {
+		return nil, ErrTwoFactorNotEnabled
+	}
+	if err := s.RequireFreshTOTP(ctx, user, code); err != nil {
+		return nil, err
+	}
+
+	codes, hashes, err := generateRecoveryCodes()
+	if err != nil {
+		return nil, err
+	}
+	if err := s.userRepo.SetRecoveryCodes(ctx, user.ID, hashes); err != nil {
+		return nil, err
+	}
+	return codes, nil
+}
+
+func (s *twoFactorService) VerifyCode(ctx context.Context, user *domain.User, code string) error {
+	code = strings.TrimSpace(code)
+	if code == "" {
+		return ErrTwoFactorRequired
+	}
+	if totpCodePattern.MatchString(code) {
+		return s.consumeTOTP(ctx, user, code)
+	}
+
+	used, err := s.userRepo.ConsumeRecoveryCode(ctx, user.ID, utils.HashToken(normalizeRecoveryCode(code)))
+	if err != nil {
+		return err
+	}
+	if !used {
+		return ErrInvalidTwoFactorCode
+	}
+	return nil
+}
+
+func (s *twoFactorService) RequireFreshTOTP(ctx context.Context, user *domain.User, code string) error {
+	if !user.TOTPEnabled {
+		return nil
+	}
+	code = strings.TrimSpace(code)
+	if code == "" {
+		return ErrTwoFactorRequired
+	}
+	return s.consumeTOTP(ctx, user, code)
+}
+
+// consumeTOTP validates a TOTP code and marks its time step as used, so the
+// same code can't be replayed within its validity window.
+func (s *twoFactorService) consumeTOTP(ctx context.Context, user *domain.User, code string) error {
+	secret, err := s.keys.OpenSecret(ctx, sealedTOTPSecret(user))
+	if err != nil {
+		return errors.New("could not decrypt two-factor secret")
+	}
+	step, ok := totp.Validate(secret, code, time.Now())
+	if !ok {
+		return ErrInvalidTwoFactorCode
+	}
+	fresh, err := s.userRepo.ConsumeTOTPStep(ctx, user.ID, step)
+	if err != nil {
+		return err
+	}
+	if !fresh {
+		return ErrInvalidTwoFactorCode
+	}
+	return nil
+}
+
+// generateRecoveryCodes returns new one-time recovery codes, formatted for
+// display, together with the hashes to store.
+func generateRecoveryCodes() ([]string, []string, error) {
+	codes := make([]string, recoveryCodeCount)
+	hashes := make([]string, recoveryCodeCount)
+	for i := range codes {
+		raw, err := utils.GenerateRandomString(recoveryCodeLength, recoveryCodeCharset)
+		if err != nil {
+			return nil, nil, err
+		}
+		codes[i] = raw[:recoveryCodeLength/2] + "-" + raw[recoveryCodeLength/2:]
+		hashes[i] = utils.HashToken(raw)
+	}
+	return codes, hashes, nil
+}
+
+// normalizeRecoveryCode accepts codes typed in lower case or without the dash.
+func normalizeRecoveryCode(code string) string {
+	code = strings.ToUpper(code)
+	code = strings.ReplaceAll(code, "-", "")
+	return strings.ReplaceAll(code, " ", "")
+}
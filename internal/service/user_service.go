@@ -2,13 +2,17 @@ package service
 
 import (
 	"context"
+	"crypto/ecdsa"
+	"encoding/json"
 	"errors"
 	"fmt"
+	"strings"
 	"time"
 	"vybes/internal/domain"
 	"vybes/internal/repository"
 	"vybes/pkg/cache"
 	"vybes/pkg/evm"
+	"vybes/pkg/jwtkeys"
 	"vybes/pkg/utils"
 
 	"github.com/ethereum/go-ethereum/common"
@@ -16,7 +20,9 @@ import (
 	"github.com/ethereum/go-ethereum/core/types"
 	"github.com/ethereum/go-ethereum/signer/core/apitypes"
 	"github.com/golang-jwt/jwt/v5"
+	"github.com/rs/zerolog/log"
 	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
 )
 
 // ... (structs are unchanged)
@@ -25,12 +31,16 @@ type UpdateProfilePayload struct {
 	PFPURL    *string `json:"pfpUrl"`
 	BannerURL *string `json:"bannerUrl"`
 	Bio       *string `json:"bio"`
+	IsPrivate *bool   `json:"isPrivate"`
 }
 type UserProfileResponse struct {
 	domain.User
 	FollowerCount    int64  `json:"followerCount"`
 	FollowingCount   int64  `json:"followingCount"`
 	FriendshipStatus string `json:"friendshipStatus"`
+	// IsRestricted is set when the profile is private and the viewer doesn't
+	// follow it; only the basic identity fields are returned then.
+	IsRestricted bool `json:"isRestricted"`
 }
 
 type UserMinimal struct {
@@ -38,91 +48,167 @@ type UserMinimal struct {
 	Username string `json:"username"`
 }
 
+// LoginResponse carries a new session. For accounts with two-factor
+// authentication, Login only returns a challenge token that must be completed
+// with CompleteTwoFactorLogin.
 type LoginResponse struct {
-	AccessToken  string       `json:"access_token"`
-	RefreshToken string       `json:"refresh_token"`
-	UserData     *UserMinimal `json:"user_data"`
+	AccessToken       string       `json:"access_token,omitempty"`
+	RefreshToken      string       `json:"refresh_token,omitempty"`
+	UserData          *UserMinimal `json:"user_data,omitempty"`
+	TwoFactorRequired bool         `json:"two_factor_required,omitempty"`
+	ChallengeToken    string       `json:"challenge_token,omitempty"`
+}
+
+const (
+	loginChallengeTTL         = 5 * time.Minute
+	loginChallengeMaxAttempts = 5
+)
+
+// ErrInvalidLoginChallenge is returned for unknown, expired or exhausted login challenges.
+var ErrInvalidLoginChallenge = errors.New("invalid or expired login challenge")
+
+const (
+	passwordResetOTPTTL = 5 * time.Minute
+	// Failed reset attempts are counted over passwordResetLockout; past either
+	// limit, attempts are refused until the window ends
+	passwordResetLockout             = 15 * time.Minute
+	passwordResetMaxAttemptsPerEmail = 5
+	passwordResetMaxAttemptsPerIP    = 20
+)
+
+var (
+	// ErrInvalidOTP is returned for wrong, expired or already used password reset OTPs.
+	ErrInvalidOTP = errors.New("invalid or expired OTP")
+	// ErrTooManyOTPAttempts is returned while password resets are locked out.
+	ErrTooManyOTPAttempts = errors.New("too many attempts, try again later")
+)
+
+// loginChallenge is what a pending two-factor login remembers about the password step.
+type loginChallenge struct {
+	UserID    string `json:"userId"`
+	UserAgent string `json:"userAgent"`
+	ClientIP  string `json:"clientIp"`
 }
 
 // UserService defines the interface for user business logic.
 type UserService interface {
 	Register(ctx context.Context, name, email, password string) error
 	Login(ctx context.Context, email, password, userAgent, clientIP string) (*LoginResponse, error)
+	LoginWithEthereum(ctx context.Context, message, signature, userAgent, clientIP string) (*LoginResponse, error)
+	LoginWithOIDC(ctx context.Context, provider, state, binding, code, userAgent, clientIP string) (*LoginResponse, error)
+	CompleteTwoFactorLogin(ctx context.Context, challengeToken, code string) (*LoginResponse, error)
 	RefreshToken(ctx context.Context, refreshToken string) (*LoginResponse, error)
-	UnlockWallet(ctx context.Context, userID, password string) error
+	UnlockWallet(ctx context.Context, userID, sessionID, password, totpCode string) error
+	LockWallet(ctx context.Context, userID, sessionID string) error
 	GetUserProfile(ctx context.Context, viewerID string, vid *int64, username *string) (*UserProfileResponse, error)
 	UpdateProfile(ctx context.Context, userID string, payload UpdateProfilePayload) (*domain.User, error)
-	ExportPrivateKey(ctx context.Context, userID, password string) (string, error)
-	PersonalSign(ctx context.Context, userID, message string) (string, error)
-	SignTransaction(ctx context.Context, userID string, tx *types.Transaction) (string, error)
-	SendTransaction(ctx context.Context, userID string, tx *types.Transaction) (common.Hash, error)
-	SignTypedDataV4(ctx context.Context, userID string, typedData apitypes.TypedData) (string, error)
-	Secp256k1Sign(ctx context.Context, userID, hash string) (string, error)
+	ExportPrivateKey(ctx context.Context, userID, password, totpCode string) (string, error)
+	PersonalSign(ctx context.Context, userID, sessionID, message string) (string, error)
+	SignTransaction(ctx context.Context, userID, sessionID string, req evm.TxRequest) (string, error)
+	SendTransaction(ctx context.Context, userID, sessionID string, req evm.TxRequest) (common.Hash, error)
+	SignTypedDataV4(ctx context.Context, userID, sessionID string, typedData apitypes.TypedData) (string, error)
+	Secp256k1Sign(ctx context.Context, userID, sessionID, hash string) (string, error)
 	RequestOTP(ctx context.Context, email string) error
-	VerifyOTPAndResetPassword(ctx context.Context, email, otp, newPassword string) error
+	VerifyOTPAndResetPassword(ctx context.Context, email, otp, newPassword, clientIP string) error
 }
 
 type userService struct {
-	userRepo            repository.UserRepository
-	followRepo          repository.FollowRepository
-	counterRepo         repository.CounterRepository
-	sessionRepo         repository.ISessionRepository
-	walletService       WalletService
-	emailService        EmailService
-	sessionService      ISessionService
-	cache               cache.Client
-	jwtSecret           string
-	walletEncryptionKey string
+	userRepo          repository.UserRepository
+	followRepo        repository.FollowRepository
+	counterRepo       repository.CounterRepository
+	sessionRepo       repository.ISessionRepository
+	walletService     WalletService
+	emailService      EmailService
+	sessionService    ISessionService
+	timelineService   TimelineService
+	twoFactorService  TwoFactorService
+	emailVerification EmailVerificationService
+	siweService       SIWEService
+	oidcService       OIDCService
+	cache             cache.Client
+	jwtKeys           *jwtkeys.KeySet
+	walletKeys        WalletKeyService
+	walletUnlock      WalletUnlockService
+	walletTx          WalletTransactionService
+	blockService      BlockService
 }
 
 // NewUserService creates a new user service.
-func NewUserService(userRepo repository.UserRepository, followRepo repository.FollowRepository, counterRepo repository.CounterRepository, sessionRepo repository.ISessionRepository, walletService WalletService, emailService EmailService, sessionService ISessionService, cache cache.Client, jwtSecret, walletEncryptionKey string) UserService {
+func NewUserService(userRepo repository.UserRepository, followRepo repository.FollowRepository, counterRepo repository.CounterRepository, sessionRepo repository.ISessionRepository, walletService WalletService, emailService EmailService, sessionService ISessionService, timelineService TimelineService, twoFactorService TwoFactorService, emailVerification EmailVerificationService, siweService SIWEService, oidcService OIDCService, cache cache.Client, jwtKeys *jwtkeys.KeySet, walletKeys WalletKeyService, walletUnlock WalletUnlockService, walletTx WalletTransactionService, blockService BlockService) UserService {
 	return &userService{
-		userRepo:            userRepo,
-		followRepo:          followRepo,
-		counterRepo:         counterRepo,
-		sessionRepo:         sessionRepo,
-		walletService:       walletService,
-		emailService:        emailService,
-		sessionService:      sessionService,
-		cache:               cache,
-		jwtSecret:           jwtSecret,
-		walletEncryptionKey: walletEncryptionKey,
+		userRepo:          userRepo,
+		followRepo:        followRepo,
+		counterRepo:       counterRepo,
+		sessionRepo:       sessionRepo,
+		walletService:     walletService,
+		emailService:      emailService,
+		sessionService:    sessionService,
+		timelineService:   timelineService,
+		twoFactorService:  twoFactorService,
+		emailVerification: emailVerification,
+		siweService:       siweService,
+		oidcService:       oidcService,
+		cache:             cache,
+		jwtKeys:           jwtKeys,
+		walletKeys:        walletKeys,
+		walletUnlock:      walletUnlock,
+		walletTx:          walletTx,
+		blockService:      blockService,
 	}
 }
 
-// ... (Register, Login are unchanged)
+// Register creates an account with an unverified email address and emails it a
+// verification code.
 func (s *userService) Register(ctx context.Context, name, email, password string) error {
-	existingUser, err := s.userRepo.GetUserByEmail(ctx, email)
-	if err != nil {
-		return err
-	}
-	if existingUser != nil {
+	_, err := s.userRepo.GetUserByEmail(ctx, email)
+	if err == nil {
 		return errors.New("user with this email already exists")
 	}
+	if !errors.Is(err, mongo.ErrNoDocuments) {
+		return err
+	}
 	hashedPassword, err := utils.HashPassword(password)
 	if err != nil {
 		return err
 	}
-	nextVID, err := s.counterRepo.GetNextSequence(ctx, "user_vid")
+	user, err := newAccount(ctx, s.counterRepo, s.walletService, name, email, hashedPassword)
+	if err != nil {
+		return err
+	}
+	if err := s.userRepo.CreateUser(ctx, user); err != nil {
+		return err
+	}
+	// The account is usable without the email; a new code can be requested later
+	if err := s.emailVerification.SendCode(ctx, user); err != nil {
+		log.Warn().Err(err).Str("user_id", user.ID.Hex()).Msg("Failed to send verification email")
+	}
+	return nil
+}
+
+// newAccount builds a user with the next VID and a new custodial wallet. The
+// caller stores it.
+func newAccount(ctx context.Context, counterRepo repository.CounterRepository, walletService WalletService, name, email, hashedPassword string) (*domain.User, error) {
+	nextVID, err := counterRepo.GetNextSequence(ctx, "user_vid")
 	if err != nil {
-		return errors.New("could not generate user VID")
+		return nil, errors.New("could not generate user VID")
 	}
-	walletAddress, encryptedPrivateKey, err := s.walletService.CreateWallet()
+	walletAddress, encryptedPrivateKey, err := walletService.CreateWallet(ctx)
 	if err != nil {
-		return errors.New("could not create user wallet")
+		return nil, errors.New("could not create user wallet")
 	}
-	user := &domain.User{
+	return &domain.User{
 		ID:                  primitive.NewObjectID(),
 		VID:                 nextVID,
 		Name:                name,
 		Email:               email,
 		Password:            hashedPassword,
 		WalletAddress:       walletAddress,
-		EncryptedPrivateKey: encryptedPrivateKey,
-	}
-	return s.userRepo.CreateUser(ctx, user)
+		EncryptedPrivateKey: encryptedPrivateKey.Ciphertext,
+		WalletDataKey:       encryptedPrivateKey.WrappedKey,
+		WalletKeyVersion:    encryptedPrivateKey.KeyVersion,
+	}, nil
 }
+
 func (s *userService) Login(ctx context.Context, email, password, userAgent, clientIP string) (*LoginResponse, error) {
 	user, err := s.userRepo.GetUserByEmail(ctx, email)
 	if err != nil {
@@ -135,23 +221,123 @@ func (s *userService) Login(ctx context.Context, email, password, userAgent, cli
 		return nil, errors.New("invalid credentials")
 	}
 
-	refreshToken, err := utils.GenerateRandomString(32, "abcdefghijklmnopqrstuvwxyzABCDEFGHIJKLMNOPQRSTUVWXYZ0123456789")
+	return s.completeFirstFactor(ctx, user, userAgent, clientIP)
+}
+
+// LoginWithEthereum logs in with a signed Sign-In with Ethereum message from the
+// account's own wallet or a linked one. It replaces the password step, so
+// accounts with two-factor authentication still get a challenge.
+func (s *userService) LoginWithEthereum(ctx context.Context, message, signature, userAgent, clientIP string) (*LoginResponse, error) {
+	address, err := s.siweService.Verify(ctx, message, signature)
 	if err != nil {
 		return nil, err
 	}
+	user, err := s.siweService.FindUser(ctx, address)
+	if err != nil {
+		return nil, err
+	}
+	return s.completeFirstFactor(ctx, user, userAgent, clientIP)
+}
 
-	session, err := s.sessionService.Create(ctx, user.ID, refreshToken, userAgent, clientIP)
+// LoginWithOIDC finishes an OpenID Connect sign-in started with
+// OIDCService.AuthorizationURL. First-time users get an account and a wallet.
+// Like a password, the provider login is only the first factor.
+func (s *userService) LoginWithOIDC(ctx context.Context, provider, state, binding, code, userAgent, clientIP string) (*LoginResponse, error) {
+	claims, err := s.oidcService.Authenticate(ctx, provider, state, binding, code)
 	if err != nil {
 		return nil, err
 	}
+	user, err := s.oidcService.FindOrCreateUser(ctx, provider, claims)
+	if err != nil {
+		return nil, err
+	}
+	return s.completeFirstFactor(ctx, user, userAgent, clientIP)
+}
 
-	claims := jwt.MapClaims{
-		"sub": user.ID.Hex(),
-		"sid": session.ID.Hex(),
-		"exp": time.Now().Add(time.Hour * 24).Unix(), // Access token expires in 24 hours
+// completeFirstFactor starts a session, or a two-factor challenge for accounts that use it.
+func (s *userService) completeFirstFactor(ctx context.Context, user *domain.User, userAgent, clientIP string) (*LoginResponse, error) {
+	if user.TOTPEnabled {
+		challengeToken, err := s.createLoginChallenge(ctx, user.ID, userAgent, clientIP)
+		if err != nil {
+			return nil, err
+		}
+		return &LoginResponse{TwoFactorRequired: true, ChallengeToken: challengeToken}, nil
+	}
+	return s.startSession(ctx, user, userAgent, clientIP)
+}
+
+// CompleteTwoFactorLogin finishes a login that Login answered with a challenge,
+// given a TOTP code or a recovery code. A challenge is dropped after too many
+// wrong codes, so the password step has to be repeated.
+func (s *userService) CompleteTwoFactorLogin(ctx context.Context, challengeToken, code string) (*LoginResponse, error) {
+	key := loginChallengeKey(challengeToken)
+	raw, err := s.cache.Get(ctx, key)
+	if err != nil {
+		return nil, ErrInvalidLoginChallenge
 	}
-	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
-	accessToken, err := token.SignedString([]byte(s.jwtSecret))
+	var challenge loginChallenge
+	if err := json.Unmarshal([]byte(raw), &challenge); err != nil {
+		return nil, ErrInvalidLoginChallenge
+	}
+	userID, err := primitive.ObjectIDFromHex(challenge.UserID)
+	if err != nil {
+		return nil, ErrInvalidLoginChallenge
+	}
+	user, err := s.userRepo.GetUserByID(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.twoFactorService.VerifyCode(ctx, user, code); err != nil {
+		attempts, incrErr := s.cache.Incr(ctx, key+":attempts", loginChallengeTTL)
+		if incrErr == nil && attempts >= loginChallengeMaxAttempts {
+			if delErr := s.cache.Del(ctx, key, key+":attempts"); delErr != nil {
+				log.Warn().Err(delErr).Msg("Failed to drop exhausted login challenge")
+			}
+		}
+		return nil, err
+	}
+
+	if err := s.cache.Del(ctx, key, key+":attempts"); err != nil {
+		log.Warn().Err(err).Msg("Failed to drop completed login challenge")
+	}
+	return s.startSession(ctx, user, challenge.UserAgent, challenge.ClientIP)
+}
+
+// createLoginChallenge remembers a successful password step and returns the
+// token that completes it. Only the token's hash is used as the cache key.
+func (s *userService) createLoginChallenge(ctx context.Context, userID primitive.ObjectID, userAgent, clientIP string) (string, error) {
+	token, err := newRefreshToken()
+	if err != nil {
+		return "", err
+	}
+	data, err := json.Marshal(loginChallenge{UserID: userID.Hex(), UserAgent: userAgent, ClientIP: clientIP})
+	if err != nil {
+		return "", err
+	}
+	if err := s.cache.Set(ctx, loginChallengeKey(token), data, loginChallengeTTL); err != nil {
+		return "", err
+	}
+	return token, nil
+}
+
+func loginChallengeKey(token string) string {
+	return fmt.Sprintf("login:challenge:%s", utils.HashToken(token))
+}
+
+// startSession creates a session for an authenticated user and issues its tokens.
+func (s *userService) startSession(ctx context.Context, user *domain.User, userAgent, clientIP string) (*LoginResponse, error) {
+	refreshToken, err := newRefreshToken()
+	if err != nil {
+		return nil, err
+	}
+
+	session, err := s.sessionService.Create(ctx, user.ID, refreshToken, userAgent, clientIP)
+	if err != nil {
+		return nil, err
+	}
+
+	accessToken, err := s.issueAccessToken(user.ID, session.ID)
 	if err != nil {
 		return nil, err
 	}
@@ -162,44 +348,49 @@ func (s *userService) Login(ctx context.Context, email, password, userAgent, cli
 	}, nil
 }
 
+// issueAccessToken signs an access token for a session with the current signing key.
+func (s *userService) issueAccessToken(userID, sessionID primitive.ObjectID) (string, error) {
+	return s.jwtKeys.Sign(jwt.MapClaims{
+		"sub": userID.Hex(),
+		"sid": sessionID.Hex(),
+		"exp": time.Now().Add(time.Hour * 24).Unix(), // Access token expires in 24 hours
+	})
+}
+
+// RefreshToken issues a new access token together with a new refresh token. The
+// refresh token that was presented can't be used again.
 func (s *userService) RefreshToken(ctx context.Context, refreshToken string) (*LoginResponse, error) {
-	session, err := s.sessionRepo.FindByRefreshToken(ctx, refreshToken)
+	session, rotatedToken, err := s.sessionService.RotateRefreshToken(ctx, refreshToken)
 	if err != nil {
 		return nil, err
 	}
 
-	if session.IsBlocked || time.Now().After(session.ExpiresAt) {
-		return nil, errors.New("invalid or expired refresh token")
-	}
-
 	user, err := s.userRepo.GetUserByID(ctx, session.UserID)
 	if err != nil {
 		return nil, err
 	}
 
-	claims := jwt.MapClaims{
-		"sub": user.ID.Hex(),
-		"sid": session.ID.Hex(),
-		"exp": time.Now().Add(time.Hour * 24).Unix(), // Access token expires in 24 hours
-	}
-	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
-	accessToken, err := token.SignedString([]byte(s.jwtSecret))
+	accessToken, err := s.issueAccessToken(user.ID, session.ID)
 	if err != nil {
 		return nil, err
 	}
 
 	return &LoginResponse{
 		AccessToken:  accessToken,
-		RefreshToken: refreshToken,
+		RefreshToken: rotatedToken,
 		UserData:     &UserMinimal{VID: user.VID, Username: user.Username},
 	}, nil
 }
 
-func (s *userService) UnlockWallet(ctx context.Context, userIDStr, password string) error {
+func (s *userService) UnlockWallet(ctx context.Context, userIDStr, sessionIDStr, password, totpCode string) error {
 	userID, err := primitive.ObjectIDFromHex(userIDStr)
 	if err != nil {
 		return errors.New("invalid user ID format")
 	}
+	sessionID, err := primitive.ObjectIDFromHex(sessionIDStr)
+	if err != nil {
+		return errors.New("invalid session ID format")
+	}
 	user, err := s.userRepo.GetUserByID(ctx, userID)
 	if err != nil {
 		return err
@@ -210,12 +401,23 @@ func (s *userService) UnlockWallet(ctx context.Context, userIDStr, password stri
 	if !utils.CheckPasswordHash(password, user.Password) {
 		return errors.New("invalid password")
 	}
-	decryptedKey, err := utils.Decrypt(user.EncryptedPrivateKey, []byte(s.walletEncryptionKey))
+	if err := s.twoFactorService.RequireFreshTOTP(ctx, user, totpCode); err != nil {
+		return err
+	}
+	return s.walletUnlock.Unlock(ctx, user, sessionID)
+}
+
+// LockWallet ends the session's wallet unlock before it expires.
+func (s *userService) LockWallet(ctx context.Context, userIDStr, sessionIDStr string) error {
+	userID, err := primitive.ObjectIDFromHex(userIDStr)
 	if err != nil {
-		return errors.New("could not decrypt private key")
+		return errors.New("invalid user ID format")
+	}
+	sessionID, err := primitive.ObjectIDFromHex(sessionIDStr)
+	if err != nil {
+		return errors.New("invalid session ID format")
 	}
-	cacheKey := fmt.Sprintf("wallet:%s", userIDStr)
-	return s.cache.Set(ctx, cacheKey, decryptedKey, 15*time.Minute)
+	return s.walletUnlock.Lock(ctx, userID, sessionID)
 }
 
 // GetUserProfile retrieves a user's public profile and friendship status, with caching.
@@ -242,6 +444,15 @@ func (s *userService) GetUserProfile(ctx context.Context, viewerIDStr string, vi
 		return nil, errors.New("invalid viewer ID format")
 	}
 
+	// Blocked profiles look the same as missing ones, whichever side blocked
+	blocked, err := s.blockService.IsBlocked(ctx, viewerID, profileUser.ID)
+	if err != nil {
+		return nil, err
+	}
+	if blocked {
+		return nil, errors.New("user not found")
+	}
+
 	followerCount, err := s.followRepo.GetFollowerCount(ctx, profileUser.ID)
 	if err != nil {
 		return nil, err
@@ -262,11 +473,21 @@ func (s *userService) GetUserProfile(ctx context.Context, viewerIDStr string, vi
 		return nil, err
 	}
 
+	viewerRequested := false
+	if !viewerIsFollowing && profileUser.IsPrivate {
+		viewerRequested, err = s.followRepo.HasFollowRequest(ctx, viewerID, profileUser.ID)
+		if err != nil {
+			return nil, err
+		}
+	}
+
 	var friendshipStatus string
 	if viewerIsFollowing && profileUserIsFollowing {
 		friendshipStatus = "mutual"
 	} else if viewerIsFollowing {
 		friendshipStatus = "following"
+	} else if viewerRequested {
+		friendshipStatus = "requested"
 	} else if profileUserIsFollowing {
 		friendshipStatus = "follows_you"
 	} else {
@@ -280,6 +501,23 @@ func (s *userService) GetUserProfile(ctx context.Context, viewerIDStr string, vi
 		FriendshipStatus: friendshipStatus,
 	}
 
+	canView, err := canViewAccount(ctx, s.followRepo, viewerID, profileUser)
+	if err != nil {
+		return nil, err
+	}
+	if !canView {
+		// Private accounts only show who they are until the viewer's follow is approved
+		profileResponse.User = domain.User{
+			ID:        profileUser.ID,
+			VID:       profileUser.VID,
+			Name:      profileUser.Name,
+			Username:  profileUser.Username,
+			PFPURL:    profileUser.PFPURL,
+			IsPrivate: true,
+		}
+		profileResponse.IsRestricted = true
+	}
+
 	return profileResponse, nil
 }
 
@@ -300,6 +538,20 @@ func (s *userService) UpdateProfile(ctx context.Context, userID string, payload
 	cacheKey := fmt.Sprintf("profile:%s:viewer:%s", user.Username, userID)
 	s.cache.Del(ctx, cacheKey)
 
+	if payload.Username != nil && *payload.Username == "" {
+		payload.Username = nil // A username can't be cleared
+	}
+	update := domain.ProfileUpdate{
+		Username:  payload.Username,
+		PFPURL:    payload.PFPURL,
+		BannerURL: payload.BannerURL,
+		Bio:       payload.Bio,
+		IsPrivate: payload.IsPrivate,
+	}
+	if err := s.userRepo.UpdateProfile(ctx, objID, update); err != nil {
+		return nil, err
+	}
+
 	if payload.Username != nil {
 		user.Username = *payload.Username
 	}
@@ -312,14 +564,28 @@ func (s *userService) UpdateProfile(ctx context.Context, userID string, payload
 	if payload.Bio != nil {
 		user.Bio = *payload.Bio
 	}
-	if err := s.userRepo.UpdateUser(ctx, user); err != nil {
-		return nil, err
+	wasPrivate := user.IsPrivate
+	if payload.IsPrivate != nil {
+		user.IsPrivate = *payload.IsPrivate
+	}
+
+	// Going public lets everyone who was waiting in
+	if wasPrivate && !user.IsPrivate {
+		approved, err := s.followRepo.ApproveAllFollowRequests(ctx, user.ID)
+		if err != nil {
+			return nil, fmt.Errorf("failed to approve pending follow requests: %w", err)
+		}
+		for _, followerID := range approved {
+			if err := s.timelineService.AddFollow(ctx, followerID, user.ID); err != nil {
+				log.Error().Err(err).Msg("Failed to add followed user's posts to timeline")
+			}
+		}
 	}
 	return user, nil
 }
 
 // ... (rest of the methods are unchanged)
-func (s *userService) ExportPrivateKey(ctx context.Context, userIDStr, password string) (string, error) {
+func (s *userService) ExportPrivateKey(ctx context.Context, userIDStr, password, totpCode string) (string, error) {
 	userID, err := primitive.ObjectIDFromHex(userIDStr)
 	if err != nil {
 		return "", errors.New("invalid user ID format")
@@ -334,105 +600,152 @@ func (s *userService) ExportPrivateKey(ctx context.Context, userIDStr, password
 	if !utils.CheckPasswordHash(password, user.Password) {
 		return "", errors.New("invalid password")
 	}
-	decryptedKey, err := utils.Decrypt(user.EncryptedPrivateKey, []byte(s.walletEncryptionKey))
+	if err := s.twoFactorService.RequireFreshTOTP(ctx, user, totpCode); err != nil {
+		return "", err
+	}
+	decryptedKey, err := s.walletKeys.Open(ctx, user)
 	if err != nil {
 		return "", errors.New("could not decrypt private key")
 	}
 	return decryptedKey, nil
 }
-func (s *userService) PersonalSign(ctx context.Context, userIDStr, message string) (string, error) {
-	cacheKey := fmt.Sprintf("wallet:%s", userIDStr)
-	decryptedKey, err := s.cache.Get(ctx, cacheKey)
-	if err != nil {
-		return "", errors.New("wallet is locked")
-	}
-	signer, err := evm.NewSignerFromHex(decryptedKey)
-	if err != nil {
-		return "", err
-	}
-	return signer.PersonalSign(message)
+func (s *userService) PersonalSign(ctx context.Context, userIDStr, sessionIDStr, message string) (string, error) {
+	var signature string
+	err := s.withWalletKey(ctx, userIDStr, sessionIDStr, func(privateKey *ecdsa.PrivateKey) error {
+		var err error
+		signature, err = evm.NewSigner(privateKey).PersonalSign(message)
+		return err
+	})
+	return signature, err
 }
-func (s *userService) SignTransaction(ctx context.Context, userIDStr string, tx *types.Transaction) (string, error) {
-	cacheKey := fmt.Sprintf("wallet:%s", userIDStr)
-	decryptedKey, err := s.cache.Get(ctx, cacheKey)
-	if err != nil {
-		return "", errors.New("wallet is locked")
-	}
-	signer, err := evm.NewSignerFromHex(decryptedKey)
-	if err != nil {
-		return "", err
-	}
-	return signer.SignTransaction(tx.Nonce(), tx.To(), tx.Value(), tx.Gas(), tx.GasPrice(), tx.Data(), tx.ChainId())
+func (s *userService) SignTransaction(ctx context.Context, userIDStr, sessionIDStr string, req evm.TxRequest) (string, error) {
+	var signedTx string
+	err := s.withWalletKey(ctx, userIDStr, sessionIDStr, func(privateKey *ecdsa.PrivateKey) error {
+		var err error
+		signedTx, err = s.walletService.SignTransaction(ctx, req, privateKey)
+		return err
+	})
+	return signedTx, err
+}
+func (s *userService) SignTypedDataV4(ctx context.Context, userIDStr, sessionIDStr string, typedData apitypes.TypedData) (string, error) {
+	var signature string
+	err := s.withWalletKey(ctx, userIDStr, sessionIDStr, func(privateKey *ecdsa.PrivateKey) error {
+		var err error
+		signature, err = evm.NewSigner(privateKey).SignTypedDataV4(typedData)
+		return err
+	})
+	return signature, err
 }
-func (s *userService) SignTypedDataV4(ctx context.Context, userIDStr string, typedData apitypes.TypedData) (string, error) {
-	cacheKey := fmt.Sprintf("wallet:%s", userIDStr)
-	decryptedKey, err := s.cache.Get(ctx, cacheKey)
+func (s *userService) SendTransaction(ctx context.Context, userIDStr, sessionIDStr string, req evm.TxRequest) (common.Hash, error) {
+	var sentTx *types.Transaction
+	err := s.withWalletKey(ctx, userIDStr, sessionIDStr, func(privateKey *ecdsa.PrivateKey) error {
+		var err error
+		sentTx, err = s.walletService.SendTransaction(ctx, req, privateKey)
+		return err
+	})
 	if err != nil {
-		return "", errors.New("wallet is locked")
+		return common.Hash{}, err
 	}
-	signer, err := evm.NewSignerFromHex(decryptedKey)
-	if err != nil {
-		return "", err
+
+	// The transaction is already broadcast, so failing to record it doesn't fail the request
+	userID, _ := primitive.ObjectIDFromHex(userIDStr)
+	if err := s.walletTx.RecordTransaction(ctx, userID, sentTx); err != nil {
+		log.Error().Err(err).Str("hash", sentTx.Hash().Hex()).Msg("Failed to record wallet transaction")
 	}
-	return signer.SignTypedDataV4(typedData)
+	return sentTx.Hash(), nil
 }
-func (s *userService) SendTransaction(ctx context.Context, userIDStr string, tx *types.Transaction) (common.Hash, error) {
-	cacheKey := fmt.Sprintf("wallet:%s", userIDStr)
-	decryptedKey, err := s.cache.Get(ctx, cacheKey)
+func (s *userService) Secp256k1Sign(ctx context.Context, userIDStr, sessionIDStr, hashStr string) (string, error) {
+	hash, err := hexutil.Decode(hashStr)
 	if err != nil {
-		return common.Hash{}, errors.New("wallet is locked")
+		return "", errors.New("invalid hash format")
 	}
-	return s.walletService.SendTransaction(ctx, tx, decryptedKey)
+	var signature string
+	err = s.withWalletKey(ctx, userIDStr, sessionIDStr, func(privateKey *ecdsa.PrivateKey) error {
+		var err error
+		signature, err = evm.NewSigner(privateKey).Secp256k1Sign(hash)
+		return err
+	})
+	return signature, err
 }
-func (s *userService) Secp256k1Sign(ctx context.Context, userIDStr, hashStr string) (string, error) {
-	cacheKey := fmt.Sprintf("wallet:%s", userIDStr)
-	decryptedKey, err := s.cache.Get(ctx, cacheKey)
-	if err != nil {
-		return "", errors.New("wallet is locked")
-	}
-	signer, err := evm.NewSignerFromHex(decryptedKey)
+
+// withWalletKey runs fn with the private key of a wallet the session unlocked.
+func (s *userService) withWalletKey(ctx context.Context, userIDStr, sessionIDStr string, fn func(*ecdsa.PrivateKey) error) error {
+	userID, err := primitive.ObjectIDFromHex(userIDStr)
 	if err != nil {
-		return "", err
+		return errors.New("invalid user ID format")
 	}
-	hash, err := hexutil.Decode(hashStr)
+	sessionID, err := primitive.ObjectIDFromHex(sessionIDStr)
 	if err != nil {
-		return "", errors.New("invalid hash format")
+		return errors.New("invalid session ID format")
 	}
-	return signer.Secp256k1Sign(hash)
+	return s.walletUnlock.WithPrivateKey(ctx, userID, sessionID, fn)
 }
+
+// RequestOTP emails a password reset OTP. Unknown addresses are ignored, so the
+// endpoint doesn't reveal which emails have accounts.
 func (s *userService) RequestOTP(ctx context.Context, email string) error {
 	user, err := s.userRepo.GetUserByEmail(ctx, email)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return nil
+	}
 	if err != nil {
 		return err
 	}
-	if user == nil {
-		return nil
-	}
 	otp, err := utils.GenerateOTP(6)
 	if err != nil {
 		return errors.New("could not generate OTP")
 	}
-	user.OTP = otp
-	user.OTPExpires = time.Now().Add(time.Minute * 5)
-	if err := s.userRepo.UpdateUser(ctx, user); err != nil {
+	if err := s.userRepo.SetPasswordResetOTP(ctx, user.ID, utils.HashToken(otp), time.Now().Add(passwordResetOTPTTL)); err != nil {
 		return err
 	}
 	return s.emailService.SendOTPEmail(user.Email, otp)
 }
-func (s *userService) VerifyOTPAndResetPassword(ctx context.Context, email, otp, newPassword string) error {
-	user, err := s.userRepo.GetUserByEmail(ctx, email)
+
+// VerifyOTPAndResetPassword sets a new password if the OTP is valid. Failed
+// attempts are counted per email and per client IP, and either limit locks out
+// further attempts for a while. A reset logs the user out everywhere and locks
+// their wallet.
+func (s *userService) VerifyOTPAndResetPassword(ctx context.Context, email, otp, newPassword, clientIP string) error {
+	emailKey := fmt.Sprintf("otp:attempts:email:%s", strings.ToLower(email))
+	ipKey := fmt.Sprintf("otp:attempts:ip:%s", clientIP)
+
+	// Count attempts before checking, so concurrent guesses can't exceed the limits
+	emailAttempts, err := s.cache.Incr(ctx, emailKey, passwordResetLockout)
 	if err != nil {
 		return err
 	}
-	if user == nil || user.OTP != otp || user.OTPExpires.Before(time.Now()) {
-		return errors.New("invalid or expired OTP")
+	ipAttempts, err := s.cache.Incr(ctx, ipKey, passwordResetLockout)
+	if err != nil {
+		return err
+	}
+	if emailAttempts > passwordResetMaxAttemptsPerEmail || ipAttempts > passwordResetMaxAttemptsPerIP {
+		return ErrTooManyOTPAttempts
+	}
+
+	user, err := s.userRepo.GetUserByEmail(ctx, email)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return ErrInvalidOTP
+	}
+	if err != nil {
+		return err
 	}
 	hashedPassword, err := utils.HashPassword(newPassword)
 	if err != nil {
 		return err
 	}
-	user.Password = hashedPassword
-	user.OTP = ""
-	user.OTPExpires = time.Time{}
-	return s.userRepo.UpdateUser(ctx, user)
+	reset, err := s.userRepo.ResetPassword(ctx, user.ID, utils.HashToken(strings.TrimSpace(otp)), hashedPassword)
+	if err != nil {
+		return err
+	}
+	if !reset {
+		return ErrInvalidOTP
+	}
+
+	if err := s.cache.Del(ctx, emailKey); err != nil {
+		log.Warn().Err(err).Msg("Failed to reset password reset attempts")
+	}
+	// Whoever knew the old password may still hold a session or an unlocked
+	// wallet; wallet unlocks belong to sessions, so revoking those ends both
+	_, err = s.sessionService.RevokeAll(ctx, user.ID, primitive.NilObjectID)
+	return err
 }
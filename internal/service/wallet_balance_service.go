@@ -0,0 +1,164 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+	"time"
+	"vybes/internal/config"
+	"vybes/internal/repository"
+	"vybes/pkg/cache"
+	"vybes/pkg/evm"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/ethclient"
+	"github.com/rs/zerolog/log"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// walletTokenDecimalsTTL is how long a token's decimals are cached; they don't change
+const walletTokenDecimalsTTL = 24 * time.Hour
+
+// nativeDecimals is the number of decimals of ether amounts in wei
+const nativeDecimals = 18
+
+// TokenBalance is a wallet's balance of an ERC-20 token.
+type TokenBalance struct {
+	Symbol     string `json:"symbol"`
+	Address    string `json:"address"`
+	Decimals   uint8  `json:"decimals"`
+	Balance    string `json:"balance"`    // In whole tokens
+	RawBalance string `json:"rawBalance"` // In the token's smallest unit
+}
+
+// WalletBalance is what a custodial wallet holds: its native balance and its
+// balances of the configured tokens.
+type WalletBalance struct {
+	Address    string         `json:"address"`
+	Balance    string         `json:"balance"`    // In ether
+	RawBalance string         `json:"rawBalance"` // In wei
+	Tokens     []TokenBalance `json:"tokens"`
+}
+
+// WalletBalanceService defines the interface for reading custodial wallet balances.
+type WalletBalanceService interface {
+	GetBalance(ctx context.Context, userID string) (*WalletBalance, error)
+}
+
+type walletBalanceService struct {
+	userRepo  repository.UserRepository
+	ethClient *ethclient.Client
+	cache     cache.Client
+	tokens    []config.WalletToken
+	cacheTTL  time.Duration
+}
+
+// NewWalletBalanceService creates a new wallet balance service for the tokens in cfg.
+func NewWalletBalanceService(userRepo repository.UserRepository, ethClient *ethclient.Client, cache cache.Client, cfg *config.Config) WalletBalanceService {
+	return &walletBalanceService{
+		userRepo:  userRepo,
+		ethClient: ethClient,
+		cache:     cache,
+		tokens:    cfg.WalletTokens,
+		cacheTTL:  cfg.WalletBalanceCacheTTL,
+	}
+}
+
+func (s *walletBalanceService) GetBalance(ctx context.Context, userIDStr string) (*WalletBalance, error) {
+	userID, err := primitive.ObjectIDFromHex(userIDStr)
+	if err != nil {
+		return nil, errors.New("invalid user ID format")
+	}
+	user, err := s.userRepo.GetUserByID(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	if user.WalletAddress == "" {
+		return nil, errors.New("user has no wallet")
+	}
+	address := common.HexToAddress(user.WalletAddress)
+
+	key := walletBalanceKey(address)
+	if cached, err := s.cache.Get(ctx, key); err == nil {
+		var balance WalletBalance
+		if err := json.Unmarshal([]byte(cached), &balance); err == nil {
+			return &balance, nil
+		}
+	}
+
+	native, err := s.ethClient.BalanceAt(ctx, address, nil)
+	if err != nil {
+		return nil, err
+	}
+	balance := &WalletBalance{
+		Address:    address.Hex(),
+		Balance:    evm.FormatUnits(native, nativeDecimals),
+		RawBalance: native.String(),
+		Tokens:     make([]TokenBalance, 0, len(s.tokens)),
+	}
+
+	complete := true
+	for _, token := range s.tokens {
+		tokenBalance, err := s.tokenBalance(ctx, token, address)
+		if err != nil {
+			// One broken token shouldn't hide the rest of the wallet
+			log.Warn().Err(err).Str("token", token.Address).Msg("Failed to read token balance")
+			complete = false
+			continue
+		}
+		balance.Tokens = append(balance.Tokens, *tokenBalance)
+	}
+
+	// Partial results aren't cached, so the next request tries the missing tokens again
+	if complete {
+		if data, err := json.Marshal(balance); err == nil {
+			if err := s.cache.Set(ctx, key, data, s.cacheTTL); err != nil {
+				log.Warn().Err(err).Msg("Failed to cache wallet balance")
+			}
+		}
+	}
+	return balance, nil
+}
+
+func (s *walletBalanceService) tokenBalance(ctx context.Context, token config.WalletToken, owner common.Address) (*TokenBalance, error) {
+	tokenAddress := common.HexToAddress(token.Address)
+	decimals, err := s.tokenDecimals(ctx, tokenAddress)
+	if err != nil {
+		return nil, err
+	}
+	amount, err := evm.ERC20BalanceOf(ctx, s.ethClient, tokenAddress, owner)
+	if err != nil {
+		return nil, err
+	}
+	return &TokenBalance{
+		Symbol:     token.Symbol,
+		Address:    tokenAddress.Hex(),
+		Decimals:   decimals,
+		Balance:    evm.FormatUnits(amount, decimals),
+		RawBalance: amount.String(),
+	}, nil
+}
+
+func (s *walletBalanceService) tokenDecimals(ctx context.Context, token common.Address) (uint8, error) {
+	key := fmt.Sprintf("wallet:token:decimals:%s", token.Hex())
+	if cached, err := s.cache.Get(ctx, key); err == nil {
+		if decimals, err := strconv.ParseUint(cached, 10, 8); err == nil {
+			return uint8(decimals), nil
+		}
+	}
+
+	decimals, err := evm.ERC20Decimals(ctx, s.ethClient, token)
+	if err != nil {
+		return 0, err
+	}
+	if err := s.cache.Set(ctx, key, strconv.Itoa(int(decimals)), walletTokenDecimalsTTL); err != nil {
+		log.Warn().Err(err).Msg("Failed to cache token decimals")
+	}
+	return decimals, nil
+}
+
+func walletBalanceKey(address common.Address) string {
+	return fmt.Sprintf("wallet:balance:%s", address.Hex())
+}
@@ -0,0 +1,233 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"vybes/internal/config"
+	"vybes/internal/domain"
+	"vybes/internal/repository"
+	"vybes/pkg/envelope"
+	"vybes/pkg/utils"
+
+	"github.com/rs/zerolog/log"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// walletRewrapBatchSize is how many users RewrapAll loads at a time
+const walletRewrapBatchSize = 100
+
+// staleUsersFunc lists users holding a secret that isn't wrapped by keyVersion,
+// in ID order after afterID.
+type staleUsersFunc func(ctx context.Context, keyVersion int, afterID primitive.ObjectID, limit int64) ([]domain.User, error)
+
+// RewrapResult counts the outcome of re-wrapping stored wallet keys and
+// two-factor secrets.
+type RewrapResult struct {
+	Rewrapped int // Secrets now wrapped by the current master key
+	Failed    int // Secrets that couldn't be opened or updated; they keep their old wrapping
+	Remaining int // Secrets still not wrapped by the current master key once the passes were done
+}
+
+// WalletKeyService defines the interface for encrypting custodial wallet
+// private keys and other per-user secrets, such as two-factor secrets. Each
+// secret is encrypted under its own data key, which is wrapped by a versioned
+// master key from the KeyProvider.
+type WalletKeyService interface {
+	// Seal encrypts a secret, such as a hex private key, for storage on the user
+	Seal(ctx context.Context, privateKeyHex string) (envelope.Sealed, error)
+	// Open decrypts the user's hex private key
+	Open(ctx context.Context, user *domain.User) (string, error)
+	// OpenSecret decrypts a sealed secret, or one encrypted with the legacy key
+	// when it has no wrapped data key
+	OpenSecret(ctx context.Context, sealed envelope.Sealed) (string, error)
+	// RewrapAll wraps every stored wallet key and two-factor secret that isn't
+	// wrapped by the current master key with it, sealing ones stored before
+	// envelope encryption on the way
+	RewrapAll(ctx context.Context) (RewrapResult, error)
+}
+
+type walletKeyService struct {
+	userRepo  repository.UserRepository
+	keys      envelope.KeyProvider
+	legacyKey string
+}
+
+// NewWalletKeyService creates a new wallet key service.
+//
+// Parameters:
+//   - userRepo: Repository holding the encrypted keys
+//   - keys: Provider of the master keys
+//   - legacyKey: The key that encrypted wallet keys directly before envelope encryption
+//
+// Returns:
+//   - WalletKeyService: A wallet key service ready for use
+func NewWalletKeyService(userRepo repository.UserRepository, keys envelope.KeyProvider, legacyKey string) WalletKeyService {
+	return &walletKeyService{
+		userRepo:  userRepo,
+		keys:      keys,
+		legacyKey: legacyKey,
+	}
+}
+
+// LoadWalletKeyProvider returns the master key provider for the configured key
+// set. Without one, master key version 1 is derived from WalletEncryptionKey.
+func LoadWalletKeyProvider(cfg *config.Config) (envelope.KeyProvider, error) {
+	if cfg.WalletMasterKeys != "" {
+		return envelope.ParseLocalKeyProvider([]byte(cfg.WalletMasterKeys))
+	}
+	log.Warn().Msg("WALLET_MASTER_KEYS is not set, deriving master key version 1 from WALLET_ENCRYPTION_KEY")
+	return envelope.DeriveLocalKeyProvider(cfg.WalletEncryptionKey)
+}
+
+func (s *walletKeyService) Seal(ctx context.Context, privateKeyHex string) (envelope.Sealed, error) {
+	return envelope.Seal(ctx, s.keys, privateKeyHex)
+}
+
+func (s *walletKeyService) Open(ctx context.Context, user *domain.User) (string, error) {
+	return s.OpenSecret(ctx, sealedWalletKey(user))
+}
+
+func (s *walletKeyService) OpenSecret(ctx context.Context, sealed envelope.Sealed) (string, error) {
+	if sealed.WrappedKey == "" {
+		return utils.Decrypt(sealed.Ciphertext, []byte(s.legacyKey))
+	}
+	return envelope.Open(ctx, s.keys, sealed)
+}
+
+// RewrapAll can run while the API serves traffic: every instance must already
+// know the current master key version, and the old versions stay readable
+// until no stored secret uses them.
+func (s *walletKeyService) RewrapAll(ctx context.Context) (RewrapResult, error) {
+	var result RewrapResult
+	current := s.keys.CurrentVersion()
+	passes := []struct {
+		name   string
+		stale  staleUsersFunc
+		rewrap func(ctx context.Context, user *domain.User) error
+	}{
+		{"wallet key", s.userRepo.GetUsersWithStaleWalletKey, s.rewrap},
+		{"two-factor secret", s.userRepo.GetUsersWithStaleTOTPSecret, s.rewrapTOTPSecret},
+	}
+	for _, pass := range passes {
+		afterID := primitive.NilObjectID
+		for {
+			users, err := pass.stale(ctx, current, afterID, walletRewrapBatchSize)
+			if err != nil {
+				return result, err
+			}
+			if len(users) == 0 {
+				break
+			}
+
+			for i := range users {
+				user := &users[i]
+				if err := pass.rewrap(ctx, user); err != nil {
+					log.Error().Err(err).Str("user_id", user.ID.Hex()).Msg("Failed to re-wrap " + pass.name)
+					result.Failed++
+					continue
+				}
+				result.Rewrapped++
+			}
+			afterID = users[len(users)-1].ID
+		}
+
+		// Look again rather than trusting the counts: a write that raced with the
+		// pass can have put back a secret wrapped by an old version
+		remaining, err := countStale(ctx, pass.stale, current)
+		if err != nil {
+			return result, err
+		}
+		result.Remaining += remaining
+	}
+	return result, nil
+}
+
+// countStale counts the users a stale query still finds.
+func countStale(ctx context.Context, stale staleUsersFunc, keyVersion int) (int, error) {
+	count := 0
+	afterID := primitive.NilObjectID
+	for {
+		users, err := stale(ctx, keyVersion, afterID, walletRewrapBatchSize)
+		if err != nil {
+			return count, err
+		}
+		if len(users) == 0 {
+			return count, nil
+		}
+		count += len(users)
+		afterID = users[len(users)-1].ID
+	}
+}
+
+func (s *walletKeyService) rewrap(ctx context.Context, user *domain.User) error {
+	if user.EncryptedPrivateKey == "" {
+		return errors.New("user has no wallet key")
+	}
+
+	sealed, err := s.reseal(ctx, sealedWalletKey(user))
+	if err != nil {
+		return err
+	}
+
+	updated, err := s.userRepo.UpdateWalletKey(ctx, user.ID, user.EncryptedPrivateKey, sealed.Ciphertext, sealed.WrappedKey, sealed.KeyVersion)
+	if err != nil {
+		return err
+	}
+	if !updated {
+		return errors.New("wallet key changed while re-wrapping")
+	}
+	return nil
+}
+
+func (s *walletKeyService) rewrapTOTPSecret(ctx context.Context, user *domain.User) error {
+	sealed, err := s.reseal(ctx, sealedTOTPSecret(user))
+	if err != nil {
+		return err
+	}
+	updated, err := s.userRepo.UpdateTOTPSecret(ctx, user.ID, user.TOTPSecret, sealed.Ciphertext, sealed.WrappedKey, sealed.KeyVersion)
+	if err != nil {
+		return err
+	}
+	if !updated {
+		return errors.New("two-factor secret changed while re-wrapping")
+	}
+	return nil
+}
+
+// reseal wraps a sealed secret with the current master key, sealing it first
+// if it was stored before envelope encryption.
+func (s *walletKeyService) reseal(ctx context.Context, sealed envelope.Sealed) (envelope.Sealed, error) {
+	if sealed.WrappedKey != "" {
+		return envelope.Rewrap(ctx, s.keys, sealed)
+	}
+	plaintext, err := utils.Decrypt(sealed.Ciphertext, []byte(s.legacyKey))
+	if err != nil {
+		return envelope.Sealed{}, fmt.Errorf("could not decrypt legacy secret: %w", err)
+	}
+	return envelope.Seal(ctx, s.keys, plaintext)
+}
+
+func sealedTOTPSecret(user *domain.User) envelope.Sealed {
+	return envelope.Sealed{
+		Ciphertext: user.TOTPSecret,
+		WrappedKey: user.TOTPDataKey,
+		KeyVersion: user.TOTPKeyVersion,
+	}
+}
+
+func sealedPendingTOTPSecret(user *domain.User) envelope.Sealed {
+	return envelope.Sealed{
+		Ciphertext: user.TOTPPendingSecret,
+		WrappedKey: user.TOTPPendingDataKey,
+		KeyVersion: user.TOTPPendingKeyVersion,
+	}
+}
+
+func sealedWalletKey(user *domain.User) envelope.Sealed {
+	return envelope.Sealed{
+		Ciphertext: user.EncryptedPrivateKey,
+		WrappedKey: user.WalletDataKey,
+		KeyVersion: user.WalletKeyVersion,
+	}
+}
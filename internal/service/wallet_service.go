@@ -4,46 +4,42 @@ import (
 	"context"
 	"crypto/ecdsa"
 	"errors"
-	"vybes/internal/config"
-	"vybes/pkg/utils"
+	"vybes/pkg/envelope"
+	"vybes/pkg/evm"
 
-	"github.com/ethereum/go-ethereum/common"
 	"github.com/ethereum/go-ethereum/common/hexutil"
 	"github.com/ethereum/go-ethereum/core/types"
 	"github.com/ethereum/go-ethereum/crypto"
 	"github.com/ethereum/go-ethereum/ethclient"
-	"github.com/rs/zerolog/log"
 )
 
 // WalletService defines the interface for wallet operations.
 type WalletService interface {
-	CreateWallet() (address string, encryptedPrivateKey string, err error)
-	SendTransaction(ctx context.Context, tx *types.Transaction, privateKeyHex string) (common.Hash, error)
+	CreateWallet(ctx context.Context) (address string, privateKey envelope.Sealed, err error)
+	// SignTransaction builds the transaction and returns it signed as hex raw bytes
+	SignTransaction(ctx context.Context, req evm.TxRequest, privateKey *ecdsa.PrivateKey) (string, error)
+	// SendTransaction builds, signs and broadcasts the transaction
+	SendTransaction(ctx context.Context, req evm.TxRequest, privateKey *ecdsa.PrivateKey) (*types.Transaction, error)
 }
 
 type walletService struct {
-	encryptionKey string
-	ethClient     *ethclient.Client
+	walletKeys WalletKeyService
+	ethClient  *ethclient.Client
 }
 
 // NewWalletService creates a new wallet service.
-func NewWalletService(cfg *config.Config) WalletService {
-	client, err := ethclient.Dial(cfg.EthRPCURL)
-	if err != nil {
-		log.Fatal().Err(err).Msg("Failed to connect to the Ethereum client")
-	}
-
+func NewWalletService(ethClient *ethclient.Client, walletKeys WalletKeyService) WalletService {
 	return &walletService{
-		encryptionKey: cfg.WalletEncryptionKey,
-		ethClient:     client,
+		walletKeys: walletKeys,
+		ethClient:  ethClient,
 	}
 }
 
 // CreateWallet generates a new Ethereum wallet and returns the address and encrypted private key.
-func (s *walletService) CreateWallet() (string, string, error) {
+func (s *walletService) CreateWallet(ctx context.Context) (string, envelope.Sealed, error) {
 	privateKey, err := crypto.GenerateKey()
 	if err != nil {
-		return "", "", err
+		return "", envelope.Sealed{}, err
 	}
 
 	privateKeyBytes := crypto.FromECDSA(privateKey)
@@ -52,40 +48,44 @@ func (s *walletService) CreateWallet() (string, string, error) {
 	publicKey := privateKey.Public()
 	publicKeyECDSA, ok := publicKey.(*ecdsa.PublicKey)
 	if !ok {
-		return "", "", errors.New("error casting public key to ECDSA")
+		return "", envelope.Sealed{}, errors.New("error casting public key to ECDSA")
 	}
 
 	address := crypto.PubkeyToAddress(*publicKeyECDSA).Hex()
 
-	encryptedPrivateKey, err := utils.Encrypt(privateKeyHex, []byte(s.encryptionKey))
+	encryptedPrivateKey, err := s.walletKeys.Seal(ctx, privateKeyHex)
 	if err != nil {
-		return "", "", err
+		return "", envelope.Sealed{}, err
 	}
 
 	return address, encryptedPrivateKey, nil
 }
 
-// SendTransaction signs and sends a transaction to the network.
-func (s *walletService) SendTransaction(ctx context.Context, tx *types.Transaction, privateKeyHex string) (common.Hash, error) {
-	privateKey, err := crypto.HexToECDSA(privateKeyHex)
+// SignTransaction builds and signs a transaction without sending it.
+func (s *walletService) SignTransaction(ctx context.Context, req evm.TxRequest, privateKey *ecdsa.PrivateKey) (string, error) {
+	tx, chainID, err := evm.BuildTransaction(ctx, s.ethClient, crypto.PubkeyToAddress(privateKey.PublicKey), req)
 	if err != nil {
-		return common.Hash{}, err
+		return "", err
 	}
+	return evm.NewSigner(privateKey).SignTransaction(tx, chainID)
+}
 
-	chainID, err := s.ethClient.ChainID(ctx)
+// SendTransaction builds, signs and sends a transaction to the network.
+func (s *walletService) SendTransaction(ctx context.Context, req evm.TxRequest, privateKey *ecdsa.PrivateKey) (*types.Transaction, error) {
+	tx, chainID, err := evm.BuildTransaction(ctx, s.ethClient, crypto.PubkeyToAddress(privateKey.PublicKey), req)
 	if err != nil {
-		return common.Hash{}, err
+		return nil, err
 	}
 
-	signedTx, err := types.SignTx(tx, types.NewEIP155Signer(chainID), privateKey)
+	signedTx, err := evm.NewSigner(privateKey).SignTx(tx, chainID)
 	if err != nil {
-		return common.Hash{}, err
+		return nil, err
 	}
 
 	err = s.ethClient.SendTransaction(ctx, signedTx)
 	if err != nil {
-		return common.Hash{}, err
+		return nil, err
 	}
 
-	return signedTx.Hash(), nil
+	return signedTx, nil
 }
@@ -0,0 +1,165 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"time"
+	"vybes/internal/config"
+	"vybes/internal/domain"
+	"vybes/internal/repository"
+	"vybes/pkg/pagination"
+
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/ethclient"
+	"github.com/rs/zerolog/log"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// walletTxCheckBatchSize is how many pending transactions one watcher run polls
+const walletTxCheckBatchSize = 100
+
+// WalletTransactionService defines the interface for the history of
+// transactions sent from custodial wallets and for tracking their status.
+type WalletTransactionService interface {
+	// RecordTransaction stores a transaction the user's wallet just sent as pending
+	RecordTransaction(ctx context.Context, userID primitive.ObjectID, tx *types.Transaction) error
+	GetTransactions(ctx context.Context, userID, cursor string, limit int) (*pagination.Page[domain.WalletTransaction], error)
+	// CheckPendingTransactions polls pending transactions and resolves the ones
+	// that were mined, dropped or replaced
+	CheckPendingTransactions(ctx context.Context)
+}
+
+type walletTransactionService struct {
+	walletTxRepo        repository.WalletTransactionRepository
+	notificationService NotificationService
+	ethClient           *ethclient.Client
+	cursorCodec         *pagination.Codec
+	dropTimeout         time.Duration
+}
+
+// NewWalletTransactionService creates a new wallet transaction service.
+func NewWalletTransactionService(walletTxRepo repository.WalletTransactionRepository, notificationService NotificationService, ethClient *ethclient.Client, cursorCodec *pagination.Codec, cfg *config.Config) WalletTransactionService {
+	return &walletTransactionService{
+		walletTxRepo:        walletTxRepo,
+		notificationService: notificationService,
+		ethClient:           ethClient,
+		cursorCodec:         cursorCodec,
+		dropTimeout:         cfg.WalletTxDropTimeout,
+	}
+}
+
+func (s *walletTransactionService) RecordTransaction(ctx context.Context, userID primitive.ObjectID, tx *types.Transaction) error {
+	from, err := types.Sender(types.LatestSignerForChainID(tx.ChainId()), tx)
+	if err != nil {
+		return err
+	}
+
+	now := time.Now()
+	record := &domain.WalletTransaction{
+		ID:        primitive.NewObjectID(),
+		UserID:    userID,
+		From:      from.Hex(),
+		Value:     tx.Value().String(),
+		Nonce:     tx.Nonce(),
+		Hash:      tx.Hash().Hex(),
+		Status:    domain.WalletTransactionPending,
+		CheckedAt: now,
+		CreatedAt: now,
+		UpdatedAt: now,
+	}
+	if to := tx.To(); to != nil {
+		record.To = to.Hex()
+	}
+	return s.walletTxRepo.CreateTransaction(ctx, record)
+}
+
+func (s *walletTransactionService) GetTransactions(ctx context.Context, userIDStr, cursor string, limit int) (*pagination.Page[domain.WalletTransaction], error) {
+	userID, err := primitive.ObjectIDFromHex(userIDStr)
+	if err != nil {
+		return nil, err
+	}
+	after, err := s.cursorCodec.Decode(cursor)
+	if err != nil {
+		return nil, err
+	}
+	// Fetch one extra transaction to learn whether another page exists
+	transactions, err := s.walletTxRepo.GetTransactionsByUser(ctx, userID, after, limit+1)
+	if err != nil {
+		return nil, err
+	}
+	page := pagination.NewPage(s.cursorCodec, transactions, limit, walletTransactionCursor)
+	return &page, nil
+}
+
+func (s *walletTransactionService) CheckPendingTransactions(ctx context.Context) {
+	pending, err := s.walletTxRepo.GetPendingTransactions(ctx, walletTxCheckBatchSize)
+	if err != nil {
+		log.Error().Err(err).Msg("Failed to load pending wallet transactions")
+		return
+	}
+
+	for i := range pending {
+		tx := &pending[i]
+		if err := s.checkTransaction(ctx, tx); err != nil {
+			log.Error().Err(err).Str("hash", tx.Hash).Msg("Failed to check wallet transaction")
+		}
+	}
+}
+
+// checkTransaction resolves a pending transaction once it has a receipt, once
+// another transaction was mined with its nonce, or once the node has forgotten
+// it for longer than the drop timeout.
+func (s *walletTransactionService) checkTransaction(ctx context.Context, tx *domain.WalletTransaction) error {
+	hash := common.HexToHash(tx.Hash)
+
+	// Read the nonce before the receipt: if the nonce was already used and the
+	// receipt is still missing afterwards, another transaction took the nonce
+	minedNonce, err := s.ethClient.NonceAt(ctx, common.HexToAddress(tx.From), nil)
+	if err != nil {
+		return err
+	}
+
+	receipt, err := s.ethClient.TransactionReceipt(ctx, hash)
+	switch {
+	case err == nil:
+		tx.BlockNumber = receipt.BlockNumber.Uint64()
+		tx.GasUsed = receipt.GasUsed
+		if receipt.Status == types.ReceiptStatusSuccessful {
+			tx.Status = domain.WalletTransactionConfirmed
+			if tx.To == "" {
+				tx.ContractAddress = receipt.ContractAddress.Hex()
+			}
+		} else {
+			tx.Status = domain.WalletTransactionFailed
+		}
+	case !errors.Is(err, ethereum.NotFound):
+		return err
+	case minedNonce > tx.Nonce:
+		tx.Status = domain.WalletTransactionReplaced
+	default:
+		_, _, err := s.ethClient.TransactionByHash(ctx, hash)
+		if err != nil && !errors.Is(err, ethereum.NotFound) {
+			return err
+		}
+		if err == nil || time.Since(tx.CreatedAt) < s.dropTimeout {
+			// Still in the mempool, or possibly not yet propagated to this node
+			return s.walletTxRepo.MarkChecked(ctx, tx.ID)
+		}
+		tx.Status = domain.WalletTransactionDropped
+	}
+
+	resolved, err := s.walletTxRepo.ResolveTransaction(ctx, tx)
+	if err != nil || !resolved {
+		return err
+	}
+	log.Info().Str("hash", tx.Hash).Str("status", string(tx.Status)).Msg("Wallet transaction resolved")
+
+	if tx.Status == domain.WalletTransactionConfirmed {
+		if err := s.notificationService.CreateTransactionNotification(ctx, tx.UserID, tx.ID); err != nil {
+			log.Error().Err(err).Str("hash", tx.Hash).Msg("Failed to create transaction notification")
+		}
+	}
+	return nil
+}
@@ -0,0 +1,158 @@
+package service
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"time"
+	"vybes/internal/domain"
+	"vybes/internal/repository"
+	"vybes/pkg/cache"
+	"vybes/pkg/keyvault"
+
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/redis/go-redis/v9"
+	"github.com/rs/zerolog/log"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// walletUnlockTTL is how long an unlocked wallet stays usable
+const walletUnlockTTL = 15 * time.Minute
+
+// ErrWalletLocked is returned when signing with a wallet the session hasn't unlocked.
+var ErrWalletLocked = errors.New("wallet is locked")
+
+// WalletUnlockService defines the interface for unlocking custodial wallets
+// for signing. An unlock is granted to one session: Redis holds only an opaque
+// grant token for the session, and the decrypted key stays in this process's
+// vault. Instances that don't have the key in their vault decrypt it again
+// for the rest of the grant.
+type WalletUnlockService interface {
+	// Unlock makes the user's wallet usable by the session. The caller must have checked the user's credentials
+	Unlock(ctx context.Context, user *domain.User, sessionID primitive.ObjectID) error
+	// Lock ends the session's unlock, if any
+	Lock(ctx context.Context, userID, sessionID primitive.ObjectID) error
+	// WithPrivateKey runs fn with the key of a wallet the session unlocked. fn must not keep the key
+	WithPrivateKey(ctx context.Context, userID, sessionID primitive.ObjectID, fn func(*ecdsa.PrivateKey) error) error
+}
+
+type walletUnlockService struct {
+	userRepo   repository.UserRepository
+	walletKeys WalletKeyService
+	cache      cache.Client
+	vault      *keyvault.Vault
+}
+
+// NewWalletUnlockService creates a new wallet unlock service.
+func NewWalletUnlockService(userRepo repository.UserRepository, walletKeys WalletKeyService, cache cache.Client, vault *keyvault.Vault) WalletUnlockService {
+	return &walletUnlockService{
+		userRepo:   userRepo,
+		walletKeys: walletKeys,
+		cache:      cache,
+		vault:      vault,
+	}
+}
+
+func (s *walletUnlockService) Unlock(ctx context.Context, user *domain.User, sessionID primitive.ObjectID) error {
+	token, err := newRefreshToken()
+	if err != nil {
+		return err
+	}
+	if err := s.storeKey(ctx, user, token, walletUnlockTTL); err != nil {
+		return err
+	}
+
+	key := walletUnlockKey(user.ID, sessionID)
+	previous, err := s.cache.Get(ctx, key)
+	if err == nil {
+		s.vault.Delete(previous)
+	}
+	if err := s.cache.Set(ctx, key, token, walletUnlockTTL); err != nil {
+		s.vault.Delete(token)
+		return err
+	}
+	return nil
+}
+
+func (s *walletUnlockService) Lock(ctx context.Context, userID, sessionID primitive.ObjectID) error {
+	key := walletUnlockKey(userID, sessionID)
+	token, err := s.cache.Get(ctx, key)
+	if errors.Is(err, redis.Nil) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	// Other instances can't reach their copy once the grant is gone; it is wiped when it expires
+	s.vault.Delete(token)
+	return s.cache.Del(ctx, key)
+}
+
+func (s *walletUnlockService) WithPrivateKey(ctx context.Context, userID, sessionID primitive.ObjectID, fn func(*ecdsa.PrivateKey) error) error {
+	key := walletUnlockKey(userID, sessionID)
+	token, err := s.cache.Get(ctx, key)
+	if errors.Is(err, redis.Nil) {
+		return ErrWalletLocked
+	}
+	if err != nil {
+		return err
+	}
+
+	use := func(secret []byte) error {
+		privateKey, err := crypto.ToECDSA(secret)
+		if err != nil {
+			return err
+		}
+		// Best effort: the scalar is the only copy of the key outside the vault
+		defer clear(privateKey.D.Bits())
+		return fn(privateKey)
+	}
+	err = s.vault.Use(token, use)
+	if !errors.Is(err, keyvault.ErrNotFound) {
+		return err
+	}
+
+	// Unlocked on another instance or before a restart: decrypt the key again
+	// for what is left of the grant
+	ttl, err := s.cache.TTL(ctx, key)
+	if errors.Is(err, redis.Nil) || (err == nil && ttl <= 0) {
+		return ErrWalletLocked
+	}
+	if err != nil {
+		return err
+	}
+	user, err := s.userRepo.GetUserByID(ctx, userID)
+	if err != nil {
+		return err
+	}
+	if err := s.storeKey(ctx, user, token, ttl); err != nil {
+		return err
+	}
+	err = s.vault.Use(token, use)
+	if errors.Is(err, keyvault.ErrNotFound) {
+		return ErrWalletLocked
+	}
+	return err
+}
+
+// storeKey decrypts the user's private key into the vault under token.
+func (s *walletUnlockService) storeKey(ctx context.Context, user *domain.User, token string, ttl time.Duration) error {
+	privateKeyHex, err := s.walletKeys.Open(ctx, user)
+	if err != nil {
+		log.Error().Err(err).Str("user_id", user.ID.Hex()).Msg("Failed to decrypt wallet key")
+		return errors.New("could not decrypt private key")
+	}
+	privateKey, err := hex.DecodeString(privateKeyHex)
+	if err != nil {
+		return errors.New("could not decrypt private key")
+	}
+	s.vault.Put(token, privateKey, ttl)
+	clear(privateKey)
+	return nil
+}
+
+func walletUnlockKey(userID, sessionID primitive.ObjectID) string {
+	return fmt.Sprintf("wallet:unlock:%s:%s", userID.Hex(), sessionID.Hex())
+}
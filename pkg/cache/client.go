@@ -2,6 +2,8 @@ package cache
 
 import (
 	"context"
+	"math"
+	"strconv"
 	"time"
 	"vybes/internal/config"
 
@@ -17,6 +19,51 @@ type Client interface {
 	Set(ctx context.Context, key string, value interface{}, expiration time.Duration) error
 	// Del removes one or more keys from cache
 	Del(ctx context.Context, keys ...string) error
+	// SetNX stores a value only if the key does not exist yet and reports whether it was stored
+	SetNX(ctx context.Context, key string, value interface{}, expiration time.Duration) (bool, error)
+	// Exists reports whether a key is present in cache
+	Exists(ctx context.Context, key string) (bool, error)
+	// Rename atomically renames a key, replacing newKey if it exists
+	Rename(ctx context.Context, key, newKey string) error
+	// Scan returns every key matching a glob pattern, without blocking the server
+	Scan(ctx context.Context, match string) ([]string, error)
+	// Incr atomically increments a counter and returns its new value. A counter
+	// created by the call expires after the given duration.
+	Incr(ctx context.Context, key string, expiration time.Duration) (int64, error)
+	// HIncrBy atomically increments a numeric field of a hash
+	HIncrBy(ctx context.Context, key, field string, incr int64) error
+	// HGetAll retrieves every field and value of a hash
+	HGetAll(ctx context.Context, key string) (map[string]string, error)
+	// Expire sets a key's time to live
+	Expire(ctx context.Context, key string, expiration time.Duration) error
+	// TTL returns a key's remaining time to live, or redis.Nil if the key doesn't exist
+	TTL(ctx context.Context, key string) (time.Duration, error)
+	// ZAdd adds members to a sorted set, updating the score of existing members
+	ZAdd(ctx context.Context, key string, members ...ZMember) error
+	// ZAddCapped adds one member to several sorted sets in a single round trip,
+	// keeping only the maxLen highest-scored members of each and refreshing their expiration
+	ZAddCapped(ctx context.Context, keys []string, member ZMember, maxLen int64, expiration time.Duration) error
+	// ZRem removes members from a sorted set
+	ZRem(ctx context.Context, key string, members ...string) error
+	// ZRemFromAll removes one member from several sorted sets in a single round trip
+	ZRemFromAll(ctx context.Context, keys []string, member string) error
+	// ZRevRangeByScore returns up to count members with a score of at most max, highest score first.
+	// A count of 0 returns every such member.
+	ZRevRangeByScore(ctx context.Context, key string, max float64, count int64) ([]ZMember, error)
+	// ZCard returns the number of members in a sorted set
+	ZCard(ctx context.Context, key string) (int64, error)
+	// SAdd adds members to a set
+	SAdd(ctx context.Context, key string, members ...string) error
+	// SRem removes members from a set
+	SRem(ctx context.Context, key string, members ...string) error
+	// SMembers returns every member of a set
+	SMembers(ctx context.Context, key string) ([]string, error)
+}
+
+// ZMember is a member of a sorted set together with its score
+type ZMember struct {
+	Member string
+	Score  float64
 }
 
 // redisClient implements the Client interface using Redis as the backend
@@ -65,3 +112,187 @@ func (c *redisClient) Set(ctx context.Context, key string, value interface{}, ex
 func (c *redisClient) Del(ctx context.Context, keys ...string) error {
 	return c.client.Del(ctx, keys...).Err()
 }
+
+// SetNX stores a value in Redis only if the key is absent.
+// It is the building block for dedup windows and simple locks.
+func (c *redisClient) SetNX(ctx context.Context, key string, value interface{}, expiration time.Duration) (bool, error) {
+	return c.client.SetNX(ctx, key, value, expiration).Result()
+}
+
+// Exists reports whether the key is present in Redis.
+func (c *redisClient) Exists(ctx context.Context, key string) (bool, error) {
+	n, err := c.client.Exists(ctx, key).Result()
+	return n > 0, err
+}
+
+// Rename atomically renames a key in Redis.
+// Returns an error if the source key does not exist.
+func (c *redisClient) Rename(ctx context.Context, key, newKey string) error {
+	return c.client.Rename(ctx, key, newKey).Err()
+}
+
+// Scan iterates the keyspace with SCAN, so large databases aren't blocked
+// the way KEYS would block them. Keys changed during the scan may be missed.
+func (c *redisClient) Scan(ctx context.Context, match string) ([]string, error) {
+	var keys []string
+	iter := c.client.Scan(ctx, 0, match, 100).Iterator()
+	for iter.Next(ctx) {
+		keys = append(keys, iter.Val())
+	}
+	return keys, iter.Err()
+}
+
+// incrScript increments a counter and gives it an expiration if it has none, in
+// one step, so a counter can never be left without one. Checking the TTL rather
+// than the new value also repairs counters stored without an expiration.
+var incrScript = redis.NewScript(`
+local n = redis.call('INCR', KEYS[1])
+if redis.call('PTTL', KEYS[1]) < 0 then
+	redis.call('PEXPIRE', KEYS[1], ARGV[1])
+end
+return n
+`)
+
+// Incr increments a counter, starting its expiration window on the first increment.
+func (c *redisClient) Incr(ctx context.Context, key string, expiration time.Duration) (int64, error) {
+	return incrScript.Run(ctx, c.client, []string{key}, expiration.Milliseconds()).Int64()
+}
+
+// HIncrBy atomically increments a field of a Redis hash, creating it if needed.
+func (c *redisClient) HIncrBy(ctx context.Context, key, field string, incr int64) error {
+	return c.client.HIncrBy(ctx, key, field, incr).Err()
+}
+
+// HGetAll retrieves all fields of a Redis hash.
+// Returns an empty map if the key doesn't exist.
+func (c *redisClient) HGetAll(ctx context.Context, key string) (map[string]string, error) {
+	return c.client.HGetAll(ctx, key).Result()
+}
+
+// Expire sets a time to live on a Redis key.
+func (c *redisClient) Expire(ctx context.Context, key string, expiration time.Duration) error {
+	return c.client.Expire(ctx, key, expiration).Err()
+}
+
+// TTL returns the remaining time to live of a Redis key. Keys without an
+// expiration report a negative duration.
+func (c *redisClient) TTL(ctx context.Context, key string) (time.Duration, error) {
+	ttl, err := c.client.TTL(ctx, key).Result()
+	if err != nil {
+		return 0, err
+	}
+	// Redis answers -2 for missing keys
+	if ttl == -2 {
+		return 0, redis.Nil
+	}
+	return ttl, nil
+}
+
+// ZAdd adds members to a Redis sorted set.
+func (c *redisClient) ZAdd(ctx context.Context, key string, members ...ZMember) error {
+	if len(members) == 0 {
+		return nil
+	}
+	zs := make([]redis.Z, len(members))
+	for i, m := range members {
+		zs[i] = redis.Z{Score: m.Score, Member: m.Member}
+	}
+	return c.client.ZAdd(ctx, key, zs...).Err()
+}
+
+// ZAddCapped pipelines ZADD, a trim to the newest maxLen members and EXPIRE for every key.
+// It is meant for fanning one item out to many bounded lists.
+func (c *redisClient) ZAddCapped(ctx context.Context, keys []string, member ZMember, maxLen int64, expiration time.Duration) error {
+	if len(keys) == 0 {
+		return nil
+	}
+	pipe := c.client.Pipeline()
+	for _, key := range keys {
+		pipe.ZAdd(ctx, key, redis.Z{Score: member.Score, Member: member.Member})
+		pipe.ZRemRangeByRank(ctx, key, 0, -maxLen-1)
+		pipe.Expire(ctx, key, expiration)
+	}
+	_, err := pipe.Exec(ctx)
+	return err
+}
+
+// ZRem removes members from a Redis sorted set.
+func (c *redisClient) ZRem(ctx context.Context, key string, members ...string) error {
+	if len(members) == 0 {
+		return nil
+	}
+	args := make([]interface{}, len(members))
+	for i, m := range members {
+		args[i] = m
+	}
+	return c.client.ZRem(ctx, key, args...).Err()
+}
+
+// ZRemFromAll pipelines ZREM of one member across many sorted sets.
+func (c *redisClient) ZRemFromAll(ctx context.Context, keys []string, member string) error {
+	if len(keys) == 0 {
+		return nil
+	}
+	pipe := c.client.Pipeline()
+	for _, key := range keys {
+		pipe.ZRem(ctx, key, member)
+	}
+	_, err := pipe.Exec(ctx)
+	return err
+}
+
+// ZRevRangeByScore returns members scored at most max, highest first.
+// Members with equal scores come back in reverse lexicographic order.
+func (c *redisClient) ZRevRangeByScore(ctx context.Context, key string, max float64, count int64) ([]ZMember, error) {
+	maxArg := "+inf"
+	if !math.IsInf(max, 1) {
+		maxArg = strconv.FormatFloat(max, 'f', -1, 64)
+	}
+	zs, err := c.client.ZRevRangeByScoreWithScores(ctx, key, &redis.ZRangeBy{
+		Max:   maxArg,
+		Min:   "-inf",
+		Count: count,
+	}).Result()
+	if err != nil {
+		return nil, err
+	}
+	members := make([]ZMember, len(zs))
+	for i, z := range zs {
+		members[i] = ZMember{Member: z.Member.(string), Score: z.Score}
+	}
+	return members, nil
+}
+
+// ZCard returns the size of a Redis sorted set, or 0 if it doesn't exist.
+func (c *redisClient) ZCard(ctx context.Context, key string) (int64, error) {
+	return c.client.ZCard(ctx, key).Result()
+}
+
+// SAdd adds members to a Redis set.
+func (c *redisClient) SAdd(ctx context.Context, key string, members ...string) error {
+	if len(members) == 0 {
+		return nil
+	}
+	args := make([]interface{}, len(members))
+	for i, m := range members {
+		args[i] = m
+	}
+	return c.client.SAdd(ctx, key, args...).Err()
+}
+
+// SRem removes members from a Redis set.
+func (c *redisClient) SRem(ctx context.Context, key string, members ...string) error {
+	if len(members) == 0 {
+		return nil
+	}
+	args := make([]interface{}, len(members))
+	for i, m := range members {
+		args[i] = m
+	}
+	return c.client.SRem(ctx, key, args...).Err()
+}
+
+// SMembers returns every member of a Redis set, or an empty slice if it doesn't exist.
+func (c *redisClient) SMembers(ctx context.Context, key string) ([]string, error) {
+	return c.client.SMembers(ctx, key).Result()
+}
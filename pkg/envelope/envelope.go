@@ -0,0 +1,153 @@
+// Package envelope encrypts secrets with envelope encryption: each secret gets
+// its own random data key, and only the data key is encrypted ("wrapped") with
+// a master key. Rotating a master key then means re-wrapping the small data
+// keys, not re-encrypting every secret, and master keys never leave the
+// KeyProvider.
+package envelope
+
+import (
+	"context"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/base64"
+	"errors"
+)
+
+// dataKeySize is the length of data keys; they are AES-256 keys
+const dataKeySize = 32
+
+// ErrUnknownKeyVersion is returned when no master key has the requested version
+var ErrUnknownKeyVersion = errors.New("unknown master key version")
+
+// KeyProvider wraps and unwraps data keys with versioned master keys. New data
+// keys are wrapped with the current version; older versions stay available for
+// unwrapping until everything they wrapped has been re-wrapped.
+type KeyProvider interface {
+	// CurrentVersion returns the master key version WrapKey uses
+	CurrentVersion() int
+	// WrapKey encrypts a data key with the current master key
+	WrapKey(ctx context.Context, dataKey []byte) (wrapped []byte, version int, err error)
+	// UnwrapKey decrypts a data key wrapped with the given master key version
+	UnwrapKey(ctx context.Context, wrapped []byte, version int) ([]byte, error)
+}
+
+// Sealed is an envelope-encrypted secret as it is stored.
+type Sealed struct {
+	Ciphertext string // Base64 AES-GCM ciphertext of the secret under the data key
+	WrappedKey string // Base64 data key wrapped with the master key
+	KeyVersion int    // Version of the master key that wrapped the data key
+}
+
+// Seal encrypts plaintext under a new data key and wraps the data key with the
+// provider's current master key.
+//
+// Parameters:
+//   - keys: Provider of the master keys
+//   - plaintext: The secret to encrypt
+//
+// Returns:
+//   - Sealed: The encrypted secret and its wrapped data key
+//   - error: Any error that occurred during encryption or wrapping
+func Seal(ctx context.Context, keys KeyProvider, plaintext string) (Sealed, error) {
+	dataKey := make([]byte, dataKeySize)
+	if _, err := rand.Read(dataKey); err != nil {
+		return Sealed{}, err
+	}
+	defer clear(dataKey)
+
+	ciphertext, err := encrypt(dataKey, []byte(plaintext), nil)
+	if err != nil {
+		return Sealed{}, err
+	}
+	wrapped, version, err := keys.WrapKey(ctx, dataKey)
+	if err != nil {
+		return Sealed{}, err
+	}
+	return Sealed{
+		Ciphertext: base64.StdEncoding.EncodeToString(ciphertext),
+		WrappedKey: base64.StdEncoding.EncodeToString(wrapped),
+		KeyVersion: version,
+	}, nil
+}
+
+// Open unwraps the data key of a sealed secret and decrypts the secret.
+func Open(ctx context.Context, keys KeyProvider, sealed Sealed) (string, error) {
+	dataKey, err := unwrap(ctx, keys, sealed)
+	if err != nil {
+		return "", err
+	}
+	defer clear(dataKey)
+
+	ciphertext, err := base64.StdEncoding.DecodeString(sealed.Ciphertext)
+	if err != nil {
+		return "", err
+	}
+	plaintext, err := decrypt(dataKey, ciphertext, nil)
+	if err != nil {
+		return "", err
+	}
+	return string(plaintext), nil
+}
+
+// Rewrap wraps the data key of a sealed secret with the provider's current
+// master key. The ciphertext stays the same.
+func Rewrap(ctx context.Context, keys KeyProvider, sealed Sealed) (Sealed, error) {
+	dataKey, err := unwrap(ctx, keys, sealed)
+	if err != nil {
+		return Sealed{}, err
+	}
+	defer clear(dataKey)
+
+	wrapped, version, err := keys.WrapKey(ctx, dataKey)
+	if err != nil {
+		return Sealed{}, err
+	}
+	return Sealed{
+		Ciphertext: sealed.Ciphertext,
+		WrappedKey: base64.StdEncoding.EncodeToString(wrapped),
+		KeyVersion: version,
+	}, nil
+}
+
+func unwrap(ctx context.Context, keys KeyProvider, sealed Sealed) ([]byte, error) {
+	wrapped, err := base64.StdEncoding.DecodeString(sealed.WrappedKey)
+	if err != nil {
+		return nil, err
+	}
+	return keys.UnwrapKey(ctx, wrapped, sealed.KeyVersion)
+}
+
+// encrypt seals plaintext with AES-GCM, prefixing the random nonce.
+func encrypt(key, plaintext, additionalData []byte) ([]byte, error) {
+	gcm, err := newGCM(key)
+	if err != nil {
+		return nil, err
+	}
+	nonce := make([]byte, gcm.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return nil, err
+	}
+	return gcm.Seal(nonce, nonce, plaintext, additionalData), nil
+}
+
+// decrypt opens a ciphertext produced by encrypt.
+func decrypt(key, ciphertext, additionalData []byte) ([]byte, error) {
+	gcm, err := newGCM(key)
+	if err != nil {
+		return nil, err
+	}
+	if len(ciphertext) < gcm.NonceSize() {
+		return nil, errors.New("ciphertext too short")
+	}
+	nonce, ciphertext := ciphertext[:gcm.NonceSize()], ciphertext[gcm.NonceSize():]
+	return gcm.Open(nil, nonce, ciphertext, additionalData)
+}
+
+func newGCM(key []byte) (cipher.AEAD, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
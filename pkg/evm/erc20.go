@@ -0,0 +1,105 @@
+package evm
+
+import (
+	"context"
+	"errors"
+	"math/big"
+	"strings"
+
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/common"
+)
+
+// Selectors of the ERC-20 view functions used to read token balances
+var (
+	balanceOfSelector = []byte{0x70, 0xa0, 0x82, 0x31} // balanceOf(address)
+	decimalsSelector  = []byte{0x31, 0x3c, 0xe5, 0x67} // decimals()
+)
+
+// ErrInvalidTokenResponse is returned when a token contract's reply isn't a
+// valid ERC-20 return value, for example because the address isn't a token.
+var ErrInvalidTokenResponse = errors.New("invalid ERC-20 response")
+
+// ContractCaller is the part of an Ethereum client needed to read contracts.
+type ContractCaller interface {
+	CallContract(ctx context.Context, call ethereum.CallMsg, blockNumber *big.Int) ([]byte, error)
+}
+
+// ERC20BalanceOf reads an account's balance of an ERC-20 token at the latest block.
+//
+// Parameters:
+//   - ctx: Context for the call
+//   - caller: Client of the chain the token is on
+//   - token: Address of the token contract
+//   - owner: Account whose balance to read
+//
+// Returns:
+//   - *big.Int: Balance in the token's smallest unit
+//   - error: Any error that occurred during the call
+func ERC20BalanceOf(ctx context.Context, caller ContractCaller, token, owner common.Address) (*big.Int, error) {
+	data := append(append([]byte{}, balanceOfSelector...), common.LeftPadBytes(owner.Bytes(), 32)...)
+	word, err := callWord(ctx, caller, token, data)
+	if err != nil {
+		return nil, err
+	}
+	return new(big.Int).SetBytes(word), nil
+}
+
+// ERC20Decimals reads how many decimals an ERC-20 token's amounts have.
+//
+// Parameters:
+//   - ctx: Context for the call
+//   - caller: Client of the chain the token is on
+//   - token: Address of the token contract
+//
+// Returns:
+//   - uint8: Number of decimals
+//   - error: Any error that occurred during the call
+func ERC20Decimals(ctx context.Context, caller ContractCaller, token common.Address) (uint8, error) {
+	word, err := callWord(ctx, caller, token, decimalsSelector)
+	if err != nil {
+		return 0, err
+	}
+	decimals := new(big.Int).SetBytes(word)
+	if !decimals.IsUint64() || decimals.Uint64() > 255 {
+		return 0, ErrInvalidTokenResponse
+	}
+	return uint8(decimals.Uint64()), nil
+}
+
+// callWord calls a view function that returns a single 32-byte word.
+func callWord(ctx context.Context, caller ContractCaller, contract common.Address, data []byte) ([]byte, error) {
+	result, err := caller.CallContract(ctx, ethereum.CallMsg{To: &contract, Data: data}, nil)
+	if err != nil {
+		return nil, err
+	}
+	// Calls to addresses without code succeed with an empty result
+	if len(result) < 32 {
+		return nil, ErrInvalidTokenResponse
+	}
+	return result[:32], nil
+}
+
+// FormatUnits formats an amount in a token's smallest unit as a decimal
+// string in whole tokens, such as "1.5" for 1500000 with 6 decimals. Trailing
+// zeros are dropped.
+func FormatUnits(amount *big.Int, decimals uint8) string {
+	digits := new(big.Int).Abs(amount).String()
+	sign := ""
+	if amount.Sign() < 0 {
+		sign = "-"
+	}
+	if decimals == 0 {
+		return sign + digits
+	}
+
+	scale := int(decimals)
+	if len(digits) <= scale {
+		digits = strings.Repeat("0", scale-len(digits)+1) + digits
+	}
+	whole, fraction := digits[:len(digits)-scale], strings.TrimRight(digits[len(digits)-scale:], "0")
+	if fraction == "" {
+		return sign + whole
+	}
+	return sign + whole + "." + fraction
+}
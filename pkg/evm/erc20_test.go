@@ -0,0 +1,44 @@
+package evm
+
+import (
+	"math/big"
+	"testing"
+)
+
+func TestFormatUnits(t *testing.T) {
+	tests := []struct {
+		name     string
+		amount   string
+		decimals uint8
+		want     string
+	}{
+		{"no decimals", "1500000", 0, "1500000"},
+		{"no decimals, zero", "0", 0, "0"},
+		{"zero", "0", 6, "0"},
+		{"whole amount", "1000000", 6, "1"},
+		{"fraction", "1500000", 6, "1.5"},
+		{"trailing zeros dropped", "1230000", 6, "1.23"},
+		{"zeros inside kept", "1000001", 6, "1.000001"},
+		{"below one unit", "500000", 6, "0.5"},
+		{"smallest unit", "1", 6, "0.000001"},
+		{"exactly as many digits as decimals", "123456", 6, "0.123456"},
+		{"one ether", "1000000000000000000", 18, "1"},
+		{"one wei", "1", 18, "0.000000000000000001"},
+		{"18 decimals", "1234567890123456789012", 18, "1234.567890123456789012"},
+		{"18 decimals, trailing zeros", "2500000000000000000", 18, "2.5"},
+		{"negative", "-1500000", 6, "-1.5"},
+		{"negative below one unit", "-5", 6, "-0.000005"},
+		{"larger than uint64", "340282366920938463463374607431768211455", 18, "340282366920938463463.374607431768211455"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			amount, ok := new(big.Int).SetString(tt.amount, 10)
+			if !ok {
+				t.Fatalf("invalid amount %q", tt.amount)
+			}
+			if got := FormatUnits(amount, tt.decimals); got != tt.want {
+				t.Errorf("FormatUnits(%s, %d) = %q, want %q", tt.amount, tt.decimals, got, tt.want)
+			}
+		})
+	}
+}
@@ -5,7 +5,6 @@ import (
 	"fmt"
 	"math/big"
 
-	"github.com/ethereum/go-ethereum/common"
 	"github.com/ethereum/go-ethereum/common/hexutil"
 	"github.com/ethereum/go-ethereum/core/types"
 	"github.com/ethereum/go-ethereum/crypto"
@@ -35,6 +34,12 @@ func NewSignerFromHex(privateKeyHex string) (*Signer, error) {
 	return &Signer{privateKey: privateKey}, nil
 }
 
+// NewSigner creates a new Signer instance from a private key. The signer uses
+// the key without copying it.
+func NewSigner(privateKey *ecdsa.PrivateKey) *Signer {
+	return &Signer{privateKey: privateKey}
+}
+
 // PersonalSign signs a message using the Ethereum personal_sign method.
 // This is commonly used for signing login messages and other user authentication.
 //
@@ -54,28 +59,33 @@ func (s *Signer) PersonalSign(message string) (string, error) {
 	return hexutil.Encode(signature), nil
 }
 
-// SignTransaction signs an Ethereum transaction with the provided parameters.
-// The transaction is signed using EIP-155 replay protection.
+// SignTransaction signs an Ethereum transaction for the given chain. The
+// signer is chosen from the transaction type, so legacy transactions get
+// EIP-155 replay protection and dynamic fee transactions are signed as
+// EIP-1559 transactions.
 //
 // Parameters:
-//   - nonce: Transaction nonce
-//   - to: Recipient address (nil for contract creation)
-//   - value: Amount of ETH to send
-//   - gasLimit: Maximum gas to use
-//   - gasPrice: Gas price in wei
-//   - data: Transaction data (nil for simple transfers)
+//   - tx: The unsigned transaction, as built by BuildTransaction
 //   - chainID: Network chain ID for replay protection
 //
 // Returns:
-//   - string: Hex-encoded signed transaction
+//   - string: Hex-encoded raw signed transaction, ready for eth_sendRawTransaction
 //   - error: Any error that occurred during signing
-func (s *Signer) SignTransaction(nonce uint64, to *common.Address, value *big.Int, gasLimit uint64, gasPrice *big.Int, data []byte, chainID *big.Int) (string, error) {
-	tx := types.NewTransaction(nonce, *to, value, gasLimit, gasPrice, data)
-	signedTx, err := types.SignTx(tx, types.NewEIP155Signer(chainID), s.privateKey)
+func (s *Signer) SignTransaction(tx *types.Transaction, chainID *big.Int) (string, error) {
+	signedTx, err := s.SignTx(tx, chainID)
 	if err != nil {
 		return "", err
 	}
-	return hexutil.Encode(signedTx.Hash().Bytes()), nil
+	raw, err := signedTx.MarshalBinary()
+	if err != nil {
+		return "", err
+	}
+	return hexutil.Encode(raw), nil
+}
+
+// SignTx signs tx like SignTransaction and returns the signed transaction.
+func (s *Signer) SignTx(tx *types.Transaction, chainID *big.Int) (*types.Transaction, error) {
+	return types.SignTx(tx, types.LatestSignerForChainID(chainID), s.privateKey)
 }
 
 // SignTypedDataV4 signs structured data according to EIP-712 specification.
@@ -0,0 +1,199 @@
+package evm
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"math/big"
+
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/core/types"
+)
+
+// ErrInvalidTransaction is wrapped by errors for transaction requests that
+// can't be built as given.
+var ErrInvalidTransaction = errors.New("invalid transaction")
+
+// Backend is the part of an Ethereum client the transaction builder needs.
+// Both *ethclient.Client and the simulated backend's client implement it.
+type Backend interface {
+	ChainID(ctx context.Context) (*big.Int, error)
+	HeaderByNumber(ctx context.Context, number *big.Int) (*types.Header, error)
+	PendingNonceAt(ctx context.Context, account common.Address) (uint64, error)
+	EstimateGas(ctx context.Context, call ethereum.CallMsg) (uint64, error)
+	SuggestGasPrice(ctx context.Context) (*big.Int, error)
+	SuggestGasTipCap(ctx context.Context) (*big.Int, error)
+}
+
+// TxRequest describes a transaction to build, in the JSON format of
+// eth_sendTransaction. Fields left out are filled in from the network.
+type TxRequest struct {
+	To                   *common.Address `json:"to"`    // Recipient; nil creates a contract from Data
+	Value                *hexutil.Big    `json:"value"` // Wei to send
+	Data                 hexutil.Bytes   `json:"data"`
+	Input                hexutil.Bytes   `json:"input"` // Alias of Data used by newer clients
+	Nonce                *hexutil.Uint64 `json:"nonce"`
+	Gas                  *hexutil.Uint64 `json:"gas"`
+	GasPrice             *hexutil.Big    `json:"gasPrice"` // Builds a legacy transaction
+	MaxFeePerGas         *hexutil.Big    `json:"maxFeePerGas"`
+	MaxPriorityFeePerGas *hexutil.Big    `json:"maxPriorityFeePerGas"`
+}
+
+// BuildTransaction builds an unsigned transaction from the request for the
+// given sender. It fetches the pending nonce, estimates the gas limit and uses
+// EIP-1559 dynamic fees unless the request sets a gas price or the chain
+// doesn't support them.
+//
+// Parameters:
+//   - ctx: Context for the network calls
+//   - backend: Client of the chain the transaction is for
+//   - from: Address that will sign the transaction
+//   - req: The transaction to build
+//
+// Returns:
+//   - *types.Transaction: The unsigned transaction
+//   - *big.Int: Chain ID to sign the transaction for
+//   - error: Any error that occurred, wrapping ErrInvalidTransaction for invalid requests
+func BuildTransaction(ctx context.Context, backend Backend, from common.Address, req TxRequest) (*types.Transaction, *big.Int, error) {
+	data, err := req.data()
+	if err != nil {
+		return nil, nil, err
+	}
+	if req.To == nil && len(data) == 0 {
+		return nil, nil, fmt.Errorf("%w: contract creation requires data", ErrInvalidTransaction)
+	}
+	if req.GasPrice != nil && (req.MaxFeePerGas != nil || req.MaxPriorityFeePerGas != nil) {
+		return nil, nil, fmt.Errorf("%w: gasPrice can't be combined with maxFeePerGas or maxPriorityFeePerGas", ErrInvalidTransaction)
+	}
+	value := new(big.Int)
+	if req.Value != nil {
+		value = req.Value.ToInt()
+	}
+
+	chainID, err := backend.ChainID(ctx)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	var nonce uint64
+	if req.Nonce != nil {
+		nonce = uint64(*req.Nonce)
+	} else {
+		// Pending, so transactions sent in a row don't reuse a nonce
+		nonce, err = backend.PendingNonceAt(ctx, from)
+		if err != nil {
+			return nil, nil, err
+		}
+	}
+
+	fees, err := suggestFees(ctx, backend, req)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	var gas uint64
+	if req.Gas != nil {
+		gas = uint64(*req.Gas)
+	} else {
+		gas, err = backend.EstimateGas(ctx, ethereum.CallMsg{
+			From:      from,
+			To:        req.To,
+			GasPrice:  fees.gasPrice,
+			GasFeeCap: fees.gasFeeCap,
+			GasTipCap: fees.gasTipCap,
+			Value:     value,
+			Data:      data,
+		})
+		if err != nil {
+			return nil, nil, fmt.Errorf("failed to estimate gas: %w", err)
+		}
+	}
+
+	if fees.gasPrice != nil {
+		return types.NewTx(&types.LegacyTx{
+			Nonce:    nonce,
+			GasPrice: fees.gasPrice,
+			Gas:      gas,
+			To:       req.To,
+			Value:    value,
+			Data:     data,
+		}), chainID, nil
+	}
+	return types.NewTx(&types.DynamicFeeTx{
+		ChainID:   chainID,
+		Nonce:     nonce,
+		GasTipCap: fees.gasTipCap,
+		GasFeeCap: fees.gasFeeCap,
+		Gas:       gas,
+		To:        req.To,
+		Value:     value,
+		Data:      data,
+	}), chainID, nil
+}
+
+func (r TxRequest) data() ([]byte, error) {
+	if r.Data != nil && r.Input != nil && !bytes.Equal(r.Data, r.Input) {
+		return nil, fmt.Errorf("%w: data and input differ", ErrInvalidTransaction)
+	}
+	if r.Input != nil {
+		return r.Input, nil
+	}
+	return r.Data, nil
+}
+
+// txFees holds either a legacy gas price or EIP-1559 fee caps.
+type txFees struct {
+	gasPrice  *big.Int
+	gasFeeCap *big.Int
+	gasTipCap *big.Int
+}
+
+func suggestFees(ctx context.Context, backend Backend, req TxRequest) (txFees, error) {
+	if req.GasPrice != nil {
+		return txFees{gasPrice: req.GasPrice.ToInt()}, nil
+	}
+
+	head, err := backend.HeaderByNumber(ctx, nil)
+	if err != nil {
+		return txFees{}, err
+	}
+	if head.BaseFee == nil {
+		// The chain hasn't activated London: dynamic fee transactions would be rejected
+		if req.MaxFeePerGas != nil || req.MaxPriorityFeePerGas != nil {
+			return txFees{}, fmt.Errorf("%w: the chain doesn't support EIP-1559 fees", ErrInvalidTransaction)
+		}
+		gasPrice, err := backend.SuggestGasPrice(ctx)
+		if err != nil {
+			return txFees{}, err
+		}
+		return txFees{gasPrice: gasPrice}, nil
+	}
+
+	var tip *big.Int
+	if req.MaxPriorityFeePerGas != nil {
+		tip = req.MaxPriorityFeePerGas.ToInt()
+	} else {
+		tip, err = backend.SuggestGasTipCap(ctx)
+		if err != nil {
+			return txFees{}, err
+		}
+	}
+	var feeCap *big.Int
+	if req.MaxFeePerGas != nil {
+		feeCap = req.MaxFeePerGas.ToInt()
+	} else {
+		// Twice the base fee keeps the transaction includable through six full blocks
+		feeCap = new(big.Int).Add(tip, new(big.Int).Mul(head.BaseFee, big.NewInt(2)))
+	}
+	if feeCap.Cmp(tip) < 0 {
+		if req.MaxPriorityFeePerGas == nil {
+			// Only the cap was given: tip whatever it leaves
+			return txFees{gasFeeCap: feeCap, gasTipCap: new(big.Int).Set(feeCap)}, nil
+		}
+		return txFees{}, fmt.Errorf("%w: maxFeePerGas is lower than maxPriorityFeePerGas", ErrInvalidTransaction)
+	}
+	return txFees{gasFeeCap: feeCap, gasTipCap: tip}, nil
+}
@@ -0,0 +1,317 @@
+package evm
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"errors"
+	"math/big"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/ethclient/simulated"
+	"github.com/ethereum/go-ethereum/params"
+)
+
+// deployCode is init code that deploys a contract whose code returns 42:
+// it stores the 10 byte runtime code in memory and returns it.
+var deployCode = hexutil.MustDecode("0x69602a60005260206000f3600052600a6016f3")
+
+type testChain struct {
+	backend *simulated.Backend
+	client  simulated.Client
+	key     *ecdsa.PrivateKey
+	from    common.Address
+}
+
+func newTestChain(t *testing.T) *testChain {
+	t.Helper()
+	key, err := crypto.GenerateKey()
+	if err != nil {
+		t.Fatalf("failed to generate key: %v", err)
+	}
+	from := crypto.PubkeyToAddress(key.PublicKey)
+	backend := simulated.NewBackend(types.GenesisAlloc{
+		from: {Balance: new(big.Int).Mul(big.NewInt(100), big.NewInt(params.Ether))},
+	})
+	t.Cleanup(func() { backend.Close() })
+	return &testChain{backend: backend, client: backend.Client(), key: key, from: from}
+}
+
+// build builds a transaction from the chain's funded account.
+func (c *testChain) build(t *testing.T, req TxRequest) (*types.Transaction, *big.Int) {
+	t.Helper()
+	tx, chainID, err := BuildTransaction(context.Background(), c.client, c.from, req)
+	if err != nil {
+		t.Fatalf("BuildTransaction: %v", err)
+	}
+	return tx, chainID
+}
+
+// send signs tx the way the API does, decodes the raw transaction and
+// submits it.
+func (c *testChain) send(t *testing.T, tx *types.Transaction, chainID *big.Int) *types.Transaction {
+	t.Helper()
+	raw, err := NewSigner(c.key).SignTransaction(tx, chainID)
+	if err != nil {
+		t.Fatalf("SignTransaction: %v", err)
+	}
+	signed := decodeRaw(t, raw)
+	if err := c.client.SendTransaction(context.Background(), signed); err != nil {
+		t.Fatalf("SendTransaction: %v", err)
+	}
+	return signed
+}
+
+// mine commits a block and checks the transactions in it succeeded.
+func (c *testChain) mine(t *testing.T, txs ...*types.Transaction) []*types.Receipt {
+	t.Helper()
+	c.backend.Commit()
+	receipts := make([]*types.Receipt, len(txs))
+	for i, tx := range txs {
+		receipt, err := c.client.TransactionReceipt(context.Background(), tx.Hash())
+		if err != nil {
+			t.Fatalf("no receipt for transaction %d: %v", i, err)
+		}
+		if receipt.Status != types.ReceiptStatusSuccessful {
+			t.Fatalf("transaction %d failed", i)
+		}
+		receipts[i] = receipt
+	}
+	return receipts
+}
+
+func decodeRaw(t *testing.T, raw string) *types.Transaction {
+	t.Helper()
+	data, err := hexutil.Decode(raw)
+	if err != nil {
+		t.Fatalf("raw transaction isn't hex: %v", err)
+	}
+	var tx types.Transaction
+	if err := tx.UnmarshalBinary(data); err != nil {
+		t.Fatalf("raw transaction doesn't decode: %v", err)
+	}
+	return &tx
+}
+
+func ptr[T any](v T) *T { return &v }
+
+func bigValue(v int64) *hexutil.Big { return (*hexutil.Big)(big.NewInt(v)) }
+
+func TestBuildTransactionUsesPendingNonce(t *testing.T) {
+	chain := newTestChain(t)
+	to := common.HexToAddress("0x000000000000000000000000000000000000dEaD")
+
+	// Sent in a row without mining in between: the second must not reuse the first one's nonce
+	tx, chainID := chain.build(t, TxRequest{To: &to, Value: bigValue(1)})
+	first := chain.send(t, tx, chainID)
+	tx, chainID = chain.build(t, TxRequest{To: &to, Value: bigValue(2)})
+	second := chain.send(t, tx, chainID)
+	if first.Nonce() != 0 || second.Nonce() != 1 {
+		t.Fatalf("nonces = %d, %d, want 0, 1", first.Nonce(), second.Nonce())
+	}
+	chain.mine(t, first, second)
+
+	third, _ := chain.build(t, TxRequest{To: &to})
+	if third.Nonce() != 2 {
+		t.Errorf("nonce after mining = %d, want 2", third.Nonce())
+	}
+	explicit, _ := chain.build(t, TxRequest{To: &to, Nonce: ptr(hexutil.Uint64(7))})
+	if explicit.Nonce() != 7 {
+		t.Errorf("explicit nonce = %d, want 7", explicit.Nonce())
+	}
+}
+
+func TestBuildTransactionEstimatesGas(t *testing.T) {
+	chain := newTestChain(t)
+	to := common.HexToAddress("0x000000000000000000000000000000000000dEaD")
+
+	transfer, _ := chain.build(t, TxRequest{To: &to, Value: bigValue(1)})
+	if transfer.Gas() != params.TxGas {
+		t.Errorf("transfer gas = %d, want %d", transfer.Gas(), params.TxGas)
+	}
+
+	// Calldata costs gas on top of the base cost
+	withData, _ := chain.build(t, TxRequest{To: &to, Data: hexutil.Bytes{1, 2, 3, 4}})
+	if withData.Gas() <= params.TxGas {
+		t.Errorf("gas with calldata = %d, want more than %d", withData.Gas(), params.TxGas)
+	}
+
+	explicit, _ := chain.build(t, TxRequest{To: &to, Gas: ptr(hexutil.Uint64(50000))})
+	if explicit.Gas() != 50000 {
+		t.Errorf("explicit gas = %d, want 50000", explicit.Gas())
+	}
+
+	// Estimation runs the transaction, so one that can't succeed fails to build
+	tooMuch := (*hexutil.Big)(new(big.Int).Mul(big.NewInt(1000), big.NewInt(params.Ether)))
+	if _, _, err := BuildTransaction(context.Background(), chain.client, chain.from, TxRequest{To: &to, Value: tooMuch}); err == nil {
+		t.Error("BuildTransaction succeeded for a value above the balance")
+	}
+}
+
+func TestBuildTransactionFees(t *testing.T) {
+	ctx := context.Background()
+	chain := newTestChain(t)
+	to := common.HexToAddress("0x000000000000000000000000000000000000dEaD")
+	head, err := chain.client.HeaderByNumber(ctx, nil)
+	if err != nil {
+		t.Fatalf("HeaderByNumber: %v", err)
+	}
+	suggestedTip, err := chain.client.SuggestGasTipCap(ctx)
+	if err != nil {
+		t.Fatalf("SuggestGasTipCap: %v", err)
+	}
+
+	t.Run("EIP-1559 by default", func(t *testing.T) {
+		tx, chainID := chain.build(t, TxRequest{To: &to, Value: bigValue(1)})
+		if tx.Type() != types.DynamicFeeTxType {
+			t.Fatalf("type = %d, want dynamic fee", tx.Type())
+		}
+		if tx.ChainId().Cmp(chainID) != 0 {
+			t.Errorf("chain ID = %v, want %v", tx.ChainId(), chainID)
+		}
+		wantFeeCap := new(big.Int).Add(suggestedTip, new(big.Int).Mul(head.BaseFee, big.NewInt(2)))
+		if tx.GasTipCap().Cmp(suggestedTip) != 0 || tx.GasFeeCap().Cmp(wantFeeCap) != 0 {
+			t.Errorf("tip, fee cap = %v, %v, want %v, %v", tx.GasTipCap(), tx.GasFeeCap(), suggestedTip, wantFeeCap)
+		}
+		chain.mine(t, chain.send(t, tx, chainID))
+	})
+
+	t.Run("explicit EIP-1559 fees", func(t *testing.T) {
+		feeCap := new(big.Int).Mul(head.BaseFee, big.NewInt(3))
+		tx, _ := chain.build(t, TxRequest{To: &to, MaxFeePerGas: (*hexutil.Big)(feeCap), MaxPriorityFeePerGas: bigValue(2)})
+		if tx.GasFeeCap().Cmp(feeCap) != 0 || tx.GasTipCap().Int64() != 2 {
+			t.Errorf("fee cap, tip = %v, %v, want %v, 2", tx.GasFeeCap(), tx.GasTipCap(), feeCap)
+		}
+	})
+
+	t.Run("fee cap below the suggested tip", func(t *testing.T) {
+		feeCap := new(big.Int).Sub(suggestedTip, big.NewInt(1))
+		tx, _ := chain.build(t, TxRequest{To: &to, Gas: ptr(hexutil.Uint64(params.TxGas)), MaxFeePerGas: (*hexutil.Big)(feeCap)})
+		if tx.GasTipCap().Cmp(feeCap) != 0 {
+			t.Errorf("tip = %v, want the whole fee cap %v", tx.GasTipCap(), feeCap)
+		}
+	})
+
+	t.Run("gasPrice builds a legacy transaction", func(t *testing.T) {
+		gasPrice := new(big.Int).Mul(head.BaseFee, big.NewInt(2))
+		tx, chainID := chain.build(t, TxRequest{To: &to, Value: bigValue(1), GasPrice: (*hexutil.Big)(gasPrice)})
+		if tx.Type() != types.LegacyTxType {
+			t.Fatalf("type = %d, want legacy", tx.Type())
+		}
+		if tx.GasPrice().Cmp(gasPrice) != 0 {
+			t.Errorf("gas price = %v, want %v", tx.GasPrice(), gasPrice)
+		}
+		chain.mine(t, chain.send(t, tx, chainID))
+	})
+
+	invalid := []struct {
+		name string
+		req  TxRequest
+	}{
+		{"gasPrice with maxFeePerGas", TxRequest{To: &to, GasPrice: bigValue(10), MaxFeePerGas: bigValue(10)}},
+		{"gasPrice with maxPriorityFeePerGas", TxRequest{To: &to, GasPrice: bigValue(10), MaxPriorityFeePerGas: bigValue(1)}},
+		{"tip above the fee cap", TxRequest{To: &to, MaxFeePerGas: bigValue(1), MaxPriorityFeePerGas: bigValue(2)}},
+	}
+	for _, tt := range invalid {
+		t.Run(tt.name, func(t *testing.T) {
+			_, _, err := BuildTransaction(ctx, chain.client, chain.from, tt.req)
+			if !errors.Is(err, ErrInvalidTransaction) {
+				t.Fatalf("err = %v, want ErrInvalidTransaction", err)
+			}
+		})
+	}
+}
+
+func TestBuildTransactionCreatesContract(t *testing.T) {
+	ctx := context.Background()
+	chain := newTestChain(t)
+
+	tx, chainID := chain.build(t, TxRequest{Data: deployCode})
+	if tx.To() != nil {
+		t.Fatalf("to = %v, want nil for contract creation", tx.To())
+	}
+	receipts := chain.mine(t, chain.send(t, tx, chainID))
+
+	want := crypto.CreateAddress(chain.from, tx.Nonce())
+	if receipts[0].ContractAddress != want {
+		t.Errorf("contract address = %v, want %v", receipts[0].ContractAddress, want)
+	}
+	code, err := chain.client.CodeAt(ctx, want, nil)
+	if err != nil {
+		t.Fatalf("CodeAt: %v", err)
+	}
+	if hexutil.Encode(code) != "0x602a60005260206000f3" {
+		t.Errorf("deployed code = %s", hexutil.Encode(code))
+	}
+
+	if _, _, err := BuildTransaction(ctx, chain.client, chain.from, TxRequest{}); !errors.Is(err, ErrInvalidTransaction) {
+		t.Errorf("contract creation without code: err = %v, want ErrInvalidTransaction", err)
+	}
+}
+
+func TestBuildTransactionDataAndInput(t *testing.T) {
+	ctx := context.Background()
+	chain := newTestChain(t)
+	to := common.HexToAddress("0x000000000000000000000000000000000000dEaD")
+
+	_, _, err := BuildTransaction(ctx, chain.client, chain.from, TxRequest{To: &to, Data: hexutil.Bytes{1}, Input: hexutil.Bytes{2}})
+	if !errors.Is(err, ErrInvalidTransaction) {
+		t.Fatalf("data and input differ: err = %v, want ErrInvalidTransaction", err)
+	}
+
+	for name, req := range map[string]TxRequest{
+		"data only":      {To: &to, Data: hexutil.Bytes{1, 2}},
+		"input only":     {To: &to, Input: hexutil.Bytes{1, 2}},
+		"the same bytes": {To: &to, Data: hexutil.Bytes{1, 2}, Input: hexutil.Bytes{1, 2}},
+	} {
+		tx, _ := chain.build(t, req)
+		if hexutil.Encode(tx.Data()) != "0x0102" {
+			t.Errorf("%s: data = %s, want 0x0102", name, hexutil.Encode(tx.Data()))
+		}
+	}
+}
+
+func TestSignTransactionReturnsRawRLP(t *testing.T) {
+	chain := newTestChain(t)
+	to := common.HexToAddress("0x000000000000000000000000000000000000dEaD")
+	signer := NewSigner(chain.key)
+
+	for name, req := range map[string]TxRequest{
+		"dynamic fee": {To: &to, Value: bigValue(1)},
+		"legacy":      {To: &to, Value: bigValue(1), GasPrice: bigValue(params.GWei * 10)},
+	} {
+		t.Run(name, func(t *testing.T) {
+			tx, chainID := chain.build(t, req)
+			raw, err := signer.SignTransaction(tx, chainID)
+			if err != nil {
+				t.Fatalf("SignTransaction: %v", err)
+			}
+			decoded := decodeRaw(t, raw)
+
+			sender, err := types.Sender(types.LatestSignerForChainID(chainID), decoded)
+			if err != nil {
+				t.Fatalf("failed to recover sender: %v", err)
+			}
+			if sender != chain.from {
+				t.Errorf("sender = %v, want %v", sender, chain.from)
+			}
+			if !decoded.Protected() {
+				t.Error("signature isn't replay protected")
+			}
+			if decoded.Type() != tx.Type() || decoded.Nonce() != tx.Nonce() || *decoded.To() != to || decoded.Value().Cmp(tx.Value()) != 0 {
+				t.Errorf("decoded transaction doesn't match the built one")
+			}
+
+			signed, err := signer.SignTx(tx, chainID)
+			if err != nil {
+				t.Fatalf("SignTx: %v", err)
+			}
+			if signed.Hash() != decoded.Hash() {
+				t.Errorf("hash = %v, want %v", decoded.Hash(), signed.Hash())
+			}
+		})
+	}
+}
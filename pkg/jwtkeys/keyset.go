@@ -0,0 +1,244 @@
+package jwtkeys
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// ErrUnknownKey is returned when a token names a key ID that isn't in the key set
+var ErrUnknownKey = errors.New("unknown signing key")
+
+// key is one signing or verification key. Retired keys only need the public half.
+type key struct {
+	id      string
+	method  jwt.SigningMethod
+	private crypto.Signer
+	public  crypto.PublicKey
+}
+
+// KeySet signs access tokens with its current key and verifies tokens signed by
+// any of its keys. Keeping the previous keys in the set while tokens signed with
+// them are still valid lets the signing key be rotated without logging anyone out.
+//
+// Supported keys are Ed25519 (EdDSA) and ECDSA P-256 (ES256). Key IDs are the
+// RFC 7638 JWK thumbprints of the public keys, so they never need configuring.
+type KeySet struct {
+	signing *key
+	keys    []*key
+	byID    map[string]*key
+}
+
+// NewKeySet builds a key set from PEM data. signingPEM holds the PKCS#8 private
+// key that signs new tokens. verificationPEM holds any number of PKIX public keys
+// or PKCS#8 private keys that are still accepted but no longer used for signing.
+//
+// Parameters:
+//   - signingPEM: PEM-encoded private key used to sign new tokens
+//   - verificationPEM: Concatenated PEM blocks of retired keys, may be empty
+//
+// Returns:
+//   - *KeySet: The key set, with the signing key listed first
+//   - error: Any error that occurred while parsing the keys
+func NewKeySet(signingPEM, verificationPEM string) (*KeySet, error) {
+	signingKeys, err := parsePEMKeys(signingPEM)
+	if err != nil {
+		return nil, fmt.Errorf("invalid signing key: %w", err)
+	}
+	if len(signingKeys) != 1 || signingKeys[0].private == nil {
+		return nil, errors.New("signing key must be exactly one PEM-encoded private key")
+	}
+	verificationKeys, err := parsePEMKeys(verificationPEM)
+	if err != nil {
+		return nil, fmt.Errorf("invalid verification key: %w", err)
+	}
+	return newKeySet(signingKeys[0], verificationKeys), nil
+}
+
+// GenerateKeySet creates a key set around a fresh Ed25519 key. Tokens signed
+// with it stop verifying when the process exits, so it only suits local
+// development and single-instance setups without configured keys.
+func GenerateKeySet() (*KeySet, error) {
+	_, private, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		return nil, err
+	}
+	signing, err := newKey(private)
+	if err != nil {
+		return nil, err
+	}
+	return newKeySet(signing, nil), nil
+}
+
+func newKeySet(signing *key, verification []*key) *KeySet {
+	ks := &KeySet{signing: signing, byID: make(map[string]*key)}
+	for _, k := range append([]*key{signing}, verification...) {
+		if _, dup := ks.byID[k.id]; dup {
+			continue
+		}
+		ks.byID[k.id] = k
+		ks.keys = append(ks.keys, k)
+	}
+	return ks
+}
+
+// SigningKeyID returns the key ID placed in the header of new tokens.
+func (ks *KeySet) SigningKeyID() string {
+	return ks.signing.id
+}
+
+// Sign signs claims with the current signing key and sets the "kid" header.
+func (ks *KeySet) Sign(claims jwt.Claims) (string, error) {
+	token := jwt.NewWithClaims(ks.signing.method, claims)
+	token.Header["kid"] = ks.signing.id
+	return token.SignedString(ks.signing.private)
+}
+
+// Parse verifies a token against the key named by its "kid" header. The token's
+// algorithm must match that key, so a token can't pick a weaker algorithm or
+// pass a public key off as an HMAC secret.
+func (ks *KeySet) Parse(tokenString string) (*jwt.Token, error) {
+	return jwt.Parse(tokenString, func(token *jwt.Token) (interface{}, error) {
+		kid, _ := token.Header["kid"].(string)
+		k, ok := ks.byID[kid]
+		if !ok {
+			return nil, ErrUnknownKey
+		}
+		if token.Method.Alg() != k.method.Alg() {
+			return nil, jwt.ErrSignatureInvalid
+		}
+		return k.public, nil
+	}, jwt.WithValidMethods([]string{jwt.SigningMethodEdDSA.Alg(), jwt.SigningMethodES256.Alg()}))
+}
+
+// JWK is a public key in JSON Web Key format (RFC 7517).
+type JWK struct {
+	Kty string `json:"kty"`
+	Crv string `json:"crv"`
+	X   string `json:"x"`
+	Y   string `json:"y,omitempty"`
+	Kid string `json:"kid"`
+	Alg string `json:"alg"`
+	Use string `json:"use"`
+}
+
+// JWKS is the document served at /.well-known/jwks.json.
+type JWKS struct {
+	Keys []JWK `json:"keys"`
+}
+
+// JWKS returns the public halves of every key in the set, signing key first.
+func (ks *KeySet) JWKS() JWKS {
+	set := JWKS{Keys: make([]JWK, 0, len(ks.keys))}
+	for _, k := range ks.keys {
+		jwk := publicJWK(k.public)
+		jwk.Kid = k.id
+		jwk.Alg = k.method.Alg()
+		jwk.Use = "sig"
+		set.Keys = append(set.Keys, jwk)
+	}
+	return set
+}
+
+// parsePEMKeys reads every PEM block in data as a private or public key.
+func parsePEMKeys(data string) ([]*key, error) {
+	var keys []*key
+	rest := []byte(strings.TrimSpace(data))
+	for len(rest) > 0 {
+		var block *pem.Block
+		block, rest = pem.Decode(rest)
+		if block == nil {
+			return nil, errors.New("malformed PEM data")
+		}
+
+		var parsed interface{}
+		var err error
+		switch block.Type {
+		case "PRIVATE KEY":
+			parsed, err = x509.ParsePKCS8PrivateKey(block.Bytes)
+		case "EC PRIVATE KEY":
+			parsed, err = x509.ParseECPrivateKey(block.Bytes)
+		case "PUBLIC KEY":
+			parsed, err = x509.ParsePKIXPublicKey(block.Bytes)
+		default:
+			return nil, fmt.Errorf("unsupported PEM block %q", block.Type)
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		k, err := newKey(parsed)
+		if err != nil {
+			return nil, err
+		}
+		keys = append(keys, k)
+	}
+	return keys, nil
+}
+
+// newKey wraps a parsed private or public key and derives its ID.
+func newKey(parsed interface{}) (*key, error) {
+	k := &key{}
+	switch v := parsed.(type) {
+	case ed25519.PrivateKey:
+		k.private, k.public = v, v.Public()
+	case *ecdsa.PrivateKey:
+		k.private, k.public = v, v.Public()
+	case ed25519.PublicKey, *ecdsa.PublicKey:
+		k.public = v
+	default:
+		return nil, fmt.Errorf("unsupported key type %T", parsed)
+	}
+
+	switch pub := k.public.(type) {
+	case ed25519.PublicKey:
+		k.method = jwt.SigningMethodEdDSA
+	case *ecdsa.PublicKey:
+		if pub.Curve != elliptic.P256() {
+			return nil, errors.New("only P-256 ECDSA keys are supported")
+		}
+		k.method = jwt.SigningMethodES256
+	}
+	k.id = thumbprint(publicJWK(k.public))
+	return k, nil
+}
+
+// publicJWK encodes the key material of a public key. Coordinates of P-256 keys
+// are padded to 32 bytes as RFC 7518 requires.
+func publicJWK(public crypto.PublicKey) JWK {
+	enc := base64.RawURLEncoding
+	switch pub := public.(type) {
+	case ed25519.PublicKey:
+		return JWK{Kty: "OKP", Crv: "Ed25519", X: enc.EncodeToString(pub)}
+	case *ecdsa.PublicKey:
+		x, y := make([]byte, 32), make([]byte, 32)
+		pub.X.FillBytes(x)
+		pub.Y.FillBytes(y)
+		return JWK{Kty: "EC", Crv: "P-256", X: enc.EncodeToString(x), Y: enc.EncodeToString(y)}
+	}
+	return JWK{}
+}
+
+// thumbprint computes the RFC 7638 thumbprint of a key: the SHA-256 of its
+// required members serialized in lexicographic order.
+func thumbprint(jwk JWK) string {
+	var canonical string
+	if jwk.Kty == "EC" {
+		canonical = fmt.Sprintf(`{"crv":"%s","kty":"%s","x":"%s","y":"%s"}`, jwk.Crv, jwk.Kty, jwk.X, jwk.Y)
+	} else {
+		canonical = fmt.Sprintf(`{"crv":"%s","kty":"%s","x":"%s"}`, jwk.Crv, jwk.Kty, jwk.X)
+	}
+	sum := sha256.Sum256([]byte(canonical))
+	return base64.RawURLEncoding.EncodeToString(sum[:])
+}
@@ -0,0 +1,245 @@
+package jwtkeys
+
+import (
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"math/big"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+func privatePEM(t *testing.T, private interface{}) string {
+	t.Helper()
+	der, err := x509.MarshalPKCS8PrivateKey(private)
+	if err != nil {
+		t.Fatalf("failed to encode private key: %v", err)
+	}
+	return string(pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: der}))
+}
+
+func publicPEM(t *testing.T, public interface{}) string {
+	t.Helper()
+	der, err := x509.MarshalPKIXPublicKey(public)
+	if err != nil {
+		t.Fatalf("failed to encode public key: %v", err)
+	}
+	return string(pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: der}))
+}
+
+func newEd25519(t *testing.T) ed25519.PrivateKey {
+	t.Helper()
+	_, private, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatalf("failed to generate Ed25519 key: %v", err)
+	}
+	return private
+}
+
+func newP256(t *testing.T) *ecdsa.PrivateKey {
+	t.Helper()
+	private, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatalf("failed to generate P-256 key: %v", err)
+	}
+	return private
+}
+
+func testClaims() jwt.MapClaims {
+	return jwt.MapClaims{"sub": "user-1", "exp": time.Now().Add(time.Hour).Unix()}
+}
+
+// keyFromJWK rebuilds a public key from a JWK the way a third-party verifier would.
+func keyFromJWK(t *testing.T, jwk JWK) interface{} {
+	t.Helper()
+	decode := func(s string) []byte {
+		b, err := base64.RawURLEncoding.DecodeString(s)
+		if err != nil {
+			t.Fatalf("JWK member isn't base64url: %v", err)
+		}
+		return b
+	}
+	switch {
+	case jwk.Kty == "OKP" && jwk.Crv == "Ed25519":
+		return ed25519.PublicKey(decode(jwk.X))
+	case jwk.Kty == "EC" && jwk.Crv == "P-256":
+		x, y := decode(jwk.X), decode(jwk.Y)
+		if len(x) != 32 || len(y) != 32 {
+			t.Fatalf("P-256 coordinates are %d and %d bytes, want 32", len(x), len(y))
+		}
+		return &ecdsa.PublicKey{Curve: elliptic.P256(), X: new(big.Int).SetBytes(x), Y: new(big.Int).SetBytes(y)}
+	}
+	t.Fatalf("unexpected JWK %+v", jwk)
+	return nil
+}
+
+func TestSignJWKSParseRoundTrip(t *testing.T) {
+	signers := map[string]interface{}{
+		"EdDSA": newEd25519(t),
+		"ES256": newP256(t),
+	}
+	for alg, private := range signers {
+		t.Run(alg, func(t *testing.T) {
+			ks, err := NewKeySet(privatePEM(t, private), "")
+			if err != nil {
+				t.Fatalf("NewKeySet: %v", err)
+			}
+			signed, err := ks.Sign(testClaims())
+			if err != nil {
+				t.Fatalf("Sign: %v", err)
+			}
+
+			// Verify through the published document only, as another service would
+			data, err := json.Marshal(ks.JWKS())
+			if err != nil {
+				t.Fatalf("failed to encode JWKS: %v", err)
+			}
+			var published JWKS
+			if err := json.Unmarshal(data, &published); err != nil {
+				t.Fatalf("failed to decode JWKS: %v", err)
+			}
+			if len(published.Keys) != 1 {
+				t.Fatalf("JWKS has %d keys, want 1", len(published.Keys))
+			}
+			jwk := published.Keys[0]
+			if jwk.Kid != ks.SigningKeyID() || jwk.Alg != alg || jwk.Use != "sig" {
+				t.Errorf("JWK = %+v", jwk)
+			}
+			token, err := jwt.Parse(signed, func(token *jwt.Token) (interface{}, error) {
+				if token.Header["kid"] != jwk.Kid {
+					return nil, ErrUnknownKey
+				}
+				return keyFromJWK(t, jwk), nil
+			}, jwt.WithValidMethods([]string{jwk.Alg}))
+			if err != nil {
+				t.Fatalf("token doesn't verify against the JWKS: %v", err)
+			}
+			if sub, _ := token.Claims.GetSubject(); sub != "user-1" {
+				t.Errorf("sub = %q, want user-1", sub)
+			}
+
+			if _, err := ks.Parse(signed); err != nil {
+				t.Errorf("Parse: %v", err)
+			}
+		})
+	}
+}
+
+func TestParseAcceptsRetiredKeys(t *testing.T) {
+	oldKey, newKey := newEd25519(t), newP256(t)
+	old, err := NewKeySet(privatePEM(t, oldKey), "")
+	if err != nil {
+		t.Fatalf("NewKeySet: %v", err)
+	}
+	signedBefore, err := old.Sign(testClaims())
+	if err != nil {
+		t.Fatalf("Sign: %v", err)
+	}
+
+	rotated, err := NewKeySet(privatePEM(t, newKey), publicPEM(t, oldKey.Public()))
+	if err != nil {
+		t.Fatalf("NewKeySet: %v", err)
+	}
+	if _, err := rotated.Parse(signedBefore); err != nil {
+		t.Errorf("token signed with the retired key rejected: %v", err)
+	}
+	if jwks := rotated.JWKS(); len(jwks.Keys) != 2 || jwks.Keys[0].Kid != rotated.SigningKeyID() {
+		t.Errorf("JWKS = %+v, want the signing key first and the retired key after it", jwks)
+	}
+
+	// Once the retired key is dropped, its tokens no longer verify
+	dropped, err := NewKeySet(privatePEM(t, newKey), "")
+	if err != nil {
+		t.Fatalf("NewKeySet: %v", err)
+	}
+	if _, err := dropped.Parse(signedBefore); !errors.Is(err, ErrUnknownKey) {
+		t.Errorf("err = %v, want ErrUnknownKey", err)
+	}
+}
+
+func TestParseRejectsAlgorithmNotMatchingKey(t *testing.T) {
+	edKey, ecKey := newEd25519(t), newP256(t)
+	ks, err := NewKeySet(privatePEM(t, edKey), publicPEM(t, ecKey.Public()))
+	if err != nil {
+		t.Fatalf("NewKeySet: %v", err)
+	}
+	ecKID := ks.JWKS().Keys[1].Kid
+
+	sign := func(method jwt.SigningMethod, kid string, key interface{}) string {
+		t.Helper()
+		token := jwt.NewWithClaims(method, testClaims())
+		token.Header["kid"] = kid
+		signed, err := token.SignedString(key)
+		if err != nil {
+			t.Fatalf("failed to sign: %v", err)
+		}
+		return signed
+	}
+	edPublic, _ := x509.MarshalPKIXPublicKey(edKey.Public())
+
+	tests := []struct {
+		name  string
+		token string
+		want  error
+	}{
+		// Signed by a key in the set, but claiming another key's ID: refused by the
+		// key lookup, before any signature is checked
+		{"EdDSA token naming the ES256 key", sign(jwt.SigningMethodEdDSA, ecKID, edKey), jwt.ErrTokenUnverifiable},
+		{"ES256 token naming the EdDSA key", sign(jwt.SigningMethodES256, ks.SigningKeyID(), ecKey), jwt.ErrTokenUnverifiable},
+		// The public key passed off as an HMAC secret
+		{"HS256 with the public key as secret", sign(jwt.SigningMethodHS256, ks.SigningKeyID(), edPublic), jwt.ErrTokenSignatureInvalid},
+		{"unsigned", sign(jwt.SigningMethodNone, ks.SigningKeyID(), jwt.UnsafeAllowNoneSignatureType), jwt.ErrTokenSignatureInvalid},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := ks.Parse(tt.token); !errors.Is(err, tt.want) {
+				t.Fatalf("err = %v, want %v", err, tt.want)
+			}
+		})
+	}
+
+	if _, err := ks.Parse(sign(jwt.SigningMethodEdDSA, "unknown", edKey)); !errors.Is(err, ErrUnknownKey) {
+		t.Errorf("unknown kid: err = %v, want ErrUnknownKey", err)
+	}
+}
+
+func TestKeyIDIsJWKThumbprint(t *testing.T) {
+	// RFC 8037 Appendix A.3
+	jwk := JWK{Kty: "OKP", Crv: "Ed25519", X: "11qYAYKxCrfVS_7TyWQHOg7hcvPapiMlrwIaaPcHURo"}
+	if got := thumbprint(jwk); got != "kPrK_qmxVWaYVA9wwBF6Iuo3vVzz7TxHCTwXBygrS4k" {
+		t.Errorf("thumbprint = %s", got)
+	}
+}
+
+func TestNewKeySetRejectsInvalidKeys(t *testing.T) {
+	ecKey := newP256(t)
+	p384, err := ecdsa.GenerateKey(elliptic.P384(), rand.Reader)
+	if err != nil {
+		t.Fatalf("failed to generate P-384 key: %v", err)
+	}
+
+	tests := []struct {
+		name         string
+		signing      string
+		verification string
+	}{
+		{"no signing key", "", ""},
+		{"public signing key", publicPEM(t, ecKey.Public()), ""},
+		{"two signing keys", privatePEM(t, ecKey) + privatePEM(t, newEd25519(t)), ""},
+		{"unsupported curve", privatePEM(t, p384), ""},
+		{"malformed verification key", privatePEM(t, ecKey), "not PEM"},
+	}
+	for _, tt := range tests {
+		if _, err := NewKeySet(tt.signing, tt.verification); err == nil {
+			t.Errorf("%s: NewKeySet succeeded", tt.name)
+		}
+	}
+}
@@ -0,0 +1,22 @@
+//go:build !unix
+
+package keyvault
+
+// lockedBuffer holds a secret on the heap; this platform can't lock memory.
+type lockedBuffer struct {
+	mem []byte
+}
+
+func newLockedBuffer(size int) *lockedBuffer {
+	return &lockedBuffer{mem: make([]byte, size)}
+}
+
+func (b *lockedBuffer) bytes() []byte {
+	return b.mem
+}
+
+// destroy wipes the buffer.
+func (b *lockedBuffer) destroy() {
+	clear(b.mem)
+	b.mem = nil
+}
@@ -0,0 +1,55 @@
+//go:build unix
+
+package keyvault
+
+import (
+	"sync"
+
+	"github.com/rs/zerolog/log"
+	"golang.org/x/sys/unix"
+)
+
+var warnUnlockedOnce sync.Once
+
+// lockedBuffer is memory outside the Go heap, so the garbage collector never
+// copies it, locked so the kernel doesn't swap it to disk.
+type lockedBuffer struct {
+	mem    []byte
+	size   int
+	mapped bool
+	locked bool
+}
+
+func newLockedBuffer(size int) *lockedBuffer {
+	mem, err := unix.Mmap(-1, 0, max(size, 1), unix.PROT_READ|unix.PROT_WRITE, unix.MAP_ANON|unix.MAP_PRIVATE)
+	if err != nil {
+		// Fall back to the heap rather than refusing to store the secret
+		return &lockedBuffer{mem: make([]byte, size), size: size}
+	}
+	b := &lockedBuffer{mem: mem, size: size, mapped: true}
+	if err := unix.Mlock(mem); err != nil {
+		// Commonly RLIMIT_MEMLOCK is too low; the secret still gets wiped
+		warnUnlockedOnce.Do(func() {
+			log.Warn().Err(err).Msg("Can't lock vault memory, secrets may be swapped to disk")
+		})
+	} else {
+		b.locked = true
+	}
+	return b
+}
+
+func (b *lockedBuffer) bytes() []byte {
+	return b.mem[:b.size]
+}
+
+// destroy wipes the buffer and returns it to the system.
+func (b *lockedBuffer) destroy() {
+	clear(b.mem)
+	if b.locked {
+		_ = unix.Munlock(b.mem)
+	}
+	if b.mapped {
+		_ = unix.Munmap(b.mem)
+	}
+	b.mem = nil
+}
@@ -0,0 +1,115 @@
+// Package keyvault holds secrets in process memory for a limited time. Secrets
+// are kept in memory locked against swapping where the platform allows it, and
+// are overwritten with zeros when they expire or are removed.
+package keyvault
+
+import (
+	"errors"
+	"sync"
+	"time"
+)
+
+// ErrNotFound is returned for secrets that were never stored, have expired or were removed
+var ErrNotFound = errors.New("secret not found in vault")
+
+// entry is one stored secret. Its lock keeps the buffer from being wiped while
+// a Use call reads it.
+type entry struct {
+	mu    sync.RWMutex
+	buf   *lockedBuffer // Nil once wiped
+	timer *time.Timer
+}
+
+// destroy wipes the secret, waiting for Use calls that are reading it. It
+// does nothing for a nil entry.
+func (e *entry) destroy() {
+	if e == nil {
+		return
+	}
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	if e.buf != nil {
+		e.buf.destroy()
+		e.buf = nil
+	}
+}
+
+// Vault maps opaque IDs to secrets. It is safe for concurrent use. The
+// vault-wide lock only guards the ID lookup, so a slow Use of one secret
+// doesn't hold up the others.
+type Vault struct {
+	mu      sync.Mutex
+	entries map[string]*entry
+}
+
+// New creates an empty vault.
+func New() *Vault {
+	return &Vault{entries: make(map[string]*entry)}
+}
+
+// Put stores a copy of secret under id for ttl, replacing any earlier secret
+// with that ID. The caller should wipe its own copy.
+func (v *Vault) Put(id string, secret []byte, ttl time.Duration) {
+	buf := newLockedBuffer(len(secret))
+	copy(buf.bytes(), secret)
+	e := &entry{buf: buf}
+
+	v.mu.Lock()
+	replaced := v.unlinkLocked(id)
+	e.timer = time.AfterFunc(ttl, func() {
+		v.mu.Lock()
+		var expired *entry
+		// A later Put may have replaced this entry
+		if v.entries[id] == e {
+			expired = v.unlinkLocked(id)
+		}
+		v.mu.Unlock()
+		expired.destroy()
+	})
+	v.entries[id] = e
+	v.mu.Unlock()
+
+	replaced.destroy()
+}
+
+// Use calls fn with the secret stored under id. The secret is only valid
+// during the call; fn must neither keep nor modify it. Calls for the same ID
+// may run at once, and removing the secret waits until they return.
+func (v *Vault) Use(id string, fn func(secret []byte) error) error {
+	v.mu.Lock()
+	e, ok := v.entries[id]
+	v.mu.Unlock()
+	if !ok {
+		return ErrNotFound
+	}
+
+	e.mu.RLock()
+	defer e.mu.RUnlock()
+	// Removed between the lookup and taking the entry's lock
+	if e.buf == nil {
+		return ErrNotFound
+	}
+	return fn(e.buf.bytes())
+}
+
+// Delete wipes and removes the secret stored under id, if any. It waits for
+// Use calls that are reading the secret to return.
+func (v *Vault) Delete(id string) {
+	v.mu.Lock()
+	e := v.unlinkLocked(id)
+	v.mu.Unlock()
+	e.destroy()
+}
+
+// unlinkLocked removes an entry from the vault and stops its expiry, returning
+// it for the caller to destroy once the lock is released, or nil if there was
+// none. The caller must hold the lock.
+func (v *Vault) unlinkLocked(id string) *entry {
+	e, ok := v.entries[id]
+	if !ok {
+		return nil
+	}
+	e.timer.Stop()
+	delete(v.entries, id)
+	return e
+}
@@ -0,0 +1,134 @@
+package oidc
+
+import (
+	"context"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rsa"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"math/big"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+)
+
+// supportedAlgorithms are the ID token signing algorithms accepted
+var supportedAlgorithms = []string{"RS256", "RS384", "RS512", "ES256", "ES384"}
+
+// jwksRefreshInterval limits how often an unknown key ID triggers a JWKS refetch
+const jwksRefreshInterval = time.Minute
+
+type jwk struct {
+	Kty string `json:"kty"`
+	Kid string `json:"kid"`
+	Use string `json:"use"`
+	Alg string `json:"alg"`
+	N   string `json:"n"`
+	E   string `json:"e"`
+	Crv string `json:"crv"`
+	X   string `json:"x"`
+	Y   string `json:"y"`
+}
+
+// keySet caches a provider's signing keys. Providers rotate keys, so a token
+// signed with an unknown key ID makes the set refetch the JWKS.
+type keySet struct {
+	uri    string
+	client *http.Client
+
+	mu        sync.Mutex
+	keys      map[string]jwk
+	fetchedAt time.Time
+}
+
+func newKeySet(uri string, client *http.Client) *keySet {
+	return &keySet{uri: uri, client: client}
+}
+
+// get returns the public key with the given ID for verifying a token signed with alg.
+func (ks *keySet) get(ctx context.Context, kid, alg string) (crypto.PublicKey, error) {
+	ks.mu.Lock()
+	defer ks.mu.Unlock()
+
+	key, ok := ks.keys[kid]
+	if !ok && time.Since(ks.fetchedAt) >= jwksRefreshInterval {
+		if err := ks.refresh(ctx); err != nil {
+			return nil, err
+		}
+		key, ok = ks.keys[kid]
+	}
+	if !ok {
+		return nil, fmt.Errorf("unknown signing key %q", kid)
+	}
+	if key.Alg != "" && key.Alg != alg {
+		return nil, errors.New("token algorithm doesn't match the key")
+	}
+	return parseJWK(key, alg)
+}
+
+// refresh refetches the JWKS. The caller must hold the lock.
+func (ks *keySet) refresh(ctx context.Context) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, ks.uri, nil)
+	if err != nil {
+		return err
+	}
+	var set struct {
+		Keys []jwk `json:"keys"`
+	}
+	if err := doJSON(ks.client, req, &set); err != nil {
+		return fmt.Errorf("fetching JWKS failed: %w", err)
+	}
+
+	ks.keys = make(map[string]jwk, len(set.Keys))
+	for _, k := range set.Keys {
+		if k.Use == "" || k.Use == "sig" {
+			ks.keys[k.Kid] = k
+		}
+	}
+	ks.fetchedAt = time.Now()
+	return nil
+}
+
+// parseJWK decodes an RSA or EC public key and checks it fits the algorithm.
+func parseJWK(k jwk, alg string) (crypto.PublicKey, error) {
+	enc := base64.RawURLEncoding
+	switch k.Kty {
+	case "RSA":
+		if !strings.HasPrefix(alg, "RS") {
+			break
+		}
+		n, err := enc.DecodeString(k.N)
+		if err != nil {
+			return nil, err
+		}
+		e, err := enc.DecodeString(k.E)
+		if err != nil {
+			return nil, err
+		}
+		return &rsa.PublicKey{N: new(big.Int).SetBytes(n), E: int(new(big.Int).SetBytes(e).Int64())}, nil
+	case "EC":
+		var curve elliptic.Curve
+		switch {
+		case k.Crv == "P-256" && alg == "ES256":
+			curve = elliptic.P256()
+		case k.Crv == "P-384" && alg == "ES384":
+			curve = elliptic.P384()
+		default:
+			return nil, errors.New("token algorithm doesn't match the key")
+		}
+		x, err := enc.DecodeString(k.X)
+		if err != nil {
+			return nil, err
+		}
+		y, err := enc.DecodeString(k.Y)
+		if err != nil {
+			return nil, err
+		}
+		return &ecdsa.PublicKey{Curve: curve, X: new(big.Int).SetBytes(x), Y: new(big.Int).SetBytes(y)}, nil
+	}
+	return nil, errors.New("token algorithm doesn't match the key")
+}
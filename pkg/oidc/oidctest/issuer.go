@@ -0,0 +1,290 @@
+// Package oidctest runs a fake OpenID Connect provider for tests. It serves
+// discovery, a JWKS and a token endpoint that checks the PKCE verifier, and
+// signs ID tokens with RSA keys that can be rotated.
+package oidctest
+
+import (
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+const (
+	// ClientID is the client the issuer expects and issues ID tokens to
+	ClientID = "test-client"
+	// ClientSecret authenticates ClientID at the token endpoint
+	ClientSecret = "test-secret"
+	// RedirectURL is the redirect URL the client is registered with
+	RedirectURL = "https://app.example.com/oidc/callback"
+)
+
+// Issuer is a running fake provider. Its URL is the issuer identifier.
+type Issuer struct {
+	URL string
+
+	server *httptest.Server
+
+	mu          sync.Mutex
+	keys        map[string]*rsa.PrivateKey // Published in the JWKS
+	currentKID  string                     // Signs new ID tokens
+	codes       map[string]authRequest     // Unredeemed authorization codes
+	claims      jwt.MapClaims              // Added to every issued ID token
+	jwksFetches int
+	tokenForms  []url.Values
+}
+
+// authRequest is what the issuer remembers about a sign-in until its code is redeemed.
+type authRequest struct {
+	nonce         string
+	codeChallenge string
+}
+
+// NewIssuer starts a fake provider with one signing key. It is shut down when
+// the test ends.
+func NewIssuer(t testing.TB) *Issuer {
+	t.Helper()
+	i := &Issuer{
+		keys:  make(map[string]*rsa.PrivateKey),
+		codes: make(map[string]authRequest),
+		claims: jwt.MapClaims{
+			"sub":            "provider-user-1",
+			"email":          "user@example.com",
+			"email_verified": true,
+		},
+	}
+	i.RotateKey(t)
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /.well-known/openid-configuration", i.serveDiscovery)
+	mux.HandleFunc("GET /jwks", i.serveJWKS)
+	mux.HandleFunc("POST /token", i.serveToken)
+	i.server = httptest.NewServer(mux)
+	i.URL = i.server.URL
+	t.Cleanup(i.server.Close)
+	return i
+}
+
+// SetClaim sets a claim on the ID tokens issued from now on; a nil value removes it.
+func (i *Issuer) SetClaim(name string, value interface{}) {
+	i.mu.Lock()
+	defer i.mu.Unlock()
+	if value == nil {
+		delete(i.claims, name)
+		return
+	}
+	i.claims[name] = value
+}
+
+// RotateKey publishes a new signing key and signs ID tokens with it from now
+// on. Earlier keys stay published. It returns the new key ID.
+func (i *Issuer) RotateKey(t testing.TB) string {
+	t.Helper()
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatalf("failed to generate signing key: %v", err)
+	}
+	i.mu.Lock()
+	defer i.mu.Unlock()
+	kid := fmt.Sprintf("key-%d", len(i.keys)+1)
+	i.keys[kid] = key
+	i.currentKID = kid
+	return kid
+}
+
+// JWKSFetches returns how many times the JWKS was requested.
+func (i *Issuer) JWKSFetches() int {
+	i.mu.Lock()
+	defer i.mu.Unlock()
+	return i.jwksFetches
+}
+
+// TokenRequests returns the forms posted to the token endpoint, oldest first.
+func (i *Issuer) TokenRequests() []url.Values {
+	i.mu.Lock()
+	defer i.mu.Unlock()
+	return append([]url.Values(nil), i.tokenForms...)
+}
+
+// Authorize plays the user signing in at the provider: it checks the
+// authorization URL a client built and returns the code and state the provider
+// would redirect back with.
+func (i *Issuer) Authorize(t testing.TB, authorizationURL string) (code, state string) {
+	t.Helper()
+	u, err := url.Parse(authorizationURL)
+	if err != nil {
+		t.Fatalf("invalid authorization URL: %v", err)
+	}
+	q := u.Query()
+	want := map[string]string{
+		"response_type":         "code",
+		"client_id":             ClientID,
+		"redirect_uri":          RedirectURL,
+		"code_challenge_method": "S256",
+	}
+	for name, value := range want {
+		if got := q.Get(name); got != value {
+			t.Fatalf("authorization URL has %s=%q, want %q", name, got, value)
+		}
+	}
+	for _, name := range []string{"state", "nonce", "code_challenge"} {
+		if q.Get(name) == "" {
+			t.Fatalf("authorization URL has no %s", name)
+		}
+	}
+
+	code = randomString()
+	i.mu.Lock()
+	i.codes[code] = authRequest{nonce: q.Get("nonce"), codeChallenge: q.Get("code_challenge")}
+	i.mu.Unlock()
+	return code, q.Get("state")
+}
+
+// IDToken signs an ID token for the given nonce with the current key. The
+// overrides replace the issuer's standard and configured claims; a nil value
+// removes a claim.
+func (i *Issuer) IDToken(t testing.TB, nonce string, overrides jwt.MapClaims) string {
+	t.Helper()
+	i.mu.Lock()
+	defer i.mu.Unlock()
+	token, err := i.signLocked(nonce, overrides)
+	if err != nil {
+		t.Fatalf("failed to sign ID token: %v", err)
+	}
+	return token
+}
+
+// SignWithKID signs claims as they are, with the current key but the given key
+// ID in the header.
+func (i *Issuer) SignWithKID(t testing.TB, kid string, claims jwt.MapClaims) string {
+	t.Helper()
+	i.mu.Lock()
+	defer i.mu.Unlock()
+	token := jwt.NewWithClaims(jwt.SigningMethodRS256, claims)
+	token.Header["kid"] = kid
+	signed, err := token.SignedString(i.keys[i.currentKID])
+	if err != nil {
+		t.Fatalf("failed to sign ID token: %v", err)
+	}
+	return signed
+}
+
+// signLocked signs an ID token. The caller must hold the lock.
+func (i *Issuer) signLocked(nonce string, overrides jwt.MapClaims) (string, error) {
+	now := time.Now()
+	claims := jwt.MapClaims{
+		"iss":   i.URL,
+		"aud":   ClientID,
+		"iat":   now.Unix(),
+		"exp":   now.Add(time.Hour).Unix(),
+		"nonce": nonce,
+	}
+	for name, value := range i.claims {
+		claims[name] = value
+	}
+	for name, value := range overrides {
+		if value == nil {
+			delete(claims, name)
+			continue
+		}
+		claims[name] = value
+	}
+	token := jwt.NewWithClaims(jwt.SigningMethodRS256, claims)
+	token.Header["kid"] = i.currentKID
+	return token.SignedString(i.keys[i.currentKID])
+}
+
+func (i *Issuer) serveDiscovery(w http.ResponseWriter, _ *http.Request) {
+	writeJSON(w, http.StatusOK, map[string]interface{}{
+		"issuer":                                i.URL,
+		"authorization_endpoint":                i.URL + "/authorize",
+		"token_endpoint":                        i.URL + "/token",
+		"jwks_uri":                              i.URL + "/jwks",
+		"token_endpoint_auth_methods_supported": []string{"client_secret_basic"},
+	})
+}
+
+func (i *Issuer) serveJWKS(w http.ResponseWriter, _ *http.Request) {
+	i.mu.Lock()
+	defer i.mu.Unlock()
+	i.jwksFetches++
+	keys := make([]map[string]string, 0, len(i.keys))
+	for kid, key := range i.keys {
+		keys = append(keys, map[string]string{
+			"kty": "RSA",
+			"kid": kid,
+			"use": "sig",
+			"alg": "RS256",
+			"n":   base64.RawURLEncoding.EncodeToString(key.N.Bytes()),
+			"e":   base64.RawURLEncoding.EncodeToString(big.NewInt(int64(key.E)).Bytes()),
+		})
+	}
+	writeJSON(w, http.StatusOK, map[string]interface{}{"keys": keys})
+}
+
+func (i *Issuer) serveToken(w http.ResponseWriter, r *http.Request) {
+	if err := r.ParseForm(); err != nil {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "invalid_request"})
+		return
+	}
+	i.mu.Lock()
+	defer i.mu.Unlock()
+	i.tokenForms = append(i.tokenForms, r.PostForm)
+
+	clientID, clientSecret, _ := r.BasicAuth()
+	if clientID != ClientID || clientSecret != ClientSecret {
+		writeJSON(w, http.StatusUnauthorized, map[string]string{"error": "invalid_client"})
+		return
+	}
+	if r.PostForm.Get("grant_type") != "authorization_code" || r.PostForm.Get("redirect_uri") != RedirectURL {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "invalid_request"})
+		return
+	}
+
+	code := r.PostForm.Get("code")
+	request, ok := i.codes[code]
+	delete(i.codes, code) // Codes are single use, even when the exchange fails
+	if !ok {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "invalid_grant", "error_description": "unknown code"})
+		return
+	}
+	sum := sha256.Sum256([]byte(r.PostForm.Get("code_verifier")))
+	if base64.RawURLEncoding.EncodeToString(sum[:]) != request.codeChallenge {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "invalid_grant", "error_description": "PKCE verification failed"})
+		return
+	}
+
+	idToken, err := i.signLocked(request.nonce, nil)
+	if err != nil {
+		writeJSON(w, http.StatusInternalServerError, map[string]string{"error": "server_error"})
+		return
+	}
+	writeJSON(w, http.StatusOK, map[string]string{
+		"access_token": randomString(),
+		"token_type":   "Bearer",
+		"id_token":     idToken,
+	})
+}
+
+func writeJSON(w http.ResponseWriter, status int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(v)
+}
+
+func randomString() string {
+	b := make([]byte, 16)
+	_, _ = rand.Read(b)
+	return base64.RawURLEncoding.EncodeToString(b)
+}
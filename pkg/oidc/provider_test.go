@@ -0,0 +1,227 @@
+package oidc
+
+import (
+	"context"
+	"errors"
+	"net/url"
+	"testing"
+	"time"
+
+	"vybes/pkg/oidc/oidctest"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+func newTestProvider(t *testing.T) (*Provider, *oidctest.Issuer) {
+	t.Helper()
+	issuer := oidctest.NewIssuer(t)
+	provider := NewProvider(Config{
+		Name:         "test",
+		IssuerURL:    issuer.URL,
+		ClientID:     oidctest.ClientID,
+		ClientSecret: oidctest.ClientSecret,
+		RedirectURL:  oidctest.RedirectURL,
+	}, nil)
+	return provider, issuer
+}
+
+func newRandomValue(t *testing.T) string {
+	t.Helper()
+	value, err := NewRandomValue()
+	if err != nil {
+		t.Fatalf("NewRandomValue: %v", err)
+	}
+	return value
+}
+
+func TestExchangeSendsPKCEVerifier(t *testing.T) {
+	ctx := context.Background()
+	provider, issuer := newTestProvider(t)
+	state, nonce, verifier := newRandomValue(t), newRandomValue(t), newRandomValue(t)
+
+	authURL, err := provider.AuthCodeURL(ctx, state, nonce, verifier)
+	if err != nil {
+		t.Fatalf("AuthCodeURL: %v", err)
+	}
+	u, _ := url.Parse(authURL)
+	if got := u.Query().Get("code_challenge"); got != codeChallenge(verifier) {
+		t.Errorf("code_challenge = %q, want the S256 challenge of the verifier", got)
+	}
+	if u.Query().Get("code_verifier") != "" {
+		t.Error("authorization URL leaks the code verifier")
+	}
+
+	code, gotState := issuer.Authorize(t, authURL)
+	if gotState != state {
+		t.Errorf("state = %q, want %q", gotState, state)
+	}
+	claims, err := provider.Exchange(ctx, code, verifier, nonce)
+	if err != nil {
+		t.Fatalf("Exchange: %v", err)
+	}
+	if claims.Subject != "provider-user-1" || claims.Email != "user@example.com" || !claims.EmailVerified {
+		t.Errorf("claims = %+v", claims)
+	}
+
+	requests := issuer.TokenRequests()
+	if len(requests) != 1 {
+		t.Fatalf("got %d token requests, want 1", len(requests))
+	}
+	if got := requests[0].Get("code_verifier"); got != verifier {
+		t.Errorf("token request code_verifier = %q, want %q", got, verifier)
+	}
+	if requests[0].Get("client_secret") != "" {
+		t.Error("client secret sent in the form although the issuer asks for client_secret_basic")
+	}
+}
+
+func TestExchangeRejectsWrongVerifier(t *testing.T) {
+	ctx := context.Background()
+	provider, issuer := newTestProvider(t)
+	nonce := newRandomValue(t)
+
+	authURL, err := provider.AuthCodeURL(ctx, newRandomValue(t), nonce, newRandomValue(t))
+	if err != nil {
+		t.Fatalf("AuthCodeURL: %v", err)
+	}
+	code, _ := issuer.Authorize(t, authURL)
+	if _, err := provider.Exchange(ctx, code, newRandomValue(t), nonce); err == nil {
+		t.Fatal("Exchange succeeded with a different code verifier")
+	}
+}
+
+func TestVerifyIDTokenRejectsInvalidTokens(t *testing.T) {
+	ctx := context.Background()
+	provider, issuer := newTestProvider(t)
+	nonce := newRandomValue(t)
+	if _, err := provider.VerifyIDToken(ctx, issuer.IDToken(t, nonce, nil), nonce); err != nil {
+		t.Fatalf("valid ID token rejected: %v", err)
+	}
+
+	tests := []struct {
+		name      string
+		overrides jwt.MapClaims
+		nonce     string
+	}{
+		{"nonce mismatch", nil, "other-nonce"},
+		{"missing nonce", jwt.MapClaims{"nonce": nil}, nonce},
+		{"wrong audience", jwt.MapClaims{"aud": "other-client"}, nonce},
+		{"wrong issuer", jwt.MapClaims{"iss": "https://evil.example.com"}, nonce},
+		{"expired", jwt.MapClaims{"exp": time.Now().Add(-2 * time.Minute).Unix()}, nonce},
+		{"missing expiry", jwt.MapClaims{"exp": nil}, nonce},
+		{"missing subject", jwt.MapClaims{"sub": nil}, nonce},
+		{"several audiences without azp", jwt.MapClaims{"aud": []string{oidctest.ClientID, "other-client"}}, nonce},
+		{"several audiences for another client", jwt.MapClaims{"aud": []string{oidctest.ClientID, "other-client"}, "azp": "other-client"}, nonce},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := provider.VerifyIDToken(ctx, issuer.IDToken(t, nonce, tt.overrides), tt.nonce)
+			if !errors.Is(err, ErrInvalidIDToken) {
+				t.Fatalf("err = %v, want ErrInvalidIDToken", err)
+			}
+		})
+	}
+}
+
+func TestVerifyIDTokenAcceptsClockSkew(t *testing.T) {
+	ctx := context.Background()
+	provider, issuer := newTestProvider(t)
+	nonce := newRandomValue(t)
+	token := issuer.IDToken(t, nonce, jwt.MapClaims{"exp": time.Now().Add(-30 * time.Second).Unix()})
+	if _, err := provider.VerifyIDToken(ctx, token, nonce); err != nil {
+		t.Fatalf("token expired within the leeway rejected: %v", err)
+	}
+}
+
+func TestVerifyIDTokenEmailVerified(t *testing.T) {
+	ctx := context.Background()
+	provider, issuer := newTestProvider(t)
+	nonce := newRandomValue(t)
+
+	tests := []struct {
+		name  string
+		value interface{}
+		want  bool
+	}{
+		{"bool true", true, true},
+		{"bool false", false, false},
+		{"string true", "true", true},
+		{"string false", "false", false},
+		{"missing", nil, false},
+		{"unexpected string", "yes", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// A nil value leaves the claim out
+			overrides := jwt.MapClaims{"email_verified": tt.value}
+			claims, err := provider.VerifyIDToken(ctx, issuer.IDToken(t, nonce, overrides), nonce)
+			if err != nil {
+				t.Fatalf("VerifyIDToken: %v", err)
+			}
+			if claims.EmailVerified != tt.want {
+				t.Errorf("EmailVerified = %v, want %v", claims.EmailVerified, tt.want)
+			}
+		})
+	}
+}
+
+func TestVerifyIDTokenRefetchesJWKSForUnknownKey(t *testing.T) {
+	ctx := context.Background()
+	provider, issuer := newTestProvider(t)
+	nonce := newRandomValue(t)
+
+	if _, err := provider.VerifyIDToken(ctx, issuer.IDToken(t, nonce, nil), nonce); err != nil {
+		t.Fatalf("VerifyIDToken: %v", err)
+	}
+	if got := issuer.JWKSFetches(); got != 1 {
+		t.Fatalf("JWKS fetched %d times, want 1", got)
+	}
+
+	// The provider rotates its key: right after a fetch the unknown key ID doesn't trigger another one
+	issuer.RotateKey(t)
+	rotated := issuer.IDToken(t, nonce, nil)
+	if _, err := provider.VerifyIDToken(ctx, rotated, nonce); !errors.Is(err, ErrInvalidIDToken) {
+		t.Fatalf("err = %v, want ErrInvalidIDToken while refetching is throttled", err)
+	}
+	if got := issuer.JWKSFetches(); got != 1 {
+		t.Fatalf("JWKS fetched %d times, want 1 while refetching is throttled", got)
+	}
+
+	provider.keys.mu.Lock()
+	provider.keys.fetchedAt = time.Now().Add(-jwksRefreshInterval)
+	provider.keys.mu.Unlock()
+	if _, err := provider.VerifyIDToken(ctx, rotated, nonce); err != nil {
+		t.Fatalf("token signed with the rotated key rejected after refetch: %v", err)
+	}
+	if got := issuer.JWKSFetches(); got != 2 {
+		t.Fatalf("JWKS fetched %d times, want 2", got)
+	}
+
+	// Known keys don't cause fetches, and key IDs the provider never published still fail
+	if _, err := provider.VerifyIDToken(ctx, issuer.IDToken(t, nonce, nil), nonce); err != nil {
+		t.Fatalf("VerifyIDToken: %v", err)
+	}
+	unknown := issuer.SignWithKID(t, "missing-key", jwt.MapClaims{
+		"iss": issuer.URL, "aud": oidctest.ClientID, "sub": "provider-user-1", "nonce": nonce,
+		"exp": time.Now().Add(time.Hour).Unix(),
+	})
+	if _, err := provider.VerifyIDToken(ctx, unknown, nonce); !errors.Is(err, ErrInvalidIDToken) {
+		t.Fatalf("err = %v, want ErrInvalidIDToken for an unpublished key", err)
+	}
+	if got := issuer.JWKSFetches(); got != 2 {
+		t.Fatalf("JWKS fetched %d times, want 2", got)
+	}
+}
+
+func TestDiscoveryRejectsIssuerMismatch(t *testing.T) {
+	issuer := oidctest.NewIssuer(t)
+	provider := NewProvider(Config{
+		Name:        "test",
+		IssuerURL:   issuer.URL + "/", // Discovery is still found, but names the issuer without the slash
+		ClientID:    oidctest.ClientID,
+		RedirectURL: oidctest.RedirectURL,
+	}, nil)
+	if _, err := provider.AuthCodeURL(context.Background(), "state", "nonce", "verifier"); err == nil {
+		t.Fatal("AuthCodeURL succeeded although discovery names another issuer")
+	}
+}
@@ -0,0 +1,115 @@
+package pagination
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/binary"
+	"errors"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+const (
+	// DefaultLimit is the page size used when a request doesn't ask for one
+	DefaultLimit = 20
+	// MaxLimit caps the page size a client can request
+	MaxLimit = 100
+
+	// payloadSize is 8 bytes of Unix milliseconds followed by the 12-byte ObjectID
+	payloadSize = 8 + 12
+	// macSize is the length of the truncated HMAC-SHA256 tag
+	macSize = 16
+)
+
+// ErrInvalidCursor is returned when a cursor is malformed or its signature doesn't match
+var ErrInvalidCursor = errors.New("invalid cursor")
+
+// Cursor marks a position in a list ordered by (createdAt, _id).
+// The ID breaks ties between items created in the same millisecond.
+type Cursor struct {
+	CreatedAt time.Time
+	ID        primitive.ObjectID
+}
+
+// Page is the response envelope shared by every paginated endpoint.
+// NextCursor is empty once the last page has been returned.
+type Page[T any] struct {
+	Items      []T    `json:"items"`
+	NextCursor string `json:"nextCursor,omitempty"`
+}
+
+// Codec turns cursors into opaque tokens and back. Tokens are signed so
+// clients can't forge positions or probe arbitrary ranges of a collection.
+type Codec struct {
+	key []byte
+}
+
+// NewCodec creates a cursor codec that signs tokens with the given secret.
+func NewCodec(secret string) *Codec {
+	return &Codec{key: []byte(secret)}
+}
+
+// Encode serializes and signs a cursor into a URL-safe token.
+func (c *Codec) Encode(cursor Cursor) string {
+	buf := make([]byte, payloadSize, payloadSize+macSize)
+	binary.BigEndian.PutUint64(buf[:8], uint64(cursor.CreatedAt.UnixMilli()))
+	copy(buf[8:], cursor.ID[:])
+	buf = append(buf, c.sign(buf)...)
+	return base64.RawURLEncoding.EncodeToString(buf)
+}
+
+// Decode verifies and parses a token produced by Encode. An empty token means
+// "start from the beginning" and yields a nil cursor.
+func (c *Codec) Decode(token string) (*Cursor, error) {
+	if token == "" {
+		return nil, nil
+	}
+	buf, err := base64.RawURLEncoding.DecodeString(token)
+	if err != nil || len(buf) != payloadSize+macSize {
+		return nil, ErrInvalidCursor
+	}
+	payload, tag := buf[:payloadSize], buf[payloadSize:]
+	if !hmac.Equal(tag, c.sign(payload)) {
+		return nil, ErrInvalidCursor
+	}
+
+	cursor := &Cursor{
+		CreatedAt: time.UnixMilli(int64(binary.BigEndian.Uint64(payload[:8]))).UTC(),
+	}
+	copy(cursor.ID[:], payload[8:])
+	return cursor, nil
+}
+
+func (c *Codec) sign(payload []byte) []byte {
+	mac := hmac.New(sha256.New, c.key)
+	mac.Write(payload)
+	return mac.Sum(nil)[:macSize]
+}
+
+// NewPage builds the response envelope from a result set fetched with a limit
+// of limit+1. The extra item only signals that another page exists; it is
+// dropped and the cursor points at the last item returned.
+func NewPage[T any](codec *Codec, items []T, limit int, key func(T) Cursor) Page[T] {
+	if items == nil {
+		items = []T{}
+	}
+	if len(items) <= limit {
+		return Page[T]{Items: items}
+	}
+	items = items[:limit]
+	return Page[T]{Items: items, NextCursor: codec.Encode(key(items[limit-1]))}
+}
+
+// ClampLimit keeps a client-supplied page size within [1, MaxLimit],
+// falling back to DefaultLimit when it is missing or invalid.
+func ClampLimit(limit int) int {
+	if limit <= 0 {
+		return DefaultLimit
+	}
+	if limit > MaxLimit {
+		return MaxLimit
+	}
+	return limit
+}
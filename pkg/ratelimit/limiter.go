@@ -0,0 +1,141 @@
+package ratelimit
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"strconv"
+	"sync/atomic"
+	"time"
+	"vybes/pkg/cache"
+
+	"github.com/redis/go-redis/v9"
+	"github.com/rs/zerolog/log"
+)
+
+// Rule limits how many requests a subject may make per window. Rules with
+// different names are counted separately.
+type Rule struct {
+	Name   string
+	Limit  int
+	Window time.Duration
+}
+
+// Result is the outcome of a rate limit check.
+type Result struct {
+	Allowed   bool
+	Limit     int
+	Remaining int
+	// Reset is the time until the current window ends
+	Reset time.Duration
+	// RetryAfter is how long a rejected subject should wait before trying again
+	RetryAfter time.Duration
+}
+
+// Limiter enforces rules with a sliding window counter: the count of the current
+// fixed window is added to the count of the previous window, weighted by how
+// much of the previous window still overlaps the sliding one. That smooths out
+// bursts at window boundaries while needing only two counters per subject.
+//
+// Counters live in Redis so limits hold across instances. When Redis can't be
+// reached the limiter keeps working with per-process counters instead of
+// letting every request through.
+type Limiter struct {
+	cache    cache.Client
+	memory   *memoryStore
+	degraded atomic.Bool
+}
+
+// NewLimiter creates a limiter that keeps its counters in the given cache.
+//
+// Parameters:
+//   - cache: Cache client used for the shared counters
+//
+// Returns:
+//   - *Limiter: A limiter ready for use
+func NewLimiter(cache cache.Client) *Limiter {
+	return &Limiter{
+		cache:  cache,
+		memory: newMemoryStore(),
+	}
+}
+
+// Allow counts a request by subject against rule and reports whether it may proceed.
+func (l *Limiter) Allow(ctx context.Context, rule Rule, subject string) Result {
+	now := time.Now()
+	windowStart := now.Truncate(rule.Window)
+	elapsed := now.Sub(windowStart)
+
+	prefix := fmt.Sprintf("ratelimit:%s:%s:", rule.Name, subject)
+	currentKey := prefix + strconv.FormatInt(windowStart.Unix(), 10)
+	previousKey := prefix + strconv.FormatInt(windowStart.Add(-rule.Window).Unix(), 10)
+
+	// A counter is still read as the previous window during the next one
+	current, previous := l.count(ctx, currentKey, previousKey, 2*rule.Window)
+
+	weight := 1 - float64(elapsed)/float64(rule.Window)
+	estimate := float64(previous)*weight + float64(current)
+
+	result := Result{
+		Allowed:   estimate <= float64(rule.Limit),
+		Limit:     rule.Limit,
+		Remaining: int(math.Max(0, math.Floor(float64(rule.Limit)-estimate))),
+		Reset:     rule.Window - elapsed,
+	}
+	if !result.Allowed {
+		result.RetryAfter = retryAfter(rule, elapsed, current, previous)
+	}
+	return result
+}
+
+// count increments the current window's counter and reads the previous one,
+// falling back to in-memory counters when Redis fails.
+func (l *Limiter) count(ctx context.Context, currentKey, previousKey string, ttl time.Duration) (int64, int64) {
+	current, err := l.cache.Incr(ctx, currentKey, ttl)
+	if err == nil {
+		var previous int64
+		previous, err = l.get(ctx, previousKey)
+		if err == nil {
+			if l.degraded.Swap(false) {
+				log.Info().Msg("Rate limiter is using Redis again")
+			}
+			return current, previous
+		}
+	}
+
+	if !l.degraded.Swap(true) {
+		log.Warn().Err(err).Msg("Rate limiter can't reach Redis, falling back to in-memory counters")
+	}
+	return l.memory.incr(currentKey, ttl), l.memory.get(previousKey)
+}
+
+func (l *Limiter) get(ctx context.Context, key string) (int64, error) {
+	value, err := l.cache.Get(ctx, key)
+	if errors.Is(err, redis.Nil) {
+		return 0, nil
+	}
+	if err != nil {
+		return 0, err
+	}
+	return strconv.ParseInt(value, 10, 64)
+}
+
+// retryAfter estimates when the sliding count drops back under the limit,
+// assuming no further requests. It is never less than a second.
+func retryAfter(rule Rule, elapsed time.Duration, current, previous int64) time.Duration {
+	limit := float64(rule.Limit)
+	window := float64(rule.Window)
+	var wait time.Duration
+	if float64(current) < limit {
+		// Within this window: previous*(1-(elapsed+t)/window) + current <= limit
+		wait = time.Duration((1-(limit-float64(current))/float64(previous))*window) - elapsed
+	} else {
+		// Once this window becomes the previous one: current*(1-s/window) <= limit
+		wait = rule.Window - elapsed + time.Duration((1-limit/float64(current))*window)
+	}
+	if wait < time.Second {
+		wait = time.Second
+	}
+	return wait
+}
@@ -0,0 +1,72 @@
+package ratelimit
+
+import (
+	"sync"
+	"time"
+)
+
+// sweepInterval is how often expired in-memory counters are dropped
+const sweepInterval = time.Minute
+
+type memoryCounter struct {
+	value   int64
+	expires time.Time
+}
+
+// memoryStore holds per-process counters for when Redis is unavailable.
+type memoryStore struct {
+	mu        sync.Mutex
+	counters  map[string]*memoryCounter
+	lastSweep time.Time
+}
+
+func newMemoryStore() *memoryStore {
+	return &memoryStore{
+		counters:  make(map[string]*memoryCounter),
+		lastSweep: time.Now(),
+	}
+}
+
+// incr increments a counter, creating it with the given time to live if it is
+// missing or expired, and returns its new value.
+func (m *memoryStore) incr(key string, ttl time.Duration) int64 {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	now := time.Now()
+	m.sweep(now)
+
+	counter, ok := m.counters[key]
+	if !ok || now.After(counter.expires) {
+		counter = &memoryCounter{expires: now.Add(ttl)}
+		m.counters[key] = counter
+	}
+	counter.value++
+	return counter.value
+}
+
+// get returns a counter's value, or 0 if it is missing or expired.
+func (m *memoryStore) get(key string) int64 {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	counter, ok := m.counters[key]
+	if !ok || time.Now().After(counter.expires) {
+		return 0
+	}
+	return counter.value
+}
+
+// sweep drops expired counters so the map doesn't grow without bound. The
+// caller must hold the lock.
+func (m *memoryStore) sweep(now time.Time) {
+	if now.Sub(m.lastSweep) < sweepInterval {
+		return
+	}
+	for key, counter := range m.counters {
+		if now.After(counter.expires) {
+			delete(m.counters, key)
+		}
+	}
+	m.lastSweep = now
+}
@@ -0,0 +1,200 @@
+package siwe
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/crypto"
+)
+
+const header = " wants you to sign in with your Ethereum account:"
+
+var (
+	// ErrMalformedMessage is returned when a message doesn't follow the EIP-4361 format
+	ErrMalformedMessage = errors.New("malformed sign-in with Ethereum message")
+	// ErrInvalidSignature is returned when a signature can't be decoded or recovered
+	ErrInvalidSignature = errors.New("invalid signature")
+)
+
+// Message is a parsed Sign-In with Ethereum message (EIP-4361).
+type Message struct {
+	Scheme         string
+	Domain         string
+	Address        common.Address
+	Statement      string
+	URI            string
+	Version        string
+	ChainID        int64
+	Nonce          string
+	IssuedAt       time.Time
+	ExpirationTime *time.Time
+	NotBefore      *time.Time
+	RequestID      string
+	Resources      []string
+}
+
+// Parse reads a message in the EIP-4361 text format:
+//
+//	example.com wants you to sign in with your Ethereum account:
+//	0xC02aaA39b223FE8D0A0e5C4F27eAD9083C756Cc2
+//
+//	Sign in to Vybes
+//
+//	URI: https://example.com/login
+//	Version: 1
+//	Chain ID: 1
+//	Nonce: 32891756
+//	Issued At: 2021-09-30T16:25:24Z
+//
+// The address must use its EIP-55 checksum. Parse checks the format only; the
+// caller still has to check the signature, domain, nonce and times.
+func Parse(raw string) (*Message, error) {
+	lines := strings.Split(strings.TrimSuffix(raw, "\n"), "\n")
+	if len(lines) < 6 {
+		return nil, ErrMalformedMessage
+	}
+
+	m := &Message{}
+	origin, ok := strings.CutSuffix(lines[0], header)
+	if !ok || origin == "" {
+		return nil, fmt.Errorf("%w: missing header", ErrMalformedMessage)
+	}
+	if scheme, domain, found := strings.Cut(origin, "://"); found {
+		m.Scheme, m.Domain = scheme, domain
+	} else {
+		m.Domain = origin
+	}
+
+	if !common.IsHexAddress(lines[1]) || common.HexToAddress(lines[1]).Hex() != lines[1] {
+		return nil, fmt.Errorf("%w: address must be EIP-55 checksummed", ErrMalformedMessage)
+	}
+	m.Address = common.HexToAddress(lines[1])
+	if lines[2] != "" {
+		return nil, ErrMalformedMessage
+	}
+
+	// An optional statement sits between two blank lines
+	i := 3
+	if lines[i] != "" && !strings.HasPrefix(lines[i], "URI: ") {
+		m.Statement = lines[i]
+		i++
+	}
+	if lines[i] == "" {
+		i++
+	}
+
+	seen := make(map[string]bool)
+	for ; i < len(lines); i++ {
+		if lines[i] == "Resources:" {
+			for i++; i < len(lines); i++ {
+				resource, ok := strings.CutPrefix(lines[i], "- ")
+				if !ok {
+					return nil, fmt.Errorf("%w: invalid resource", ErrMalformedMessage)
+				}
+				m.Resources = append(m.Resources, resource)
+			}
+			break
+		}
+
+		key, value, ok := strings.Cut(lines[i], ": ")
+		if !ok || seen[key] {
+			return nil, fmt.Errorf("%w: unexpected line %q", ErrMalformedMessage, lines[i])
+		}
+		seen[key] = true
+		if err := m.setField(key, value); err != nil {
+			return nil, err
+		}
+	}
+
+	for _, required := range []string{"URI", "Version", "Chain ID", "Nonce", "Issued At"} {
+		if !seen[required] {
+			return nil, fmt.Errorf("%w: missing %s", ErrMalformedMessage, required)
+		}
+	}
+	if m.Version != "1" {
+		return nil, fmt.Errorf("%w: unsupported version %q", ErrMalformedMessage, m.Version)
+	}
+	return m, nil
+}
+
+func (m *Message) setField(key, value string) error {
+	var err error
+	switch key {
+	case "URI":
+		m.URI = value
+	case "Version":
+		m.Version = value
+	case "Chain ID":
+		m.ChainID, err = strconv.ParseInt(value, 10, 64)
+	case "Nonce":
+		if len(value) < 8 {
+			return fmt.Errorf("%w: nonce too short", ErrMalformedMessage)
+		}
+		m.Nonce = value
+	case "Issued At":
+		m.IssuedAt, err = time.Parse(time.RFC3339Nano, value)
+	case "Expiration Time":
+		var t time.Time
+		t, err = time.Parse(time.RFC3339Nano, value)
+		m.ExpirationTime = &t
+	case "Not Before":
+		var t time.Time
+		t, err = time.Parse(time.RFC3339Nano, value)
+		m.NotBefore = &t
+	case "Request ID":
+		m.RequestID = value
+	default:
+		return fmt.Errorf("%w: unknown field %q", ErrMalformedMessage, key)
+	}
+	if err != nil {
+		return fmt.Errorf("%w: invalid %s", ErrMalformedMessage, key)
+	}
+	return nil
+}
+
+// ValidAt reports whether the message may be used at time t, given its
+// expiration time and not-before time.
+func (m *Message) ValidAt(t time.Time) bool {
+	if m.ExpirationTime != nil && !t.Before(*m.ExpirationTime) {
+		return false
+	}
+	if m.NotBefore != nil && t.Before(*m.NotBefore) {
+		return false
+	}
+	return true
+}
+
+// RecoverAddress returns the account that signed message with personal_sign
+// (EIP-191). Signatures from smart contract wallets (EIP-1271) aren't supported.
+//
+// Parameters:
+//   - message: The exact message text that was signed
+//   - signature: 0x-prefixed 65-byte signature, with a recovery ID of 0/1 or 27/28
+//
+// Returns:
+//   - common.Address: The signer's address
+//   - error: ErrInvalidSignature if no address can be recovered
+func RecoverAddress(message, signature string) (common.Address, error) {
+	sig, err := hexutil.Decode(signature)
+	if err != nil || len(sig) != 65 {
+		return common.Address{}, ErrInvalidSignature
+	}
+	if sig[64] >= 27 {
+		sig[64] -= 27
+	}
+	if sig[64] > 1 {
+		return common.Address{}, ErrInvalidSignature
+	}
+
+	hash := crypto.Keccak256([]byte(fmt.Sprintf("\x19Ethereum Signed Message:\n%d%s", len(message), message)))
+	pub, err := crypto.SigToPub(hash, sig)
+	if err != nil {
+		return common.Address{}, ErrInvalidSignature
+	}
+	return crypto.PubkeyToAddress(*pub), nil
+}
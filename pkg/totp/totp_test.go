@@ -0,0 +1,170 @@
+package totp
+
+import (
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+)
+
+// rfcSecret is the SHA-1 seed of the RFC 6238 Appendix B test vectors, base32-encoded
+var rfcSecret = encoding.EncodeToString([]byte("12345678901234567890"))
+
+// codeAt returns the code for the step t falls in.
+func codeAt(t *testing.T, secret string, at time.Time) string {
+	t.Helper()
+	key, err := encoding.DecodeString(secret)
+	if err != nil {
+		t.Fatalf("invalid secret: %v", err)
+	}
+	return generate(key, at.Unix()/Period)
+}
+
+func TestRFC6238Vectors(t *testing.T) {
+	// RFC 6238 Appendix B lists 8 digit codes; 6 digit codes are their last six digits
+	tests := []struct {
+		unix int64
+		want string
+	}{
+		{59, "94287082"},
+		{1111111109, "07081804"},
+		{1111111111, "14050471"},
+		{1234567890, "89005924"},
+		{2000000000, "69279037"},
+		{20000000000, "65353130"},
+	}
+	for _, tt := range tests {
+		at := time.Unix(tt.unix, 0)
+		want := tt.want[len(tt.want)-Digits:]
+		if got := codeAt(t, rfcSecret, at); got != want {
+			t.Errorf("code at %d = %s, want %s", tt.unix, got, want)
+		}
+		step, ok := Validate(rfcSecret, want, at)
+		if !ok || step != tt.unix/Period {
+			t.Errorf("Validate at %d = %d, %v, want %d, true", tt.unix, step, ok, tt.unix/Period)
+		}
+	}
+}
+
+func TestValidateStepWindow(t *testing.T) {
+	now := time.Unix(1700000015, 0) // Halfway through a period
+	current := now.Unix() / Period
+
+	for offset := int64(-Skew - 1); offset <= Skew+1; offset++ {
+		code := codeAt(t, rfcSecret, now.Add(time.Duration(offset*Period)*time.Second))
+		step, ok := Validate(rfcSecret, code, now)
+		inWindow := offset >= -Skew && offset <= Skew
+		if ok != inWindow {
+			t.Errorf("code from %d periods away: accepted = %v, want %v", offset, ok, inWindow)
+		}
+		if ok && step != current+offset {
+			t.Errorf("code from %d periods away: step = %d, want %d", offset, step, current+offset)
+		}
+	}
+}
+
+func TestValidateReportsTheCodesStep(t *testing.T) {
+	// A code keeps reporting the step it was generated for wherever in the window
+	// it is presented, so refusing steps at or below the last accepted one stops
+	// every replay of it
+	issued := time.Unix(1700000010, 0)
+	code := codeAt(t, rfcSecret, issued)
+	want := issued.Unix() / Period
+
+	var lastStep int64
+	consume := func(at time.Time) bool {
+		step, ok := Validate(rfcSecret, code, at)
+		if !ok {
+			return false
+		}
+		if step != want {
+			t.Errorf("step at %v = %d, want %d", at.Unix(), step, want)
+		}
+		if step <= lastStep {
+			return false
+		}
+		lastStep = step
+		return true
+	}
+
+	if !consume(issued) {
+		t.Fatal("fresh code rejected")
+	}
+	for _, at := range []time.Time{issued, issued.Add(5 * time.Second), issued.Add(Period * time.Second), issued.Add(-Period * time.Second)} {
+		if consume(at) {
+			t.Errorf("replayed code accepted at %d", at.Unix())
+		}
+	}
+	if _, ok := Validate(rfcSecret, code, issued.Add((Skew+1)*Period*time.Second)); ok {
+		t.Error("code accepted after its window")
+	}
+
+	// Codes of earlier steps stay refused once a later one was used
+	later := issued.Add(Period * time.Second)
+	step, ok := Validate(rfcSecret, codeAt(t, rfcSecret, later), later)
+	if !ok || step <= lastStep {
+		t.Fatalf("next code = %d, %v, want a step after %d", step, ok, lastStep)
+	}
+	lastStep = step
+	if consume(later) {
+		t.Error("earlier code accepted after a later one was used")
+	}
+}
+
+func TestValidateInput(t *testing.T) {
+	now := time.Unix(1700000015, 0)
+	code := codeAt(t, rfcSecret, now)
+
+	tests := []struct {
+		name   string
+		secret string
+		code   string
+		want   bool
+	}{
+		{"surrounding spaces", rfcSecret, " " + code + " ", true},
+		{"lower case secret", strings.ToLower(rfcSecret), code, true},
+		{"too short", rfcSecret, code[1:], false},
+		{"too long", rfcSecret, code + "0", false},
+		{"empty", rfcSecret, "", false},
+		{"invalid secret", "not base32!", code, false},
+		{"other secret", "JBSWY3DPEHPK3PXP", code, false},
+	}
+	for _, tt := range tests {
+		if _, ok := Validate(tt.secret, tt.code, now); ok != tt.want {
+			t.Errorf("%s: accepted = %v, want %v", tt.name, ok, tt.want)
+		}
+	}
+}
+
+func TestGenerateSecret(t *testing.T) {
+	secret, err := GenerateSecret()
+	if err != nil {
+		t.Fatalf("GenerateSecret: %v", err)
+	}
+	key, err := encoding.DecodeString(secret)
+	if err != nil {
+		t.Fatalf("secret isn't base32: %v", err)
+	}
+	if len(key) != secretSize {
+		t.Errorf("secret has %d bytes, want %d", len(key), secretSize)
+	}
+	if other, _ := GenerateSecret(); other == secret {
+		t.Error("GenerateSecret returned the same secret twice")
+	}
+}
+
+func TestProvisioningURI(t *testing.T) {
+	u, err := url.Parse(ProvisioningURI(rfcSecret, "Vybes", "user@example.com"))
+	if err != nil {
+		t.Fatalf("invalid URI: %v", err)
+	}
+	if u.Scheme != "otpauth" || u.Host != "totp" || u.Path != "/Vybes:user@example.com" {
+		t.Errorf("URI = %s", u)
+	}
+	want := map[string]string{"secret": rfcSecret, "issuer": "Vybes", "algorithm": "SHA1", "digits": "6", "period": "30"}
+	for name, value := range want {
+		if got := u.Query().Get(name); got != value {
+			t.Errorf("%s = %q, want %q", name, got, value)
+		}
+	}
+}
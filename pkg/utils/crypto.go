@@ -4,7 +4,9 @@ import (
 	"crypto/aes"
 	"crypto/cipher"
 	"crypto/rand"
+	"crypto/sha256"
 	"encoding/base64"
+	"encoding/hex"
 	"errors"
 )
 
@@ -114,3 +116,17 @@ func GenerateRandomString(length int, charset string) (string, error) {
 
 	return string(result), nil
 }
+
+// HashToken returns the hex-encoded SHA-256 digest of a random token, such as a
+// refresh token, so that only the digest needs to be stored. A fast hash is
+// enough because the tokens are long and random, unlike passwords.
+//
+// Parameters:
+//   - token: The token to hash
+//
+// Returns:
+//   - string: Hex-encoded SHA-256 digest of the token
+func HashToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
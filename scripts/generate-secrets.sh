@@ -19,10 +19,16 @@ fi
 echo "📝 Generating secure secrets for Railway deployment..."
 echo ""
 
-# Generate JWT Secret (32 bytes = 256 bits)
-echo "🔑 JWT_SECRET:"
-JWT_SECRET=$(openssl rand -base64 32)
-echo "JWT_SECRET=$JWT_SECRET"
+# Generate JWT Signing Key (Ed25519, PEM with escaped newlines for a single-line variable)
+echo "✍️  JWT_SIGNING_KEY:"
+JWT_SIGNING_KEY=$(openssl genpkey -algorithm ed25519 | awk '{printf "%s\\n", $0}')
+echo "JWT_SIGNING_KEY=$JWT_SIGNING_KEY"
+echo ""
+
+# Generate Cursor Secret (32 bytes = 256 bits)
+echo "📑 CURSOR_SECRET:"
+CURSOR_SECRET=$(openssl rand -base64 32)
+echo "CURSOR_SECRET=$CURSOR_SECRET"
 echo ""
 
 # Generate Wallet Encryption Key (32 bytes = 256 bits)
@@ -31,6 +37,12 @@ WALLET_ENCRYPTION_KEY=$(openssl rand -base64 32)
 echo "WALLET_ENCRYPTION_KEY=$WALLET_ENCRYPTION_KEY"
 echo ""
 
+# Generate Wallet Master Key Set (version 1, 32 bytes = 256 bits)
+echo "🗝️  WALLET_MASTER_KEYS:"
+WALLET_MASTER_KEYS="{\"currentVersion\":1,\"keys\":{\"1\":\"$(openssl rand -base64 32)\"}}"
+echo "WALLET_MASTER_KEYS=$WALLET_MASTER_KEYS"
+echo ""
+
 # Generate MongoDB Root Password (16 bytes = 128 bits)
 echo "🗄️  MONGO_ROOT_PASSWORD:"
 MONGO_ROOT_PASSWORD=$(openssl rand -base64 16)
@@ -68,6 +80,8 @@ echo "🔒 Security Notes:"
 echo "- Keep these secrets secure and never commit them to version control"
 echo "- Use different secrets for development, staging, and production"
 echo "- Rotate secrets regularly in production"
+echo "- To rotate JWT_SIGNING_KEY, move the old key to JWT_VERIFICATION_KEYS for 24 hours so issued tokens keep working"
+echo "- To rotate the wallet master key, add a new version to WALLET_MASTER_KEYS, make it current, deploy, then run 'make rewrap-keys'"
 echo "- R2 credentials are managed through Cloudflare dashboard"
 echo ""
 echo "🚀 Ready to deploy on Railway!"
\ No newline at end of file